@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/fake"
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// benchCalendarID 是基準測試寫入的模擬日曆
+const benchCalendarID = "bench@group.calendar.google.com"
+
+// benchPhase 是基準測試一個階段的結果，延遲以毫秒表示
+type benchPhase struct {
+	Name       string  `json:"name"`
+	Ops        int     `json:"ops"`
+	Failed     int     `json:"failed"`
+	ElapsedMS  float64 `json:"elapsed_ms"`
+	Throughput float64 `json:"ops_per_second"`
+	P50MS      float64 `json:"p50_ms,omitempty"`
+	P95MS      float64 `json:"p95_ms,omitempty"`
+	P99MS      float64 `json:"p99_ms,omitempty"`
+	MaxMS      float64 `json:"max_ms,omitempty"`
+	Repaired   int     `json:"repaired,omitempty"`
+}
+
+// runBench 以模擬的 SimplyBook 與 Google 日曆執行合成的同步與對帳，輸出吞吐量與延遲分位數。
+// 不需要配置文件與任何憑證，供 CI 追蹤同步流程的效能變化
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	bookings := fs.Int("bookings", 500, "合成預約數量")
+	concurrency := fs.Int("concurrency", 8, "並行同步的預約數")
+	latency := fs.Duration("latency", 5*time.Millisecond, "模擬伺服器每個請求的延遲")
+	drift := fs.Float64("drift", 0.1, "對帳前修改的預約比例（0 到 1）")
+	format := fs.String("format", "text", "輸出格式：text 或 json")
+	maxP95 := fs.Duration("max-p95", 0, "任一階段的 p95 延遲超過此值時以非零狀態碼結束，0 表示不檢查")
+	fs.Parse(args)
+
+	if *bookings <= 0 || *concurrency <= 0 {
+		return fmt.Errorf("--bookings 與 --concurrency 必須為正整數")
+	}
+	if *drift < 0 || *drift > 1 {
+		return fmt.Errorf("--drift 必須介於 0 與 1 之間: %g", *drift)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("--format 必須為 text 或 json: %s", *format)
+	}
+
+	server := fake.NewServer()
+	defer server.Close()
+	server.Latency = *latency
+
+	h, err := newBenchHandler(server)
+	if err != nil {
+		return err
+	}
+	ids := seedBenchBookings(server.SimplyBook, *bookings)
+
+	// 同步流程每筆預約都會記錄日誌，測量期間不輸出
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	var phases []*benchPhase
+	phases = append(phases, runBenchPhase("create", ids, *concurrency, h.ReplayBooking))
+
+	drifted := driftBenchBookings(server.SimplyBook, ids, *drift)
+	start := time.Now()
+	result, err := h.Reconcile()
+	if err != nil {
+		return fmt.Errorf("對帳失敗: %w", err)
+	}
+	phases = append(phases, reconcilePhase(result, time.Since(start)))
+
+	phases = append(phases, runBenchPhase("resync", ids, *concurrency, h.ReplayBooking))
+	log.SetOutput(os.Stderr)
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]interface{}{
+			"bookings":    *bookings,
+			"concurrency": *concurrency,
+			"latency_ms":  milliseconds(*latency),
+			"drifted":     drifted,
+			"phases":      phases,
+		}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("預約 %d 筆，並行 %d，模擬延遲 %s，對帳前修改 %d 筆\n\n", *bookings, *concurrency, *latency, drifted)
+		fmt.Printf("%-10s %6s %6s %10s %10s %9s %9s %9s %9s\n", "phase", "ops", "failed", "elapsed_ms", "ops/s", "p50_ms", "p95_ms", "p99_ms", "max_ms")
+		for _, p := range phases {
+			latency := fmt.Sprintf("%9.1f %9.1f %9.1f %9.1f", p.P50MS, p.P95MS, p.P99MS, p.MaxMS)
+			if p.Name == "reconcile" {
+				latency = fmt.Sprintf("%9s %9s %9s %9s", "-", "-", "-", "-")
+			}
+			fmt.Printf("%-10s %6d %6d %10.0f %10.1f %s\n", p.Name, p.Ops, p.Failed, p.ElapsedMS, p.Throughput, latency)
+		}
+	}
+
+	for _, p := range phases {
+		if p.Failed > 0 {
+			return fmt.Errorf("%s 階段有 %d 筆失敗", p.Name, p.Failed)
+		}
+		if *maxP95 > 0 && p.P95MS > milliseconds(*maxP95) {
+			return fmt.Errorf("%s 階段的 p95 延遲 %.1fms 超過 %s", p.Name, p.P95MS, *maxP95)
+		}
+	}
+	if result.Repaired != drifted {
+		return fmt.Errorf("對帳修復 %d 筆，預期 %d 筆", result.Repaired, drifted)
+	}
+	return nil
+}
+
+// newBenchHandler 以模擬伺服器與記憶體儲存創建同步處理器
+func newBenchHandler(server *fake.Server) (*handler.WebhookHandler, error) {
+	httpClient := server.Client()
+	sb, err := simplybook.NewClient("bench", "bench", "bench", simplybook.Options{HTTPClient: httpClient})
+	if err != nil {
+		return nil, fmt.Errorf("初始化模擬 SimplyBook 客戶端失敗: %w", err)
+	}
+
+	creds, err := server.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := gcalendar.NewClient(creds, benchCalendarID, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("初始化模擬日曆客戶端失敗: %w", err)
+	}
+
+	return handler.NewWebhookHandler(sb, calendar, store.NewMemoryStore(), nil, handler.Options{}), nil
+}
+
+// seedBenchBookings 在模擬 SimplyBook 建立分散於未來 30 天營業時間內的預約
+func seedBenchBookings(sb *fake.SimplyBook, n int) []string {
+	loc, err := time.LoadLocation("Asia/Taipei")
+	if err != nil {
+		loc = time.FixedZone("GMT+8", 8*60*60)
+	}
+	day := time.Now().In(loc).Truncate(24 * time.Hour)
+
+	ids := make([]string, n)
+	for i := 0; i < n; i++ {
+		date := day.AddDate(0, 0, 1+i%30)
+		start := time.Date(date.Year(), date.Month(), date.Day(), 9+i%9, 0, 0, 0, loc)
+		id := sb.Add(fake.Booking{
+			Start:       start,
+			ServiceID:   1 + i%2,
+			ProviderID:  1 + i/2%2,
+			ClientName:  fmt.Sprintf("測試客戶 %d", i+1),
+			ClientEmail: fmt.Sprintf("client%d@example.com", i+1),
+		})
+		ids[i] = strconv.Itoa(id)
+	}
+	return ids
+}
+
+// driftBenchBookings 將指定比例的預約延後一小時，讓對帳有偏差可修復，返回修改的筆數
+func driftBenchBookings(sb *fake.SimplyBook, ids []string, ratio float64) int {
+	count := int(float64(len(ids)) * ratio)
+	if count == 0 {
+		return 0
+	}
+	step := len(ids) / count
+	for i := 0; i < count; i++ {
+		id, _ := strconv.Atoi(ids[i*step])
+		sb.Update(id, func(b *fake.Booking) {
+			b.Start = b.Start.Add(time.Hour)
+			b.End = b.End.Add(time.Hour)
+		})
+	}
+	return count
+}
+
+// runBenchPhase 以 concurrency 個 worker 對每筆預約執行 fn，記錄每筆的延遲
+func runBenchPhase(name string, ids []string, concurrency int, fn func(bookingID string) error) *benchPhase {
+	jobs := make(chan string)
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failed    int
+		wg        sync.WaitGroup
+	)
+
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				opStart := time.Now()
+				err := fn(id)
+				elapsed := time.Since(opStart)
+
+				mu.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil {
+					failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return &benchPhase{
+		Name:       name,
+		Ops:        len(latencies),
+		Failed:     failed,
+		ElapsedMS:  milliseconds(elapsed),
+		Throughput: float64(len(latencies)) / elapsed.Seconds(),
+		P50MS:      milliseconds(percentile(latencies, 0.50)),
+		P95MS:      milliseconds(percentile(latencies, 0.95)),
+		P99MS:      milliseconds(percentile(latencies, 0.99)),
+		MaxMS:      milliseconds(percentile(latencies, 1)),
+	}
+}
+
+// reconcilePhase 將對帳結果轉為階段結果；對帳在處理器內逐筆依序進行，無法取得每筆的延遲
+func reconcilePhase(result *handler.ReconcileResult, elapsed time.Duration) *benchPhase {
+	checked := result.Report.Checked
+	phase := &benchPhase{
+		Name:      "reconcile",
+		Ops:       checked,
+		Failed:    result.Failed,
+		ElapsedMS: milliseconds(elapsed),
+		Repaired:  result.Repaired,
+	}
+	if checked > 0 {
+		phase.Throughput = float64(checked) / elapsed.Seconds()
+	}
+	return phase
+}
+
+// percentile 返回已排序延遲的第 p 分位數（最近排名法）
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// milliseconds 將時間長度轉為毫秒
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/config"
+	"github.com/booking-sync-455103/booking-sync/pkg/export"
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/retention"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// commandEnv 包含子命令可使用的依賴
+type commandEnv struct {
+	store      store.Store
+	simplybook *simplybook.Client
+	calendar   *gcalendar.Client
+	httpClient *http.Client
+	webhookURL string // 配置中的 webhook 網址，作為子命令的預設值
+}
+
+// runCommand 執行命令行子命令
+func runCommand(args []string, env *commandEnv) error {
+	switch args[0] {
+	case "export":
+		return runExport(args[1:], env.store)
+	case "adopt":
+		return runAdopt(args[1:], env)
+	case "purge-client":
+		return runPurgeClient(args[1:], env)
+	case "webhook":
+		return runWebhook(args[1:], env)
+	case "skip":
+		return runSkip(args[1:], env.store)
+	case "migrate":
+		return runMigrate(args[1:], env)
+	default:
+		return fmt.Errorf("未知的子命令: %s", args[0])
+	}
+}
+
+// printEffectiveConfig 輸出合併配置文件、環境變數與預設值後的有效配置，敏感資訊會被遮蔽
+func printEffectiveConfig(cfg *config.Config) error {
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(redacted)
+}
+
+// runExport 匯出對應關係與同步記錄，供報表或遷移至其他系統使用
+func runExport(args []string, syncStore store.Store) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "json", "輸出格式：json 或 csv")
+	table := fs.String("table", "mappings", "CSV 輸出的資料：mappings 或 history（JSON 一律包含兩者）")
+	output := fs.String("output", "", "輸出檔案路徑，未指定時輸出到標準輸出")
+	fs.Parse(args)
+
+	dump, err := export.Load(syncStore)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("建立輸出檔案失敗: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "json":
+		err = export.WriteJSON(w, dump)
+	case "csv":
+		switch *table {
+		case "mappings":
+			err = export.WriteMappingsCSV(w, dump.Mappings)
+		case "history":
+			err = export.WriteHistoryCSV(w, dump.History)
+		default:
+			return fmt.Errorf("不支持的資料表: %s", *table)
+		}
+	default:
+		return fmt.Errorf("不支持的輸出格式: %s", *format)
+	}
+	if err != nil {
+		return fmt.Errorf("寫入匯出資料失敗: %w", err)
+	}
+
+	return nil
+}
+
+// runAdopt 掃描日曆中描述含有預約編號的既有事件，建立對應關係以避免同步時重複建立事件
+func runAdopt(args []string, env *commandEnv) error {
+	fs := flag.NewFlagSet("adopt", flag.ExitOnError)
+	from := fs.String("from", time.Now().AddDate(0, 0, -30).Format("2006-01-02"), "掃描的開始日期（YYYY-MM-DD）")
+	to := fs.String("to", time.Now().AddDate(0, 0, 180).Format("2006-01-02"), "掃描的結束日期（YYYY-MM-DD，含）")
+	calendarID := fs.String("calendar", "", "掃描的日曆 ID，未指定時使用預設日曆")
+	dryRun := fs.Bool("dry-run", false, "僅列出將建立的對應關係，不寫入儲存")
+	fs.Parse(args)
+
+	dateFrom, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		return fmt.Errorf("開始日期格式錯誤: %w", err)
+	}
+	dateTo, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		return fmt.Errorf("結束日期格式錯誤: %w", err)
+	}
+
+	// 以預約編號建立索引，事件描述中出現的編號才能對應到預約 ID
+	bookings, err := env.simplybook.ListBookings(simplybook.BookingFilter{DateFrom: dateFrom, DateTo: dateTo})
+	if err != nil {
+		return err
+	}
+	byCode := make(map[string]*simplybook.Booking, len(bookings))
+	for i := range bookings {
+		if bookings[i].Code != "" {
+			byCode[bookings[i].Code] = &bookings[i]
+		}
+	}
+	log.Printf("SimplyBook 中共有 %d 筆預約", len(byCode))
+
+	var scanned, adopted, skipped int
+	seen := make(map[string]string)
+	err = env.calendar.EachEvent(*calendarID, dateFrom, dateTo.AddDate(0, 0, 1), func(event *gcalendar.CalendarEvent) error {
+		scanned++
+
+		booking := findBookingCode(event.Description, byCode)
+		if booking == nil {
+			return nil
+		}
+		bookingID := strconv.Itoa(booking.ID)
+
+		if eventID, ok := seen[bookingID]; ok {
+			log.Printf("預約 %s 對應多個事件（%s、%s），僅採用第一個", bookingID, eventID, event.ID)
+			skipped++
+			return nil
+		}
+		seen[bookingID] = event.ID
+
+		existing, err := env.store.GetMapping(bookingID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			skipped++
+			return nil
+		}
+
+		excluded, err := env.store.GetSkippedBooking(booking.Code)
+		if err != nil {
+			return err
+		}
+		if excluded != nil {
+			log.Printf("預約 %s（%s）已手動排除同步，不建立對應關係", bookingID, booking.Code)
+			skipped++
+			return nil
+		}
+
+		log.Printf("預約 %s（%s）→ 事件 %s", bookingID, booking.Code, event.ID)
+		adopted++
+		if *dryRun {
+			return nil
+		}
+
+		return env.store.SaveMapping(&store.Mapping{
+			BookingID:   bookingID,
+			BookingCode: booking.Code,
+			EventID:     event.ID,
+			CalendarID:  *calendarID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("掃描 %d 個事件，建立 %d 筆對應關係，略過 %d 筆", scanned, adopted, skipped)
+	return nil
+}
+
+// runPurgeClient 刪除指定客戶電子郵件的所有預約資料（被遺忘權）
+func runPurgeClient(args []string, env *commandEnv) error {
+	fs := flag.NewFlagSet("purge-client", flag.ExitOnError)
+	email := fs.String("email", "", "客戶電子郵件")
+	deleteEvents := fs.Bool("delete-events", false, "一併刪除對應的 Google 日曆事件")
+	dryRun := fs.Bool("dry-run", false, "僅列出將刪除的預約，不寫入儲存")
+	fs.Parse(args)
+
+	if *email == "" {
+		return fmt.Errorf("必須指定 --email")
+	}
+
+	bookingIDs, err := retention.FindClientBookings(env.store, env.simplybook, *email)
+	if err != nil {
+		return err
+	}
+	log.Printf("客戶 %s 共有 %d 筆預約", *email, len(bookingIDs))
+
+	for _, bookingID := range bookingIDs {
+		mapping, err := env.store.GetMapping(bookingID)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("刪除預約 %s 的資料", bookingID)
+		if *dryRun {
+			continue
+		}
+
+		if *deleteEvents && mapping != nil {
+			if err := env.calendar.DeleteEvent(mapping.CalendarID, mapping.EventID); err != nil {
+				return fmt.Errorf("刪除預約 %s 的日曆事件失敗: %w", bookingID, err)
+			}
+		}
+		if err := env.store.PurgeBooking(bookingID); err != nil {
+			return fmt.Errorf("刪除預約 %s 的資料失敗: %w", bookingID, err)
+		}
+	}
+
+	return nil
+}
+
+// runSkip 管理手動排除同步的預約編號（例如內部測試預約），未指定 --add 或 --remove 時列出排除清單
+func runSkip(args []string, syncStore store.Store) error {
+	fs := flag.NewFlagSet("skip", flag.ExitOnError)
+	add := fs.String("add", "", "加入排除清單的預約編號")
+	reason := fs.String("reason", "", "排除原因，與 --add 一起使用")
+	remove := fs.String("remove", "", "移出排除清單的預約編號")
+	fs.Parse(args)
+
+	switch {
+	case *add != "" && *remove != "":
+		return fmt.Errorf("--add 與 --remove 不能同時指定")
+	case *add != "":
+		code := strings.TrimSpace(*add)
+		if err := syncStore.AddSkippedBooking(&store.SkippedBooking{BookingCode: code, Reason: *reason}); err != nil {
+			return err
+		}
+		log.Printf("已將預約 %s 加入排除清單", code)
+		return nil
+	case *remove != "":
+		code := strings.TrimSpace(*remove)
+		removed, err := syncStore.RemoveSkippedBooking(code)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("預約編號 %s 不在排除清單中", code)
+		}
+		log.Printf("已將預約 %s 移出排除清單", code)
+		return nil
+	}
+
+	skipped, err := syncStore.ListSkippedBookings()
+	if err != nil {
+		return err
+	}
+	for _, b := range skipped {
+		fmt.Printf("%s\t%s\t%s\n", b.BookingCode, b.CreatedAt.Format(time.RFC3339), b.Reason)
+	}
+	log.Printf("排除清單共有 %d 筆預約", len(skipped))
+	return nil
+}
+
+// runMigrate 將來源日曆中已同步的事件（含交通事件）搬到目標日曆：在目標日曆重新建立事件、
+// 更新對應關係後刪除原事件。重新建立而非移動事件，服務帳號不是事件擁有者時也能搬移
+func runMigrate(args []string, env *commandEnv) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	sourceCalendar := fs.String("source-calendar", "", "來源日曆 ID")
+	targetCalendar := fs.String("target-calendar", "", "目標日曆 ID")
+	dryRun := fs.Bool("dry-run", false, "僅列出將搬移的事件，不寫入日曆與儲存")
+	fs.Parse(args)
+
+	if *sourceCalendar == "" || *targetCalendar == "" {
+		return fmt.Errorf("必須指定 --source-calendar 與 --target-calendar")
+	}
+	from := env.calendar.ResolveCalendar(*sourceCalendar)
+	to := env.calendar.ResolveCalendar(*targetCalendar)
+	if from == to {
+		return fmt.Errorf("來源與目標日曆相同")
+	}
+
+	mappings, err := env.store.ListMappings()
+	if err != nil {
+		return err
+	}
+
+	var moved, missing, failed int
+	for _, m := range mappings {
+		if env.calendar.ResolveCalendar(m.CalendarID) != from {
+			continue
+		}
+
+		event, err := env.calendar.GetEvent(from, m.EventID)
+		if err != nil {
+			log.Printf("讀取預約 %s 的事件 %s 失敗: %v", m.BookingID, m.EventID, err)
+			failed++
+			continue
+		}
+		if event.Cancelled() {
+			log.Printf("預約 %s 的事件 %s 已從日曆刪除，略過", m.BookingID, m.EventID)
+			missing++
+			continue
+		}
+
+		log.Printf("預約 %s: 事件 %s → %s", m.BookingID, m.EventID, to)
+		moved++
+		if *dryRun {
+			continue
+		}
+
+		if err := migrateEvent(env, m, event, to); err != nil {
+			log.Printf("搬移預約 %s 的事件失敗: %v", m.BookingID, err)
+			moved--
+			failed++
+		}
+	}
+
+	log.Printf("搬移 %d 個事件，%d 個已刪除，%d 個失敗", moved, missing, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d 個事件搬移失敗，可重新執行以繼續", failed)
+	}
+	return nil
+}
+
+// migrateEvent 在目標日曆重新建立預約事件與其交通事件，更新對應關係後刪除原事件；
+// 對應關係更新前任何一步失敗都會刪除已在目標日曆建立的事件，重新執行時從頭開始。
+// 對應關係更新後原事件刪除失敗時，重新執行不會重複建立，但原事件需手動刪除
+func migrateEvent(env *commandEnv, m *store.Mapping, event *gcalendar.CalendarEvent, to string) error {
+	from := event.CalendarID
+
+	found, err := env.calendar.FindEventsByProperty(from, gcalendar.PropertyBookingID, m.BookingID)
+	if err != nil {
+		return err
+	}
+
+	// CreateEvent 會以新事件的 ID 覆寫傳入的事件，先記下原事件的 ID 供之後刪除
+	var oldIDs, created []string
+	rollback := func() {
+		for _, id := range created {
+			if err := env.calendar.DeleteEvent(to, id); err != nil {
+				log.Printf("刪除目標日曆中的事件 %s 失敗，需手動刪除: %v", id, err)
+			}
+		}
+	}
+
+	// 交通事件先於對應關係搬移，失敗時對應關係仍指向來源日曆，重新執行會再找到這些交通事件
+	for _, t := range found {
+		if t.Properties[gcalendar.PropertyTravel] == "" || t.Cancelled() {
+			continue
+		}
+		oldID := t.ID
+		t.CalendarID = to
+		id, err := env.calendar.CreateEvent(t)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("搬移交通事件 %s 失敗: %w", oldID, err)
+		}
+		oldIDs = append(oldIDs, oldID)
+		created = append(created, id)
+	}
+
+	oldEventID := event.ID
+	event.CalendarID = to
+	eventID, err := env.calendar.CreateEvent(event)
+	if err != nil {
+		rollback()
+		return err
+	}
+	created = append(created, eventID)
+
+	m.EventID = eventID
+	m.CalendarID = to
+	m.EventLink = event.HTMLLink
+	if err := env.store.SaveMapping(m); err != nil {
+		// 對應關係未更新，刪除新事件讓重新執行時從頭開始
+		rollback()
+		return fmt.Errorf("更新對應關係失敗: %w", err)
+	}
+
+	if err := env.calendar.DeleteEvent(from, oldEventID); err != nil {
+		log.Printf("刪除來源日曆中的事件 %s 失敗，需手動刪除: %v", oldEventID, err)
+	}
+	for _, id := range oldIDs {
+		if err := env.calendar.DeleteEvent(from, id); err != nil {
+			log.Printf("刪除來源日曆中的交通事件 %s 失敗，需手動刪除: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// runWebhook 檢查 SimplyBook 的 webhook 回呼設定，並可註冊本服務的 webhook 網址
+func runWebhook(args []string, env *commandEnv) error {
+	fs := flag.NewFlagSet("webhook", flag.ExitOnError)
+	callbackURL := fs.String("url", env.webhookURL, "本服務對外的 webhook 網址（例如 https://your-domain.com/webhook）")
+	register := fs.Bool("register", false, "設定不符時更新 SimplyBook 的回呼網址")
+	skipReachability := fs.Bool("skip-reachability", false, "不檢查網址是否可從外部連線（例如服務尚未部署）")
+	fs.Parse(args)
+
+	if *callbackURL == "" {
+		return fmt.Errorf("必須指定 --url 或設置 SIMPLYBOOK_WEBHOOK_URL")
+	}
+
+	if !*skipReachability {
+		if err := simplybook.CheckCallbackReachable(env.httpClient, *callbackURL); err != nil {
+			return err
+		}
+		log.Printf("回呼網址 %s 可正常連線", *callbackURL)
+	}
+
+	changed, err := env.simplybook.EnsureCallbackURL(*callbackURL, *register)
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Printf("已將 SimplyBook 的 webhook 回呼網址設為 %s", *callbackURL)
+	} else {
+		log.Printf("SimplyBook 的 webhook 回呼網址已正確設定")
+	}
+
+	return nil
+}
+
+// findBookingCode 在事件描述中尋找已知的預約編號，優先採用預約編號標記，沒有標記時逐字比對
+func findBookingCode(description string, byCode map[string]*simplybook.Booking) *simplybook.Booking {
+	if code, ok := gcalendar.ParseBookingCode(description); ok {
+		return byCode[code]
+	}
+	for _, word := range gcalendar.DescriptionWords(description) {
+		if booking, ok := byCode[word]; ok {
+			return booking
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/config"
+	"github.com/booking-sync-455103/booking-sync/pkg/chaos"
+	"github.com/booking-sync-455103/booking-sync/pkg/version"
+)
+
+// startupSummary 是啟動時以單行 JSON 輸出到標準輸出的摘要；
+// severity 與 message 欄位讓 Cloud Run 等平台將其解析為結構化日誌
+type startupSummary struct {
+	Severity     string             `json:"severity"`
+	Message      string             `json:"message"`
+	Event        string             `json:"event"`
+	Version      string             `json:"version"`
+	GoVersion    string             `json:"go_version"`
+	Instance     string             `json:"instance"`
+	Dev          bool               `json:"dev,omitempty"`
+	StartupMS    float64            `json:"startup_ms"` // 從程序啟動到開始接收請求的時間
+	Warnings     []string           `json:"warnings,omitempty"`
+	Subsystems   []startupSubsystem `json:"subsystems"`
+	Dependencies []dependencyCheck  `json:"dependencies"`
+	Config       *config.Config     `json:"config"` // 已遮蔽敏感欄位
+}
+
+// startupSubsystem 是啟動摘要中的子系統狀態
+type startupSubsystem struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// dependency 是啟動時檢查的相依服務
+type dependency struct {
+	name  string
+	check func() error
+}
+
+// dependencyCheck 是相依服務的檢查結果
+type dependencyCheck struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// emitStartupDiagnostics 並行檢查相依服務後輸出啟動摘要。檢查失敗不中斷服務，
+// 摘要的 severity 改為 WARNING，方便在冷啟動的日誌中篩選
+func emitStartupDiagnostics(cfg *config.Config, instanceID string, startup time.Duration, deps []dependency) {
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		log.Printf("輸出啟動摘要失敗: %v", err)
+		return
+	}
+
+	summary := &startupSummary{
+		Severity:     "INFO",
+		Message:      "booking-sync 啟動摘要",
+		Event:        "startup",
+		Version:      version.Version,
+		GoVersion:    runtime.Version(),
+		Instance:     instanceID,
+		Dev:          cfg.Dev,
+		StartupMS:    milliseconds(startup),
+		Warnings:     cfg.Warnings,
+		Subsystems:   startupSubsystems(cfg),
+		Dependencies: checkDependencies(deps),
+		Config:       redacted,
+	}
+	for _, d := range summary.Dependencies {
+		if !d.OK {
+			summary.Severity = "WARNING"
+			log.Printf("警告: 啟動檢查 %s 失敗: %s", d.Name, d.Error)
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(summary); err != nil {
+		log.Printf("輸出啟動摘要失敗: %v", err)
+	}
+}
+
+// checkDependencies 並行執行相依服務檢查，結果依傳入順序排列
+func checkDependencies(deps []dependency) []dependencyCheck {
+	results := make([]dependencyCheck, len(deps))
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			start := time.Now()
+			err := dep.check()
+			results[i] = dependencyCheck{Name: dep.name, OK: err == nil, LatencyMS: milliseconds(time.Since(start))}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, dep)
+	}
+	wg.Wait()
+	return results
+}
+
+// startupSubsystems 依有效配置列出各子系統是否啟用及其主要設定
+func startupSubsystems(cfg *config.Config) []startupSubsystem {
+	store := cfg.Store.Driver
+	if cfg.Store.EncryptionKeys != "" {
+		store += "，已加密"
+	}
+
+	var notifiers []string
+	if cfg.Notify.SMTP.Host != "" && len(cfg.Notify.SMTP.To) > 0 {
+		notifiers = append(notifiers, "smtp")
+	}
+	if cfg.Notify.SlackWebhookURL != "" {
+		notifiers = append(notifiers, "slack")
+	}
+
+	var reports []string
+	if cfg.Report.SheetID != "" {
+		reports = append(reports, "sheets")
+	}
+	if cfg.Report.Bucket != "" {
+		reports = append(reports, "gcs")
+	}
+	if cfg.Report.BigQuery.Dataset != "" {
+		reports = append(reports, "bigquery")
+	}
+
+	var errorSinks []string
+	if cfg.ErrorReporting.SentryDSN != "" {
+		errorSinks = append(errorSinks, "sentry")
+	}
+	if cfg.ErrorReporting.Google {
+		errorSinks = append(errorSinks, "google")
+	}
+
+	retention := cfg.Retention.SyncRecords.Duration > 0 || cfg.Retention.DeadLetters.Duration > 0 || cfg.Retention.Payloads.Duration > 0
+	watch := cfg.GoogleCalendar.Watch.Address != "" && cfg.Event.Notes
+	chaosEnabled := cfg.Chaos.CalendarWriteFailRate > 0 || cfg.Chaos.SimplyBookDelay.Duration > 0
+	shedding := cfg.LoadShedding.MaxHeapMB > 0 || cfg.LoadShedding.MaxQueueDepth > 0
+	providers, services := cfg.Sync.Providers, cfg.Sync.Services
+	scoped := len(providers.Allow)+len(providers.Deny)+len(services.Allow)+len(services.Deny) > 0
+
+	return []startupSubsystem{
+		{"store", true, store},
+		{"lock", true, cfg.Lock.Backend},
+		{"redis", cfg.Redis.Addr != "", cfg.Redis.Addr},
+		{"webhook_debounce", cfg.Sync.Debounce.Duration > 0, durationDetail(cfg.Sync.Debounce.Duration)},
+		{"sync_scope", scoped, fmt.Sprintf("服務提供者允許 %d、排除 %d，服務允許 %d、排除 %d",
+			len(providers.Allow), len(providers.Deny), len(services.Allow), len(services.Deny))},
+		{"calendar_outbox", true, "探測間隔 " + cfg.GoogleCalendar.ProbeInterval.Duration.String()},
+		{"reconcile", cfg.Reconcile.Interval.Duration > 0, durationDetail(cfg.Reconcile.Interval.Duration)},
+		{"load_shedding", shedding, fmt.Sprintf("堆積記憶體 %d MB，處理中 webhook %d 筆", cfg.LoadShedding.MaxHeapMB, cfg.LoadShedding.MaxQueueDepth)},
+		{"webhook_watchdog", cfg.Watchdog.Silence.Duration > 0, fmt.Sprintf("靜默 %s，輪詢間隔 %s", cfg.Watchdog.Silence.Duration, cfg.Watchdog.Interval.Duration)},
+		{"calendar_watch", watch, cfg.GoogleCalendar.Watch.Address},
+		{"retention", retention, "清除間隔 " + cfg.Retention.Interval.Duration.String()},
+		{"schedules", len(cfg.Schedules) > 0, fmt.Sprintf("%d 個任務", len(cfg.Schedules))},
+		{"digest", cfg.Digest.Time != "" && len(notifiers) > 0, cfg.Digest.Time},
+		{"notifications", len(notifiers) > 0, strings.Join(notifiers, ",")},
+		{"reminders", cfg.Reminder.Before.Duration > 0, durationDetail(cfg.Reminder.Before.Duration)},
+		{"client_invites", cfg.Event.InviteClients, ""},
+		{"confirmations", cfg.Confirmation.Enabled, ""},
+		{"slack_approvals", cfg.Approval.SlackWebhookURL != "", cfg.Approval.SlackActionsPath},
+		{"calendly", cfg.Calendly.Token != "", cfg.Calendly.WebhookPath},
+		{"stripe", cfg.Stripe.SecretKey != "", ""},
+		{"reports", len(reports) > 0, strings.Join(reports, ",")},
+		{"audit", cfg.Audit.Address != "", cfg.Audit.Format},
+		{"error_reporting", len(errorSinks) > 0, strings.Join(errorSinks, ",")},
+		{"admin_ui", cfg.Admin.Password != "", "/ui"},
+		{"chaos", chaosEnabled && chaos.Available, ""},
+		{"dev_simulator", cfg.Dev, "/dev"},
+	}
+}
+
+// durationDetail 將時間長度轉為摘要說明，未設置時為空
+func durationDetail(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
+}
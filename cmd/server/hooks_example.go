@@ -0,0 +1,26 @@
+//go:build examplehooks
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// 範例 hook：以 go build -tags examplehooks 編入，
+// 不建立內部服務的事件，並在其他事件標題加上服務名稱
+func init() {
+	handler.RegisterHook(handler.HookFuncs{
+		Create: func(booking *simplybook.Booking, event *gcalendar.CalendarEvent) error {
+			if strings.Contains(booking.ServiceName, "內部") {
+				return fmt.Errorf("內部服務不寫入日曆: %w", handler.ErrVeto)
+			}
+			event.Summary = fmt.Sprintf("[%s] %s", booking.ServiceName, event.Summary)
+			return nil
+		},
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// shutdownTimeout 是收到關閉信號後停止所有子系統的時限
+const shutdownTimeout = 10 * time.Second
+
+// lifecycle 管理子系統的啟動與關閉：子系統依註冊順序啟動，關閉時依相反順序停止，
+// 因此應先註冊被依賴的子系統（例如錯誤回報），最後註冊接收請求的 HTTP 伺服器
+type lifecycle struct {
+	hooks   []lifecycleHook
+	started int // 已啟動的子系統數量，關閉時只停止這些子系統
+}
+
+// lifecycleHook 是子系統的啟動與停止函式，兩者皆可為 nil
+type lifecycleHook struct {
+	name  string
+	start func(ctx context.Context) error
+	stop  func(ctx context.Context) error
+}
+
+// Add 註冊子系統的啟動與停止函式
+func (l *lifecycle) Add(name string, start, stop func(ctx context.Context) error) {
+	l.hooks = append(l.hooks, lifecycleHook{name: name, start: start, stop: stop})
+}
+
+// OnStop 註冊只需在關閉時執行的函式，例如送出緩衝中的資料
+func (l *lifecycle) OnStop(name string, stop func(ctx context.Context) error) {
+	l.Add(name, nil, stop)
+}
+
+// Go 註冊在背景執行直到 context 取消的子系統（例如定期任務），停止時取消 context 並等待其結束
+func (l *lifecycle) Go(name string, run func(ctx context.Context)) {
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	l.Add(name, func(ctx context.Context) error {
+		var runCtx context.Context
+		runCtx, cancel = context.WithCancel(context.Background())
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			run(runCtx)
+		}()
+		return nil
+	}, func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("未在時限內結束（可能仍在執行任務）")
+		}
+	})
+}
+
+// Start 依註冊順序啟動子系統，任一子系統啟動失敗時停止已啟動的子系統並返回錯誤
+func (l *lifecycle) Start(ctx context.Context) error {
+	for _, hook := range l.hooks {
+		if hook.start != nil {
+			if err := hook.start(ctx); err != nil {
+				l.Stop(ctx)
+				return fmt.Errorf("啟動 %s 失敗: %w", hook.name, err)
+			}
+		}
+		l.started++
+	}
+	return nil
+}
+
+// Stop 依註冊的相反順序停止已啟動的子系統；個別子系統停止失敗或逾時時記錄日誌並繼續停止其他子系統，
+// 返回停止失敗的子系統數量
+func (l *lifecycle) Stop(ctx context.Context) int {
+	failed := 0
+	for i := l.started - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if hook.stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := hook.stop(ctx); err != nil {
+			log.Printf("停止 %s 失敗: %v", hook.name, err)
+			failed++
+			continue
+		}
+		log.Printf("已停止 %s（%s）", hook.name, time.Since(start).Round(time.Millisecond))
+	}
+	l.started = 0
+	return failed
+}
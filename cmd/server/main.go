@@ -6,21 +6,51 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/booking-sync-455103/booking-sync/config"
+	"github.com/booking-sync-455103/booking-sync/pkg/admin"
+	"github.com/booking-sync-455103/booking-sync/pkg/audit"
+	"github.com/booking-sync-455103/booking-sync/pkg/chaos"
+	"github.com/booking-sync-455103/booking-sync/pkg/confirm"
+	"github.com/booking-sync-455103/booking-sync/pkg/dedup"
+	"github.com/booking-sync-455103/booking-sync/pkg/digest"
+	"github.com/booking-sync-455103/booking-sync/pkg/encrypt"
+	"github.com/booking-sync-455103/booking-sync/pkg/errreport"
+	"github.com/booking-sync-455103/booking-sync/pkg/export"
+	"github.com/booking-sync-455103/booking-sync/pkg/fake"
 	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
 	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/httpclient"
+	"github.com/booking-sync-455103/booking-sync/pkg/jobs"
+	"github.com/booking-sync-455103/booking-sync/pkg/lock"
+	"github.com/booking-sync-455103/booking-sync/pkg/logging"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/notify"
+	"github.com/booking-sync-455103/booking-sync/pkg/payment"
+	"github.com/booking-sync-455103/booking-sync/pkg/reminder"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
+	"github.com/booking-sync-455103/booking-sync/pkg/retention"
+	"github.com/booking-sync-455103/booking-sync/pkg/rules"
 	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+	"github.com/booking-sync-455103/booking-sync/pkg/version"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	startedAt := time.Now()
+
 	// 解析命令行參數
 	configPath := flag.String("config", "", "配置文件路徑")
+	dev := flag.Bool("dev", false, "開發模式：使用記憶體儲存與模擬的 SimplyBook 及 Google 日曆，並在 /dev 提供 webhook 模擬器，不需任何憑證")
 	flag.Parse()
 
 	// 如果沒有指定配置文件，則使用環境變數
@@ -31,49 +61,715 @@ func main() {
 		}
 	}
 
-	// 加載配置
-	cfg, err := config.LoadConfig(*configPath)
+	log.Printf("booking-sync 版本: %s", version.Version)
+
+	// 基準測試使用模擬伺服器，不需要配置文件與憑證
+	if flag.Arg(0) == "bench" {
+		if err := runBench(flag.Args()[1:]); err != nil {
+			log.Fatalf("基準測試失敗: %v", err)
+		}
+		return
+	}
+
+	// 加載配置，開發模式不要求憑證
+	loadConfig := config.LoadConfig
+	if *dev {
+		loadConfig = config.LoadDevConfig
+	}
+	cfg, err := loadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("加載配置失敗: %v", err)
 	}
+	for _, warning := range cfg.Warnings {
+		log.Printf("配置警告: %s", warning)
+	}
+	if err := logging.SetLevel(cfg.Log.Level); err != nil {
+		log.Fatalf("設置日誌等級失敗: %v", err)
+	}
+
+	// 輸出有效配置不需要連線任何外部服務
+	if flag.Arg(0) == "print-effective-config" {
+		if err := printEffectiveConfig(cfg); err != nil {
+			log.Fatalf("輸出有效配置失敗: %v", err)
+		}
+		return
+	}
+
+	// 初始化同步狀態儲存
+	var syncStore store.Store
+	switch cfg.Store.Driver {
+	case "postgres":
+		pgStore, err := store.NewPostgresStore(cfg.Store.DSN)
+		if err != nil {
+			log.Fatalf("初始化 PostgreSQL 儲存失敗: %v", err)
+		}
+		defer pgStore.Close()
+		syncStore = pgStore
+	case "bolt":
+		boltStore, err := store.NewBoltStore(cfg.Store.Path)
+		if err != nil {
+			log.Fatalf("初始化 BoltDB 儲存失敗: %v", err)
+		}
+		defer boltStore.Close()
+		syncStore = boltStore
+	default:
+		syncStore = store.NewMemoryStore()
+	}
+	log.Printf("使用 %s 同步狀態儲存", cfg.Store.Driver)
+
+	// 加密儲存中含個人資料的預約快照（可選）
+	if cfg.Store.EncryptionKeys != "" {
+		keys, err := encrypt.ParseKeys(cfg.Store.EncryptionKeys)
+		if err != nil {
+			log.Fatalf("解析儲存加密金鑰失敗: %v", err)
+		}
+		syncStore = store.NewEncryptedStore(syncStore, keys)
+		log.Printf("已啟用儲存加密，主要金鑰: %s", keys.PrimaryKeyID())
+	}
+
+	// 創建對外 HTTP 客戶端（支援代理與自訂 CA）
+	outboundClient, err := httpclient.New(httpclient.Options{
+		ProxyURL: cfg.HTTP.ProxyURL,
+		CAFile:   cfg.HTTP.CAFile,
+		Timeout:  30 * time.Second,
+		Record:   cfg.HTTP.Record,
+		Replay:   cfg.HTTP.Replay,
+
+		MaxIdleConnsPerHost: cfg.HTTP.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.HTTP.MaxConnsPerHost,
+		IdleConnTimeout:     cfg.HTTP.IdleConnTimeout.Duration,
+		DisableKeepAlives:   cfg.HTTP.DisableKeepAlives,
+		DisableHTTP2:        cfg.HTTP.DisableHTTP2,
+		TLSSessionCache:     cfg.HTTP.TLSSessionCache,
+	})
+	if err != nil {
+		log.Fatalf("初始化對外 HTTP 客戶端失敗: %v", err)
+	}
+
+	// 開發模式將所有對外請求導向本機的模擬伺服器
+	var devServer *fake.Server
+	if cfg.Dev {
+		devServer = fake.NewServer()
+		defer devServer.Close()
+		outboundClient = devServer.Client()
+		log.Printf("開發模式: SimplyBook 與 Google 日曆由模擬伺服器 %s 取代", devServer.URL())
+	}
+
+	// 測試環境的故障注入，僅在以 -tags chaos 建置時生效
+	chaosOpts := chaos.Options{
+		CalendarWriteFailRate: cfg.Chaos.CalendarWriteFailRate,
+		SimplyBookDelay:       cfg.Chaos.SimplyBookDelay.Duration,
+	}
+	if chaosOpts.Enabled() {
+		if chaos.Available {
+			outboundClient.Transport = chaos.Wrap(outboundClient.Transport, chaosOpts)
+			log.Printf("警告: 已啟用故障注入，日曆寫入失敗比例 %g，SimplyBook 延遲 %s",
+				chaosOpts.CalendarWriteFailRate, chaosOpts.SimplyBookDelay)
+		} else {
+			log.Println("警告: 已設置故障注入，但此版本未以 -tags chaos 建置，設定不會生效")
+		}
+	}
+
+	// 自我測試自行建立客戶端，以便回報每個步驟的結果
+	if flag.Arg(0) == "selftest" {
+		if err := runSelftest(cfg, outboundClient); err != nil {
+			log.Fatalf("自我測試失敗: %v", err)
+		}
+		return
+	}
+
+	// 指標以 SimplyBook 公司登入名作為租戶標籤
+	metrics.SetTenant(cfg.SimplyBook.CompanyLogin)
 
 	// 初始化 SimplyBook 客戶端
 	simplybookClient, err := simplybook.NewClient(
 		cfg.SimplyBook.CompanyLogin,
 		cfg.SimplyBook.UserName,
 		cfg.SimplyBook.Password,
+		simplybook.Options{
+			HTTPClient: outboundClient,
+			UserAgent:  cfg.SimplyBook.UserAgent,
+			Headers:    cfg.SimplyBook.Headers,
+
+			BasicBookings: cfg.SimplyBook.BasicBookings,
+		},
 	)
 	if err != nil {
 		log.Fatalf("初始化 SimplyBook 客戶端失敗: %v", err)
 	}
 
-	// 載入 Google 服務帳號憑證
-	googleCreds, err := config.LoadGoogleCredentials(cfg.GoogleCalendar.CredentialsFile)
-	if err != nil {
-		log.Fatalf("載入 Google 憑證失敗: %v", err)
+	// 載入 Google 服務帳號憑證，開發模式使用模擬伺服器產生的憑證
+	var googleCreds [][]byte
+	if devServer != nil {
+		creds, err := devServer.Credentials()
+		if err != nil {
+			log.Fatalf("產生模擬 Google 憑證失敗: %v", err)
+		}
+		googleCreds = [][]byte{creds}
+	} else {
+		googleCreds, err = cfg.GoogleCredentialsList()
+		if err != nil {
+			log.Fatalf("載入 Google 憑證失敗: %v", err)
+		}
 	}
 
 	// 初始化 Google 日曆客戶端
-	calendarClient, err := gcalendar.NewClient(googleCreds, cfg.GoogleCalendar.CalendarID)
+	calendarClient, err := gcalendar.NewClientWithCredentials(googleCreds, cfg.GoogleCalendar.CalendarID, outboundClient)
 	if err != nil {
 		log.Fatalf("初始化 Google 日曆客戶端失敗: %v", err)
 	}
+	calendarClient.SetBudget(gcalendar.Budget{
+		QPS:         cfg.GoogleCalendar.WriteBudget.QPS,
+		CalendarQPS: cfg.GoogleCalendar.WriteBudget.CalendarQPS,
+		Daily:       cfg.GoogleCalendar.WriteBudget.Daily,
+	})
+
+	// 執行命令行子命令後結束，不啟動服務
+	if flag.NArg() > 0 {
+		env := &commandEnv{store: syncStore, simplybook: simplybookClient, calendar: calendarClient, httpClient: outboundClient, webhookURL: cfg.SimplyBook.WebhookURL}
+		if err := runCommand(flag.Args(), env); err != nil {
+			log.Fatalf("執行 %s 失敗: %v", flag.Arg(0), err)
+		}
+		return
+	}
+
+	// 檢查 SimplyBook 的 webhook 回呼設定，設定錯誤時不中斷啟動
+	if cfg.SimplyBook.WebhookURL != "" {
+		changed, err := simplybookClient.EnsureCallbackURL(cfg.SimplyBook.WebhookURL, cfg.SimplyBook.RegisterWebhook)
+		switch {
+		case err != nil:
+			log.Printf("警告: 檢查 SimplyBook webhook 設定失敗: %v", err)
+		case changed:
+			log.Printf("已將 SimplyBook 的 webhook 回呼網址設為 %s", cfg.SimplyBook.WebhookURL)
+		default:
+			log.Printf("SimplyBook 的 webhook 回呼網址已正確設定")
+		}
+	}
+
+	// 連接 Redis（可選），用於跨實例的去重與預約鎖
+	var redisClient *redis.Client
+	if cfg.Redis.Addr != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer redisClient.Close()
+
+		if err := redisClient.Ping(context.Background()).Err(); err != nil {
+			log.Fatalf("連接 Redis 失敗: %v", err)
+		}
+		log.Printf("已連接 Redis: %s", cfg.Redis.Addr)
+	}
+
+	// 初始化分散式鎖，確保背景任務在多個實例中只執行一次
+	instanceID := lock.InstanceID()
+	var locker lock.Locker
+	switch cfg.Lock.Backend {
+	case "gcs":
+		locker, err = lock.NewGCSLocker(googleCreds[0], cfg.Lock.Bucket, cfg.Lock.Prefix, instanceID)
+		if err != nil {
+			log.Fatalf("初始化 GCS 分散式鎖失敗: %v", err)
+		}
+	case "redis":
+		locker = lock.NewRedisLocker(redisClient, cfg.Redis.Prefix+"lock:", instanceID)
+	default:
+		locker = lock.NewMemoryLocker(instanceID)
+	}
+	log.Printf("使用 %s 分散式鎖，實例 ID: %s", cfg.Lock.Backend, instanceID)
+
+	// 初始化通知管道
+	var notifiers notify.Multi
+	var smtpNotifier *notify.SMTPNotifier
+	if cfg.Notify.SMTP.Host != "" {
+		smtpNotifier = &notify.SMTPNotifier{
+			Host:     cfg.Notify.SMTP.Host,
+			Port:     cfg.Notify.SMTP.Port,
+			Username: cfg.Notify.SMTP.Username,
+			Password: cfg.Notify.SMTP.Password,
+			From:     cfg.Notify.SMTP.From,
+			To:       cfg.Notify.SMTP.To,
+		}
+		if len(smtpNotifier.To) > 0 {
+			notifiers = append(notifiers, smtpNotifier)
+		}
+	}
+	if cfg.Notify.SlackWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewSlackNotifier(cfg.Notify.SlackWebhookURL))
+	}
+
+	// 初始化事件規則，狀態圖示排在最前面
+	eventRules := rules.IconRules(cfg.Event.StatusIcons, cfg.Event.PaymentIcons)
+	for _, r := range cfg.Event.Rules {
+		eventRules = append(eventRules, rules.Rule{
+			Name: r.Name,
+			Match: rules.Match{
+				Services:   r.Match.Services,
+				Categories: r.Match.Categories,
+				Providers:  r.Match.Providers,
+				Statuses:   r.Match.Statuses,
+				Payments:   r.Match.Payments,
+				Fields:     r.Match.Fields,
+			},
+			TitlePrefix: r.TitlePrefix,
+			TitleSuffix: r.TitleSuffix,
+			ColorID:     r.ColorID,
+			CalendarID:  r.CalendarID,
+			Timezone:    r.Timezone,
+			Checklist:   r.Checklist,
+			ChecklistBy: r.ChecklistBy.Duration,
+			Skip:        r.Skip,
+			Stop:        r.Stop,
+
+			TravelBefore: r.TravelBefore.Duration,
+			TravelAfter:  r.TravelAfter.Duration,
+			TravelMode:   r.TravelMode,
+		})
+	}
+	ruleEngine, err := rules.New(eventRules)
+	if err != nil {
+		log.Fatalf("初始化事件規則失敗: %v", err)
+	}
+
+	// 初始化事件渲染器
+	renderOpts := render.Options{
+		Company:  cfg.SimplyBook.CompanyLogin,
+		AdminURL: cfg.SimplyBook.AdminURL,
+		Rules:    ruleEngine,
+		FieldMap: cfg.Event.FieldMap,
+		Notes:    cfg.Event.Notes,
+
+		InviteClients: cfg.Event.InviteClients,
+		CodeMarker:    cfg.Event.CodeMarker,
+
+		TitleMaxLength:    cfg.Event.TitleMaxLength,
+		Locale:            cfg.Locale,
+		ResourceCalendars: cfg.Event.ResourceCalendars,
+		GroupClasses:      cfg.Event.GroupClasses,
+
+		WaitingList:      cfg.Event.WaitingList.Mode,
+		WaitingListColor: cfg.Event.WaitingList.ColorID,
+	}
+	for _, l := range cfg.Event.Links {
+		renderOpts.Links = append(renderOpts.Links, render.LinkOption{Title: l.Title, URL: l.URL, Mode: l.Mode})
+	}
+	renderer, err := render.New(renderOpts)
+	if err != nil {
+		log.Fatalf("初始化事件渲染器失敗: %v", err)
+	}
+
+	// 營業時間已於載入配置時驗證
+	businessHours, _ := cfg.BusinessHoursSet()
+
+	// 設置 webhook 去重與預約鎖，未配置 Redis 時退回記憶體實作
+	handlerOpts := handler.Options{
+		Renderer:     renderer,
+		PastWindow:   cfg.Sync.PastWindow.Duration,
+		FutureWindow: cfg.Sync.FutureWindow.Duration,
+		Scope: handler.Scope{
+			AllowProviders: cfg.Sync.Providers.Allow,
+			DenyProviders:  cfg.Sync.Providers.Deny,
+			AllowServices:  cfg.Sync.Services.Allow,
+			DenyServices:   cfg.Sync.Services.Deny,
+		},
+		TimeTolerance: cfg.Sync.TimeTolerance.Duration,
+		MaxDuration:   cfg.Sync.MaxDuration.Duration,
+
+		SendUpdates:        cfg.Event.SendUpdates,
+		UpdateSignificance: cfg.Event.UpdateSignificance,
+		Debounce:           cfg.Sync.Debounce.Duration,
+
+		BookingCacheSize: cfg.BookingCache.Size,
+		BookingCacheTTL:  cfg.BookingCache.TTL.Duration,
+
+		NotFoundThreshold: cfg.BookingCache.NotFoundThreshold,
+		NotFoundTTL:       cfg.BookingCache.NotFoundTTL.Duration,
+
+		MaxQueueDepth: cfg.Server.MaxQueueDepth,
+		RetryAfter:    cfg.Server.RetryAfter.Duration,
+
+		SlowFetchThreshold: cfg.Server.SlowFetchThreshold.Duration,
+		LatencySLO:         cfg.Server.LatencySLO.Duration,
+
+		MaxWebhookAge: cfg.Server.WebhookMaxAge.Duration,
+		ReplayToken:   cfg.Server.ReplayToken,
+
+		DegradedBackoff: cfg.GoogleCalendar.ProbeInterval.Duration,
+		DedupTTL:        cfg.Dedup.TTL.Duration,
+		LockTTL:         cfg.Lock.TTL.Duration,
+		AlertCooldown:   cfg.Notify.AlertCooldown.Duration,
+
+		WebhookSilence: cfg.Watchdog.Silence.Duration,
+		CatchUpWindow:  cfg.Watchdog.CatchUpWindow.Duration,
+	}
+	handlerOpts.BusinessHours = businessHours
+	handlerOpts.Tenant = cfg.SimplyBook.CompanyLogin
+	if len(notifiers) > 0 {
+		handlerOpts.OpsNotifier = notifiers
+	}
+	if redisClient != nil {
+		handlerOpts.Deduper = dedup.NewRedisDeduper(redisClient, cfg.Redis.Prefix+"dedup:")
+		handlerOpts.Locker = lock.NewRedisLocker(redisClient, cfg.Redis.Prefix+"lock:", instanceID)
+	}
+
+	// 初始化客戶確認郵件（可選）
+	if cfg.Confirmation.Enabled {
+		confirmations, err := confirm.NewSender(smtpNotifier, renderer, cfg.Confirmation.Subject, cfg.Confirmation.Body)
+		if err != nil {
+			log.Fatalf("初始化確認郵件失敗: %v", err)
+		}
+		handlerOpts.Confirmations = confirmations
+		log.Println("已啟用客戶確認郵件")
+	}
+
+	// 初始化簡訊提醒（可選）
+	var reminderScheduler *reminder.Scheduler
+	if cfg.Reminder.Before.Duration > 0 {
+		sms := notify.NewTwilioSMS(
+			cfg.Reminder.Twilio.AccountSID,
+			cfg.Reminder.Twilio.AuthToken,
+			cfg.Reminder.Twilio.From,
+		)
+		reminderScheduler, err = reminder.NewScheduler(syncStore, sms, cfg.Reminder.Before.Duration, cfg.Reminder.Template, cfg.Locale)
+		if err != nil {
+			log.Fatalf("初始化簡訊提醒失敗: %v", err)
+		}
+		reminderScheduler.SetBusinessHours(businessHours, cfg.SimplyBook.CompanyLogin)
+		handlerOpts.Reminders = reminderScheduler
+	}
+
+	// 設定已在載入時驗證
+	loc, _ := cfg.Location()
+
+	// 初始化同步報表輸出（可選）
+	var reports export.MultiSink
+	if cfg.Report.SheetID != "" {
+		sink, err := export.NewSheetsSink(googleCreds[0], cfg.Report.SheetID, cfg.Report.SheetRange, outboundClient)
+		if err != nil {
+			log.Fatalf("初始化試算表報表失敗: %v", err)
+		}
+		reports = append(reports, sink)
+		log.Printf("同步結果將附加到試算表 %s", cfg.Report.SheetID)
+	}
+	if cfg.Report.Bucket != "" {
+		sink, err := export.NewGCSSink(googleCreds[0], cfg.Report.Bucket, cfg.Report.Prefix, loc, outboundClient)
+		if err != nil {
+			log.Fatalf("初始化 GCS 報表失敗: %v", err)
+		}
+		reports = append(reports, sink)
+		log.Printf("同步結果將寫入 gs://%s/%s 的每日 CSV", cfg.Report.Bucket, cfg.Report.Prefix)
+	}
+	if bq := cfg.Report.BigQuery; bq.Dataset != "" {
+		sink, err := export.NewBigQuerySink(googleCreds[0], bq.Project, bq.Dataset, bq.Table, outboundClient)
+		if err != nil {
+			log.Fatalf("初始化 BigQuery 輸出失敗: %v", err)
+		}
+		created, err := sink.EnsureTable()
+		if err != nil {
+			log.Fatalf("初始化 BigQuery 輸出失敗: %v", err)
+		}
+		if created {
+			log.Printf("已建立 BigQuery 資料表 %s", sink.Table())
+		}
+		reports = append(reports, sink)
+		log.Printf("預約事件將串流寫入 BigQuery 資料表 %s", sink.Table())
+	}
+	if len(reports) > 0 {
+		handlerOpts.Reports = reports
+	}
+
+	// 訂閱日曆變更通知，將日曆上編輯的備註寫回 SimplyBook（可選）
+	if watch := cfg.GoogleCalendar.Watch; watch.Address != "" {
+		if cfg.Event.Notes {
+			handlerOpts.WatchAddress = watch.Address
+			handlerOpts.WatchToken = watch.Token
+			handlerOpts.WatchTTL = watch.TTL.Duration
+			for _, r := range cfg.Event.Rules {
+				if r.CalendarID != "" {
+					handlerOpts.WatchCalendars = append(handlerOpts.WatchCalendars, r.CalendarID)
+				}
+			}
+		} else {
+			log.Println("警告: 已設置 GOOGLE_CALENDAR_WATCH_ADDRESS 但未啟用 EVENT_NOTES，不訂閱日曆變更通知")
+		}
+	}
+
+	// 以 Slack 按鈕核准待核准的預約（可選）
+	var slackApprovals *notify.SlackApprovals
+	if cfg.Approval.SlackWebhookURL != "" {
+		slackApprovals = notify.NewSlackApprovals(cfg.Approval.SlackWebhookURL, cfg.Approval.SlackSigningSecret)
+		handlerOpts.Approvals = slackApprovals
+	}
+
+	// 子系統依註冊順序啟動、相反順序關閉：先註冊稽核與錯誤回報，關閉時才能記錄其他子系統停止前的失敗
+	lc := &lifecycle{}
+
+	// 轉送同步稽核事件到 syslog 或 fluentd（可選）
+	var auditForwarder *audit.Forwarder
+	if cfg.Audit.Address != "" {
+		auditForwarder, err = audit.New(audit.Options{
+			Format:  cfg.Audit.Format,
+			Network: cfg.Audit.Network,
+			Address: cfg.Audit.Address,
+			Tag:     cfg.Audit.Tag,
+			Rate:    cfg.Audit.Rate,
+		})
+		if err != nil {
+			log.Fatalf("初始化稽核事件轉送失敗: %v", err)
+		}
+		handlerOpts.Audit = auditForwarder
+		lc.OnStop("audit", func(context.Context) error {
+			auditForwarder.Close()
+			return nil
+		})
+		log.Printf("同步稽核事件將以 %s 格式轉送到 %s", cfg.Audit.Format, cfg.Audit.Address)
+	}
+
+	// 回報同步失敗到 Sentry 或 Google Error Reporting（可選）
+	var errorSinks []errreport.Sink
+	if dsn := cfg.ErrorReporting.SentryDSN; dsn != "" {
+		sink, err := errreport.NewSentry(dsn, cfg.ErrorReporting.Environment, outboundClient)
+		if err != nil {
+			log.Fatalf("初始化 Sentry 失敗: %v", err)
+		}
+		errorSinks = append(errorSinks, sink)
+		log.Println("同步失敗將回報到 Sentry")
+	}
+	if cfg.ErrorReporting.Google {
+		sink, err := errreport.NewGoogle(googleCreds[0], cfg.ErrorReporting.Project, outboundClient)
+		if err != nil {
+			log.Fatalf("初始化 Google Error Reporting 失敗: %v", err)
+		}
+		errorSinks = append(errorSinks, sink)
+		log.Println("同步失敗將回報到 Google Error Reporting")
+	}
+	var errorReporter *errreport.Reporter
+	if len(errorSinks) > 0 {
+		errorReporter = errreport.New(errorSinks...)
+		handlerOpts.Errors = errorReporter
+		lc.OnStop("error-reporting", func(context.Context) error {
+			errorReporter.Close()
+			return nil
+		})
+	}
+
+	// 預約缺少服務或服務提供者名稱時，以快取的列表補上
+	handlerOpts.Enrichers = append(handlerOpts.Enrichers, simplybook.NewNameResolver(simplybookClient))
+
+	// 規則依服務分類比對時，以快取的分類列表補上預約服務所屬的分類
+	for _, r := range cfg.Event.Rules {
+		if len(r.Match.Categories) > 0 {
+			handlerOpts.Enrichers = append(handlerOpts.Enrichers, simplybook.NewCategoryResolver(simplybookClient))
+			break
+		}
+	}
+
+	// 初始化 Stripe 付款狀態（可選）
+	if cfg.Stripe.SecretKey != "" {
+		handlerOpts.Enrichers = append(handlerOpts.Enrichers, payment.NewStripe(cfg.Stripe.SecretKey, cfg.Stripe.MetadataKey, outboundClient))
+		log.Println("已啟用 Stripe 付款狀態")
+	}
+
+	// 初始化 Calendly webhook（可選）
+	var calendlySource *source.Calendly
+	if cfg.Calendly.Token != "" {
+		calendlySource = source.NewCalendly(cfg.Calendly.Token, cfg.Calendly.SigningKey, outboundClient, loc)
+		handlerOpts.Sources = append(handlerOpts.Sources, calendlySource)
+		if cfg.Calendly.SigningKey == "" {
+			log.Println("警告: 未設置 CALENDLY_SIGNING_KEY，將不驗證 Calendly webhook 的簽章")
+		}
+	}
+
+	// 各租戶的 SimplyBook webhook 令牌
+	webhookSecrets := make(map[string]source.WebhookSecret, len(cfg.SimplyBook.WebhookSecrets))
+	for company, secret := range cfg.SimplyBook.WebhookSecrets {
+		webhookSecrets[company] = source.WebhookSecret{
+			Token:         secret.Token,
+			PreviousToken: secret.PreviousToken,
+			PreviousUntil: secret.PreviousUntil,
+		}
+	}
+	if len(webhookSecrets) == 0 {
+		log.Println("警告: 未設置 SIMPLYBOOK_WEBHOOK_SECRET 或 SIMPLYBOOK_WEBHOOK_SECRETS，將不驗證 SimplyBook webhook 的令牌")
+	}
 
 	// 創建 webhook 處理器
 	webhookHandler := handler.NewWebhookHandler(
 		simplybookClient,
 		calendarClient,
-		"",
+		syncStore,
+		webhookSecrets,
+		handlerOpts,
 	)
 
+	// 註冊背景任務，在開始接收請求前啟動
+	runner := jobs.NewRunner(locker)
+
+	// 記憶體或佇列壓力過高時延後非即時的背景任務，排程任務（對帳、偏差檢查、清除、摘要）皆可延後；
+	// 暫存操作補送、提醒、webhook 監控與日曆通知續訂照常執行
+	if shed := cfg.LoadShedding; shed.MaxHeapMB > 0 || shed.MaxQueueDepth > 0 {
+		monitor := jobs.NewPressureMonitor(uint64(shed.MaxHeapMB)<<20, int64(shed.MaxQueueDepth), webhookHandler.QueueDepth)
+		deferrable := []string{"reconcile", "retention", "digest"}
+		for _, job := range cfg.Schedules {
+			deferrable = append(deferrable, job.Name)
+		}
+		runner.DeferUnderPressure(monitor, deferrable...)
+		lc.Go("load-shedding", func(ctx context.Context) {
+			monitor.Run(ctx, shed.Interval.Duration)
+		})
+		log.Printf("已啟用負載卸除，堆積記憶體門檻 %d MB，處理中 webhook 門檻 %d 筆", shed.MaxHeapMB, shed.MaxQueueDepth)
+	}
+	if interval := cfg.Reconcile.Interval.Duration; interval > 0 {
+		lc.Go("reconcile", func(ctx context.Context) {
+			runner.RunPeriodic(ctx, "reconcile", interval, func() error {
+				_, err := webhookHandler.Reconcile()
+				return err
+			})
+		})
+		log.Printf("已啟用定期對帳，間隔 %s", interval)
+	}
+
+	// 營業時間內長時間未收到 webhook 時告警並輪詢補同步
+	if cfg.Watchdog.Silence.Duration > 0 {
+		lc.Go("webhook-watchdog", func(ctx context.Context) {
+			runner.RunPeriodic(ctx, "webhook-watchdog", cfg.Watchdog.Interval.Duration, webhookHandler.CheckWebhooks)
+		})
+		log.Printf("已啟用 webhook 監控，營業時間內超過 %s 未收到 webhook 時告警", cfg.Watchdog.Silence.Duration)
+	}
+
+	// 啟動時訂閱日曆變更通知，並在頻道到期前續訂；頻道不在關閉時停止，讓其他實例繼續接收通知
+	if handlerOpts.WatchAddress != "" {
+		lc.Go("calendar-watch", func(ctx context.Context) {
+			runner.RunOnce("calendar-watch", time.Minute, webhookHandler.RenewCalendarWatches)
+			runner.RunPeriodic(ctx, "calendar-watch", handlerOpts.WatchTTL/2, webhookHandler.RenewCalendarWatches)
+		})
+		log.Printf("已啟用備註雙向同步，通知網址: %s", handlerOpts.WatchAddress)
+	}
+
+	// Google 日曆恢復後補送暫存的同步操作
+	lc.Go("calendar-outbox", func(ctx context.Context) {
+		runner.RunPeriodic(ctx, "calendar-outbox", cfg.GoogleCalendar.ProbeInterval.Duration, func() error {
+			_, err := webhookHandler.FlushOutbox()
+			return err
+		})
+	})
+
+	// 依保留政策定期清除過期的同步記錄與死信
+	retentionPolicy := retention.Policy{
+		SyncRecords: cfg.Retention.SyncRecords.Duration,
+		DeadLetters: cfg.Retention.DeadLetters.Duration,
+		Payloads:    cfg.Retention.Payloads.Duration,
+	}
+	purger := retention.NewPurger(syncStore, retentionPolicy)
+	if retentionPolicy.Enabled() {
+		lc.Go("retention", func(ctx context.Context) {
+			runner.RunPeriodic(ctx, "retention", cfg.Retention.Interval.Duration, purger.Run)
+		})
+		log.Printf("已啟用資料保留清除，間隔 %s", cfg.Retention.Interval.Duration)
+	}
+
+	if reminderScheduler != nil {
+		lc.Go("reminders", func(ctx context.Context) {
+			runner.RunPeriodic(ctx, "reminders", time.Minute, reminderScheduler.SendDue)
+		})
+		log.Printf("已啟用簡訊提醒，於預約前 %s 發送", cfg.Reminder.Before.Duration)
+	}
+
+	var generator *digest.Generator
+	if len(notifiers) > 0 {
+		generator = digest.NewGenerator(syncStore, webhookHandler, simplybookClient, notifiers, loc)
+	}
+
+	// 啟動每日摘要
+	if cfg.Digest.Time != "" {
+		if generator == nil {
+			log.Println("未設置任何通知管道，停用每日摘要")
+		} else {
+			hour, minute, _ := config.ParseClock(cfg.Digest.Time)
+			lc.Go("digest", func(ctx context.Context) {
+				runner.RunDaily(ctx, "digest", hour, minute, loc, businessHours.For(cfg.SimplyBook.CompanyLogin), generator.Send)
+			})
+			log.Printf("已啟用每日摘要，發送時間 %s", cfg.Digest.Time)
+		}
+	}
+
+	// 啟動 cron 排程任務
+	if len(cfg.Schedules) > 0 {
+		scheduler := jobs.NewScheduler(runner, loc)
+		scheduledJobs := map[string]func() error{
+			"reconcile": func() error {
+				_, err := webhookHandler.Reconcile()
+				return err
+			},
+			"drift": func() error {
+				report, err := webhookHandler.DetectDrift()
+				if err != nil {
+					return err
+				}
+				log.Printf("偏差檢查完成: 檢查 %d 筆，偏差 %d 筆", report.Checked, len(report.Drifts))
+				if len(report.Drifts) > 0 && len(notifiers) > 0 {
+					return notifiers.Notify("日曆偏差檢查", fmt.Sprintf("檢查 %d 筆預約，發現 %d 筆偏差，請至管理儀表板查看或執行對帳", report.Checked, len(report.Drifts)))
+				}
+				return nil
+			},
+			"purge":       purger.Run,
+			"dedup-sweep": webhookHandler.SweepDedup,
+		}
+		if generator != nil {
+			scheduledJobs["digest"] = generator.Send
+		}
+
+		for _, job := range cfg.Schedules {
+			fn, ok := scheduledJobs[job.Job]
+			if !ok {
+				log.Printf("排程任務 %s 的類型 %s 無法使用（例如未設置通知管道），略過", job.Name, job.Job)
+				continue
+			}
+			if err := scheduler.Add(job.Name, job.Cron, fn); err != nil {
+				log.Fatalf("註冊排程任務失敗: %v", err)
+			}
+			log.Printf("已排程任務 %s（%s）: %s", job.Name, job.Job, job.Cron)
+		}
+		lc.Go("schedules", scheduler.Run)
+	}
+
 	// 設置 HTTP 路由
 	mux := http.NewServeMux()
 	mux.HandleFunc(cfg.Server.WebhookPath, webhookHandler.HandleWebhook)
+	for _, tenant := range cfg.Server.WebhookTenants {
+		tenantPath := strings.TrimSuffix(cfg.Server.WebhookPath, "/") + "/" + tenant
+		mux.HandleFunc(tenantPath, webhookHandler.HandleTenant(tenant))
+		log.Printf("租戶 %s 的 webhook 路徑: %s", tenant, tenantPath)
+	}
+	if calendlySource != nil {
+		mux.HandleFunc(cfg.Calendly.WebhookPath, webhookHandler.HandleSource(calendlySource))
+		log.Printf("已啟用 Calendly webhook: %s", cfg.Calendly.WebhookPath)
+	}
+	if handlerOpts.WatchAddress != "" {
+		// 位址已在載入配置時驗證
+		watchURL, _ := url.Parse(handlerOpts.WatchAddress)
+		mux.HandleFunc(watchURL.Path, webhookHandler.HandleCalendarNotification)
+	}
+	if slackApprovals != nil {
+		mux.Handle(cfg.Approval.SlackActionsPath, slackApprovals.Handler(webhookHandler.DecideBooking))
+		log.Printf("待核准的預約將發送到 Slack，按鈕回呼路徑: %s", cfg.Approval.SlackActionsPath)
+	}
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("服務正常運行中"))
 	})
 
+	// Prometheus 指標
+	mux.Handle("/metrics", metrics.Handler())
+
+	// 設置管理儀表板（需設置管理員密碼）
+	if cfg.Admin.Password != "" {
+		admin.NewUI(syncStore, webhookHandler).Register(mux, cfg.Admin.Username, cfg.Admin.Password)
+		log.Println("管理儀表板已啟用於 /ui")
+	} else {
+		log.Println("未設置管理員密碼，停用管理儀表板")
+	}
+
 	// 優先使用環境變數 PORT
 	port := cfg.Server.Port
 	if portEnv := os.Getenv("PORT"); portEnv != "" {
@@ -85,34 +781,74 @@ func main() {
 		}
 	}
 
+	// 開發模式的 webhook 模擬器，送出的 webhook 直接交給同步處理器
+	if devServer != nil {
+		fake.NewSimulator(devServer, cfg.SimplyBook.CompanyLogin, http.HandlerFunc(webhookHandler.HandleWebhook), loc).Register(mux)
+		log.Printf("開發模式: webhook 模擬器已啟用於 http://localhost:%d/dev", port)
+	}
+
 	// 設置伺服器
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: mux,
 	}
 
-	// 設置優雅關閉的處理
-	go func() {
-		// 等待中斷信號
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-		<-quit
-		log.Println("關閉伺服器...")
+	// 收到 SIGUSR1 時切換除錯日誌
+	lc.Go("loglevel-signal", watchLogLevelSignal)
+
+	// 不再接收 webhook 後，立即處理仍在合併窗口中的變更，並在時限內等待背景處理中的事件完成
+	lc.OnStop("webhooks", func(ctx context.Context) error {
+		webhookHandler.FlushDebounced()
+		return webhookHandler.Drain(ctx)
+	})
 
-		// 創建關閉伺服器的上下文
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	// HTTP 伺服器最後註冊，關閉時最先停止接收請求
+	lc.Add("http-server", func(context.Context) error {
+		go func() {
+			log.Printf("伺服器正在監聽端口 %d...", port)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("伺服器啟動失敗: %v", err)
+			}
+		}()
+		return nil
+	}, server.Shutdown)
 
-		if err := server.Shutdown(ctx); err != nil {
-			log.Fatalf("強制關閉伺服器: %v", err)
+	// 輸出啟動摘要；相依服務檢查在背景進行，不延遲開始接收請求
+	if !cfg.Log.DisableStartupDiagnostics {
+		deps := []dependency{
+			{"store", func() error {
+				_, err := syncStore.ListSyncRecords(1, false)
+				return err
+			}},
+			{"simplybook", func() error {
+				_, err := simplybookClient.ListBookings(simplybook.BookingFilter{Limit: 1})
+				return err
+			}},
+			{"google_calendar", calendarClient.Ping},
+		}
+		if redisClient != nil {
+			deps = append(deps, dependency{"redis", func() error {
+				return redisClient.Ping(context.Background()).Err()
+			}})
 		}
+		go emitStartupDiagnostics(cfg, instanceID, time.Since(startedAt), deps)
+	}
+
+	if err := lc.Start(context.Background()); err != nil {
+		log.Fatalf("%v", err)
+	}
 
-		log.Println("伺服器已優雅關閉")
-	}()
+	// 等待中斷信號後依序關閉子系統，完成後才結束程序
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("關閉伺服器...")
 
-	// 直接啟動伺服器（不在 goroutine 中）
-	log.Printf("伺服器正在監聽端口 %d...", port)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("伺服器啟動失敗: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if failed := lc.Stop(ctx); failed > 0 {
+		log.Printf("伺服器已關閉，%d 個子系統未正常停止", failed)
+		return
 	}
+	log.Println("伺服器已優雅關閉")
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/config"
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// selftestStep 是自我測試的單一步驟，返回成功時的說明
+type selftestStep struct {
+	name string
+	run  func() (string, error)
+}
+
+// runSelftest 依序驗證 SimplyBook 認證、讀取預約，以及在設定的日曆建立與刪除暫時事件，
+// 並輸出每個步驟的結果與延遲；任一步驟失敗時略過後續步驟
+func runSelftest(cfg *config.Config, httpClient *http.Client) error {
+	var sb *simplybook.Client
+	var calendar *gcalendar.Client
+	var eventID string
+
+	steps := []selftestStep{
+		{"SimplyBook 認證", func() (string, error) {
+			var err error
+			sb, err = simplybook.NewClient(cfg.SimplyBook.CompanyLogin, cfg.SimplyBook.UserName, cfg.SimplyBook.Password,
+				simplybook.Options{HTTPClient: httpClient, UserAgent: cfg.SimplyBook.UserAgent, Headers: cfg.SimplyBook.Headers})
+			return "公司 " + cfg.SimplyBook.CompanyLogin, err
+		}},
+		{"讀取一筆預約", func() (string, error) {
+			bookings, err := sb.ListBookings(simplybook.BookingFilter{Limit: 1})
+			if err != nil {
+				return "", err
+			}
+			if len(bookings) == 0 {
+				return "沒有任何預約", nil
+			}
+			return "預約 " + strconv.Itoa(bookings[0].ID), nil
+		}},
+		{"Google 日曆認證", func() (string, error) {
+			creds, err := cfg.GoogleCredentialsList()
+			if err != nil {
+				return "", err
+			}
+			calendar, err = gcalendar.NewClientWithCredentials(creds, cfg.GoogleCalendar.CalendarID, httpClient)
+			if err != nil {
+				return "", err
+			}
+			return "日曆 " + calendar.CalendarID(), calendar.Ping()
+		}},
+		{"建立暫時事件", func() (string, error) {
+			start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+			var err error
+			eventID, err = calendar.CreateEvent(&gcalendar.CalendarEvent{
+				Summary:     "booking-sync 自我測試",
+				Description: "此事件由 booking-sync selftest 建立，應已自動刪除",
+				StartTime:   start,
+				EndTime:     start.Add(15 * time.Minute),
+			})
+			return "事件 " + eventID, err
+		}},
+		{"刪除暫時事件", func() (string, error) {
+			return "事件 " + eventID, calendar.DeleteEvent("", eventID)
+		}},
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "步驟\t結果\t延遲\t說明")
+
+	var failed error
+	for _, step := range steps {
+		if failed != nil {
+			fmt.Fprintf(w, "%s\t略過\t-\t\n", step.name)
+			continue
+		}
+
+		started := time.Now()
+		detail, err := step.run()
+		latency := time.Since(started).Round(time.Millisecond)
+		if err != nil {
+			failed = fmt.Errorf("%s: %w", step.name, err)
+			fmt.Fprintf(w, "%s\t失敗\t%s\t%v\n", step.name, latency, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t成功\t%s\t%s\n", step.name, latency, detail)
+	}
+	w.Flush()
+
+	return failed
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/logging"
+)
+
+// watchLogLevelSignal 每次收到 SIGUSR1 時切換除錯日誌，直到 ctx 結束
+func watchLogLevelSignal(ctx context.Context) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			log.Printf("收到 SIGUSR1，日誌等級已切換為 %s", logging.ToggleDebug())
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package main
+
+import "context"
+
+// watchLogLevelSignal Windows 不支持 SIGUSR1，請改用 /admin/loglevel 切換日誌等級
+func watchLogLevelSignal(ctx context.Context) {}
@@ -1,34 +1,465 @@
 package config
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/hours"
 )
 
-// Config 包含應用程式配置
+// Config 包含應用程式配置，標記 secret:"true" 的欄位在輸出有效配置時會遮蔽
 type Config struct {
+	// ConfigVersion 配置文件的結構版本，未設置時視為版本 1
+	ConfigVersion int `json:"config_version"`
+
+	Log struct {
+		Level string `json:"level"` // info（預設）或 debug，執行期間可透過 SIGUSR1 或 /admin/loglevel 切換
+		// DisableStartupDiagnostics 不在啟動時輸出 JSON 格式的啟動摘要與相依服務檢查
+		DisableStartupDiagnostics bool `json:"disable_startup_diagnostics"`
+	} `json:"log"`
+
 	Server struct {
 		Port        int    `json:"port"`
 		WebhookPath string `json:"webhook_path"`
+		// WebhookTenants 為租戶（SimplyBook company login）另外註冊 <webhook_path>/<租戶> 路徑，
+		// 以路徑決定租戶的令牌，並拒絕負載中 company 不同的請求。
+		// 預約一律以 SimplyBook.CompanyLogin 讀取，且去重鍵、鎖與對應關係不區分租戶，因此只能列出該公司
+		WebhookTenants []string `json:"webhook_tenants"`
+		MaxQueueDepth  int      `json:"max_queue_depth"` // 處理中的 webhook 超過此數量時回應 429，預設 1000
+		RetryAfter     Duration `json:"retry_after"`     // 回應 429 時建議的重試間隔，預設 30s
+		// SlowFetchThreshold 讀取預約超過此時間時記錄耗時分解並計入指標，預設 3s
+		SlowFetchThreshold Duration `json:"slow_fetch_threshold"`
+		// WebhookMaxAge 拒絕 webhook_timestamp 超出此範圍的 webhook 以防重放，0 表示不檢查
+		WebhookMaxAge Duration `json:"webhook_max_age"`
+		// LatencySLO 最近 webhook 從 webhook_timestamp 到寫入日曆的延遲 p95 超過此值時告警，0 表示不告警
+		LatencySLO Duration `json:"latency_slo"`
+		// ReplayToken 請求標頭 X-Booking-Sync-Replay 攜帶此令牌時略過時間戳檢查，供管理員重送 webhook
+		ReplayToken string `json:"replay_token" secret:"true"`
 	} `json:"server"`
 
 	SimplyBook struct {
-		CompanyLogin string `json:"company_login"`
-		UserName     string `json:"user_name"`
-		Password     string `json:"password"`
+		CompanyLogin string            `json:"company_login"`
+		UserName     string            `json:"user_name"`
+		Password     string            `json:"password" secret:"true"`
+		AdminURL     string            `json:"admin_url"`             // 後台預約頁面的網址模板
+		UserAgent    string            `json:"user_agent"`            // 未設置時使用 booking-sync/<版本>
+		Headers      map[string]string `json:"headers" secret:"true"` // 附加於每個請求的自訂標頭
+		// BasicBookings 讀取預約時不使用詳細端點展開服務、服務提供者與帳單，用於不支援展開參數的帳號
+		BasicBookings bool `json:"basic_bookings"`
+		// WebhookURL 本服務對外的 webhook 網址，設置時啟動時檢查 SimplyBook 的回呼設定
+		WebhookURL string `json:"webhook_url"`
+		// RegisterWebhook 回呼設定與 WebhookURL 不符時自動更新，否則僅記錄警告
+		RegisterWebhook bool `json:"register_webhook"`
+		// WebhookSecrets 各租戶（以 company login 為鍵）驗證 webhook 的令牌，"*" 為未列出租戶的預設；未設置時不驗證
+		WebhookSecrets map[string]WebhookSecret `json:"webhook_secrets"`
 	} `json:"simplybook"`
 
+	// Stripe 設置 SecretKey 時以預約代碼查詢付款狀態，顯示於事件並可用於事件規則
+	Stripe struct {
+		SecretKey   string `json:"secret_key" secret:"true"` // 建議使用僅可讀取 PaymentIntent 的受限金鑰
+		MetadataKey string `json:"metadata_key"`             // PaymentIntent metadata 中存放預約代碼的鍵，預設 booking_code
+	} `json:"stripe"`
+
+	// Calendly 設置 Token 時啟用 Calendly webhook，預約與 SimplyBook 預約一起同步到日曆
+	Calendly struct {
+		Token       string `json:"token" secret:"true"`       // 個人存取令牌，用於讀取預約詳情
+		SigningKey  string `json:"signing_key" secret:"true"` // webhook 簽章金鑰，未設置時不驗證簽章
+		WebhookPath string `json:"webhook_path"`              // 預設 /webhook/calendly
+	} `json:"calendly"`
+
 	GoogleCalendar struct {
 		CredentialsFile string `json:"credentials_file"`
-		CalendarID      string `json:"calendar_id"`
+		// CredentialsJSON 服務帳號金鑰內容（原始 JSON 或 base64），設置時優先於 CredentialsFile
+		CredentialsJSON string `json:"credentials_json" secret:"true"`
+		// AdditionalCredentialsFiles 備用的服務帳號金鑰檔案，主要金鑰遇到配額或金鑰失效時依序輪替
+		AdditionalCredentialsFiles []string `json:"additional_credentials_files"`
+		// AdditionalCredentialsJSON 備用的服務帳號金鑰內容（原始 JSON 或 base64）
+		AdditionalCredentialsJSON []string `json:"additional_credentials_json" secret:"true"`
+		CalendarID                string   `json:"calendar_id"`
+		// ProbeInterval Google 日曆無法使用時檢查恢復並補送暫存操作的間隔，預設 30s
+		ProbeInterval Duration `json:"probe_interval"`
+		// Watch 訂閱日曆變更通知，將工作人員在事件描述備註區塊的編輯寫回 SimplyBook（需啟用 event.notes）
+		Watch struct {
+			Address string   `json:"address"`             // Google 推送通知的公開 HTTPS 網址，路徑即為本服務接收通知的路徑
+			Token   string   `json:"token" secret:"true"` // 驗證通知來源的頻道令牌
+			TTL     Duration `json:"ttl"`                 // 通知頻道的有效期，到期前自動續訂，預設 24h
+		} `json:"watch"`
+		// WriteBudget 限制日曆寫入頻率，避免大量對帳時觸發 usageLimits 錯誤；0 表示不限制
+		WriteBudget struct {
+			QPS         float64 `json:"qps"`          // 所有日曆合計每秒寫入上限，超過時排隊等待
+			CalendarQPS float64 `json:"calendar_qps"` // 單一日曆每秒寫入上限，超過時排隊等待
+			Daily       int     `json:"daily"`        // 每日寫入上限，用完後暫存操作到額度重置
+		} `json:"write_budget"`
 	} `json:"google_calendar"`
+
+	BookingCache struct {
+		Size int      `json:"size"` // 最近預約快取的容量，預設 500
+		TTL  Duration `json:"ttl"`  // 快取預約的存活時間，預設 10s
+		// NotFoundThreshold 預約連續查無資料（404）達此次數後暫停查詢，預設 2
+		NotFoundThreshold int `json:"not_found_threshold"`
+		// NotFoundTTL 暫停查詢查無資料預約的時間，預設 5m
+		NotFoundTTL Duration `json:"not_found_ttl"`
+	} `json:"booking_cache"`
+
+	Sync struct {
+		PastWindow   Duration `json:"past_window"`   // 略過結束時間早於此範圍的預約（例如 168h），未設置時不限制
+		FutureWindow Duration `json:"future_window"` // 略過開始時間晚於此範圍的預約（例如 2160h），未設置時不限制
+		// TimeTolerance 對帳時開始與結束時間差異小於此值視為一致，預設 1m
+		TimeTolerance Duration `json:"time_tolerance"`
+		// MaxDuration 預約時長上限，超過時拒絕寫入日曆並寫入死信，預設 12h
+		MaxDuration Duration `json:"max_duration"`
+		// Debounce 合併同一預約在此時間內的變更通知為一次同步（例如 30s），未設置時不合併
+		Debounce Duration `json:"debounce"`
+		// Providers 與 Services 限定同步的服務提供者與服務，只想讓部分員工的預約進入 Google 日曆時使用
+		Providers ScopeList `json:"providers"`
+		Services  ScopeList `json:"services"`
+	} `json:"sync"`
+
+	Event struct {
+		Links []EventLink `json:"links"` // 附加於事件的連結
+		Rules []EventRule `json:"rules"` // 依序評估的事件規則
+		// FieldMap 將 SimplyBook 自訂欄位 ID 或名稱對應到模板變數名稱（例如 "field_12345": "room_preference"）
+		FieldMap map[string]string `json:"field_map"`
+		// Notes 在事件描述中加入預約備註區塊，搭配 google_calendar.watch 可將日曆上的編輯寫回 SimplyBook
+		Notes bool `json:"notes"`
+		// InviteClients 將客戶加為事件參與者，格式錯誤或在停止邀請清單中的地址會略過
+		InviteClients bool `json:"invite_clients"`
+		// CodeMarker 以 [SB:預約編號] 標記寫入事件描述中的預約編號，描述加入其他文字後仍可解析
+		CodeMarker bool `json:"code_marker"`
+		// TitleMaxLength 事件標題的字元數上限，超過時從中段刪減（保留預約編號），0 表示不限制
+		TitleMaxLength int `json:"title_max_length"`
+		// ResourceCalendars 將 SimplyBook 服務資源（房間、設備）的名稱或 ID 對應到資源日曆，占用資源的預約在該日曆另建事件
+		ResourceCalendars map[string]string `json:"resource_calendars"`
+		// GroupClasses 將團體課程服務的名稱或 ID 對應到每時段人數上限，同一時段的預約共用一個課程事件，
+		// 標題顯示目前人數；上限為 0 時使用 SimplyBook 服務設定的人數上限
+		GroupClasses map[string]int `json:"group_classes"`
+		// SendUpdates 建立、取消與重要更新時通知參與者的方式：all（預設）、externalOnly 或 none
+		SendUpdates string `json:"send_updates"`
+		// UpdateSignificance 更新的欄位變更分數達此值才通知參與者，預設 3（改期、地點或參與者變更）
+		UpdateSignificance int `json:"update_significance"`
+		// StatusIcons 與 PaymentIcons 依預約狀態與付款狀態在事件標題前加上圖示（例如 "confirmed": "✅"）
+		StatusIcons  map[string]string `json:"status_icons"`
+		PaymentIcons map[string]string `json:"payment_icons"`
+		// WaitingList 候補名單預約的處理方式
+		WaitingList struct {
+			Mode    string `json:"mode"`     // tentative（預設，建立不佔用時間的暫定事件）或 skip（轉正後才同步）
+			ColorID string `json:"color_id"` // 暫定事件的顏色 ID，預設 8
+		} `json:"waiting_list"`
+	} `json:"event"`
+
+	HTTP struct {
+		ProxyURL string `json:"proxy_url"` // 對外代理伺服器，未設置時使用 HTTPS_PROXY 等環境變數
+		CAFile   string `json:"ca_file"`   // 額外信任的 CA 憑證（PEM 格式）
+		// Record 將去除敏感資料的對外請求與響應錄製到此檔案，用於產生測試資料；Replay 從檔案重播而不連線
+		Record string `json:"record"`
+		Replay string `json:"replay"`
+
+		MaxIdleConnsPerHost int      `json:"max_idle_conns_per_host"` // 每個主機保留的閒置連線數，預設 16
+		MaxConnsPerHost     int      `json:"max_conns_per_host"`      // 每個主機的連線上限，0 表示不限制
+		IdleConnTimeout     Duration `json:"idle_conn_timeout"`       // 閒置連線保留的時間，預設 90s
+		DisableKeepAlives   bool     `json:"disable_keep_alives"`     // 每個請求使用新連線
+		DisableHTTP2        bool     `json:"disable_http2"`           // 僅使用 HTTP/1.1，用於不支援 HTTP/2 的代理
+		TLSSessionCache     int      `json:"tls_session_cache"`       // TLS session 快取的容量，預設 64
+	} `json:"http"`
+
+	// Chaos 在對外請求中注入故障，供測試環境驗證重試與死信流程；需以 -tags chaos 建置才會生效
+	Chaos struct {
+		CalendarWriteFailRate float64  `json:"calendar_write_fail_rate"` // 以 503 回應的 Google 日曆寫入比例（0 到 1）
+		SimplyBookDelay       Duration `json:"simplybook_delay"`         // 延遲每個 SimplyBook 請求的時間
+	} `json:"chaos"`
+
+	Admin struct {
+		Username string `json:"username"`
+		Password string `json:"password" secret:"true"` // 未設置時停用管理介面
+	} `json:"admin"`
+
+	Reconcile struct {
+		Interval Duration `json:"interval"` // 定期對帳間隔，未設置時停用
+	} `json:"reconcile"`
+
+	// BusinessHours 預設營業時間，webhook 監控、每日摘要與簡訊提醒只在營業時間內進行；未設置時全天
+	BusinessHours BusinessHours `json:"business_hours"`
+	// Locale 租戶的語系，決定事件連結、確認郵件與提醒模板中 localTime 等函式的時間格式：zh-TW（預設）或 en
+	Locale string `json:"locale"`
+	// TenantBusinessHours 個別租戶的營業時間，以 SimplyBook company login 或平台名稱（例如 calendly）為鍵
+	TenantBusinessHours map[string]BusinessHours `json:"tenant_business_hours"`
+
+	// Watchdog 營業時間內超過 Silence 未收到 SimplyBook webhook 時告警，並輪詢補同步遺漏的預約
+	Watchdog struct {
+		Silence       Duration `json:"silence"`         // 未設置時停用，例如 2h
+		Interval      Duration `json:"interval"`        // 檢查間隔，預設 15m
+		CatchUpWindow Duration `json:"catch_up_window"` // 輪詢補同步涵蓋的未來預約範圍，預設 720h
+	} `json:"watchdog"`
+
+	// LoadShedding 堆積記憶體或處理中的 webhook 超過門檻時延後對帳、清除與摘要等背景任務，兩個門檻皆未設置時停用
+	LoadShedding struct {
+		MaxHeapMB     int      `json:"max_heap_mb"`     // 堆積記憶體門檻（MB）
+		MaxQueueDepth int      `json:"max_queue_depth"` // 處理中的 webhook 數量門檻，應低於 server.max_queue_depth
+		Interval      Duration `json:"interval"`        // 檢查間隔，預設 10s
+	} `json:"load_shedding"`
+
+	Notify struct {
+		SMTP struct {
+			Host     string   `json:"host"`
+			Port     int      `json:"port"`
+			Username string   `json:"username"`
+			Password string   `json:"password" secret:"true"`
+			From     string   `json:"from"`
+			To       []string `json:"to"`
+		} `json:"smtp"`
+		SlackWebhookURL string   `json:"slack_webhook_url" secret:"true"`
+		AlertCooldown   Duration `json:"alert_cooldown"` // 相同類型維運告警的最短間隔
+	} `json:"notify"`
+
+	// Approval 以 Slack 按鈕核准待核准的預約（SimplyBook 的 Approve booking 外掛），設置 SlackWebhookURL 時啟用
+	Approval struct {
+		SlackWebhookURL    string `json:"slack_webhook_url" secret:"true"`    // 屬於啟用 Interactivity 的 Slack App 的 Incoming Webhook
+		SlackSigningSecret string `json:"slack_signing_secret" secret:"true"` // Slack App 的 Signing Secret，驗證按鈕回呼
+		SlackActionsPath   string `json:"slack_actions_path"`                 // 接收按鈕回呼的路徑，預設 /slack/actions
+	} `json:"approval"`
+
+	Confirmation struct {
+		Enabled bool   `json:"enabled"` // 預約建立時寄送 .ics 確認郵件給客戶（需設置 SMTP）
+		Subject string `json:"subject"` // 郵件標題模板
+		Body    string `json:"body"`    // 郵件內容模板
+	} `json:"confirmation"`
+
+	Digest struct {
+		Time string `json:"time"` // 每日摘要的發送時間（HH:MM，以 Location 返回的設定時區解讀），未設置時停用
+	} `json:"digest"`
+
+	Reminder struct {
+		Before   Duration `json:"before"`   // 預約開始前多久發送提醒，未設置時停用
+		Template string   `json:"template"` // 簡訊模板（Go text/template）
+		Twilio   struct {
+			AccountSID string `json:"account_sid"`
+			AuthToken  string `json:"auth_token" secret:"true"`
+			From       string `json:"from"`
+		} `json:"twilio"`
+	} `json:"reminder"`
+
+	Schedules []ScheduledJob `json:"schedules"` // 以 cron 表達式排程的背景任務
+	// Timezone 排程任務與每日摘要使用的 IANA 時區，未設置時沿用營業時間的時區（預設 Asia/Taipei）；
+	// 每日摘要的日期與預約時間以此時區顯示
+	Timezone string `json:"timezone"`
+
+	Store struct {
+		Driver string `json:"driver"` // memory、postgres 或 bolt
+		DSN    string `json:"dsn" secret:"true"`
+		Path   string `json:"path"` // bolt 資料庫檔案路徑，預設 booking-sync.db
+		// EncryptionKeys 以 AES-GCM 加密儲存中的預約快照，格式為 "id:base64金鑰,..."，
+		// 第一把金鑰用於加密，其餘保留用於解密輪替前的資料
+		EncryptionKeys string `json:"encryption_keys" secret:"true"`
+	} `json:"store"`
+
+	// Retention 定義資料保留期限，0 表示永久保留
+	Retention struct {
+		SyncRecords Duration `json:"sync_records"` // 同步記錄（稽核日誌）
+		DeadLetters Duration `json:"dead_letters"` // 死信
+		Payloads    Duration `json:"payloads"`     // 死信中的預約快照，到期後清除但保留死信本身
+		Interval    Duration `json:"interval"`     // 清除任務的執行間隔，預設 24h
+	} `json:"retention"`
+
+	// Report 將同步成功的預約輸出給財務報表與分析，皆未設置時不輸出
+	Report struct {
+		SheetID    string `json:"sheet_id"`    // 附加報表列的 Google 試算表 ID，服務帳號須有編輯權限
+		SheetRange string `json:"sheet_range"` // 附加的工作表範圍，預設 Sheet1
+		Bucket     string `json:"bucket"`      // 寫入每日 CSV 的 GCS 儲存桶
+		Prefix     string `json:"prefix"`      // 每日 CSV 的物件名稱前綴，預設 bookings
+
+		// BigQuery 串流寫入預約事件，設置 Dataset 時啟用
+		BigQuery struct {
+			Project string `json:"project"` // 未設置時使用服務帳號所屬的專案
+			Dataset string `json:"dataset"`
+			Table   string `json:"table"` // 預設 booking_events，不存在時自動建立
+		} `json:"bigquery"`
+	} `json:"report"`
+
+	Redis struct {
+		Addr     string `json:"addr"` // 未設置時使用記憶體去重與鎖
+		Password string `json:"password" secret:"true"`
+		DB       int    `json:"db"`
+		Prefix   string `json:"prefix"`
+		// Deprecated: 自版本 2 起改用 dedup.ttl
+		DedupTTL Duration `json:"dedup_ttl,omitempty"`
+		// Deprecated: 自版本 2 起改用 lock.ttl
+		LockTTL Duration `json:"lock_ttl,omitempty"`
+	} `json:"redis"`
+
+	Dedup struct {
+		TTL Duration `json:"ttl"` // webhook 去重鍵的存活時間，預設 10m
+	} `json:"dedup"`
+
+	Lock struct {
+		Backend string   `json:"backend"` // memory、gcs 或 redis
+		Bucket  string   `json:"bucket"`
+		Prefix  string   `json:"prefix"`
+		TTL     Duration `json:"ttl"` // 預約鎖的租約時間，預設 2m
+	} `json:"lock"`
+
+	// Audit 將每筆同步記錄轉送到 syslog 或 fluentd 供 SIEM 接收，設置 Address 時啟用
+	Audit struct {
+		Format  string  `json:"format"`  // syslog 或 fluentd，預設 syslog
+		Network string  `json:"network"` // tcp 或 udp，預設 udp（syslog）或 tcp（fluentd）
+		Address string  `json:"address"` // 接收端位址，例如 syslog.internal:514
+		Tag     string  `json:"tag"`     // syslog 的 APP-NAME 或 fluentd 的 tag，預設 booking-sync
+		Rate    float64 `json:"rate"`    // 每秒最多轉送的事件數，超過時略過，預設 50
+	} `json:"audit"`
+
+	// ErrorReporting 將同步失敗回報到 Sentry 或 Google Error Reporting，可同時啟用
+	ErrorReporting struct {
+		SentryDSN   string `json:"sentry_dsn" secret:"true"`
+		Environment string `json:"environment"` // Sentry 的 environment，例如 production
+		Google      bool   `json:"google"`      // 以服務帳號回報到 Google Error Reporting
+		Project     string `json:"project"`     // Google Error Reporting 的專案，未設置時使用服務帳號所屬的專案
+	} `json:"error_reporting"`
+
+	// Warnings 載入配置時產生的警告（例如已棄用的設定），由呼叫者記錄
+	Warnings []string `json:"-"`
+
+	// Dev 表示以 LoadDevConfig 載入的開發模式配置，外部服務由模擬伺服器取代
+	Dev bool `json:"-"`
+}
+
+// ScopeList 是同步範圍的允許與排除清單，項目可填名稱或 ID，比對不分大小寫
+type ScopeList struct {
+	Allow []string `json:"allow"` // 非空時只同步清單中的項目
+	Deny  []string `json:"deny"`  // 一律不同步的項目，優先於 Allow
+}
+
+// EventLink 定義附加於日曆事件的連結
+type EventLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`  // Go text/template，可使用預約資料
+	Mode  string `json:"mode"` // description（預設，寫入事件描述）或 attachment（事件附件）
+}
+
+// EventRule 定義依預約內容調整日曆事件的規則，所有符合的規則依序套用
+type EventRule struct {
+	Name  string `json:"name"`
+	Match struct {
+		Services   []string          `json:"services"`   // 服務名稱或 ID
+		Categories []string          `json:"categories"` // 服務分類名稱或 ID
+		Providers  []string          `json:"providers"`  // 服務提供者名稱或 ID
+		Statuses   []string          `json:"statuses"`
+		Payments   []string          `json:"payments"` // 付款狀態（需設置 Stripe）
+		Fields     map[string]string `json:"fields"`   // 自訂欄位名稱或標題 → 欄位值
+	} `json:"match"`
+	TitlePrefix string `json:"title_prefix"`
+	TitleSuffix string `json:"title_suffix"`
+	ColorID     string `json:"color_id"`    // Google 日曆事件顏色 ID（1-11）
+	CalendarID  string `json:"calendar_id"` // 事件寫入的日曆，未設置時使用預設日曆
+	Timezone    string `json:"timezone"`    // 服務提供者所在的 IANA 時區（例如 Asia/Tokyo），預約時間以此時區解讀
+	// Checklist 附加於事件描述的準備事項，須於預約開始前 ChecklistBy（預設 1h）完成
+	Checklist   []string `json:"checklist"`
+	ChecklistBy Duration `json:"checklist_by"`
+	Skip        bool     `json:"skip"` // 不同步符合條件的預約
+	Stop        bool     `json:"stop"` // 符合時停止評估後續規則
+	// TravelBefore、TravelAfter 到府服務等預約前後的交通時間，TravelMode 為 extend（預設，延長事件）或 events（另建交通事件）
+	TravelBefore Duration `json:"travel_before"`
+	TravelAfter  Duration `json:"travel_after"`
+	TravelMode   string   `json:"travel_mode"`
+}
+
+// BusinessHours 定義每週的營業時間
+type BusinessHours struct {
+	Days     []string `json:"days"`     // 營業日（mon、tue、wed、thu、fri、sat、sun），未設置時每天營業
+	Start    string   `json:"start"`    // 開始時間（HH:MM）
+	End      string   `json:"end"`      // 結束時間（HH:MM），早於開始時間時表示跨越午夜
+	Timezone string   `json:"timezone"` // IANA 時區，預設 Asia/Taipei
+}
+
+// Schedule 解析營業時間，未設置開始與結束時間時返回 nil，表示全天營業
+func (b BusinessHours) Schedule() (*hours.Schedule, error) {
+	return hours.New(b.Days, b.Start, b.End, b.Timezone)
+}
+
+// Location 返回排程任務與每日摘要使用的時區：依序為 timezone、SimplyBook 租戶的營業時區、
+// 預設營業時間的時區與 Asia/Taipei，系統缺少時區資料時預設時區改用固定的 GMT+8
+func (c *Config) Location() (*time.Location, error) {
+	tz := c.Timezone
+	if tz == "" {
+		tz = c.TenantBusinessHours[c.SimplyBook.CompanyLogin].Timezone
+	}
+	if tz == "" {
+		tz = c.BusinessHours.Timezone
+	}
+	if tz == "" {
+		loc, err := time.LoadLocation("Asia/Taipei")
+		if err != nil {
+			return time.FixedZone("GMT+8", 8*60*60), nil
+		}
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("無效的時區 %s: %w", tz, err)
+	}
+	return loc, nil
+}
+
+// BusinessHoursSet 解析預設與各租戶的營業時間
+func (c *Config) BusinessHoursSet() (*hours.Set, error) {
+	set := &hours.Set{Tenants: make(map[string]*hours.Schedule)}
+
+	var err error
+	if set.Default, err = c.BusinessHours.Schedule(); err != nil {
+		return nil, err
+	}
+	for tenant, b := range c.TenantBusinessHours {
+		schedule, err := b.Schedule()
+		if err != nil {
+			return nil, fmt.Errorf("租戶 %s: %w", tenant, err)
+		}
+		set.Tenants[tenant] = schedule
+	}
+	return set, nil
+}
+
+// WebhookSecret 定義租戶的 webhook 令牌，輪替時舊令牌在寬限期內仍接受
+type WebhookSecret struct {
+	Token         string    `json:"token" secret:"true"`
+	PreviousToken string    `json:"previous_token" secret:"true"` // 輪替前的令牌
+	PreviousUntil time.Time `json:"previous_until"`               // 舊令牌的寬限期結束時間（RFC 3339）
+}
+
+// ScheduledJob 定義以 cron 表達式排程的背景任務
+type ScheduledJob struct {
+	Name string `json:"name"`
+	Cron string `json:"cron"` // 五欄位 cron 表達式（以 timezone 解讀）或 @daily、@every 1h 等描述
+	Job  string `json:"job"`  // reconcile、drift、digest、purge 或 dedup-sweep
+}
+
+// scheduledJobTypes 代表支持排程的任務類型
+var scheduledJobTypes = map[string]bool{
+	"reconcile":   true,
+	"drift":       true,
+	"digest":      true,
+	"purge":       true,
+	"dedup-sweep": true,
 }
 
 // LoadConfig 從文件或環境變量加載配置
 func LoadConfig(configPath string) (*Config, error) {
+	return load(configPath, false)
+}
+
+// LoadDevConfig 以開發模式加載配置：不要求 SimplyBook 與 Google 憑證，
+// 並強制使用記憶體儲存，供本機搭配模擬伺服器執行完整的同步流程
+func LoadDevConfig(configPath string) (*Config, error) {
+	return load(configPath, true)
+}
+
+// load 從文件或環境變量加載配置，dev 為 true 時套用開發模式的設定
+func load(configPath string, dev bool) (*Config, error) {
 	config := &Config{}
 
 	// 如果提供了配置文件路徑，則從文件加載
@@ -41,9 +472,20 @@ func LoadConfig(configPath string) (*Config, error) {
 		if err := json.Unmarshal(file, config); err != nil {
 			return nil, fmt.Errorf("解析配置文件失敗: %w", err)
 		}
+
+		if err := config.upgrade(); err != nil {
+			return nil, err
+		}
 	}
 
 	// 從環境變數讀取配置，優先於文件配置
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		config.Log.Level = level
+	}
+	if disable := os.Getenv("DISABLE_STARTUP_DIAGNOSTICS"); disable != "" {
+		config.Log.DisableStartupDiagnostics = disable == "true" || disable == "1"
+	}
+
 	if port := os.Getenv("SERVER_PORT"); port != "" {
 		var p int
 		if _, err := fmt.Sscanf(port, "%d", &p); err == nil {
@@ -55,6 +497,45 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.Server.WebhookPath = path
 	}
 
+	if tenants := os.Getenv("WEBHOOK_TENANTS"); tenants != "" {
+		config.Server.WebhookTenants = splitList(tenants)
+	}
+
+	if depth := os.Getenv("WEBHOOK_MAX_QUEUE_DEPTH"); depth != "" {
+		var d int
+		if _, err := fmt.Sscanf(depth, "%d", &d); err == nil {
+			config.Server.MaxQueueDepth = d
+		}
+	}
+
+	if retryAfter := os.Getenv("WEBHOOK_RETRY_AFTER"); retryAfter != "" {
+		if err := config.Server.RetryAfter.parse(retryAfter); err != nil {
+			return nil, fmt.Errorf("解析 WEBHOOK_RETRY_AFTER 失敗: %w", err)
+		}
+	}
+
+	if threshold := os.Getenv("SLOW_FETCH_THRESHOLD"); threshold != "" {
+		if err := config.Server.SlowFetchThreshold.parse(threshold); err != nil {
+			return nil, fmt.Errorf("解析 SLOW_FETCH_THRESHOLD 失敗: %w", err)
+		}
+	}
+
+	if slo := os.Getenv("WEBHOOK_LATENCY_SLO"); slo != "" {
+		if err := config.Server.LatencySLO.parse(slo); err != nil {
+			return nil, fmt.Errorf("解析 WEBHOOK_LATENCY_SLO 失敗: %w", err)
+		}
+	}
+
+	if maxAge := os.Getenv("WEBHOOK_MAX_AGE"); maxAge != "" {
+		if err := config.Server.WebhookMaxAge.parse(maxAge); err != nil {
+			return nil, fmt.Errorf("解析 WEBHOOK_MAX_AGE 失敗: %w", err)
+		}
+	}
+
+	if token := os.Getenv("WEBHOOK_REPLAY_TOKEN"); token != "" {
+		config.Server.ReplayToken = token
+	}
+
 	if login := os.Getenv("SIMPLYBOOK_COMPANY_LOGIN"); login != "" {
 		config.SimplyBook.CompanyLogin = login
 	}
@@ -67,45 +548,1093 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.SimplyBook.Password = password
 	}
 
+	if adminURL := os.Getenv("SIMPLYBOOK_ADMIN_URL"); adminURL != "" {
+		config.SimplyBook.AdminURL = adminURL
+	}
+
+	if userAgent := os.Getenv("SIMPLYBOOK_USER_AGENT"); userAgent != "" {
+		config.SimplyBook.UserAgent = userAgent
+	}
+
+	// 格式為 "Header-Name=value,Other-Header=value"
+	if headers := os.Getenv("SIMPLYBOOK_HEADERS"); headers != "" {
+		if config.SimplyBook.Headers == nil {
+			config.SimplyBook.Headers = make(map[string]string)
+		}
+		for _, pair := range strings.Split(headers, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(key) == "" {
+				return nil, fmt.Errorf("無效的 SIMPLYBOOK_HEADERS 項目: %s", pair)
+			}
+			config.SimplyBook.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	if basic := os.Getenv("SIMPLYBOOK_BASIC_BOOKINGS"); basic != "" {
+		config.SimplyBook.BasicBookings = basic == "true" || basic == "1"
+	}
+
+	if webhookURL := os.Getenv("SIMPLYBOOK_WEBHOOK_URL"); webhookURL != "" {
+		config.SimplyBook.WebhookURL = webhookURL
+	}
+
+	// 格式為 JSON 物件，例如 {"choice":{"token":"new","previous_token":"old","previous_until":"2026-11-01T00:00:00+08:00"}}
+	if secrets := os.Getenv("SIMPLYBOOK_WEBHOOK_SECRETS"); secrets != "" {
+		if err := json.Unmarshal([]byte(secrets), &config.SimplyBook.WebhookSecrets); err != nil {
+			return nil, fmt.Errorf("解析 SIMPLYBOOK_WEBHOOK_SECRETS 失敗: %w", err)
+		}
+	}
+
+	// 單一租戶部署只需一個令牌，作為未列出租戶的預設令牌
+	if secret := os.Getenv("SIMPLYBOOK_WEBHOOK_SECRET"); secret != "" {
+		if config.SimplyBook.WebhookSecrets == nil {
+			config.SimplyBook.WebhookSecrets = make(map[string]WebhookSecret)
+		}
+		defaultSecret := config.SimplyBook.WebhookSecrets["*"]
+		defaultSecret.Token = secret
+		config.SimplyBook.WebhookSecrets["*"] = defaultSecret
+	}
+
+	if register := os.Getenv("SIMPLYBOOK_REGISTER_WEBHOOK"); register != "" {
+		config.SimplyBook.RegisterWebhook = register == "true" || register == "1"
+	}
+
+	if key := os.Getenv("STRIPE_SECRET_KEY"); key != "" {
+		config.Stripe.SecretKey = key
+	}
+
+	if key := os.Getenv("STRIPE_METADATA_KEY"); key != "" {
+		config.Stripe.MetadataKey = key
+	}
+
+	if token := os.Getenv("CALENDLY_TOKEN"); token != "" {
+		config.Calendly.Token = token
+	}
+
+	if key := os.Getenv("CALENDLY_SIGNING_KEY"); key != "" {
+		config.Calendly.SigningKey = key
+	}
+
+	if path := os.Getenv("CALENDLY_WEBHOOK_PATH"); path != "" {
+		config.Calendly.WebhookPath = path
+	}
+
 	if credsFile := os.Getenv("GOOGLE_CALENDAR_CREDENTIALS_FILE"); credsFile != "" {
 		config.GoogleCalendar.CredentialsFile = credsFile
 	}
 
+	if credsJSON := os.Getenv("GOOGLE_CALENDAR_CREDENTIALS_JSON"); credsJSON != "" {
+		config.GoogleCalendar.CredentialsJSON = credsJSON
+	}
+
+	if files := os.Getenv("GOOGLE_CALENDAR_ADDITIONAL_CREDENTIALS_FILES"); files != "" {
+		config.GoogleCalendar.AdditionalCredentialsFiles = splitList(files)
+	}
+
+	// 以逗號分隔多個 base64 編碼的金鑰
+	if credsJSON := os.Getenv("GOOGLE_CALENDAR_ADDITIONAL_CREDENTIALS_JSON"); credsJSON != "" {
+		config.GoogleCalendar.AdditionalCredentialsJSON = splitList(credsJSON)
+	}
+
 	if calID := os.Getenv("GOOGLE_CALENDAR_ID"); calID != "" {
 		config.GoogleCalendar.CalendarID = calID
 	}
 
-	// 設置默認值
-	if config.Server.Port == 0 {
-		config.Server.Port = 8080
+	if interval := os.Getenv("GOOGLE_CALENDAR_PROBE_INTERVAL"); interval != "" {
+		if err := config.GoogleCalendar.ProbeInterval.parse(interval); err != nil {
+			return nil, fmt.Errorf("解析 GOOGLE_CALENDAR_PROBE_INTERVAL 失敗: %w", err)
+		}
 	}
 
-	if config.Server.WebhookPath == "" {
-		config.Server.WebhookPath = "/webhook"
+	if qps := os.Getenv("GOOGLE_CALENDAR_WRITE_QPS"); qps != "" {
+		var q float64
+		if _, err := fmt.Sscanf(qps, "%g", &q); err == nil {
+			config.GoogleCalendar.WriteBudget.QPS = q
+		}
 	}
 
-	// 驗證必要的配置項
-	if config.SimplyBook.CompanyLogin == "" {
-		return nil, fmt.Errorf("缺少 SimplyBook 公司登錄名")
+	if qps := os.Getenv("GOOGLE_CALENDAR_CALENDAR_WRITE_QPS"); qps != "" {
+		var q float64
+		if _, err := fmt.Sscanf(qps, "%g", &q); err == nil {
+			config.GoogleCalendar.WriteBudget.CalendarQPS = q
+		}
 	}
 
-	if config.SimplyBook.UserName == "" {
-		return nil, fmt.Errorf("缺少 SimplyBook 使用者名稱")
+	if daily := os.Getenv("GOOGLE_CALENDAR_DAILY_WRITES"); daily != "" {
+		var d int
+		if _, err := fmt.Sscanf(daily, "%d", &d); err == nil {
+			config.GoogleCalendar.WriteBudget.Daily = d
+		}
 	}
 
-	if config.SimplyBook.Password == "" {
-		return nil, fmt.Errorf("缺少 SimplyBook 密碼")
+	if address := os.Getenv("GOOGLE_CALENDAR_WATCH_ADDRESS"); address != "" {
+		config.GoogleCalendar.Watch.Address = address
 	}
 
-	if config.GoogleCalendar.CredentialsFile == "" {
-		return nil, fmt.Errorf("缺少 Google 日曆憑證文件")
+	if token := os.Getenv("GOOGLE_CALENDAR_WATCH_TOKEN"); token != "" {
+		config.GoogleCalendar.Watch.Token = token
 	}
 
-	if config.GoogleCalendar.CalendarID == "" {
-		return nil, fmt.Errorf("缺少 Google 日曆 ID")
+	if ttl := os.Getenv("GOOGLE_CALENDAR_WATCH_TTL"); ttl != "" {
+		if err := config.GoogleCalendar.Watch.TTL.parse(ttl); err != nil {
+			return nil, fmt.Errorf("解析 GOOGLE_CALENDAR_WATCH_TTL 失敗: %w", err)
+		}
 	}
 
-	return config, nil
+	if window := os.Getenv("SYNC_PAST_WINDOW"); window != "" {
+		if err := config.Sync.PastWindow.parse(window); err != nil {
+			return nil, fmt.Errorf("解析 SYNC_PAST_WINDOW 失敗: %w", err)
+		}
+	}
+
+	if window := os.Getenv("SYNC_FUTURE_WINDOW"); window != "" {
+		if err := config.Sync.FutureWindow.parse(window); err != nil {
+			return nil, fmt.Errorf("解析 SYNC_FUTURE_WINDOW 失敗: %w", err)
+		}
+	}
+
+	if size := os.Getenv("BOOKING_CACHE_SIZE"); size != "" {
+		var n int
+		if _, err := fmt.Sscanf(size, "%d", &n); err == nil {
+			config.BookingCache.Size = n
+		}
+	}
+
+	if ttl := os.Getenv("BOOKING_CACHE_TTL"); ttl != "" {
+		if err := config.BookingCache.TTL.parse(ttl); err != nil {
+			return nil, fmt.Errorf("解析 BOOKING_CACHE_TTL 失敗: %w", err)
+		}
+	}
+
+	if threshold := os.Getenv("BOOKING_CACHE_NOT_FOUND_THRESHOLD"); threshold != "" {
+		var n int
+		if _, err := fmt.Sscanf(threshold, "%d", &n); err == nil {
+			config.BookingCache.NotFoundThreshold = n
+		}
+	}
+
+	if ttl := os.Getenv("BOOKING_CACHE_NOT_FOUND_TTL"); ttl != "" {
+		if err := config.BookingCache.NotFoundTTL.parse(ttl); err != nil {
+			return nil, fmt.Errorf("解析 BOOKING_CACHE_NOT_FOUND_TTL 失敗: %w", err)
+		}
+	}
+
+	if maxDuration := os.Getenv("SYNC_MAX_DURATION"); maxDuration != "" {
+		if err := config.Sync.MaxDuration.parse(maxDuration); err != nil {
+			return nil, fmt.Errorf("解析 SYNC_MAX_DURATION 失敗: %w", err)
+		}
+	}
+
+	if tolerance := os.Getenv("SYNC_TIME_TOLERANCE"); tolerance != "" {
+		if err := config.Sync.TimeTolerance.parse(tolerance); err != nil {
+			return nil, fmt.Errorf("解析 SYNC_TIME_TOLERANCE 失敗: %w", err)
+		}
+	}
+
+	if debounce := os.Getenv("SYNC_DEBOUNCE"); debounce != "" {
+		if err := config.Sync.Debounce.parse(debounce); err != nil {
+			return nil, fmt.Errorf("解析 SYNC_DEBOUNCE 失敗: %w", err)
+		}
+	}
+
+	if providers := os.Getenv("SYNC_PROVIDERS_ALLOW"); providers != "" {
+		config.Sync.Providers.Allow = splitList(providers)
+	}
+	if providers := os.Getenv("SYNC_PROVIDERS_DENY"); providers != "" {
+		config.Sync.Providers.Deny = splitList(providers)
+	}
+	if services := os.Getenv("SYNC_SERVICES_ALLOW"); services != "" {
+		config.Sync.Services.Allow = splitList(services)
+	}
+	if services := os.Getenv("SYNC_SERVICES_DENY"); services != "" {
+		config.Sync.Services.Deny = splitList(services)
+	}
+
+	// 格式為 JSON 陣列，例如 [{"title":"付款連結","url":"https://pay.example.com/{{.Code}}"}]
+	if links := os.Getenv("EVENT_LINKS"); links != "" {
+		if err := json.Unmarshal([]byte(links), &config.Event.Links); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_LINKS 失敗: %w", err)
+		}
+	}
+
+	if eventRules := os.Getenv("EVENT_RULES"); eventRules != "" {
+		if err := json.Unmarshal([]byte(eventRules), &config.Event.Rules); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_RULES 失敗: %w", err)
+		}
+	}
+
+	if fieldMap := os.Getenv("EVENT_FIELD_MAP"); fieldMap != "" {
+		if err := json.Unmarshal([]byte(fieldMap), &config.Event.FieldMap); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_FIELD_MAP 失敗: %w", err)
+		}
+	}
+
+	if notes := os.Getenv("EVENT_NOTES"); notes != "" {
+		config.Event.Notes = notes == "true" || notes == "1"
+	}
+
+	if invite := os.Getenv("EVENT_INVITE_CLIENTS"); invite != "" {
+		config.Event.InviteClients = invite == "true" || invite == "1"
+	}
+
+	if marker := os.Getenv("EVENT_CODE_MARKER"); marker != "" {
+		config.Event.CodeMarker = marker == "true" || marker == "1"
+	}
+
+	if maxLength := os.Getenv("EVENT_TITLE_MAX_LENGTH"); maxLength != "" {
+		fmt.Sscanf(maxLength, "%d", &config.Event.TitleMaxLength)
+	}
+
+	if resources := os.Getenv("EVENT_RESOURCE_CALENDARS"); resources != "" {
+		if err := json.Unmarshal([]byte(resources), &config.Event.ResourceCalendars); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_RESOURCE_CALENDARS 失敗: %w", err)
+		}
+	}
+
+	if classes := os.Getenv("EVENT_GROUP_CLASSES"); classes != "" {
+		if err := json.Unmarshal([]byte(classes), &config.Event.GroupClasses); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_GROUP_CLASSES 失敗: %w", err)
+		}
+	}
+
+	if sendUpdates := os.Getenv("EVENT_SEND_UPDATES"); sendUpdates != "" {
+		config.Event.SendUpdates = sendUpdates
+	}
+
+	if significance := os.Getenv("EVENT_UPDATE_SIGNIFICANCE"); significance != "" {
+		fmt.Sscanf(significance, "%d", &config.Event.UpdateSignificance)
+	}
+
+	if mode := os.Getenv("EVENT_WAITING_LIST_MODE"); mode != "" {
+		config.Event.WaitingList.Mode = mode
+	}
+
+	if color := os.Getenv("EVENT_WAITING_LIST_COLOR"); color != "" {
+		config.Event.WaitingList.ColorID = color
+	}
+
+	if icons := os.Getenv("EVENT_STATUS_ICONS"); icons != "" {
+		if err := json.Unmarshal([]byte(icons), &config.Event.StatusIcons); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_STATUS_ICONS 失敗: %w", err)
+		}
+	}
+
+	if icons := os.Getenv("EVENT_PAYMENT_ICONS"); icons != "" {
+		if err := json.Unmarshal([]byte(icons), &config.Event.PaymentIcons); err != nil {
+			return nil, fmt.Errorf("解析 EVENT_PAYMENT_ICONS 失敗: %w", err)
+		}
+	}
+
+	if proxyURL := os.Getenv("OUTBOUND_PROXY_URL"); proxyURL != "" {
+		config.HTTP.ProxyURL = proxyURL
+	}
+
+	if caFile := os.Getenv("OUTBOUND_CA_FILE"); caFile != "" {
+		config.HTTP.CAFile = caFile
+	}
+
+	if conns := os.Getenv("OUTBOUND_MAX_IDLE_CONNS_PER_HOST"); conns != "" {
+		var n int
+		if _, err := fmt.Sscanf(conns, "%d", &n); err == nil {
+			config.HTTP.MaxIdleConnsPerHost = n
+		}
+	}
+
+	if conns := os.Getenv("OUTBOUND_MAX_CONNS_PER_HOST"); conns != "" {
+		var n int
+		if _, err := fmt.Sscanf(conns, "%d", &n); err == nil {
+			config.HTTP.MaxConnsPerHost = n
+		}
+	}
+
+	if timeout := os.Getenv("OUTBOUND_IDLE_CONN_TIMEOUT"); timeout != "" {
+		if err := config.HTTP.IdleConnTimeout.parse(timeout); err != nil {
+			return nil, fmt.Errorf("解析 OUTBOUND_IDLE_CONN_TIMEOUT 失敗: %w", err)
+		}
+	}
+
+	if disable := os.Getenv("OUTBOUND_DISABLE_KEEP_ALIVES"); disable != "" {
+		config.HTTP.DisableKeepAlives = disable == "true" || disable == "1"
+	}
+
+	if disable := os.Getenv("OUTBOUND_DISABLE_HTTP2"); disable != "" {
+		config.HTTP.DisableHTTP2 = disable == "true" || disable == "1"
+	}
+
+	if size := os.Getenv("OUTBOUND_TLS_SESSION_CACHE"); size != "" {
+		var n int
+		if _, err := fmt.Sscanf(size, "%d", &n); err == nil {
+			config.HTTP.TLSSessionCache = n
+		}
+	}
+
+	if rate := os.Getenv("CHAOS_CALENDAR_WRITE_FAIL_RATE"); rate != "" {
+		var r float64
+		if _, err := fmt.Sscanf(rate, "%g", &r); err == nil {
+			config.Chaos.CalendarWriteFailRate = r
+		}
+	}
+
+	if d := os.Getenv("CHAOS_SIMPLYBOOK_DELAY"); d != "" {
+		if err := config.Chaos.SimplyBookDelay.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 CHAOS_SIMPLYBOOK_DELAY 失敗: %w", err)
+		}
+	}
+
+	if record := os.Getenv("OUTBOUND_RECORD"); record != "" {
+		config.HTTP.Record = record
+	}
+
+	if replay := os.Getenv("OUTBOUND_REPLAY"); replay != "" {
+		config.HTTP.Replay = replay
+	}
+
+	if adminUser := os.Getenv("ADMIN_USERNAME"); adminUser != "" {
+		config.Admin.Username = adminUser
+	}
+
+	if adminPassword := os.Getenv("ADMIN_PASSWORD"); adminPassword != "" {
+		config.Admin.Password = adminPassword
+	}
+
+	if interval := os.Getenv("RECONCILE_INTERVAL"); interval != "" {
+		if err := config.Reconcile.Interval.parse(interval); err != nil {
+			return nil, fmt.Errorf("解析 RECONCILE_INTERVAL 失敗: %w", err)
+		}
+	}
+
+	if span := os.Getenv("BUSINESS_HOURS"); span != "" {
+		start, end, ok := strings.Cut(span, "-")
+		if !ok {
+			return nil, fmt.Errorf("BUSINESS_HOURS 格式必須為 HH:MM-HH:MM: %s", span)
+		}
+		config.BusinessHours.Start = strings.TrimSpace(start)
+		config.BusinessHours.End = strings.TrimSpace(end)
+	}
+	if days := os.Getenv("BUSINESS_DAYS"); days != "" {
+		config.BusinessHours.Days = splitList(days)
+	}
+	if tz := os.Getenv("BUSINESS_TIMEZONE"); tz != "" {
+		config.BusinessHours.Timezone = tz
+	}
+	if locale := os.Getenv("LOCALE"); locale != "" {
+		config.Locale = locale
+	}
+	if tenants := os.Getenv("TENANT_BUSINESS_HOURS"); tenants != "" {
+		if err := json.Unmarshal([]byte(tenants), &config.TenantBusinessHours); err != nil {
+			return nil, fmt.Errorf("解析 TENANT_BUSINESS_HOURS 失敗: %w", err)
+		}
+	}
+
+	if d := os.Getenv("WEBHOOK_WATCHDOG_SILENCE"); d != "" {
+		if err := config.Watchdog.Silence.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 WEBHOOK_WATCHDOG_SILENCE 失敗: %w", err)
+		}
+	}
+	if d := os.Getenv("WEBHOOK_WATCHDOG_INTERVAL"); d != "" {
+		if err := config.Watchdog.Interval.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 WEBHOOK_WATCHDOG_INTERVAL 失敗: %w", err)
+		}
+	}
+	if d := os.Getenv("WEBHOOK_CATCH_UP_WINDOW"); d != "" {
+		if err := config.Watchdog.CatchUpWindow.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 WEBHOOK_CATCH_UP_WINDOW 失敗: %w", err)
+		}
+	}
+
+	if v := os.Getenv("LOAD_SHED_MAX_HEAP_MB"); v != "" {
+		fmt.Sscanf(v, "%d", &config.LoadShedding.MaxHeapMB)
+	}
+	if v := os.Getenv("LOAD_SHED_MAX_QUEUE_DEPTH"); v != "" {
+		fmt.Sscanf(v, "%d", &config.LoadShedding.MaxQueueDepth)
+	}
+	if d := os.Getenv("LOAD_SHED_INTERVAL"); d != "" {
+		if err := config.LoadShedding.Interval.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 LOAD_SHED_INTERVAL 失敗: %w", err)
+		}
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		config.Notify.SMTP.Host = host
+	}
+
+	if port := os.Getenv("SMTP_PORT"); port != "" {
+		var p int
+		if _, err := fmt.Sscanf(port, "%d", &p); err == nil {
+			config.Notify.SMTP.Port = p
+		}
+	}
+
+	if userName := os.Getenv("SMTP_USERNAME"); userName != "" {
+		config.Notify.SMTP.Username = userName
+	}
+
+	if password := os.Getenv("SMTP_PASSWORD"); password != "" {
+		config.Notify.SMTP.Password = password
+	}
+
+	if from := os.Getenv("SMTP_FROM"); from != "" {
+		config.Notify.SMTP.From = from
+	}
+
+	if to := os.Getenv("SMTP_TO"); to != "" {
+		config.Notify.SMTP.To = splitList(to)
+	}
+
+	if webhookURL := os.Getenv("SLACK_WEBHOOK_URL"); webhookURL != "" {
+		config.Notify.SlackWebhookURL = webhookURL
+	}
+
+	if webhookURL := os.Getenv("APPROVAL_SLACK_WEBHOOK_URL"); webhookURL != "" {
+		config.Approval.SlackWebhookURL = webhookURL
+	}
+
+	if secret := os.Getenv("APPROVAL_SLACK_SIGNING_SECRET"); secret != "" {
+		config.Approval.SlackSigningSecret = secret
+	}
+
+	if path := os.Getenv("APPROVAL_SLACK_ACTIONS_PATH"); path != "" {
+		config.Approval.SlackActionsPath = path
+	}
+
+	if cooldown := os.Getenv("ALERT_COOLDOWN"); cooldown != "" {
+		if err := config.Notify.AlertCooldown.parse(cooldown); err != nil {
+			return nil, fmt.Errorf("解析 ALERT_COOLDOWN 失敗: %w", err)
+		}
+	}
+
+	if enabled := os.Getenv("CONFIRMATION_EMAIL_ENABLED"); enabled != "" {
+		config.Confirmation.Enabled = enabled == "true" || enabled == "1"
+	}
+
+	if subject := os.Getenv("CONFIRMATION_SUBJECT"); subject != "" {
+		config.Confirmation.Subject = subject
+	}
+
+	if body := os.Getenv("CONFIRMATION_BODY"); body != "" {
+		config.Confirmation.Body = body
+	}
+
+	if digestTime := os.Getenv("DIGEST_TIME"); digestTime != "" {
+		config.Digest.Time = digestTime
+	}
+
+	if before := os.Getenv("REMINDER_BEFORE"); before != "" {
+		if err := config.Reminder.Before.parse(before); err != nil {
+			return nil, fmt.Errorf("解析 REMINDER_BEFORE 失敗: %w", err)
+		}
+	}
+
+	if tmpl := os.Getenv("REMINDER_TEMPLATE"); tmpl != "" {
+		config.Reminder.Template = tmpl
+	}
+
+	if sid := os.Getenv("TWILIO_ACCOUNT_SID"); sid != "" {
+		config.Reminder.Twilio.AccountSID = sid
+	}
+
+	if token := os.Getenv("TWILIO_AUTH_TOKEN"); token != "" {
+		config.Reminder.Twilio.AuthToken = token
+	}
+
+	if from := os.Getenv("TWILIO_FROM"); from != "" {
+		config.Reminder.Twilio.From = from
+	}
+
+	if tz := os.Getenv("TIMEZONE"); tz != "" {
+		config.Timezone = tz
+	}
+
+	// 格式為 JSON 陣列，例如 [{"name":"nightly","cron":"0 3 * * *","job":"reconcile"}]
+	if schedules := os.Getenv("SCHEDULES"); schedules != "" {
+		if err := json.Unmarshal([]byte(schedules), &config.Schedules); err != nil {
+			return nil, fmt.Errorf("解析 SCHEDULES 失敗: %w", err)
+		}
+	}
+
+	if driver := os.Getenv("STORE_DRIVER"); driver != "" {
+		config.Store.Driver = driver
+	}
+
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		config.Store.DSN = dsn
+	}
+
+	if path := os.Getenv("STORE_PATH"); path != "" {
+		config.Store.Path = path
+	}
+
+	if keys := os.Getenv("STORE_ENCRYPTION_KEYS"); keys != "" {
+		config.Store.EncryptionKeys = keys
+	}
+
+	if d := os.Getenv("RETENTION_SYNC_RECORDS"); d != "" {
+		if err := config.Retention.SyncRecords.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 RETENTION_SYNC_RECORDS 失敗: %w", err)
+		}
+	}
+
+	if d := os.Getenv("RETENTION_DEAD_LETTERS"); d != "" {
+		if err := config.Retention.DeadLetters.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 RETENTION_DEAD_LETTERS 失敗: %w", err)
+		}
+	}
+
+	if d := os.Getenv("RETENTION_PAYLOADS"); d != "" {
+		if err := config.Retention.Payloads.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 RETENTION_PAYLOADS 失敗: %w", err)
+		}
+	}
+
+	if d := os.Getenv("RETENTION_INTERVAL"); d != "" {
+		if err := config.Retention.Interval.parse(d); err != nil {
+			return nil, fmt.Errorf("解析 RETENTION_INTERVAL 失敗: %w", err)
+		}
+	}
+
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		config.Redis.Addr = addr
+	}
+
+	if password := os.Getenv("REDIS_PASSWORD"); password != "" {
+		config.Redis.Password = password
+	}
+
+	if db := os.Getenv("REDIS_DB"); db != "" {
+		var d int
+		if _, err := fmt.Sscanf(db, "%d", &d); err == nil {
+			config.Redis.DB = d
+		}
+	}
+
+	if prefix := os.Getenv("REDIS_PREFIX"); prefix != "" {
+		config.Redis.Prefix = prefix
+	}
+
+	if ttl := os.Getenv("DEDUP_TTL"); ttl != "" {
+		if err := config.Dedup.TTL.parse(ttl); err != nil {
+			return nil, fmt.Errorf("解析 DEDUP_TTL 失敗: %w", err)
+		}
+	}
+
+	if ttl := os.Getenv("BOOKING_LOCK_TTL"); ttl != "" {
+		if err := config.Lock.TTL.parse(ttl); err != nil {
+			return nil, fmt.Errorf("解析 BOOKING_LOCK_TTL 失敗: %w", err)
+		}
+	}
+
+	if backend := os.Getenv("LOCK_BACKEND"); backend != "" {
+		config.Lock.Backend = backend
+	}
+
+	if sheetID := os.Getenv("REPORT_SHEET_ID"); sheetID != "" {
+		config.Report.SheetID = sheetID
+	}
+
+	if sheetRange := os.Getenv("REPORT_SHEET_RANGE"); sheetRange != "" {
+		config.Report.SheetRange = sheetRange
+	}
+
+	if bucket := os.Getenv("REPORT_BUCKET"); bucket != "" {
+		config.Report.Bucket = bucket
+	}
+
+	if prefix := os.Getenv("REPORT_PREFIX"); prefix != "" {
+		config.Report.Prefix = prefix
+	}
+
+	if project := os.Getenv("REPORT_BIGQUERY_PROJECT"); project != "" {
+		config.Report.BigQuery.Project = project
+	}
+
+	if dataset := os.Getenv("REPORT_BIGQUERY_DATASET"); dataset != "" {
+		config.Report.BigQuery.Dataset = dataset
+	}
+
+	if table := os.Getenv("REPORT_BIGQUERY_TABLE"); table != "" {
+		config.Report.BigQuery.Table = table
+	}
+
+	if bucket := os.Getenv("LOCK_BUCKET"); bucket != "" {
+		config.Lock.Bucket = bucket
+	}
+
+	if prefix := os.Getenv("LOCK_PREFIX"); prefix != "" {
+		config.Lock.Prefix = prefix
+	}
+
+	if format := os.Getenv("AUDIT_FORWARD_FORMAT"); format != "" {
+		config.Audit.Format = format
+	}
+
+	if network := os.Getenv("AUDIT_FORWARD_NETWORK"); network != "" {
+		config.Audit.Network = network
+	}
+
+	if addr := os.Getenv("AUDIT_FORWARD_ADDRESS"); addr != "" {
+		config.Audit.Address = addr
+	}
+
+	if tag := os.Getenv("AUDIT_FORWARD_TAG"); tag != "" {
+		config.Audit.Tag = tag
+	}
+
+	if rate := os.Getenv("AUDIT_FORWARD_RATE"); rate != "" {
+		var r float64
+		if _, err := fmt.Sscanf(rate, "%g", &r); err == nil {
+			config.Audit.Rate = r
+		}
+	}
+
+	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
+		config.ErrorReporting.SentryDSN = dsn
+	}
+
+	if env := os.Getenv("SENTRY_ENVIRONMENT"); env != "" {
+		config.ErrorReporting.Environment = env
+	}
+
+	if enabled := os.Getenv("ERROR_REPORTING_GOOGLE"); enabled != "" {
+		config.ErrorReporting.Google = enabled == "true" || enabled == "1"
+	}
+
+	if project := os.Getenv("ERROR_REPORTING_PROJECT"); project != "" {
+		config.ErrorReporting.Project = project
+	}
+
+	// 設置默認值
+	if config.ConfigVersion == 0 {
+		config.ConfigVersion = CurrentVersion
+	}
+
+	if config.Log.Level == "" {
+		config.Log.Level = "info"
+	}
+
+	if config.Server.Port == 0 {
+		config.Server.Port = 8080
+	}
+
+	if config.Server.WebhookPath == "" {
+		config.Server.WebhookPath = "/webhook"
+	}
+
+	if config.Calendly.WebhookPath == "" {
+		config.Calendly.WebhookPath = "/webhook/calendly"
+	}
+
+	if config.GoogleCalendar.Watch.TTL.Duration <= 0 {
+		config.GoogleCalendar.Watch.TTL.Duration = 24 * time.Hour
+	}
+
+	if config.GoogleCalendar.ProbeInterval.Duration <= 0 {
+		config.GoogleCalendar.ProbeInterval.Duration = 30 * time.Second
+	}
+
+	if config.Admin.Username == "" {
+		config.Admin.Username = "admin"
+	}
+
+	if config.Notify.SMTP.Port == 0 {
+		config.Notify.SMTP.Port = 587
+	}
+
+	if config.Store.Driver == "" {
+		config.Store.Driver = "memory"
+	}
+
+	if config.Store.Path == "" {
+		config.Store.Path = "booking-sync.db"
+	}
+
+	if config.Report.SheetRange == "" {
+		config.Report.SheetRange = "Sheet1"
+	}
+
+	if config.Report.Prefix == "" {
+		config.Report.Prefix = "bookings"
+	}
+
+	if config.Report.BigQuery.Table == "" {
+		config.Report.BigQuery.Table = "booking_events"
+	}
+
+	if config.Retention.Interval.Duration <= 0 {
+		config.Retention.Interval.Duration = 24 * time.Hour
+	}
+
+	if config.Watchdog.Interval.Duration <= 0 {
+		config.Watchdog.Interval.Duration = 15 * time.Minute
+	}
+	if config.Watchdog.CatchUpWindow.Duration <= 0 {
+		config.Watchdog.CatchUpWindow.Duration = 30 * 24 * time.Hour
+	}
+
+	if config.LoadShedding.Interval.Duration <= 0 {
+		config.LoadShedding.Interval.Duration = 10 * time.Second
+	}
+
+	if config.Redis.Prefix == "" {
+		config.Redis.Prefix = "booking-sync:"
+	}
+
+	if config.Dedup.TTL.Duration <= 0 {
+		config.Dedup.TTL.Duration = 10 * time.Minute
+	}
+
+	if config.Lock.TTL.Duration <= 0 {
+		config.Lock.TTL.Duration = 2 * time.Minute
+	}
+
+	if config.Lock.Backend == "" {
+		config.Lock.Backend = "memory"
+	}
+
+	if config.Lock.Prefix == "" {
+		config.Lock.Prefix = "booking-sync/locks"
+	}
+
+	if config.Approval.SlackActionsPath == "" {
+		config.Approval.SlackActionsPath = "/slack/actions"
+	}
+
+	if config.Locale == "" {
+		config.Locale = "zh-TW"
+	}
+	if config.Event.SendUpdates == "" {
+		config.Event.SendUpdates = "all"
+	}
+	if config.Event.UpdateSignificance <= 0 {
+		config.Event.UpdateSignificance = 3
+	}
+	if config.Event.WaitingList.Mode == "" {
+		config.Event.WaitingList.Mode = "tentative"
+	}
+
+	if config.Event.WaitingList.ColorID == "" {
+		config.Event.WaitingList.ColorID = "8"
+	}
+
+	if config.Audit.Format == "" {
+		config.Audit.Format = "syslog"
+	}
+
+	if dev {
+		config.applyDevDefaults()
+	}
+
+	// 驗證必要的配置項
+	if config.SimplyBook.CompanyLogin == "" {
+		return nil, fmt.Errorf("缺少 SimplyBook 公司登錄名")
+	}
+
+	if config.SimplyBook.UserName == "" {
+		return nil, fmt.Errorf("缺少 SimplyBook 使用者名稱")
+	}
+
+	if config.SimplyBook.Password == "" {
+		return nil, fmt.Errorf("缺少 SimplyBook 密碼")
+	}
+
+	if !config.Dev && config.GoogleCalendar.CredentialsFile == "" && config.GoogleCalendar.CredentialsJSON == "" {
+		return nil, fmt.Errorf("缺少 Google 日曆憑證文件或憑證內容")
+	}
+
+	if config.GoogleCalendar.CalendarID == "" {
+		return nil, fmt.Errorf("缺少 Google 日曆 ID")
+	}
+
+	if config.Notify.SMTP.Host != "" && config.Notify.SMTP.From == "" {
+		return nil, fmt.Errorf("使用 SMTP 通知時缺少寄件者地址")
+	}
+
+	if config.Confirmation.Enabled && config.Notify.SMTP.Host == "" {
+		return nil, fmt.Errorf("啟用確認郵件時缺少 SMTP 設定")
+	}
+
+	if rate := config.Chaos.CalendarWriteFailRate; rate < 0 || rate > 1 {
+		return nil, fmt.Errorf("chaos.calendar_write_fail_rate 必須介於 0 與 1 之間: %g", rate)
+	}
+
+	if config.Locale != "zh-TW" && config.Locale != "en" {
+		return nil, fmt.Errorf("locale 必須為 zh-TW 或 en: %s", config.Locale)
+	}
+
+	if config.Event.TitleMaxLength < 0 {
+		return nil, fmt.Errorf("event.title_max_length 不可為負數: %d", config.Event.TitleMaxLength)
+	}
+	if config.LoadShedding.MaxHeapMB < 0 || config.LoadShedding.MaxQueueDepth < 0 {
+		return nil, fmt.Errorf("load_shedding 的門檻不可為負數")
+	}
+	if config.Server.LatencySLO.Duration < 0 {
+		return nil, fmt.Errorf("server.latency_slo 不可為負數: %s", config.Server.LatencySLO.Duration)
+	}
+	if item := config.Sync.Providers.overlap(); item != "" {
+		return nil, fmt.Errorf("sync.providers 的 allow 與 deny 同時包含 %q", item)
+	}
+	if item := config.Sync.Services.overlap(); item != "" {
+		return nil, fmt.Errorf("sync.services 的 allow 與 deny 同時包含 %q", item)
+	}
+
+	if s := config.Event.SendUpdates; s != "all" && s != "externalOnly" && s != "none" {
+		return nil, fmt.Errorf("event.send_updates 必須為 all、externalOnly 或 none: %s", s)
+	}
+
+	if mode := config.Event.WaitingList.Mode; mode != "tentative" && mode != "skip" {
+		return nil, fmt.Errorf("event.waiting_list.mode 必須為 tentative 或 skip: %s", mode)
+	}
+
+	if config.Approval.SlackWebhookURL != "" && config.Approval.SlackSigningSecret == "" {
+		return nil, fmt.Errorf("使用 Slack 核准預約時缺少 Signing Secret")
+	}
+
+	if config.Audit.Address != "" {
+		if config.Audit.Format != "syslog" && config.Audit.Format != "fluentd" {
+			return nil, fmt.Errorf("不支持的稽核事件格式: %s", config.Audit.Format)
+		}
+		if n := config.Audit.Network; n != "" && n != "tcp" && n != "udp" {
+			return nil, fmt.Errorf("不支持的稽核事件傳輸方式: %s", n)
+		}
+	}
+
+	if config.HTTP.Record != "" && config.HTTP.Replay != "" {
+		return nil, fmt.Errorf("不可同時設置 OUTBOUND_RECORD 與 OUTBOUND_REPLAY")
+	}
+
+	if _, err := config.BusinessHoursSet(); err != nil {
+		return nil, fmt.Errorf("無效的營業時間: %w", err)
+	}
+
+	if config.Digest.Time != "" {
+		if _, _, err := ParseClock(config.Digest.Time); err != nil {
+			return nil, fmt.Errorf("無效的每日摘要時間: %w", err)
+		}
+	}
+
+	if config.Reminder.Before.Duration > 0 {
+		if config.Reminder.Twilio.AccountSID == "" || config.Reminder.Twilio.AuthToken == "" || config.Reminder.Twilio.From == "" {
+			return nil, fmt.Errorf("啟用簡訊提醒時缺少 Twilio 帳號設定")
+		}
+	}
+
+	if _, err := config.Location(); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, job := range config.Schedules {
+		if job.Name == "" || job.Cron == "" {
+			return nil, fmt.Errorf("排程任務缺少名稱或 cron 表達式")
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("排程任務名稱重複: %s", job.Name)
+		}
+		names[job.Name] = true
+		if !scheduledJobTypes[job.Job] {
+			return nil, fmt.Errorf("排程任務 %s 的類型無效: %s", job.Name, job.Job)
+		}
+	}
+
+	switch config.Store.Driver {
+	case "memory", "bolt":
+	case "postgres":
+		if config.Store.DSN == "" {
+			return nil, fmt.Errorf("使用 PostgreSQL 儲存時缺少資料庫連線字串")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的儲存驅動: %s", config.Store.Driver)
+	}
+
+	seenTenants := make(map[string]bool)
+	for _, tenant := range config.Server.WebhookTenants {
+		if !tenantPattern.MatchString(tenant) {
+			return nil, fmt.Errorf("無效的 webhook 租戶: %q（僅可包含英數字、- 與 _）", tenant)
+		}
+		if seenTenants[tenant] {
+			return nil, fmt.Errorf("重複的 webhook 租戶: %s", tenant)
+		}
+		seenTenants[tenant] = true
+		if tenant != config.SimplyBook.CompanyLogin {
+			return nil, fmt.Errorf("webhook 租戶 %s 與 SimplyBook 公司登錄名 %s 不同；每個服務只同步一家公司的預約，其他公司請另外部署", tenant, config.SimplyBook.CompanyLogin)
+		}
+		if strings.TrimSuffix(config.Server.WebhookPath, "/")+"/"+tenant == config.Calendly.WebhookPath {
+			return nil, fmt.Errorf("webhook 租戶 %s 的路徑與 Calendly webhook 路徑相同", tenant)
+		}
+	}
+
+	for company, secret := range config.SimplyBook.WebhookSecrets {
+		if secret.Token == "" {
+			return nil, fmt.Errorf("租戶 %s 的 webhook 令牌為空", company)
+		}
+		if secret.PreviousToken != "" && secret.PreviousUntil.IsZero() {
+			return nil, fmt.Errorf("租戶 %s 設置了舊令牌但未設置寬限期結束時間", company)
+		}
+	}
+
+	if address := config.GoogleCalendar.Watch.Address; address != "" {
+		u, err := url.Parse(address)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.Path == "" || u.Path == "/" {
+			return nil, fmt.Errorf("日曆變更通知網址必須是包含路徑的 HTTPS 網址: %s", address)
+		}
+	}
+
+	switch config.Lock.Backend {
+	case "memory":
+	case "gcs":
+		if config.Lock.Bucket == "" {
+			return nil, fmt.Errorf("使用 GCS 鎖時缺少儲存桶名稱")
+		}
+	case "redis":
+		if config.Redis.Addr == "" {
+			return nil, fmt.Errorf("使用 Redis 鎖時缺少 Redis 位址")
+		}
+	default:
+		return nil, fmt.Errorf("不支持的鎖後端: %s", config.Lock.Backend)
+	}
+
+	return config, nil
+}
+
+// applyDevDefaults 套用開發模式的設定：SimplyBook 帳號與日曆 ID 未設置時使用佔位值，
+// 同步狀態與鎖只存在記憶體中，並停用 webhook 回呼檢查與令牌驗證，讓 webhook 模擬器直接送出通知
+func (c *Config) applyDevDefaults() {
+	c.Dev = true
+
+	if c.SimplyBook.CompanyLogin == "" {
+		c.SimplyBook.CompanyLogin = "dev"
+	}
+	if c.SimplyBook.UserName == "" {
+		c.SimplyBook.UserName = "dev"
+	}
+	if c.SimplyBook.Password == "" {
+		c.SimplyBook.Password = "dev"
+	}
+	if c.GoogleCalendar.CalendarID == "" {
+		c.GoogleCalendar.CalendarID = "dev@group.calendar.google.com"
+	}
+	if c.Admin.Password == "" {
+		c.Admin.Password = "dev"
+	}
+	// 模擬 SimplyBook 的團體課程使用服務設定的人數上限
+	if len(c.Event.GroupClasses) == 0 {
+		c.Event.GroupClasses = map[string]int{"瑜伽團體課": 0}
+	}
+	// 模擬 SimplyBook 的兩個房間各有一個資源日曆
+	if len(c.Event.ResourceCalendars) == 0 {
+		c.Event.ResourceCalendars = map[string]string{
+			"A 室": "room-a@resource.calendar.google.com",
+			"B 室": "room-b@resource.calendar.google.com",
+		}
+	}
+	// 模擬器修改預約後立即送出 webhook，快取的預約會讓同步使用修改前的資料
+	if c.BookingCache.TTL.Duration <= 0 {
+		c.BookingCache.TTL.Duration = time.Millisecond
+	}
+
+	if c.Store.Driver != "memory" {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("開發模式忽略儲存驅動 %s，改用記憶體儲存", c.Store.Driver))
+		c.Store.Driver = "memory"
+	}
+	if c.Lock.Backend != "memory" {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("開發模式忽略鎖後端 %s，改用記憶體鎖", c.Lock.Backend))
+		c.Lock.Backend = "memory"
+	}
+	c.SimplyBook.WebhookURL = ""
+	c.SimplyBook.RegisterWebhook = false
+	c.SimplyBook.WebhookSecrets = nil
+}
+
+// ParseClock 解析 HH:MM 格式的時間
+func ParseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("時間格式必須為 HH:MM: %s", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// tenantPattern 是 webhook 路徑中租戶名稱允許的字元
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// overlap 返回同時出現在允許與排除清單的第一個項目，沒有時返回空字串
+func (l ScopeList) overlap() string {
+	for _, allow := range l.Allow {
+		for _, deny := range l.Deny {
+			if strings.EqualFold(strings.TrimSpace(allow), strings.TrimSpace(deny)) {
+				return allow
+			}
+		}
+	}
+	return ""
+}
+
+// splitList 解析以逗號分隔的清單，忽略空白項目
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GoogleCredentials 返回 Google 服務帳號憑證，優先使用 CredentialsJSON，否則讀取 CredentialsFile
+func (c *Config) GoogleCredentials() ([]byte, error) {
+	raw := strings.TrimSpace(c.GoogleCalendar.CredentialsJSON)
+	if raw == "" {
+		return LoadGoogleCredentials(c.GoogleCalendar.CredentialsFile)
+	}
+	return decodeCredentials(raw)
+}
+
+// GoogleCredentialsList 返回主要與備用的 Google 服務帳號憑證，主要憑證在第一個
+func (c *Config) GoogleCredentialsList() ([][]byte, error) {
+	primary, err := c.GoogleCredentials()
+	if err != nil {
+		return nil, err
+	}
+	creds := [][]byte{primary}
+
+	for _, path := range c.GoogleCalendar.AdditionalCredentialsFiles {
+		data, err := LoadGoogleCredentials(path)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, data)
+	}
+
+	for _, raw := range c.GoogleCalendar.AdditionalCredentialsJSON {
+		data, err := decodeCredentials(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, data)
+	}
+
+	return creds, nil
+}
+
+// decodeCredentials 解析原始 JSON 或 base64 編碼的服務帳號憑證
+func decodeCredentials(raw string) ([]byte, error) {
+	// 原始 JSON 以 { 開頭，否則視為 base64 編碼
+	if strings.HasPrefix(raw, "{") {
+		return []byte(raw), nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		// 容許不含填充字元的編碼
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(raw, "="))
+		if err != nil {
+			return nil, fmt.Errorf("解析 Google 服務帳號憑證失敗，需為 JSON 或 base64 編碼: %w", err)
+		}
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("Google 服務帳號憑證解碼後不是有效的 JSON")
+	}
+
+	return data, nil
 }
 
 // LoadGoogleCredentials 加載 Google 服務帳號憑證
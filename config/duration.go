@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration 包裝 time.Duration，支援以 "30s"、"1h" 等字串設定
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON 自定義時間長度解析方法
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("時間長度必須是字串: %w", err)
+	}
+
+	return d.parse(s)
+}
+
+// MarshalJSON 以字串輸出時間長度
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// parse 解析時間長度字串，空字串代表 0
+func (d *Duration) parse(s string) error {
+	if s == "" {
+		d.Duration = 0
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("無效的時間長度 %q: %w", s, err)
+	}
+
+	d.Duration = parsed
+	return nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// CurrentVersion 目前的配置文件結構版本
+//
+// 版本 2：redis.dedup_ttl 與 redis.lock_ttl 移至 dedup.ttl 與 lock.ttl，
+// 這兩項設定在未使用 Redis 時同樣生效
+const CurrentVersion = 2
+
+// redactedValue 遮蔽後的敏感值
+const redactedValue = "[REDACTED]"
+
+// upgrade 檢查配置文件版本，並將已棄用的設定轉換為目前的結構
+func (c *Config) upgrade() error {
+	if c.ConfigVersion > CurrentVersion {
+		return fmt.Errorf("配置文件版本 %d 高於支持的版本 %d，請升級 booking-sync", c.ConfigVersion, CurrentVersion)
+	}
+	if c.ConfigVersion == 0 {
+		c.ConfigVersion = 1
+		c.warn("配置文件未設置 config_version，視為版本 1；目前版本為 %d", CurrentVersion)
+	}
+
+	if c.Redis.DedupTTL.Duration > 0 {
+		if c.Dedup.TTL.Duration == 0 {
+			c.Dedup.TTL = c.Redis.DedupTTL
+		}
+		c.Redis.DedupTTL.Duration = 0
+		c.warn("redis.dedup_ttl 已棄用，請改用 dedup.ttl")
+	}
+
+	if c.Redis.LockTTL.Duration > 0 {
+		if c.Lock.TTL.Duration == 0 {
+			c.Lock.TTL = c.Redis.LockTTL
+		}
+		c.Redis.LockTTL.Duration = 0
+		c.warn("redis.lock_ttl 已棄用，請改用 lock.ttl")
+	}
+
+	return nil
+}
+
+// warn 記錄載入配置時的警告
+func (c *Config) warn(format string, args ...interface{}) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Redacted 返回遮蔽敏感欄位後的配置副本，供輸出有效配置使用
+func (c *Config) Redacted() (*Config, error) {
+	// 以 JSON 往返取得深層副本，避免修改到原配置中的 map 與 slice
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失敗: %w", err)
+	}
+	copied := &Config{}
+	if err := json.Unmarshal(data, copied); err != nil {
+		return nil, fmt.Errorf("複製配置失敗: %w", err)
+	}
+
+	redact(reflect.ValueOf(copied).Elem())
+	return copied, nil
+}
+
+// redact 遞迴遮蔽標記為 secret 的非空字串與 map 值
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			if t.Field(i).Tag.Get("secret") == "true" {
+				redactValue(field)
+				continue
+			}
+			redact(field)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redact(v.Index(i))
+		}
+	case reflect.Map:
+		// map 的值無法直接修改，遮蔽副本後寫回
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			redact(value)
+			v.SetMapIndex(key, value)
+		}
+	}
+}
+
+// redactValue 遮蔽單一敏感欄位，空值保持不變以便看出是否已設置
+func redactValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			v.SetString(redactedValue)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			v.SetMapIndex(key, reflect.ValueOf(redactedValue))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+)
+
+// handleApproval 核准或拒絕待核准的預約（POST booking_id=...&decision=approve|decline），並立即更新日曆事件
+func (u *UI) handleApproval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	bookingID := strings.TrimSpace(r.FormValue("booking_id"))
+	if bookingID == "" {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 booking_id 參數")
+		return
+	}
+
+	decision := r.FormValue("decision")
+	if decision != "approve" && decision != "decline" {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "decision 必須為 approve 或 decline")
+		return
+	}
+
+	if err := u.handler.DecideBooking(bookingID, decision == "approve"); err != nil {
+		log.Printf("處理預約 %s 的核准決定失敗: %v", bookingID, err)
+		if errors.Is(err, handler.ErrNotPending) {
+			problem.Write(w, r, http.StatusConflict, problem.CodeConflict, err.Error())
+			return
+		}
+		problem.Write(w, r, http.StatusBadGateway, problem.CodeUpstream, "處理核准決定失敗: "+err.Error())
+		return
+	}
+
+	writeJSON(w, map[string]string{"booking_id": bookingID, "decision": decision})
+}
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+)
+
+// RequireAuth 以 HTTP Basic 認證保護管理端點
+func RequireAuth(username, password string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="booking-sync admin"`)
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "未授權")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+)
+
+const (
+	// defaultErrorLimit 與 maxErrorLimit 是 /admin/errors 返回的預設與最大筆數
+	defaultErrorLimit = 50
+	maxErrorLimit     = 500
+)
+
+// processingError 是單筆同步失敗的摘要
+type processingError struct {
+	ID        int64     `json:"id"`
+	BookingID string    `json:"booking_id"`
+	Action    string    `json:"action"`
+	Kind      string    `json:"kind"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// errorsReport 是 /admin/errors 的回應
+type errorsReport struct {
+	Counts map[string]int     `json:"counts"` // 依分類統計的筆數
+	Errors []*processingError `json:"errors"`
+}
+
+// handleErrors 以 JSON 返回最近的同步失敗（?limit=N，可用 ?kind= 篩選分類），
+// 讓支援人員不需查看伺服器日誌即可分流
+func (u *UI) handleErrors(w http.ResponseWriter, r *http.Request) {
+	limit := defaultErrorLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "limit 必須為正整數")
+			return
+		}
+		limit = n
+	}
+	if limit > maxErrorLimit {
+		limit = maxErrorLimit
+	}
+	kind := r.URL.Query().Get("kind")
+
+	failures, err := u.store.ListSyncRecords(limit, true)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取失敗記錄失敗")
+		return
+	}
+
+	report := errorsReport{Counts: make(map[string]int), Errors: []*processingError{}}
+	for _, f := range failures {
+		// 舊版本寫入的記錄沒有分類
+		recordKind := f.ErrorKind
+		if recordKind == "" {
+			recordKind = handler.ErrorKindOther
+		}
+
+		report.Counts[recordKind]++
+		if kind != "" && kind != recordKind {
+			continue
+		}
+		report.Errors = append(report.Errors, &processingError{
+			ID:        f.ID,
+			BookingID: f.BookingID,
+			Action:    f.Action,
+			Kind:      recordKind,
+			Error:     f.Error,
+			CreatedAt: f.CreatedAt,
+		})
+	}
+
+	writeJSON(w, report)
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/logging"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+)
+
+// runtimeFlags 是執行期間可查詢的狀態
+type runtimeFlags struct {
+	LogLevel         string `json:"log_level"`
+	QueueDepth       int64  `json:"queue_depth"`
+	CalendarDegraded bool   `json:"calendar_degraded"`
+
+	Credentials []gcalendar.CredentialStatus `json:"google_credentials"`
+}
+
+// handleFlags 以 JSON 返回執行期間的狀態
+func (u *UI) handleFlags(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, runtimeFlags{
+		LogLevel:         logging.Level(),
+		QueueDepth:       u.handler.QueueDepth(),
+		CalendarDegraded: u.handler.CalendarDegraded(),
+		Credentials:      u.handler.CredentialStatuses(),
+	})
+}
+
+// handleLogLevel 查詢（GET）或設置（POST level=debug|info）日誌等級，不需重啟服務
+func (u *UI) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		level := r.FormValue("level")
+		if level == "" {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 level 參數")
+			return
+		}
+		if err := logging.SetLevel(level); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, err.Error())
+			return
+		}
+		log.Printf("日誌等級已設為 %s", logging.Level())
+	default:
+		problem.MethodNotAllowed(w, r, "GET, POST", "僅支持 GET 與 POST 請求")
+		return
+	}
+
+	writeJSON(w, map[string]string{"level": logging.Level()})
+}
+
+// handleBookingHistory 以 JSON 返回預約的快照版本與每版的變更
+func (u *UI) handleBookingHistory(w http.ResponseWriter, r *http.Request) {
+	bookingID := strings.TrimSpace(r.URL.Query().Get("booking_id"))
+	if bookingID == "" {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 booking_id 參數")
+		return
+	}
+
+	versions, err := u.handler.BookingHistory(bookingID)
+	if err != nil {
+		log.Printf("讀取預約 %s 的歷史失敗: %v", bookingID, err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取預約歷史失敗")
+		return
+	}
+
+	writeJSON(w, versions)
+}
+
+// handleBookingState 以 JSON 返回 /admin/bookings/{id} 的同步狀態：預約快照、對應的日曆事件、
+// 最近的同步結果與暫存操作；帶平台前綴的識別碼（例如 calendly:<uuid>/<uuid>）可直接放在路徑中
+func (u *UI) handleBookingState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		problem.MethodNotAllowed(w, r, "GET", "僅支持 GET 請求")
+		return
+	}
+
+	bookingID := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/admin/bookings/"))
+	if bookingID == "" {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少預約 ID")
+		return
+	}
+
+	state, err := u.handler.BookingState(bookingID)
+	if err != nil {
+		log.Printf("讀取預約 %s 的同步狀態失敗: %v", bookingID, err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取預約同步狀態失敗")
+		return
+	}
+	if state == nil {
+		problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "找不到預約的同步記錄")
+		return
+	}
+
+	writeJSON(w, state)
+}
+
+// writeJSON 輸出 JSON 回應
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("輸出 JSON 回應失敗: %v", err)
+	}
+}
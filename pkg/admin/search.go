@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// maxSearchResults 是 /admin/search 返回的最大筆數
+const maxSearchResults = 200
+
+// clientBooking 是客戶搜尋結果中的單筆預約，包含對應的日曆事件與最近一次同步結果
+type clientBooking struct {
+	BookingID     string    `json:"booking_id"`
+	BookingCode   string    `json:"booking_code"`
+	ClientName    string    `json:"client_name"`
+	ClientEmail   string    `json:"client_email"`
+	ClientAliases []string  `json:"client_aliases,omitempty"`
+	EventID       string    `json:"event_id"`
+	CalendarID    string    `json:"calendar_id,omitempty"`
+	EventLink     string    `json:"event_link,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	LastSync *store.SyncRecord `json:"last_sync,omitempty"`
+}
+
+// handleSearch 以 JSON 返回客戶姓名或電子郵件（?client=，不分大小寫的部分比對，包含客戶先前使用的資料）
+// 符合的已同步預約及其日曆事件，供櫃台人員處理「邀請內容不對」的來電
+func (u *UI) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		problem.MethodNotAllowed(w, r, "GET", "僅支持 GET 請求")
+		return
+	}
+
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("client")))
+	if query == "" {
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 client 參數")
+		return
+	}
+
+	mappings, err := u.store.ListMappings()
+	if err != nil {
+		log.Printf("搜尋客戶預約失敗: %v", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取事件對應關係失敗")
+		return
+	}
+
+	results := []*clientBooking{}
+	for _, m := range mappings {
+		if !matchesClient(m, query) {
+			continue
+		}
+		if len(results) >= maxSearchResults {
+			break
+		}
+
+		result := &clientBooking{
+			BookingID:     m.BookingID,
+			BookingCode:   m.BookingCode,
+			ClientName:    m.ClientName,
+			ClientEmail:   m.ClientEmail,
+			ClientAliases: m.ClientAliases,
+			EventID:       m.EventID,
+			CalendarID:    m.CalendarID,
+			EventLink:     m.EventLink,
+			UpdatedAt:     m.UpdatedAt,
+		}
+		if records, err := u.store.ListBookingSyncRecords(m.BookingID, 1); err != nil {
+			log.Printf("讀取預約 %s 的同步記錄失敗: %v", m.BookingID, err)
+		} else if len(records) > 0 {
+			result.LastSync = records[0]
+		}
+		results = append(results, result)
+	}
+
+	writeJSON(w, results)
+}
+
+// matchesClient 判斷對應關係的客戶姓名、電子郵件或舊資料是否包含查詢字串（query 須為小寫）
+func matchesClient(m *store.Mapping, query string) bool {
+	for _, value := range append([]string{m.ClientName, m.ClientEmail}, m.ClientAliases...) {
+		if value != "" && strings.Contains(strings.ToLower(value), query) {
+			return true
+		}
+	}
+	return false
+}
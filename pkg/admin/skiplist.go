@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// handleSkipList 列出（GET）、新增（POST code=...&reason=...）或移除（DELETE ?code=...）手動排除同步的預約編號
+func (u *UI) handleSkipList(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		code := strings.TrimSpace(r.FormValue("code"))
+		if code == "" {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 code 參數")
+			return
+		}
+		skipped := &store.SkippedBooking{BookingCode: code, Reason: strings.TrimSpace(r.FormValue("reason"))}
+		if err := u.store.AddSkippedBooking(skipped); err != nil {
+			log.Printf("排除預約 %s 失敗: %v", code, err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "儲存排除清單失敗")
+			return
+		}
+		log.Printf("已將預約 %s 加入排除清單: %s", code, skipped.Reason)
+	case http.MethodDelete:
+		code := strings.TrimSpace(r.URL.Query().Get("code"))
+		if code == "" {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 code 參數")
+			return
+		}
+		removed, err := u.store.RemoveSkippedBooking(code)
+		if err != nil {
+			log.Printf("取消排除預約 %s 失敗: %v", code, err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "更新排除清單失敗")
+			return
+		}
+		if !removed {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "預約編號不在排除清單中")
+			return
+		}
+		log.Printf("已將預約 %s 移出排除清單", code)
+	default:
+		problem.MethodNotAllowed(w, r, "GET, POST, DELETE", "僅支持 GET、POST 與 DELETE 請求")
+		return
+	}
+
+	skipped, err := u.store.ListSkippedBookings()
+	if err != nil {
+		log.Printf("讀取排除清單失敗: %v", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取排除清單失敗")
+		return
+	}
+	if skipped == nil {
+		skipped = []*store.SkippedBooking{}
+	}
+	writeJSON(w, skipped)
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// handleSuppressions 列出（GET）、新增（POST email=...&reason=...）或移除（DELETE ?email=...）停止邀請為事件參與者的地址，
+// 供退信或垃圾郵件檢舉的通知（例如郵件服務的 webhook）寫入
+func (u *UI) handleSuppressions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		email := strings.TrimSpace(r.FormValue("email"))
+		if email == "" {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 email 參數")
+			return
+		}
+		suppressed := &store.SuppressedEmail{Email: email, Reason: strings.TrimSpace(r.FormValue("reason"))}
+		if err := u.store.AddSuppressedEmail(suppressed); err != nil {
+			log.Printf("將地址加入停止邀請清單失敗: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "儲存停止邀請清單失敗")
+			return
+		}
+		log.Printf("已將一個地址加入停止邀請清單: %s", suppressed.Reason)
+	case http.MethodDelete:
+		email := strings.TrimSpace(r.URL.Query().Get("email"))
+		if email == "" {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidRequest, "缺少 email 參數")
+			return
+		}
+		removed, err := u.store.RemoveSuppressedEmail(email)
+		if err != nil {
+			log.Printf("將地址移出停止邀請清單失敗: %v", err)
+			problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "更新停止邀請清單失敗")
+			return
+		}
+		if !removed {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "地址不在停止邀請清單中")
+			return
+		}
+		log.Printf("已將一個地址移出停止邀請清單")
+	default:
+		problem.MethodNotAllowed(w, r, "GET, POST, DELETE", "僅支持 GET、POST 與 DELETE 請求")
+		return
+	}
+
+	suppressed, err := u.store.ListSuppressedEmails()
+	if err != nil {
+		log.Printf("讀取停止邀請清單失敗: %v", err)
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取停止邀請清單失敗")
+		return
+	}
+	if suppressed == nil {
+		suppressed = []*store.SuppressedEmail{}
+	}
+	writeJSON(w, suppressed)
+}
@@ -0,0 +1,182 @@
+package admin
+
+import (
+	"embed"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var uiTemplates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// recentLimit 儀表板顯示的最近記錄筆數
+const recentLimit = 50
+
+// UI 提供內嵌的同步儀表板
+type UI struct {
+	store   store.Store
+	handler *handler.WebhookHandler
+
+	mu         sync.Mutex
+	lastDrift  *handler.DriftReport
+	lastResult *handler.ReconcileResult
+}
+
+// NewUI 創建新的同步儀表板
+func NewUI(syncStore store.Store, webhookHandler *handler.WebhookHandler) *UI {
+	return &UI{
+		store:   syncStore,
+		handler: webhookHandler,
+	}
+}
+
+// Register 在路由上註冊儀表板，所有路徑皆需管理員認證
+func (u *UI) Register(mux *http.ServeMux, username, password string) {
+	mux.Handle("/ui", RequireAuth(username, password, http.HandlerFunc(u.handleIndex)))
+	mux.Handle("/ui/replay", RequireAuth(username, password, http.HandlerFunc(u.handleReplay)))
+	mux.Handle("/ui/drift", RequireAuth(username, password, http.HandlerFunc(u.handleDrift)))
+	mux.Handle("/ui/reconcile", RequireAuth(username, password, http.HandlerFunc(u.handleReconcile)))
+	mux.Handle("/admin/flags", RequireAuth(username, password, http.HandlerFunc(u.handleFlags)))
+	mux.Handle("/admin/loglevel", RequireAuth(username, password, http.HandlerFunc(u.handleLogLevel)))
+	mux.Handle("/admin/errors", RequireAuth(username, password, http.HandlerFunc(u.handleErrors)))
+	mux.Handle("/admin/search", RequireAuth(username, password, http.HandlerFunc(u.handleSearch)))
+	mux.Handle("/admin/skiplist", RequireAuth(username, password, http.HandlerFunc(u.handleSkipList)))
+	mux.Handle("/admin/suppressions", RequireAuth(username, password, http.HandlerFunc(u.handleSuppressions)))
+	mux.Handle("/admin/approvals", RequireAuth(username, password, http.HandlerFunc(u.handleApproval)))
+	mux.Handle("/admin/bookings/history", RequireAuth(username, password, http.HandlerFunc(u.handleBookingHistory)))
+	mux.Handle("/admin/bookings/", RequireAuth(username, password, http.HandlerFunc(u.handleBookingState)))
+}
+
+// indexData 是儀表板頁面的模板資料
+type indexData struct {
+	Message    string
+	QueueDepth int64
+	Outbox     *store.OutboxStats
+	Recent     []*store.SyncRecord
+	Failures   []*store.SyncRecord
+	Dead       []*store.DeadLetter
+	Drift      *handler.DriftReport
+	Reconcile  *handler.ReconcileResult
+}
+
+// handleIndex 顯示儀表板首頁
+func (u *UI) handleIndex(w http.ResponseWriter, r *http.Request) {
+	recent, err := u.store.ListSyncRecords(recentLimit, false)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取同步記錄失敗")
+		return
+	}
+
+	failures, err := u.store.ListSyncRecords(recentLimit, true)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取失敗記錄失敗")
+		return
+	}
+
+	dead, err := u.store.ListDeadLetters(recentLimit)
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取死信失敗")
+		return
+	}
+
+	outbox, err := u.handler.OutboxStats()
+	if err != nil {
+		problem.Write(w, r, http.StatusInternalServerError, problem.CodeStorage, "讀取暫存操作失敗")
+		return
+	}
+
+	u.mu.Lock()
+	data := indexData{
+		Message:    r.URL.Query().Get("msg"),
+		QueueDepth: u.handler.QueueDepth(),
+		Outbox:     outbox,
+		Recent:     recent,
+		Failures:   failures,
+		Dead:       dead,
+		Drift:      u.lastDrift,
+		Reconcile:  u.lastResult,
+	}
+	u.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := uiTemplates.ExecuteTemplate(w, "index.html", data); err != nil {
+		log.Printf("渲染儀表板失敗: %v", err)
+	}
+}
+
+// handleReplay 重新同步指定預約
+func (u *UI) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	bookingID := strings.TrimSpace(r.FormValue("booking_id"))
+	if bookingID == "" {
+		redirect(w, r, "缺少預約 ID")
+		return
+	}
+
+	if err := u.handler.ReplayBooking(bookingID); err != nil {
+		redirect(w, r, "重新同步預約 "+bookingID+" 失敗: "+err.Error())
+		return
+	}
+
+	redirect(w, r, "已重新同步預約 "+bookingID)
+}
+
+// handleDrift 執行偏差檢查
+func (u *UI) handleDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	report, err := u.handler.DetectDrift()
+	if err != nil {
+		redirect(w, r, "偏差檢查失敗: "+err.Error())
+		return
+	}
+
+	u.mu.Lock()
+	u.lastDrift = report
+	u.mu.Unlock()
+
+	redirect(w, r, "偏差檢查完成")
+}
+
+// handleReconcile 執行對帳
+func (u *UI) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	result, err := u.handler.Reconcile()
+	if err != nil {
+		redirect(w, r, "對帳失敗: "+err.Error())
+		return
+	}
+
+	u.mu.Lock()
+	u.lastDrift = result.Report
+	u.lastResult = result
+	u.mu.Unlock()
+
+	redirect(w, r, "對帳完成")
+}
+
+// redirect 帶著提示訊息返回儀表板首頁
+func redirect(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/ui?msg="+url.QueryEscape(msg), http.StatusSeeOther)
+}
@@ -0,0 +1,255 @@
+// Package audit 將同步活動以結構化事件轉送到 syslog 或 fluentd，讓既有的 SIEM 直接接收，
+// 不需擷取容器的標準輸出
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+)
+
+// 支援的輸出格式
+const (
+	FormatSyslog  = "syslog"  // RFC 5424，訊息內容為 JSON
+	FormatFluentd = "fluentd" // 每行一筆 JSON，供 fluentd 或 fluent-bit 的 in_tcp（json 解析器）接收
+)
+
+// Event 是一筆同步活動，不包含客戶姓名等個人資料
+type Event struct {
+	Time          time.Time `json:"time"`
+	Tenant        string    `json:"tenant,omitempty"`
+	Source        string    `json:"source"`
+	BookingID     string    `json:"booking_id"`
+	Action        string    `json:"action"`
+	EventID       string    `json:"event_id,omitempty"`
+	Success       bool      `json:"success"`
+	ErrorKind     string    `json:"error_kind,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	ChangedFields []string  `json:"changed_fields,omitempty"`
+}
+
+// Options 包含轉送器的設定
+type Options struct {
+	Format  string  // syslog 或 fluentd
+	Network string  // tcp 或 udp，預設 udp（syslog）或 tcp（fluentd）
+	Address string  // 接收端位址，例如 syslog.internal:514
+	Tag     string  // syslog 的 APP-NAME 或 fluentd 的 tag，預設 booking-sync
+	Rate    float64 // 每秒最多轉送的事件數，超過時略過，預設 50
+	Buffer  int     // 等待轉送的事件上限，接收端緩慢或中斷時超過的事件會被略過，預設 1000
+}
+
+// Forwarder 在背景非同步轉送事件，連線中斷時於下一筆事件重新連線
+type Forwarder struct {
+	opts     Options
+	hostname string
+	events   chan *Event
+	done     chan struct{}
+
+	mu      sync.Mutex
+	bucket  float64
+	last    time.Time
+	dropped int  // 上次成功轉送後略過的事件數
+	closed  bool // Close 之後不再接收事件
+
+	conn net.Conn
+}
+
+// New 驗證設定並啟動轉送器；首次連線在轉送第一筆事件時建立
+func New(opts Options) (*Forwarder, error) {
+	switch opts.Format {
+	case FormatSyslog:
+		if opts.Network == "" {
+			opts.Network = "udp"
+		}
+	case FormatFluentd:
+		if opts.Network == "" {
+			opts.Network = "tcp"
+		}
+	default:
+		return nil, fmt.Errorf("不支持的稽核事件格式: %s", opts.Format)
+	}
+	if opts.Network != "tcp" && opts.Network != "udp" {
+		return nil, fmt.Errorf("不支持的稽核事件傳輸方式: %s", opts.Network)
+	}
+	if opts.Address == "" {
+		return nil, fmt.Errorf("未設置稽核事件接收端位址")
+	}
+	if opts.Tag == "" {
+		opts.Tag = "booking-sync"
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = 50
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 1000
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	f := &Forwarder{
+		opts:     opts,
+		hostname: hostname,
+		events:   make(chan *Event, opts.Buffer),
+		done:     make(chan struct{}),
+		bucket:   math.Max(opts.Rate, 1),
+	}
+	go f.run()
+	return f, nil
+}
+
+// Send 將事件放入轉送佇列，不會阻塞；超過速率或佇列已滿時略過事件
+func (f *Forwarder) Send(e *Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	if !f.allow(time.Now()) {
+		f.dropLocked()
+		return
+	}
+
+	select {
+	case f.events <- e:
+	default:
+		f.dropLocked()
+	}
+}
+
+// Close 停止接收事件，送出佇列中剩餘的事件後關閉連線
+func (f *Forwarder) Close() {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.events)
+	}
+	f.mu.Unlock()
+	<-f.done
+}
+
+// allow 以 token bucket 限制轉送速率，呼叫前須持有 f.mu
+func (f *Forwarder) allow(now time.Time) bool {
+	limit := math.Max(f.opts.Rate, 1)
+	if !f.last.IsZero() {
+		f.bucket = math.Min(limit, f.bucket+now.Sub(f.last).Seconds()*f.opts.Rate)
+	}
+	f.last = now
+	if f.bucket < 1 {
+		return false
+	}
+	f.bucket--
+	return true
+}
+
+// dropLocked 記錄略過的事件，呼叫前須持有 f.mu
+func (f *Forwarder) dropLocked() {
+	metrics.AuditEventsDropped.Inc()
+	f.dropped++
+}
+
+// run 依序轉送佇列中的事件，直到 Close
+func (f *Forwarder) run() {
+	defer close(f.done)
+	for e := range f.events {
+		if err := f.write(e); err != nil {
+			log.Printf("轉送稽核事件到 %s 失敗: %v", f.opts.Address, err)
+			f.mu.Lock()
+			f.dropLocked()
+			f.mu.Unlock()
+			continue
+		}
+
+		f.mu.Lock()
+		dropped := f.dropped
+		f.dropped = 0
+		f.mu.Unlock()
+		if dropped > 0 {
+			log.Printf("轉送稽核事件時略過了 %d 筆（超過速率、佇列已滿或傳送失敗）", dropped)
+		}
+	}
+	if f.conn != nil {
+		f.conn.Close()
+	}
+}
+
+// write 編碼並傳送單一事件，失敗時關閉連線讓下一筆事件重新連線
+func (f *Forwarder) write(e *Event) error {
+	payload, err := f.encode(e)
+	if err != nil {
+		return err
+	}
+
+	if f.conn == nil {
+		conn, err := net.DialTimeout(f.opts.Network, f.opts.Address, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		f.conn = conn
+	}
+
+	f.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := f.conn.Write(payload); err != nil {
+		f.conn.Close()
+		f.conn = nil
+		return err
+	}
+	return nil
+}
+
+// encode 依格式編碼事件
+func (f *Forwarder) encode(e *Event) ([]byte, error) {
+	if f.opts.Format == FormatFluentd {
+		line, err := json.Marshal(struct {
+			Tag string `json:"tag"`
+			*Event
+		}{f.opts.Tag, e})
+		if err != nil {
+			return nil, fmt.Errorf("編碼稽核事件失敗: %w", err)
+		}
+		return append(line, '\n'), nil
+	}
+
+	// RFC 5424：facility local0（16），成功為 informational（6），失敗為 warning（4）
+	severity := 6
+	if !e.Success {
+		severity = 4
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("編碼稽核事件失敗: %w", err)
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		16*8+severity, e.Time.UTC().Format(time.RFC3339Nano), f.hostname, syslogField(f.opts.Tag), os.Getpid(), syslogField(e.Action), body)
+
+	// TCP 以 RFC 6587 的長度前綴分隔訊息，UDP 每個封包一則訊息
+	if f.opts.Network == "tcp" {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg)), nil
+	}
+	return []byte(msg), nil
+}
+
+// syslogField 將 syslog 標頭欄位限制為可列印的 ASCII 且不含空白，空值以 - 表示
+func syslogField(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+// Package chaos 在對外請求中注入故障，用於在測試環境驗證重試、暫存補送與死信流程。
+// 僅在以 -tags chaos 建置時生效，避免正式環境誤用
+package chaos
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options 包含故障注入的設定
+type Options struct {
+	CalendarWriteFailRate float64       // 以 503 回應的 Google 日曆寫入比例（0 到 1）
+	SimplyBookDelay       time.Duration // 延遲每個 SimplyBook 請求的時間
+}
+
+// Enabled 判斷是否設置了任何故障注入
+func (o Options) Enabled() bool {
+	return o.CalendarWriteFailRate > 0 || o.SimplyBookDelay > 0
+}
+
+// isCalendarWrite 判斷請求是否為 Google 日曆的寫入
+func isCalendarWrite(req *http.Request) bool {
+	return req.Method != http.MethodGet &&
+		strings.HasSuffix(req.URL.Host, "googleapis.com") &&
+		strings.HasPrefix(req.URL.Path, "/calendar/")
+}
+
+// isSimplyBook 判斷請求是否送往 SimplyBook
+func isSimplyBook(req *http.Request) bool {
+	return strings.Contains(req.URL.Host, "simplybook")
+}
@@ -0,0 +1,64 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Available 表示此建置支援故障注入
+const Available = true
+
+// calendarUnavailableBody 模擬 Google API 的 503 錯誤內容
+const calendarUnavailableBody = `{"error":{"code":503,"message":"故障注入: 模擬 Google 日曆無法使用","errors":[{"reason":"backendError","message":"故障注入"}]}}`
+
+// transport 在轉送請求前依設定注入故障
+type transport struct {
+	next http.RoundTripper
+	opts Options
+}
+
+// Wrap 以故障注入包裝 HTTP 傳輸，未設置任何故障時返回原傳輸
+func Wrap(next http.RoundTripper, opts Options) http.RoundTripper {
+	if !opts.Enabled() {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next: next, opts: opts}
+}
+
+// RoundTrip 實作 http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isCalendarWrite(req) && rand.Float64() < t.opts.CalendarWriteFailRate {
+		log.Printf("故障注入: 丟棄日曆寫入 %s %s", req.Method, req.URL.Path)
+		return &http.Response{
+			Status:     "503 Service Unavailable",
+			StatusCode: http.StatusServiceUnavailable,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(calendarUnavailableBody)),
+			Request:    req,
+		}, nil
+	}
+
+	if isSimplyBook(req) && t.opts.SimplyBookDelay > 0 {
+		timer := time.NewTimer(t.opts.SimplyBookDelay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
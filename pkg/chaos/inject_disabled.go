@@ -0,0 +1,13 @@
+//go:build !chaos
+
+package chaos
+
+import "net/http"
+
+// Available 表示此建置支援故障注入
+const Available = false
+
+// Wrap 在未以 -tags chaos 建置時不注入任何故障
+func Wrap(next http.RoundTripper, opts Options) http.RoundTripper {
+	return next
+}
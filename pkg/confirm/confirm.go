@@ -0,0 +1,100 @@
+package confirm
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/ics"
+	"github.com/booking-sync-455103/booking-sync/pkg/notify"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// DefaultSubject 是預設的確認郵件標題模板
+const DefaultSubject = `預約確認：{{.ServiceName}} {{.StartTime.Format "2006/01/02 15:04"}}`
+
+// DefaultBody 是預設的確認郵件內容模板
+const DefaultBody = `{{.ClientName}} 您好，
+
+您的預約已確認：
+
+服務：{{.ServiceName}}
+服務人員：{{.ProviderName}}
+時間：{{.StartTime.Format "2006/01/02 15:04"}} - {{.EndTime.Format "15:04"}}
+預約編號：{{.Code}}
+
+附件為行事曆檔案（.ics），可直接加入您的行事曆。`
+
+// Sender 在預約建立時寄送附帶 .ics 行事曆檔案的確認郵件給客戶
+type Sender struct {
+	mailer   *notify.SMTPNotifier
+	renderer *render.Renderer
+	subject  *template.Template
+	body     *template.Template
+}
+
+// NewSender 創建新的確認郵件發送器，模板為空時使用預設模板
+func NewSender(mailer *notify.SMTPNotifier, renderer *render.Renderer, subject, body string) (*Sender, error) {
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	if body == "" {
+		body = DefaultBody
+	}
+
+	subjectTmpl, err := template.New("subject").Funcs(renderer.Funcs()).Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("解析確認郵件標題模板失敗: %w", err)
+	}
+
+	bodyTmpl, err := template.New("body").Funcs(renderer.Funcs()).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("解析確認郵件內容模板失敗: %w", err)
+	}
+
+	return &Sender{
+		mailer:   mailer,
+		renderer: renderer,
+		subject:  subjectTmpl,
+		body:     bodyTmpl,
+	}, nil
+}
+
+// Send 寄送確認郵件，客戶沒有電子郵件時略過
+func (s *Sender) Send(booking *simplybook.Booking) error {
+	if booking.Client.Email == "" {
+		return nil
+	}
+
+	data := s.renderer.Data(booking)
+
+	var subject, body bytes.Buffer
+	if err := s.subject.Execute(&subject, data); err != nil {
+		return fmt.Errorf("產生確認郵件標題失敗: %w", err)
+	}
+	if err := s.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("產生確認郵件內容失敗: %w", err)
+	}
+
+	invite := ics.Build(ics.Event{
+		UID:         booking.Code + "@booking-sync",
+		Summary:     subject.String(),
+		Description: body.String(),
+		Start:       booking.StartTime.Time,
+		End:         booking.EndTime.Time,
+	})
+
+	if err := s.mailer.SendWithAttachment(
+		[]string{booking.Client.Email},
+		subject.String(),
+		body.String(),
+		"booking.ics",
+		"text/calendar; charset=UTF-8; method=PUBLISH",
+		invite,
+	); err != nil {
+		return fmt.Errorf("寄送確認郵件失敗: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package dedup
+
+import (
+	"time"
+)
+
+// Deduper 定義 webhook 去重介面
+type Deduper interface {
+	// Seen 標記 key 已處理；若 key 在 ttl 內已出現過則返回 true
+	Seen(key string, ttl time.Duration) (bool, error)
+	// Sweep 清除已過期或未設置存活時間而殘留的去重鍵，返回清除的數量
+	Sweep() (int, error)
+}
@@ -0,0 +1,85 @@
+package dedup
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// MemoryDeduper 是單一實例使用的記憶體去重實作
+type MemoryDeduper struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	expiry  expiryHeap // 依到期時間排序的去重鍵，只需檢查最早到期的項目即可清除過期的鍵
+}
+
+// NewMemoryDeduper 創建新的記憶體去重器
+func NewMemoryDeduper() *MemoryDeduper {
+	return &MemoryDeduper{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// Seen 標記 key 已處理；若 key 在 ttl 內已出現過則返回 true
+func (d *MemoryDeduper) Seen(key string, ttl time.Duration) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+
+	// 順便清除已到期的項目，避免無限增長
+	d.expire(now)
+
+	if _, ok := d.entries[key]; ok {
+		return true, nil
+	}
+
+	expiresAt := now.Add(ttl)
+	d.entries[key] = expiresAt
+	heap.Push(&d.expiry, expiryItem{key: key, expiresAt: expiresAt})
+	return false, nil
+}
+
+// Sweep 清除已過期的去重鍵
+func (d *MemoryDeduper) Sweep() (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.expire(time.Now()), nil
+}
+
+// expire 從最早到期的項目開始清除已過期的去重鍵，返回清除的數量，呼叫前須持有 d.mu
+func (d *MemoryDeduper) expire(now time.Time) int {
+	n := 0
+	for len(d.expiry) > 0 && now.After(d.expiry[0].expiresAt) {
+		item := heap.Pop(&d.expiry).(expiryItem)
+		// 每個鍵在存活期間只會加入一次，到期時間相同即為目前的項目
+		if expiresAt, ok := d.entries[item.key]; ok && expiresAt.Equal(item.expiresAt) {
+			delete(d.entries, item.key)
+			n++
+		}
+	}
+	return n
+}
+
+// expiryItem 是去重鍵與其到期時間
+type expiryItem struct {
+	key       string
+	expiresAt time.Time
+}
+
+// expiryHeap 是依到期時間排序的最小堆積，實作 heap.Interface
+type expiryHeap []expiryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *expiryHeap) Push(x interface{}) { *h = append(*h, x.(expiryItem)) }
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,60 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisDeduper 使用 Redis 在多個實例間共享去重狀態
+type RedisDeduper struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisDeduper 創建新的 Redis 去重器
+func NewRedisDeduper(client *redis.Client, prefix string) *RedisDeduper {
+	return &RedisDeduper{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Seen 標記 key 已處理；若 key 在 ttl 內已出現過則返回 true
+func (d *RedisDeduper) Seen(key string, ttl time.Duration) (bool, error) {
+	created, err := d.client.SetNX(context.Background(), d.prefix+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("寫入 Redis 去重鍵失敗: %w", err)
+	}
+
+	return !created, nil
+}
+
+// Sweep 刪除沒有存活時間的去重鍵（例如手動寫入或過期設定失敗而殘留的鍵），
+// 已設置存活時間的鍵由 Redis 自行過期
+func (d *RedisDeduper) Sweep() (int, error) {
+	ctx := context.Background()
+	n := 0
+	iter := d.client.Scan(ctx, 0, d.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		ttl, err := d.client.TTL(ctx, key).Result()
+		if err != nil {
+			return n, fmt.Errorf("讀取 Redis 去重鍵存活時間失敗: %w", err)
+		}
+		// TTL 返回 -1 表示鍵沒有存活時間
+		if ttl != -1 {
+			continue
+		}
+		if err := d.client.Del(ctx, key).Err(); err != nil {
+			return n, fmt.Errorf("刪除 Redis 去重鍵失敗: %w", err)
+		}
+		n++
+	}
+	if err := iter.Err(); err != nil {
+		return n, fmt.Errorf("掃描 Redis 去重鍵失敗: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,157 @@
+package digest
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/notify"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// Digest 代表每日同步摘要
+type Digest struct {
+	GeneratedAt time.Time
+	Stats       *store.SyncStats
+	DriftCount  int
+	DriftError  string
+	Upcoming    map[string][]simplybook.Booking // 依服務提供者分組的未來 24 小時預約
+}
+
+// Generator 負責產生並發送每日摘要
+type Generator struct {
+	store            store.Store
+	handler          *handler.WebhookHandler
+	simplybookClient *simplybook.Client
+	notifier         notify.Notifier
+	loc              *time.Location
+}
+
+// NewGenerator 創建新的每日摘要產生器，摘要的日期與預約時間以 loc 時區顯示
+func NewGenerator(syncStore store.Store, webhookHandler *handler.WebhookHandler, simplybookClient *simplybook.Client, notifier notify.Notifier, loc *time.Location) *Generator {
+	return &Generator{
+		store:            syncStore,
+		handler:          webhookHandler,
+		simplybookClient: simplybookClient,
+		notifier:         notifier,
+		loc:              loc,
+	}
+}
+
+// Generate 產生截至 now 的每日摘要
+func (g *Generator) Generate(now time.Time) (*Digest, error) {
+	stats, err := g.store.SyncStats(now.Add(-24 * time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("統計同步結果失敗: %w", err)
+	}
+
+	now = now.In(g.loc)
+	d := &Digest{
+		GeneratedAt: now,
+		Stats:       stats,
+		Upcoming:    make(map[string][]simplybook.Booking),
+	}
+
+	// 偏差檢查失敗不影響摘要的其他部分
+	report, err := g.handler.DetectDrift()
+	if err != nil {
+		log.Printf("產生摘要時偏差檢查失敗: %v", err)
+		d.DriftError = err.Error()
+	} else {
+		d.DriftCount = len(report.Drifts)
+	}
+
+	end := now.Add(24 * time.Hour)
+	bookings, err := g.simplybookClient.ListBookings(simplybook.BookingFilter{
+		DateFrom: now,
+		DateTo:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("獲取未來預約失敗: %w", err)
+	}
+
+	for _, b := range bookings {
+		if b.StartTime.Before(now) || !b.StartTime.Before(end) {
+			continue
+		}
+		provider := b.ProviderName
+		if provider == "" {
+			provider = "未指定"
+		}
+		d.Upcoming[provider] = append(d.Upcoming[provider], b)
+	}
+
+	for provider := range d.Upcoming {
+		list := d.Upcoming[provider]
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].StartTime.Before(list[j].StartTime.Time)
+		})
+	}
+
+	return d, nil
+}
+
+// Subject 返回摘要通知的標題
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("預約同步每日摘要 %s", d.GeneratedAt.Format("2006-01-02"))
+}
+
+// Body 返回摘要通知的純文字內容
+func (d *Digest) Body() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "過去 24 小時同步成功: %d 筆\n", d.Stats.Succeeded)
+	fmt.Fprintf(&b, "過去 24 小時同步失敗: %d 筆\n", d.Stats.Failed)
+	if d.DriftError != "" {
+		fmt.Fprintf(&b, "偏差檢查失敗: %s\n", d.DriftError)
+	} else {
+		fmt.Fprintf(&b, "日曆偏差: %d 筆\n", d.DriftCount)
+	}
+
+	b.WriteString("\n未來 24 小時預約:\n")
+	if len(d.Upcoming) == 0 {
+		b.WriteString("（無）\n")
+		return b.String()
+	}
+
+	providers := make([]string, 0, len(d.Upcoming))
+	for provider := range d.Upcoming {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	for _, provider := range providers {
+		fmt.Fprintf(&b, "\n%s（%d 筆）\n", provider, len(d.Upcoming[provider]))
+		for _, booking := range d.Upcoming[provider] {
+			fmt.Fprintf(&b, "  %s-%s %s %s [%s]\n",
+				booking.StartTime.In(d.GeneratedAt.Location()).Format("01/02 15:04"),
+				booking.EndTime.In(d.GeneratedAt.Location()).Format("15:04"),
+				booking.Client.Name,
+				booking.ServiceName,
+				booking.Code,
+			)
+		}
+	}
+
+	return b.String()
+}
+
+// Send 產生並發送每日摘要
+func (g *Generator) Send() error {
+	d, err := g.Generate(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := g.notifier.Notify(d.Subject(), d.Body()); err != nil {
+		return err
+	}
+
+	log.Printf("已發送每日摘要: 成功 %d 筆，失敗 %d 筆，偏差 %d 筆",
+		d.Stats.Succeeded, d.Stats.Failed, d.DriftCount)
+	return nil
+}
@@ -0,0 +1,138 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// envelopeVersion 加密格式版本
+const envelopeVersion = "aes-gcm-v1"
+
+// envelope 是加密後的 JSON 格式，kid 標示加密所用的金鑰以支援金鑰輪替
+type envelope struct {
+	Version string `json:"enc"`
+	KeyID   string `json:"kid"`
+	Data    string `json:"data"` // base64(nonce || ciphertext)
+}
+
+// Keyring 以 AES-GCM 加解密資料，第一把金鑰用於加密，其餘金鑰僅用於解密舊資料
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseKeys 解析 "id:base64金鑰,id:base64金鑰" 格式的金鑰列表，
+// 金鑰需為 16、24 或 32 位元組（AES-128/192/256）
+func ParseKeys(spec string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(item, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("金鑰格式應為 id:base64金鑰")
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("金鑰 ID 重複: %s", id)
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("金鑰 %s 不是有效的 base64: %w", id, err)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("金鑰 %s 無效: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("金鑰 %s 無效: %w", id, err)
+		}
+
+		if k.primary == "" {
+			k.primary = id
+		}
+		k.keys[id] = aead
+	}
+
+	if k.primary == "" {
+		return nil, errors.New("未提供任何金鑰")
+	}
+	return k, nil
+}
+
+// PrimaryKeyID 返回用於加密的金鑰 ID
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Seal 以主要金鑰加密資料，返回 JSON 格式的密文
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	aead := k.keys[k.primary]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("產生 nonce 失敗: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(k.primary))
+	return json.Marshal(envelope{
+		Version: envelopeVersion,
+		KeyID:   k.primary,
+		Data:    base64.StdEncoding.EncodeToString(sealed),
+	})
+}
+
+// Open 解密 Seal 產生的密文；非加密格式的資料（啟用加密前寫入）原樣返回
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return data, nil
+	}
+
+	aead, ok := k.keys[env.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("找不到金鑰 %s，輪替時請保留舊金鑰直到舊資料清除", env.KeyID)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("密文格式錯誤: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("密文長度不足")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("解密失敗: %w", err)
+	}
+	return plaintext, nil
+}
+
+// IsSealed 判斷資料是否為加密格式
+func IsSealed(data []byte) bool {
+	_, ok := parseEnvelope(data)
+	return ok
+}
+
+// parseEnvelope 解析加密格式，非加密資料返回 false
+func parseEnvelope(data []byte) (envelope, bool) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Version != envelopeVersion {
+		return envelope{}, false
+	}
+	return env, true
+}
@@ -0,0 +1,85 @@
+// Package errreport 將同步失敗回報到 Sentry 或 Google Error Reporting，
+// 以預約、租戶與同步階段標記，不需再從日誌搜尋錯誤
+package errreport
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Report 是一筆同步失敗，不包含客戶姓名等個人資料
+type Report struct {
+	Time      time.Time
+	Err       error
+	BookingID string
+	Tenant    string
+	Source    string // 預約平台
+	Stage     string // 失敗的同步階段
+	Kind      string // 錯誤分類（auth、rate_limit、not_found、validation、unavailable、conflict 或 other）
+}
+
+// Sink 是錯誤回報的目的地
+type Sink interface {
+	Name() string
+	Send(r *Report) error
+}
+
+// Reporter 在背景將錯誤送往各個 Sink，不會延遲同步
+type Reporter struct {
+	sinks   []Sink
+	reports chan *Report
+	done    chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New 創建錯誤回報器，佇列已滿時（例如大量失敗）略過新的錯誤
+func New(sinks ...Sink) *Reporter {
+	r := &Reporter{
+		sinks:   sinks,
+		reports: make(chan *Report, 100),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Report 將錯誤放入回報佇列，不會阻塞
+func (r *Reporter) Report(report *Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	select {
+	case r.reports <- report:
+	default:
+		log.Printf("錯誤回報佇列已滿，略過預約 %s 的錯誤", report.BookingID)
+	}
+}
+
+// Close 停止接收錯誤，送出佇列中剩餘的錯誤
+func (r *Reporter) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.reports)
+	}
+	r.mu.Unlock()
+	<-r.done
+}
+
+// run 依序將錯誤送往各個 Sink，送出失敗僅記錄日誌
+func (r *Reporter) run() {
+	defer close(r.done)
+	for report := range r.reports {
+		for _, sink := range r.sinks {
+			if err := sink.Send(report); err != nil {
+				log.Printf("回報錯誤到 %s 失敗: %v", sink.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/version"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/clouderrorreporting/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// Google 將錯誤送到 Google Error Reporting，服務帳號須有 Error Reporting Writer 角色
+type Google struct {
+	service   *clouderrorreporting.Service
+	projectID string
+}
+
+// NewGoogle 以服務帳號創建 Google Error Reporting 回報目的地，projectID 為空時使用金鑰所屬的專案
+func NewGoogle(credentialsJSON []byte, projectID string, httpClient *http.Client) (*Google, error) {
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, credentialsJSON, clouderrorreporting.CloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析服務帳號金鑰: %w", err)
+	}
+	if projectID == "" {
+		projectID = creds.ProjectID
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("未設置 Error Reporting 專案，且服務帳號金鑰中沒有 project_id")
+	}
+
+	service, err := clouderrorreporting.NewService(ctx, option.WithHTTPClient(oauth2.NewClient(ctx, creds.TokenSource)))
+	if err != nil {
+		return nil, fmt.Errorf("無法創建 Error Reporting 服務: %w", err)
+	}
+
+	return &Google{service: service, projectID: projectID}, nil
+}
+
+// Name 返回回報目的地名稱
+func (g *Google) Name() string {
+	return "Google Error Reporting"
+}
+
+// Send 回報錯誤。Error Reporting 不支援標籤，預約、租戶與階段附加於訊息中；
+// 沒有堆疊時以同步階段作為回報位置，相同階段的錯誤歸為同一組
+func (g *Google) Send(r *Report) error {
+	event := &clouderrorreporting.ReportedErrorEvent{
+		EventTime: r.Time.UTC().Format(time.RFC3339Nano),
+		Message: fmt.Sprintf("%v\nstage: %s\nerror_kind: %s\ntenant: %s\nsource: %s\nbooking_id: %s",
+			r.Err, r.Stage, r.Kind, r.Tenant, r.Source, r.BookingID),
+		ServiceContext: &clouderrorreporting.ServiceContext{
+			Service: "booking-sync",
+			Version: version.Version,
+		},
+		Context: &clouderrorreporting.ErrorContext{
+			ReportLocation: &clouderrorreporting.SourceLocation{FunctionName: "stage/" + r.Stage},
+		},
+	}
+
+	if _, err := g.service.Projects.Events.Report("projects/"+g.projectID, event).Do(); err != nil {
+		return fmt.Errorf("回報錯誤失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package errreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/version"
+)
+
+// Sentry 以 envelope API 將錯誤送到 Sentry，不依賴 Sentry SDK
+type Sentry struct {
+	endpoint    string
+	dsn         string
+	publicKey   string
+	environment string
+	hostname    string
+	httpClient  *http.Client
+}
+
+// NewSentry 解析 DSN（https://<key>@<host>/<project>）並創建 Sentry 回報目的地
+func NewSentry(dsn, environment string, httpClient *http.Client) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析 Sentry DSN: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("Sentry DSN 缺少公開金鑰")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if slash < 0 || projectID == "" {
+		return nil, fmt.Errorf("Sentry DSN 缺少專案 ID")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	hostname, _ := os.Hostname()
+	return &Sentry{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:slash], projectID),
+		dsn:         dsn,
+		publicKey:   u.User.Username(),
+		environment: environment,
+		hostname:    hostname,
+		httpClient:  httpClient,
+	}, nil
+}
+
+// Name 返回回報目的地名稱
+func (s *Sentry) Name() string {
+	return "Sentry"
+}
+
+// Send 將錯誤以單一事件的 envelope 送出；相同階段與錯誤分類的錯誤歸為同一個 issue
+func (s *Sentry) Send(r *Report) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("產生事件 ID 失敗: %w", err)
+	}
+	eventID := hex.EncodeToString(id)
+
+	event := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   r.Time.UTC().Format(time.RFC3339Nano),
+		"level":       "error",
+		"platform":    "go",
+		"logger":      "booking-sync",
+		"release":     version.Version,
+		"environment": s.environment,
+		"server_name": s.hostname,
+		"exception": map[string]interface{}{
+			"values": []map[string]string{{"type": r.Stage + "/" + r.Kind, "value": r.Err.Error()}},
+		},
+		"tags": map[string]string{
+			"booking_id": r.BookingID,
+			"tenant":     r.Tenant,
+			"source":     r.Source,
+			"stage":      r.Stage,
+			"error_kind": r.Kind,
+		},
+		"fingerprint": []string{r.Stage, r.Kind},
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, item := range []interface{}{
+		map[string]string{"event_id": eventID, "dsn": s.dsn, "sent_at": time.Now().UTC().Format(time.RFC3339Nano)},
+		map[string]string{"type": "event"},
+		event,
+	} {
+		if err := enc.Encode(item); err != nil {
+			return fmt.Errorf("編碼 Sentry 事件失敗: %w", err)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("創建請求失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", version.UserAgent(), s.publicKey))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("發送請求失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("發送 Sentry 事件失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
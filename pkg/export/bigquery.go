@@ -0,0 +1,152 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+// bookingEventSchema 是預約事件資料表的結構，以 event_time 按日分區
+var bookingEventSchema = &bigquery.TableSchema{
+	Fields: []*bigquery.TableFieldSchema{
+		{Name: "booking_id", Type: "STRING", Mode: "REQUIRED"},
+		{Name: "booking_code", Type: "STRING"},
+		{Name: "event", Type: "STRING", Mode: "REQUIRED", Description: "created、updated 或 cancelled"},
+		{Name: "service_id", Type: "INTEGER"},
+		{Name: "service", Type: "STRING"},
+		{Name: "provider_id", Type: "INTEGER"},
+		{Name: "provider", Type: "STRING"},
+		{Name: "start_time", Type: "TIMESTAMP"},
+		{Name: "end_time", Type: "TIMESTAMP"},
+		{Name: "event_time", Type: "TIMESTAMP", Mode: "REQUIRED"},
+	},
+}
+
+// BigQuerySink 將預約事件以串流方式寫入 BigQuery，供分析使用率與未到率，
+// 不包含客戶姓名等個人資料
+type BigQuerySink struct {
+	service   *bigquery.Service
+	projectID string
+	datasetID string
+	tableID   string
+}
+
+// NewBigQuerySink 創建 BigQuery 輸出，projectID 為空時使用服務帳號所屬的專案
+func NewBigQuerySink(credentialsJSON []byte, projectID, datasetID, tableID string, httpClient *http.Client) (*BigQuerySink, error) {
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, credentialsJSON, bigquery.BigqueryInsertdataScope, bigquery.BigqueryScope)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析服務帳號金鑰: %w", err)
+	}
+	if projectID == "" {
+		projectID = creds.ProjectID
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("未設置 BigQuery 專案，且服務帳號金鑰中沒有 project_id")
+	}
+
+	service, err := bigquery.NewService(ctx, option.WithHTTPClient(oauth2.NewClient(ctx, creds.TokenSource)))
+	if err != nil {
+		return nil, fmt.Errorf("無法創建 BigQuery 服務: %w", err)
+	}
+
+	return &BigQuerySink{
+		service:   service,
+		projectID: projectID,
+		datasetID: datasetID,
+		tableID:   tableID,
+	}, nil
+}
+
+// Table 返回完整的資料表名稱
+func (s *BigQuerySink) Table() string {
+	return fmt.Sprintf("%s.%s.%s", s.projectID, s.datasetID, s.tableID)
+}
+
+// EnsureTable 資料表不存在時依預設結構建立，資料集須已存在
+func (s *BigQuerySink) EnsureTable() (bool, error) {
+	_, err := s.service.Tables.Get(s.projectID, s.datasetID, s.tableID).Do()
+	if err == nil {
+		return false, nil
+	}
+	if !isStatus(err, http.StatusNotFound) {
+		return false, fmt.Errorf("讀取資料表 %s 失敗: %w", s.Table(), err)
+	}
+
+	_, err = s.service.Tables.Insert(s.projectID, s.datasetID, &bigquery.Table{
+		TableReference: &bigquery.TableReference{
+			ProjectId: s.projectID,
+			DatasetId: s.datasetID,
+			TableId:   s.tableID,
+		},
+		Schema: bookingEventSchema,
+		TimePartitioning: &bigquery.TimePartitioning{
+			Type:  "DAY",
+			Field: "event_time",
+		},
+	}).Do()
+	if err != nil {
+		if isStatus(err, http.StatusConflict) {
+			// 其他實例已建立
+			return false, nil
+		}
+		return false, fmt.Errorf("建立資料表 %s 失敗: %w", s.Table(), err)
+	}
+	return true, nil
+}
+
+// Append 寫入一筆預約事件，以預約、事件與時間作為去重 ID，重試時不會重複寫入
+func (s *BigQuerySink) Append(row *ReportRow) error {
+	event := map[string]bigquery.JsonValue{
+		"booking_id":   row.BookingID,
+		"booking_code": row.Code,
+		"event":        row.Status,
+		"service_id":   row.ServiceID,
+		"service":      row.Service,
+		"provider_id":  row.ProviderID,
+		"provider":     row.Provider,
+		"start_time":   timestampValue(row.Start),
+		"end_time":     timestampValue(row.End),
+		"event_time":   timestampValue(row.SyncedAt),
+	}
+
+	resp, err := s.service.Tabledata.InsertAll(s.projectID, s.datasetID, s.tableID, &bigquery.TableDataInsertAllRequest{
+		Rows: []*bigquery.TableDataInsertAllRequestRows{{
+			InsertId: fmt.Sprintf("%s:%s:%d", row.BookingID, row.Status, row.SyncedAt.UnixNano()),
+			Json:     event,
+		}},
+	}).Do()
+	if err != nil {
+		return fmt.Errorf("寫入 BigQuery 失敗: %w", err)
+	}
+
+	if len(resp.InsertErrors) > 0 {
+		var msgs []string
+		for _, insertErr := range resp.InsertErrors {
+			for _, e := range insertErr.Errors {
+				msgs = append(msgs, e.Message)
+			}
+		}
+		return fmt.Errorf("寫入 BigQuery 失敗: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// timestampValue 將時間轉為 BigQuery TIMESTAMP，零值寫入 NULL
+func timestampValue(t time.Time) bigquery.JsonValue {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
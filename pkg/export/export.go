@@ -0,0 +1,90 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// Dump 是匯出的完整資料
+type Dump struct {
+	ExportedAt time.Time           `json:"exported_at"`
+	Mappings   []*store.Mapping    `json:"mappings"`
+	History    []*store.SyncRecord `json:"history"`
+}
+
+// Load 從儲存讀取所有對應關係與同步記錄
+func Load(syncStore store.Store) (*Dump, error) {
+	mappings, err := syncStore.ListMappings()
+	if err != nil {
+		return nil, fmt.Errorf("讀取對應關係失敗: %w", err)
+	}
+
+	history, err := syncStore.ListSyncRecords(0, false)
+	if err != nil {
+		return nil, fmt.Errorf("讀取同步記錄失敗: %w", err)
+	}
+
+	return &Dump{
+		ExportedAt: time.Now(),
+		Mappings:   mappings,
+		History:    history,
+	}, nil
+}
+
+// WriteJSON 以 JSON 格式輸出完整資料
+func WriteJSON(w io.Writer, dump *Dump) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dump)
+}
+
+// WriteMappingsCSV 以 CSV 格式輸出對應關係
+func WriteMappingsCSV(w io.Writer, mappings []*store.Mapping) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"booking_id", "booking_code", "event_id", "calendar_id", "created_at", "updated_at"})
+	for _, m := range mappings {
+		cw.Write([]string{
+			m.BookingID,
+			m.BookingCode,
+			m.EventID,
+			m.CalendarID,
+			m.CreatedAt.Format(time.RFC3339),
+			m.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteHistoryCSV 以 CSV 格式輸出同步記錄
+func WriteHistoryCSV(w io.Writer, records []*store.SyncRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "booking_id", "action", "event_id", "success", "error", "changes", "created_at"})
+	for _, r := range records {
+		var changes []byte
+		if len(r.Changes) > 0 {
+			changes, _ = json.Marshal(r.Changes)
+		}
+
+		cw.Write([]string{
+			strconv.FormatInt(r.ID, 10),
+			r.BookingID,
+			r.Action,
+			r.EventID,
+			strconv.FormatBool(r.Success),
+			r.Error,
+			string(changes),
+			r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,276 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+	"google.golang.org/api/storage/v1"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// ReportHeader 是同步報表的欄位名稱
+var ReportHeader = []string{"booking_code", "client", "service", "provider", "start", "end", "status", "synced_at"}
+
+// ReportRow 是同步報表中的一筆預約
+type ReportRow struct {
+	BookingID  string
+	ServiceID  int
+	ProviderID int
+
+	Code     string
+	Client   string
+	Service  string
+	Provider string
+	Start    time.Time
+	End      time.Time
+	Status   string // created、updated 或 cancelled
+	SyncedAt time.Time
+}
+
+// reportStatus 將同步操作對應到報表中的狀態
+var reportStatus = map[string]string{
+	"create": "created",
+	"change": "updated",
+	"cancel": "cancelled",
+}
+
+// NewReportRow 由同步成功的預約建立報表列
+func NewReportRow(action string, booking *simplybook.Booking) *ReportRow {
+	status, ok := reportStatus[action]
+	if !ok {
+		status = action
+	}
+
+	return &ReportRow{
+		BookingID:  booking.Key(),
+		ServiceID:  booking.ServiceID,
+		ProviderID: booking.ProviderID,
+
+		Code:     booking.Code,
+		Client:   booking.Client.Name,
+		Service:  booking.ServiceName,
+		Provider: booking.ProviderName,
+		Start:    booking.StartTime.Time,
+		End:      booking.EndTime.Time,
+		Status:   status,
+		SyncedAt: time.Now(),
+	}
+}
+
+// Values 返回報表列的欄位值，順序與 ReportHeader 相同
+func (r *ReportRow) Values() []string {
+	return []string{
+		r.Code,
+		r.Client,
+		r.Service,
+		r.Provider,
+		r.Start.Format(time.RFC3339),
+		r.End.Format(time.RFC3339),
+		r.Status,
+		r.SyncedAt.Format(time.RFC3339),
+	}
+}
+
+// ReportSink 定義同步報表的輸出目的地
+type ReportSink interface {
+	Append(row *ReportRow) error
+}
+
+// MultiSink 將報表列寫入多個目的地
+type MultiSink []ReportSink
+
+// Append 依序寫入所有目的地，返回第一個錯誤
+func (m MultiSink) Append(row *ReportRow) error {
+	var firstErr error
+	for _, sink := range m {
+		if err := sink.Append(row); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SheetsSink 將報表列附加到 Google 試算表，服務帳號須有該試算表的編輯權限
+type SheetsSink struct {
+	service       *sheets.Service
+	spreadsheetID string
+	sheetRange    string
+}
+
+// NewSheetsSink 創建 Google 試算表輸出
+func NewSheetsSink(credentialsJSON []byte, spreadsheetID, sheetRange string, httpClient *http.Client) (*SheetsSink, error) {
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
+	config, err := google.JWTConfigFromJSON(credentialsJSON, sheets.SpreadsheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析服務帳號金鑰: %w", err)
+	}
+
+	service, err := sheets.NewService(ctx, option.WithHTTPClient(config.Client(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("無法創建 Google 試算表服務: %w", err)
+	}
+
+	return &SheetsSink{
+		service:       service,
+		spreadsheetID: spreadsheetID,
+		sheetRange:    sheetRange,
+	}, nil
+}
+
+// Append 在工作表最後附加一列
+func (s *SheetsSink) Append(row *ReportRow) error {
+	values := row.Values()
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = v
+	}
+
+	_, err := s.service.Spreadsheets.Values.Append(s.spreadsheetID, s.sheetRange, &sheets.ValueRange{
+		Values: [][]interface{}{cells},
+	}).ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Do()
+	if err != nil {
+		return fmt.Errorf("附加試算表資料失敗: %w", err)
+	}
+	return nil
+}
+
+// gcsAppendAttempts 並行寫入同一物件時的重試次數
+const gcsAppendAttempts = 5
+
+// GCSSink 將報表列寫入 GCS 上的每日 CSV（例如 bookings/2024-05-01.csv），
+// 物件以 generation 前置條件改寫，多個實例同時寫入時不會遺失資料
+type GCSSink struct {
+	service  *storage.Service
+	bucket   string
+	prefix   string
+	location *time.Location
+
+	mu sync.Mutex
+}
+
+// NewGCSSink 創建 GCS 每日 CSV 輸出，location 決定每日檔案的切分時區
+func NewGCSSink(credentialsJSON []byte, bucket, prefix string, location *time.Location, httpClient *http.Client) (*GCSSink, error) {
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
+	config, err := google.JWTConfigFromJSON(credentialsJSON, storage.DevstorageReadWriteScope)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析服務帳號金鑰: %w", err)
+	}
+
+	service, err := storage.NewService(ctx, option.WithHTTPClient(config.Client(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("無法創建 Cloud Storage 服務: %w", err)
+	}
+
+	if location == nil {
+		location = time.Local
+	}
+
+	return &GCSSink{
+		service:  service,
+		bucket:   bucket,
+		prefix:   prefix,
+		location: location,
+	}, nil
+}
+
+// objectName 返回同步時間所屬日期的 CSV 物件名稱
+func (s *GCSSink) objectName(syncedAt time.Time) string {
+	return path.Join(s.prefix, syncedAt.In(s.location).Format("2006-01-02")+".csv")
+}
+
+// Append 讀取當日的 CSV 並附加一列後寫回，物件不存在時建立並寫入標題列
+func (s *GCSSink) Append(row *ReportRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objectName := s.objectName(row.SyncedAt)
+
+	var line bytes.Buffer
+	cw := csv.NewWriter(&line)
+	cw.Write(row.Values())
+	cw.Flush()
+
+	for attempt := 0; attempt < gcsAppendAttempts; attempt++ {
+		content, generation, err := s.read(objectName)
+		if err != nil {
+			return err
+		}
+		if generation == 0 {
+			var header bytes.Buffer
+			hw := csv.NewWriter(&header)
+			hw.Write(ReportHeader)
+			hw.Flush()
+			content = header.Bytes()
+		}
+		content = append(content, line.Bytes()...)
+
+		_, err = s.service.Objects.Insert(s.bucket, &storage.Object{
+			Name:        objectName,
+			ContentType: "text/csv; charset=utf-8",
+		}).IfGenerationMatch(generation).Media(bytes.NewReader(content)).Do()
+		if err == nil {
+			return nil
+		}
+		if !isStatus(err, http.StatusPreconditionFailed) {
+			return fmt.Errorf("寫入報表 %s 失敗: %w", objectName, err)
+		}
+		// 其他實例已改寫物件，重新讀取後再試
+	}
+
+	return fmt.Errorf("寫入報表 %s 失敗: 重試 %d 次後仍發生衝突", objectName, gcsAppendAttempts)
+}
+
+// read 返回物件內容與 generation，物件不存在時 generation 為 0
+func (s *GCSSink) read(objectName string) ([]byte, int64, error) {
+	obj, err := s.service.Objects.Get(s.bucket, objectName).Do()
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("讀取報表 %s 失敗: %w", objectName, err)
+	}
+
+	resp, err := s.service.Objects.Get(s.bucket, objectName).IfGenerationMatch(obj.Generation).Download()
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) || isStatus(err, http.StatusPreconditionFailed) {
+			// 讀取期間物件被改寫，以舊的 generation 寫入時會觸發重試
+			return nil, obj.Generation, nil
+		}
+		return nil, 0, fmt.Errorf("下載報表 %s 失敗: %w", objectName, err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("下載報表 %s 失敗: %w", objectName, err)
+	}
+	return content, obj.Generation, nil
+}
+
+// isStatus 判斷錯誤是否為指定的 HTTP 狀態碼
+func isStatus(err error, code int) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
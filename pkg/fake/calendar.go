@@ -0,0 +1,287 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Calendar 模擬 Google Calendar API v3 的事件端點，已刪除的事件保留為 cancelled 狀態
+type Calendar struct {
+	mu     sync.Mutex
+	events map[string]map[string]*calendar.Event // 日曆 ID → 事件 ID → 事件
+	nextID int
+}
+
+// NewCalendar 創建空白的模擬日曆
+func NewCalendar() *Calendar {
+	return &Calendar{events: make(map[string]map[string]*calendar.Event)}
+}
+
+// Events 依開始時間返回日曆中未刪除的事件
+func (c *Calendar) Events(calendarID string) []*calendar.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var list []*calendar.Event
+	for _, e := range c.events[calendarID] {
+		if e.Status != "cancelled" {
+			copied := *e
+			list = append(list, &copied)
+		}
+	}
+	sortByStart(list)
+	return list
+}
+
+// Calendars 返回有事件的日曆 ID
+func (c *Calendar) Calendars() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.events))
+	for id := range c.events {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ServeHTTP 處理 /calendar/v3/calendars/{calendarId}[/events[/{eventId}[/move]]] 請求
+func (c *Calendar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/calendar/v3/calendars/"), "/")
+	for i, part := range parts {
+		parts[i], _ = url.PathUnescape(part)
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, &calendar.Calendar{Id: parts[0], Summary: parts[0], TimeZone: "Asia/Taipei"})
+	case len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodGet:
+		c.list(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodPost:
+		c.insert(w, r, parts[0])
+	case len(parts) == 3 && parts[1] == "events":
+		c.serveEvent(w, r, parts[0], parts[2])
+	case len(parts) == 4 && parts[1] == "events" && parts[3] == "move" && r.Method == http.MethodPost:
+		c.move(w, r, parts[0], parts[2])
+	default:
+		writeGoogleError(w, http.StatusNotFound, "notFound", "Not Found")
+	}
+}
+
+// insert 創建事件
+func (c *Calendar) insert(w http.ResponseWriter, r *http.Request, calendarID string) {
+	var event calendar.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeGoogleError(w, http.StatusBadRequest, "invalid", "無效的事件")
+		return
+	}
+	if err := normalizeTimes(&event); err != nil {
+		writeGoogleError(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	event.Id = fmt.Sprintf("fake%06d", c.nextID)
+	c.store(calendarID, &event)
+	writeJSON(w, http.StatusOK, &event)
+}
+
+// serveEvent 處理單一事件的讀取、更新與刪除
+func (c *Calendar) serveEvent(w http.ResponseWriter, r *http.Request, calendarID, eventID string) {
+	var update calendar.Event
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeGoogleError(w, http.StatusBadRequest, "invalid", "無效的事件")
+			return
+		}
+		if err := normalizeTimes(&update); err != nil {
+			writeGoogleError(w, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	event, ok := c.events[calendarID][eventID]
+	if !ok {
+		writeGoogleError(w, http.StatusNotFound, "notFound", "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, event)
+	case http.MethodPut:
+		update.Id = eventID
+		c.store(calendarID, &update)
+		writeJSON(w, http.StatusOK, &update)
+	case http.MethodDelete:
+		if event.Status == "cancelled" {
+			writeGoogleError(w, http.StatusGone, "deleted", "Resource has been deleted")
+			return
+		}
+		event.Status = "cancelled"
+		event.Updated = time.Now().UTC().Format(time.RFC3339Nano)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeGoogleError(w, http.StatusMethodNotAllowed, "methodNotAllowed", "Method Not Allowed")
+	}
+}
+
+// move 將事件移到 destination 日曆
+func (c *Calendar) move(w http.ResponseWriter, r *http.Request, calendarID, eventID string) {
+	destination := r.URL.Query().Get("destination")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	event, ok := c.events[calendarID][eventID]
+	if !ok || event.Status == "cancelled" || destination == "" {
+		writeGoogleError(w, http.StatusNotFound, "notFound", "Not Found")
+		return
+	}
+	delete(c.events[calendarID], eventID)
+	c.store(destination, event)
+	writeJSON(w, http.StatusOK, event)
+}
+
+// store 寫入事件並更新修改時間與網址，呼叫前須持有 c.mu
+func (c *Calendar) store(calendarID string, event *calendar.Event) {
+	if event.Status == "" {
+		event.Status = "confirmed"
+	}
+	event.Updated = time.Now().UTC().Format(time.RFC3339Nano)
+	event.HtmlLink = "https://calendar.google.com/calendar/event?eid=" + url.QueryEscape(event.Id)
+	if c.events[calendarID] == nil {
+		c.events[calendarID] = make(map[string]*calendar.Event)
+	}
+	c.events[calendarID][event.Id] = event
+}
+
+// list 依 timeMin、timeMax、privateExtendedProperty、q 與 showDeleted 篩選事件並分頁輸出；
+// 同步令牌固定失效，呼叫端會改為完整列出
+func (c *Calendar) list(w http.ResponseWriter, r *http.Request, calendarID string) {
+	query := r.URL.Query()
+	if query.Get("syncToken") != "" {
+		writeGoogleError(w, http.StatusGone, "fullSyncRequired", "Sync token is no longer valid")
+		return
+	}
+	timeMin, _ := time.Parse(time.RFC3339, query.Get("timeMin"))
+	timeMax, _ := time.Parse(time.RFC3339, query.Get("timeMax"))
+	showDeleted := query.Get("showDeleted") == "true"
+	offset, _ := strconv.Atoi(query.Get("pageToken"))
+	pageSize, _ := strconv.Atoi(query.Get("maxResults"))
+	if pageSize <= 0 {
+		pageSize = 250
+	}
+
+	c.mu.Lock()
+	var matched []*calendar.Event
+	for _, e := range c.events[calendarID] {
+		if e.Status == "cancelled" && !showDeleted {
+			continue
+		}
+		start, _ := time.Parse(time.RFC3339, e.Start.DateTime)
+		end, _ := time.Parse(time.RFC3339, e.End.DateTime)
+		if !timeMin.IsZero() && !end.After(timeMin) || !timeMax.IsZero() && !start.Before(timeMax) {
+			continue
+		}
+		if !matchProperties(e, query["privateExtendedProperty"]) {
+			continue
+		}
+		if q := query.Get("q"); q != "" && !strings.Contains(e.Summary, q) && !strings.Contains(e.Description, q) {
+			continue
+		}
+		copied := *e
+		matched = append(matched, &copied)
+	}
+	c.mu.Unlock()
+	sortByStart(matched)
+
+	result := &calendar.Events{Items: []*calendar.Event{}}
+	if offset < len(matched) {
+		end := offset + pageSize
+		if end < len(matched) {
+			result.NextPageToken = strconv.Itoa(end)
+		} else {
+			end = len(matched)
+			result.NextSyncToken = "fake-sync-token"
+		}
+		result.Items = matched[offset:end]
+	} else {
+		result.NextSyncToken = "fake-sync-token"
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// matchProperties 判斷事件是否帶有所有 key=value 形式的私有擴充屬性
+func matchProperties(e *calendar.Event, filters []string) bool {
+	for _, filter := range filters {
+		key, value, _ := strings.Cut(filter, "=")
+		if e.ExtendedProperties == nil || e.ExtendedProperties.Private[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTimes 將不帶時區的開始與結束時間依事件時區轉為 RFC 3339，與 Google 回傳的格式一致
+func normalizeTimes(event *calendar.Event) error {
+	for _, t := range []*calendar.EventDateTime{event.Start, event.End} {
+		if t == nil {
+			return fmt.Errorf("缺少開始或結束時間")
+		}
+		if _, err := time.Parse(time.RFC3339, t.DateTime); err == nil {
+			continue
+		}
+		loc := time.UTC
+		if t.TimeZone != "" {
+			var err error
+			if loc, err = time.LoadLocation(t.TimeZone); err != nil {
+				return fmt.Errorf("無效的時區: %s", t.TimeZone)
+			}
+		}
+		parsed, err := time.ParseInLocation("2006-01-02T15:04:05", t.DateTime, loc)
+		if err != nil {
+			return fmt.Errorf("無效的時間: %s", t.DateTime)
+		}
+		t.DateTime = parsed.Format(time.RFC3339)
+	}
+	return nil
+}
+
+// sortByStart 依開始時間與 ID 排序事件
+func sortByStart(events []*calendar.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i].Start.DateTime, events[j].Start.DateTime
+		if a != b {
+			return a < b
+		}
+		return events[i].Id < events[j].Id
+	})
+}
+
+// writeGoogleError 以 Google API 的錯誤格式輸出錯誤
+func writeGoogleError(w http.ResponseWriter, status int, reason, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+			"errors":  []map[string]string{{"reason": reason, "message": message}},
+		},
+	})
+}
@@ -0,0 +1,124 @@
+// Package fake 提供在記憶體中模擬 SimplyBook 與 Google 日曆 API 的 HTTP 伺服器，
+// 基準測試與本機開發不需任何憑證即可執行完整的同步流程
+package fake
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Server 在同一個本機 HTTP 伺服器上模擬 SimplyBook、Google 日曆與 OAuth2 令牌端點
+type Server struct {
+	SimplyBook *SimplyBook
+	Calendar   *Calendar
+	Latency    time.Duration // 每個請求的模擬延遲，須在發出請求前設置
+
+	server *httptest.Server
+}
+
+// NewServer 啟動模擬伺服器
+func NewServer() *Server {
+	s := &Server{
+		SimplyBook: NewSimplyBook(),
+		Calendar:   NewCalendar(),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL 返回模擬伺服器的位址
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Close 關閉模擬伺服器
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client 返回將所有請求導向模擬伺服器的 HTTP 客戶端，
+// SimplyBook 與 Google 客戶端使用原本的網址即可連到模擬伺服器
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.server.URL)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 100
+	return &http.Client{
+		Transport: &rewriteTransport{target: target, base: transport},
+		Timeout:   30 * time.Second,
+	}
+}
+
+// rewriteTransport 將請求的主機改寫為模擬伺服器
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+// RoundTrip 改寫請求網址後送出
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = ""
+	return t.base.RoundTrip(r)
+}
+
+// Credentials 產生模擬的服務帳號金鑰，Google 客戶端以此簽署 JWT 並向模擬的令牌端點換取存取令牌
+func (s *Server) Credentials() ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("產生模擬金鑰失敗: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("編碼模擬金鑰失敗: %w", err)
+	}
+
+	return json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "booking-sync-fake",
+		"private_key_id": "fake",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "booking-sync@booking-sync-fake.iam.gserviceaccount.com",
+		"client_id":      "1",
+		"token_uri":      "https://oauth2.googleapis.com/token",
+	})
+}
+
+// serveHTTP 依路徑將請求分派到各個模擬服務
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Latency > 0 {
+		time.Sleep(s.Latency)
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/token":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": "fake-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	case strings.HasPrefix(path, "/admin/"):
+		s.SimplyBook.ServeHTTP(w, r)
+	case strings.HasPrefix(path, "/calendar/v3/"):
+		s.Calendar.ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeJSON 以 JSON 輸出回應
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
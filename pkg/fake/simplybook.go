@@ -0,0 +1,353 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimplyBook 模擬 SimplyBook REST API 的認證、預約、服務、服務分類與服務提供者端點
+type SimplyBook struct {
+	mu         sync.Mutex
+	bookings   map[int]*Booking
+	services   map[int]Service
+	categories map[int]string
+	providers  map[int]string
+	resources  map[int]string
+	nextID     int
+}
+
+// Booking 是模擬的預約
+type Booking struct {
+	ID          int
+	Code        string
+	Start       time.Time
+	End         time.Time
+	ServiceID   int
+	ProviderID  int
+	ResourceID  int // 占用的服務資源（房間），0 表示不占用
+	ClientName  string
+	ClientEmail string
+	ClientPhone string
+	Status      string
+	Notes       string
+}
+
+// Service 是模擬的服務
+type Service struct {
+	Name     string
+	Duration time.Duration
+	Capacity int // 團體課程每時段的人數上限，0 表示一般服務
+	Category int // 所屬的服務分類，0 表示不屬於任何分類
+}
+
+// NewSimplyBook 創建預設有兩個服務、一個團體課程、兩個服務分類、兩個服務提供者與兩個房間資源的模擬 SimplyBook
+func NewSimplyBook() *SimplyBook {
+	return &SimplyBook{
+		bookings: make(map[int]*Booking),
+		services: map[int]Service{
+			1: {Name: "剪髮", Duration: 45 * time.Minute, Category: 1},
+			2: {Name: "染髮", Duration: 2 * time.Hour, Category: 1},
+			3: {Name: "瑜伽團體課", Duration: time.Hour, Capacity: 8, Category: 2},
+		},
+		categories: map[int]string{1: "美髮", 2: "課程"},
+		providers:  map[int]string{1: "小美", 2: "阿明"},
+		resources:  map[int]string{1: "A 室", 2: "B 室"},
+		nextID:     1000,
+	}
+}
+
+// Add 新增預約並返回預約 ID；ID 與 Code 為空時自動產生，End 為零值時依服務時長推算
+func (s *SimplyBook) Add(b Booking) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b.ID == 0 {
+		s.nextID++
+		b.ID = s.nextID
+	}
+	if b.Code == "" {
+		b.Code = "F" + strconv.Itoa(b.ID)
+	}
+	if b.End.IsZero() {
+		b.End = b.Start.Add(s.services[b.ServiceID].Duration)
+	}
+	if b.Status == "" {
+		b.Status = "confirmed"
+	}
+	s.bookings[b.ID] = &b
+	return b.ID
+}
+
+// Update 以 fn 修改預約，預約不存在時返回 false
+func (s *SimplyBook) Update(id int, fn func(b *Booking)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bookings[id]
+	if ok {
+		fn(b)
+	}
+	return ok
+}
+
+// Get 返回預約的副本
+func (s *SimplyBook) Get(id int) (Booking, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bookings[id]
+	if !ok {
+		return Booking{}, false
+	}
+	return *b, true
+}
+
+// List 依 ID 排序返回所有預約的副本
+func (s *SimplyBook) List() []Booking {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Booking, 0, len(s.bookings))
+	for _, b := range s.bookings {
+		list = append(list, *b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Services 返回服務 ID 與名稱
+func (s *SimplyBook) Services() map[int]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make(map[int]string, len(s.services))
+	for id, service := range s.services {
+		names[id] = service.Name
+	}
+	return names
+}
+
+// Providers 返回服務提供者 ID 與名稱
+func (s *SimplyBook) Providers() map[int]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make(map[int]string, len(s.providers))
+	for id, name := range s.providers {
+		names[id] = name
+	}
+	return names
+}
+
+// Resources 返回服務資源 ID 與名稱
+func (s *SimplyBook) Resources() map[int]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make(map[int]string, len(s.resources))
+	for id, name := range s.resources {
+		names[id] = name
+	}
+	return names
+}
+
+// ServeHTTP 處理 SimplyBook API 請求
+func (s *SimplyBook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case path == "/admin/auth" && r.Method == http.MethodPost:
+		writeJSON(w, http.StatusOK, map[string]string{"token": "fake-token"})
+	case path == "/admin/services":
+		s.writeServices(w)
+	case path == "/admin/providers":
+		s.writeProviders(w)
+	case path == "/admin/categories":
+		s.writeCategories(w)
+	case path == "/admin/bookings" && r.Method == http.MethodGet:
+		s.writeBookingList(w, r)
+	case strings.HasPrefix(path, "/admin/bookings/"):
+		s.serveBooking(w, r, strings.TrimPrefix(path, "/admin/bookings/"))
+	default:
+		writeSimplyBookError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// serveBooking 處理單一預約的讀取、更新備註、核准與取消
+func (s *SimplyBook) serveBooking(w http.ResponseWriter, r *http.Request, rest string) {
+	idPart, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		writeSimplyBookError(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bookings[id]
+	if !ok {
+		writeSimplyBookError(w, http.StatusNotFound, "Booking not found")
+		return
+	}
+
+	switch {
+	case action == "approve" && r.Method == http.MethodPut:
+		b.Status = "confirmed"
+	case action == "" && r.Method == http.MethodPut:
+		var body struct {
+			Notes string `json:"notes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeSimplyBookError(w, http.StatusBadRequest, "Invalid body")
+			return
+		}
+		b.Notes = body.Notes
+	case action == "" && r.Method == http.MethodDelete:
+		b.Status = "canceled"
+	case action == "" && r.Method == http.MethodGet:
+	default:
+		writeSimplyBookError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bookingJSON(b))
+}
+
+// writeBookingList 依 filter[date_from]、filter[date_to] 與分頁參數列出預約
+func (s *SimplyBook) writeBookingList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, _ := time.Parse("2006-01-02", query.Get("filter[date_from]"))
+	to, _ := time.Parse("2006-01-02", query.Get("filter[date_to]"))
+	page, _ := strconv.Atoi(query.Get("page"))
+	onPage, _ := strconv.Atoi(query.Get("on_page"))
+	if page < 1 {
+		page = 1
+	}
+	if onPage < 1 {
+		onPage = 100
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*Booking
+	for _, b := range s.bookings {
+		date := b.Start.Format("2006-01-02")
+		if !from.IsZero() && date < from.Format("2006-01-02") {
+			continue
+		}
+		if !to.IsZero() && date > to.Format("2006-01-02") {
+			continue
+		}
+		matched = append(matched, b)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	data := []map[string]interface{}{}
+	for i := (page - 1) * onPage; i < len(matched) && i < page*onPage; i++ {
+		data = append(data, s.bookingJSON(matched[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"metadata": map[string]int{
+			"items_count": len(matched),
+			"pages_count": (len(matched) + onPage - 1) / onPage,
+			"page":        page,
+			"on_page":     onPage,
+		},
+	})
+}
+
+// bookingJSON 以詳細預約端點的格式輸出預約，呼叫前須持有 s.mu
+func (s *SimplyBook) bookingJSON(b *Booking) map[string]interface{} {
+	const layout = "2006-01-02 15:04:05"
+	service := s.services[b.ServiceID]
+	resources := []map[string]interface{}{}
+	if name, ok := s.resources[b.ResourceID]; ok {
+		resources = append(resources, map[string]interface{}{"id": b.ResourceID, "name": name})
+	}
+	return map[string]interface{}{
+		"id":             b.ID,
+		"code":           b.Code,
+		"start_datetime": b.Start.Format(layout),
+		"end_datetime":   b.End.Format(layout),
+		"status":         b.Status,
+		"notes":          b.Notes,
+		"client": map[string]string{
+			"name":  b.ClientName,
+			"email": b.ClientEmail,
+			"phone": b.ClientPhone,
+		},
+		"service_id":  b.ServiceID,
+		"provider_id": b.ProviderID,
+		"service": map[string]interface{}{
+			"id":       b.ServiceID,
+			"name":     service.Name,
+			"duration": int(service.Duration / time.Minute),
+			"capacity": service.Capacity,
+		},
+		"provider": map[string]interface{}{
+			"id":   b.ProviderID,
+			"name": s.providers[b.ProviderID],
+		},
+		"resources": resources,
+	}
+}
+
+// writeServices 輸出服務列表
+func (s *SimplyBook) writeServices(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make(map[string]interface{}, len(s.services))
+	for id, service := range s.services {
+		list[strconv.Itoa(id)] = map[string]interface{}{
+			"id":       strconv.Itoa(id),
+			"name":     service.Name,
+			"duration": int(service.Duration / time.Minute),
+			"capacity": service.Capacity,
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// writeCategories 輸出服務分類列表，每個分類列出其中的服務 ID
+func (s *SimplyBook) writeCategories(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]map[string]interface{}, 0, len(s.categories))
+	for id, name := range s.categories {
+		services := []string{}
+		for serviceID, service := range s.services {
+			if service.Category == id {
+				services = append(services, strconv.Itoa(serviceID))
+			}
+		}
+		sort.Strings(services)
+		list = append(list, map[string]interface{}{"id": id, "name": name, "services": services})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i]["id"].(int) < list[j]["id"].(int) })
+	writeJSON(w, http.StatusOK, list)
+}
+
+// writeProviders 輸出服務提供者列表
+func (s *SimplyBook) writeProviders(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make(map[string]interface{}, len(s.providers))
+	for id, name := range s.providers {
+		list[strconv.Itoa(id)] = map[string]string{"id": strconv.Itoa(id), "name": name}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// writeSimplyBookError 以 SimplyBook 的錯誤格式輸出錯誤
+func writeSimplyBookError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"code": status, "message": message, "data": []string{}})
+}
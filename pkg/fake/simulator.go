@@ -0,0 +1,260 @@
+package fake
+
+import (
+	"bytes"
+	"crypto/md5"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var simulatorTemplates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// formTimeLayout 是表單中 datetime-local 欄位的時間格式
+const formTimeLayout = "2006-01-02T15:04"
+
+// Simulator 是本機開發用的 webhook 模擬器頁面：在模擬 SimplyBook 建立、修改或取消預約後，
+// 以 SimplyBook 的格式將 webhook 送進同步處理器，並列出模擬日曆上的事件
+type Simulator struct {
+	server  *Server
+	company string
+	webhook http.Handler
+	loc     *time.Location
+}
+
+// NewSimulator 創建 webhook 模擬器，webhook 為處理 SimplyBook webhook 的處理器，loc 為預約時間的時區
+func NewSimulator(server *Server, company string, webhook http.Handler, loc *time.Location) *Simulator {
+	return &Simulator{
+		server:  server,
+		company: company,
+		webhook: webhook,
+		loc:     loc,
+	}
+}
+
+// Register 在路由上註冊模擬器頁面
+func (s *Simulator) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/dev", s.handleIndex)
+	mux.HandleFunc("/dev/create", s.handleCreate)
+	mux.HandleFunc("/dev/change", s.handleChange)
+	mux.HandleFunc("/dev/cancel", s.handleCancel)
+}
+
+// simulatorData 是模擬器頁面的模板資料
+type simulatorData struct {
+	Message      string
+	DefaultStart string
+	Services     []option
+	Providers    []option
+	Resources    []option
+	Bookings     []bookingRow
+	Events       []eventRow
+}
+
+// option 是下拉選單的選項
+type option struct {
+	ID   int
+	Name string
+}
+
+// bookingRow 是模擬 SimplyBook 預約列表的一列
+type bookingRow struct {
+	Booking
+	Service  string
+	Provider string
+	Resource string
+}
+
+// eventRow 是模擬日曆事件列表的一列
+type eventRow struct {
+	CalendarID string
+	ID         string
+	Summary    string
+	Start      string
+	End        string
+	BookingID  string
+}
+
+// handleIndex 顯示模擬 SimplyBook 的預約與模擬日曆的事件
+func (s *Simulator) handleIndex(w http.ResponseWriter, r *http.Request) {
+	services := s.server.SimplyBook.Services()
+	providers := s.server.SimplyBook.Providers()
+	resources := s.server.SimplyBook.Resources()
+
+	tomorrow := time.Now().In(s.loc).AddDate(0, 0, 1)
+	data := simulatorData{
+		Message:      r.URL.Query().Get("msg"),
+		DefaultStart: time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, s.loc).Format(formTimeLayout),
+		Services:     sortedOptions(services),
+		Providers:    sortedOptions(providers),
+		Resources:    sortedOptions(resources),
+	}
+
+	for _, b := range s.server.SimplyBook.List() {
+		data.Bookings = append(data.Bookings, bookingRow{
+			Booking:  b,
+			Service:  services[b.ServiceID],
+			Provider: providers[b.ProviderID],
+			Resource: resources[b.ResourceID],
+		})
+	}
+
+	for _, calendarID := range s.server.Calendar.Calendars() {
+		for _, e := range s.server.Calendar.Events(calendarID) {
+			row := eventRow{
+				CalendarID: calendarID,
+				ID:         e.Id,
+				Summary:    e.Summary,
+				Start:      s.formatEventTime(e.Start.DateTime),
+				End:        s.formatEventTime(e.End.DateTime),
+			}
+			if e.ExtendedProperties != nil {
+				row.BookingID = e.ExtendedProperties.Private[gcalendar.PropertyBookingID]
+			}
+			data.Events = append(data.Events, row)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := simulatorTemplates.ExecuteTemplate(w, "simulator.html", data); err != nil {
+		log.Printf("渲染 webhook 模擬器失敗: %v", err)
+	}
+}
+
+// handleCreate 在模擬 SimplyBook 建立預約並送出 create webhook
+func (s *Simulator) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	start, err := time.ParseInLocation(formTimeLayout, r.FormValue("start"), s.loc)
+	if err != nil {
+		s.redirect(w, r, "無效的開始時間: "+r.FormValue("start"))
+		return
+	}
+	serviceID, _ := strconv.Atoi(r.FormValue("service_id"))
+	providerID, _ := strconv.Atoi(r.FormValue("provider_id"))
+	resourceID, _ := strconv.Atoi(r.FormValue("resource_id"))
+	if _, ok := s.server.SimplyBook.Services()[serviceID]; !ok {
+		s.redirect(w, r, "無效的服務")
+		return
+	}
+
+	id := s.server.SimplyBook.Add(Booking{
+		Start:       start,
+		ServiceID:   serviceID,
+		ProviderID:  providerID,
+		ResourceID:  resourceID,
+		ClientName:  strings.TrimSpace(r.FormValue("client_name")),
+		ClientEmail: strings.TrimSpace(r.FormValue("client_email")),
+		ClientPhone: strings.TrimSpace(r.FormValue("client_phone")),
+		Notes:       strings.TrimSpace(r.FormValue("notes")),
+	})
+	s.redirect(w, r, fmt.Sprintf("已建立預約 %d，%s", id, s.send("create", id)))
+}
+
+// handleChange 修改模擬 SimplyBook 預約的開始時間（保留原本的時長）與房間並送出 change webhook
+func (s *Simulator) handleChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	id, _ := strconv.Atoi(r.FormValue("booking_id"))
+	start, err := time.ParseInLocation(formTimeLayout, r.FormValue("start"), s.loc)
+	if err != nil {
+		s.redirect(w, r, "無效的開始時間: "+r.FormValue("start"))
+		return
+	}
+
+	resourceID, _ := strconv.Atoi(r.FormValue("resource_id"))
+
+	ok := s.server.SimplyBook.Update(id, func(b *Booking) {
+		b.End = start.Add(b.End.Sub(b.Start))
+		b.Start = start
+		b.ResourceID = resourceID
+	})
+	if !ok {
+		s.redirect(w, r, fmt.Sprintf("找不到預約 %d", id))
+		return
+	}
+	s.redirect(w, r, fmt.Sprintf("已修改預約 %d，%s", id, s.send("change", id)))
+}
+
+// handleCancel 取消模擬 SimplyBook 預約並送出 cancel webhook
+func (s *Simulator) handleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+
+	id, _ := strconv.Atoi(r.FormValue("booking_id"))
+	ok := s.server.SimplyBook.Update(id, func(b *Booking) {
+		b.Status = "canceled"
+	})
+	if !ok {
+		s.redirect(w, r, fmt.Sprintf("找不到預約 %d", id))
+		return
+	}
+	s.redirect(w, r, fmt.Sprintf("已取消預約 %d，%s", id, s.send("cancel", id)))
+}
+
+// send 以 SimplyBook 的 JSON 格式將 webhook 送進同步處理器，返回處理結果的說明
+func (s *Simulator) send(action string, bookingID int) string {
+	id := strconv.Itoa(bookingID)
+	body, _ := json.Marshal(map[string]interface{}{
+		"booking_id":        id,
+		"booking_hash":      fmt.Sprintf("%x", md5.Sum([]byte(s.company+id))),
+		"company":           s.company,
+		"notification_type": action,
+		"webhook_timestamp": time.Now().Unix(),
+		"signature_algo":    "sha256",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.webhook.ServeHTTP(rec, req)
+
+	return fmt.Sprintf("%s webhook 回應 %d %s", action, rec.Code, strings.TrimSpace(rec.Body.String()))
+}
+
+// formatEventTime 將事件的 RFC 3339 時間轉為模擬器時區的顯示格式
+func (s *Simulator) formatEventTime(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.In(s.loc).Format("2006-01-02 15:04")
+}
+
+// redirect 帶著提示訊息返回模擬器頁面
+func (s *Simulator) redirect(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/dev?msg="+url.QueryEscape(msg), http.StatusSeeOther)
+}
+
+// sortedOptions 將 ID 與名稱依 ID 排序為選項
+func sortedOptions(names map[int]string) []option {
+	options := make([]option, 0, len(names))
+	for id, name := range names {
+		options = append(options, option{ID: id, Name: name})
+	}
+	sort.Slice(options, func(i, j int) bool { return options[i].ID < options[j].ID })
+	return options
+}
@@ -0,0 +1,132 @@
+package gcalendar
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted 表示今日的日曆寫入額度已用完，操作應暫存到額度重置後再送出
+var ErrBudgetExhausted = errors.New("Google 日曆今日的寫入額度已用完")
+
+// budgetWaitLogThreshold 寫入等待超過此時間時記錄日誌
+const budgetWaitLogThreshold = time.Second
+
+// Budget 限制日曆寫入（建立、更新、刪除、移動事件）的頻率，避免大量對帳時觸發 usageLimits 錯誤；
+// 欄位為 0 表示不限制
+type Budget struct {
+	QPS         float64 // 所有日曆合計每秒寫入上限，超過時排隊等待
+	CalendarQPS float64 // 單一日曆每秒寫入上限，超過時排隊等待
+	Daily       int     // 每日寫入上限，依 Google 配額的重置時間（太平洋時間午夜）計算，用完時返回 ErrBudgetExhausted
+}
+
+// budgeter 以 token bucket 限制寫入頻率並計算每日用量
+type budgeter struct {
+	budget    Budget
+	quotaZone *time.Location
+
+	mu        sync.Mutex
+	global    *rateBucket
+	calendars map[string]*rateBucket
+	day       string
+	used      int
+}
+
+// rateBucket 是允許短暫突發一秒用量的 token bucket
+type rateBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// SetBudget 設定日曆寫入的頻率與每日上限，應在開始同步前呼叫
+func (c *Client) SetBudget(budget Budget) {
+	if budget.QPS <= 0 && budget.CalendarQPS <= 0 && budget.Daily <= 0 {
+		c.budget = nil
+		return
+	}
+
+	quotaZone, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		quotaZone = time.UTC
+	}
+	c.budget = &budgeter{
+		budget:    budget,
+		quotaZone: quotaZone,
+		calendars: make(map[string]*rateBucket),
+	}
+	if budget.QPS > 0 {
+		c.budget.global = newRateBucket(budget.QPS)
+	}
+}
+
+// acquireWrite 取得一次寫入額度，超過頻率上限時等待，超過每日上限時返回 ErrBudgetExhausted
+func (c *Client) acquireWrite(calendarID string) error {
+	if c.budget == nil {
+		return nil
+	}
+
+	wait, err := c.budget.reserve(calendarID, time.Now())
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		if wait >= budgetWaitLogThreshold {
+			log.Printf("日曆 %s 的寫入超過頻率上限，等待 %s", calendarID, wait.Round(time.Millisecond))
+		}
+		time.Sleep(wait)
+	}
+	return nil
+}
+
+// reserve 預留一次寫入並返回需等待的時間
+func (b *budgeter) reserve(calendarID string, now time.Time) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.budget.Daily > 0 {
+		if day := now.In(b.quotaZone).Format("2006-01-02"); day != b.day {
+			b.day, b.used = day, 0
+		}
+		if b.used >= b.budget.Daily {
+			return 0, ErrBudgetExhausted
+		}
+		b.used++
+	}
+
+	var wait time.Duration
+	if b.global != nil {
+		wait = b.global.reserve(now)
+	}
+	if b.budget.CalendarQPS > 0 {
+		bucket, ok := b.calendars[calendarID]
+		if !ok {
+			bucket = newRateBucket(b.budget.CalendarQPS)
+			b.calendars[calendarID] = bucket
+		}
+		if w := bucket.reserve(now); w > wait {
+			wait = w
+		}
+	}
+	return wait, nil
+}
+
+// newRateBucket 創建裝滿的 token bucket
+func newRateBucket(rate float64) *rateBucket {
+	return &rateBucket{rate: rate, tokens: math.Max(rate, 1)}
+}
+
+// reserve 取出一個 token，不足時允許預支並返回需等待的時間
+func (r *rateBucket) reserve(now time.Time) time.Duration {
+	if !r.last.IsZero() {
+		r.tokens = math.Min(math.Max(r.rate, 1), r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	}
+	r.last = now
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
@@ -3,115 +3,242 @@ package gcalendar
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
 	"google.golang.org/api/option"
 )
 
-// Client 代表 Google 日曆 API 客戶端
+// Client 代表 Google 日曆 API 客戶端，可設置多個服務帳號，
+// 其中一個遇到配額或金鑰失效時輪替使用下一個
 type Client struct {
-	service       *calendar.Service
+	credentials   []*credential
+	active        atomic.Int64 // 目前優先使用的服務帳號索引
 	calendarID    string
 	calendarEmail string
+	budget        *budgeter // 未設定 Budget 時為 nil，寫入不受限制
 }
 
 // CalendarEvent 代表 Google 日曆事件
 type CalendarEvent struct {
 	ID          string
+	CalendarID  string // 事件所在的日曆，空字串表示使用客戶端的預設日曆
+	ColorID     string
 	Summary     string
 	Description string
 	Location    string
 	StartTime   time.Time
 	EndTime     time.Time
+	TimeZone    string // 事件的 IANA 時區，空字串表示 Asia/Taipei
 	Attendees   []string
+	Attachments []Attachment
+	Status      string    // confirmed、tentative 或 cancelled（已刪除），僅讀取時設置
+	Updated     time.Time // 最後修改時間，僅讀取時設置
+	HTMLLink    string    // 事件在 Google 日曆的網址，讀取、創建與更新後設置
+	Tentative   bool      // 暫定事件（status=tentative），例如候補名單的預約
+	Free        bool      // 不佔用時間（transparency=transparent），忙碌狀態顯示為有空
+
+	// Properties 事件的私有擴充屬性（extendedProperties.private），本服務以 Property* 鍵標記建立的事件
+	Properties map[string]string
+
+	// SendUpdates 創建或更新時通知參與者的方式（SendUpdates* 常數），不屬於事件內容；空字串使用 Google 的預設（不通知）
+	SendUpdates string
 }
 
-// NewClient 創建新的 Google 日曆 API 客戶端
-func NewClient(credentialsJSON []byte, calendarID string) (*Client, error) {
-	ctx := context.Background()
+// 寫入事件時通知參與者的方式，對應 Google Calendar API 的 sendUpdates 參數
+const (
+	SendUpdatesAll          = "all"          // 通知所有參與者
+	SendUpdatesExternalOnly = "externalOnly" // 只通知非 Google 日曆使用者
+	SendUpdatesNone         = "none"         // 不通知
+)
 
-	// 使用服務帳號憑證創建 OAuth2 配置
-	config, err := google.JWTConfigFromJSON(credentialsJSON, calendar.CalendarScope)
-	if err != nil {
-		return nil, fmt.Errorf("無法解析服務帳號金鑰: %w", err)
+// 本服務寫入事件私有擴充屬性的鍵，清理工具與日曆變更通知可據此分辨本服務建立的事件與手動建立的事件
+const (
+	PropertySource    = "bookingSyncSource"    // 預約平台，例如 simplybook 或 calendly
+	PropertyTenant    = "bookingSyncTenant"    // 租戶，SimplyBook 為 company login，其他平台為平台名稱
+	PropertyBookingID = "bookingSyncBookingId" // 預約識別碼
+	PropertyVersion   = "bookingSyncVersion"   // 最後寫入事件的服務版本
+	PropertyTravel    = "bookingSyncTravel"    // 交通事件位於預約之前（before）或之後（after），預約事件不設置
+	PropertyResource  = "bookingSyncResource"  // 資源日曆事件對應的服務資源 ID，預約事件不設置
+	// 團體課程的共用事件以服務、服務提供者與開始時間識別，參與的預約識別碼以逗號分隔
+	PropertyClass        = "bookingSyncClass"
+	PropertyParticipants = "bookingSyncParticipants"
+	PropertyCapacity     = "bookingSyncCapacity" // 課程人數上限，未知時不設置
+)
+
+// Managed 判斷事件是否帶有本服務的標記；升級前建立且尚未更新過的事件沒有標記
+func (e *CalendarEvent) Managed() bool {
+	return e.Properties[PropertySource] != ""
+}
+
+// Cancelled 判斷事件是否已從日曆刪除
+func (e *CalendarEvent) Cancelled() bool {
+	return e.Status == "cancelled"
+}
+
+// Attachment 代表附加於事件的檔案或連結
+type Attachment struct {
+	Title    string
+	URL      string
+	MimeType string
+}
+
+// NewClient 創建新的 Google 日曆 API 客戶端，
+// httpClient 不為 nil 時作為 OAuth2 與 API 請求的底層客戶端（例如代理或自訂 CA）
+func NewClient(credentialsJSON []byte, calendarID string, httpClient *http.Client) (*Client, error) {
+	return NewClientWithCredentials([][]byte{credentialsJSON}, calendarID, httpClient)
+}
+
+// NewClientWithCredentials 以多個服務帳號金鑰創建 Google 日曆 API 客戶端，
+// 依順序優先使用，遇到配額或金鑰失效時輪替
+func NewClientWithCredentials(credentialsJSON [][]byte, calendarID string, httpClient *http.Client) (*Client, error) {
+	if len(credentialsJSON) == 0 {
+		return nil, fmt.Errorf("未提供任何服務帳號金鑰")
 	}
 
-	// 創建帶有 OAuth2 客戶端的日曆服務
-	client := config.Client(ctx)
-	service, err := calendar.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		return nil, fmt.Errorf("無法創建日曆服務: %w", err)
+	ctx := context.Background()
+	if httpClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+
+	c := &Client{calendarID: calendarID}
+	for i, data := range credentialsJSON {
+		// 使用服務帳號憑證創建 OAuth2 配置
+		config, err := google.JWTConfigFromJSON(data, calendar.CalendarScope)
+		if err != nil {
+			return nil, fmt.Errorf("無法解析第 %d 個服務帳號金鑰: %w", i+1, err)
+		}
+
+		// 創建帶有 OAuth2 客戶端的日曆服務
+		service, err := calendar.NewService(ctx, option.WithHTTPClient(config.Client(ctx)))
+		if err != nil {
+			return nil, fmt.Errorf("無法創建日曆服務: %w", err)
+		}
+
+		c.credentials = append(c.credentials, newCredential(config.Email, service))
 	}
+	c.calendarEmail = c.credentials[0].email
 
-	return &Client{
-		service:       service,
-		calendarID:    calendarID,
-		calendarEmail: config.Email,
-	}, nil
+	return c, nil
 }
 
-// CreateEvent 在 Google 日曆中創建事件
+// CreateEvent 在 Google 日曆中創建事件，成功後設置 event.ID 與 event.HTMLLink
 func (c *Client) CreateEvent(event *CalendarEvent) (string, error) {
 	calEvent, err := c.prepareCalendarEvent(event)
 	if err != nil {
 		return "", fmt.Errorf("準備日曆事件失敗: %w", err)
 	}
 
-	createdEvent, err := c.service.Events.Insert(c.calendarID, calEvent).Do()
+	calendarID := c.ResolveCalendar(event.CalendarID)
+	if err := c.acquireWrite(calendarID); err != nil {
+		return "", fmt.Errorf("創建事件失敗: %w", err)
+	}
+	metrics.ObserveGoogleAPICall(calendarID, "events.insert")
+	var createdEvent *calendar.Event
+	err = c.call(func(service *calendar.Service) error {
+		var err error
+		call := service.Events.Insert(calendarID, calEvent).SupportsAttachments(len(calEvent.Attachments) > 0)
+		if event.SendUpdates != "" {
+			call = call.SendUpdates(event.SendUpdates)
+		}
+		createdEvent, err = call.Do()
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("創建事件失敗: %w", err)
 	}
 
+	event.ID = createdEvent.Id
+	event.HTMLLink = createdEvent.HtmlLink
 	return createdEvent.Id, nil
 }
 
-// UpdateEvent 更新 Google 日曆中的事件
+// UpdateEvent 更新 Google 日曆中的事件，成功後設置 event.HTMLLink
 func (c *Client) UpdateEvent(eventID string, event *CalendarEvent) error {
 	calEvent, err := c.prepareCalendarEvent(event)
 	if err != nil {
 		return fmt.Errorf("準備日曆事件失敗: %w", err)
 	}
 
-	_, err = c.service.Events.Update(c.calendarID, eventID, calEvent).Do()
+	calendarID := c.ResolveCalendar(event.CalendarID)
+	if err := c.acquireWrite(calendarID); err != nil {
+		return fmt.Errorf("更新事件失敗: %w", err)
+	}
+	metrics.ObserveGoogleAPICall(calendarID, "events.update")
+	var updatedEvent *calendar.Event
+	err = c.call(func(service *calendar.Service) error {
+		var err error
+		call := service.Events.Update(calendarID, eventID, calEvent).SupportsAttachments(len(calEvent.Attachments) > 0)
+		if event.SendUpdates != "" {
+			call = call.SendUpdates(event.SendUpdates)
+		}
+		updatedEvent, err = call.Do()
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("更新事件失敗: %w", err)
 	}
+	event.HTMLLink = updatedEvent.HtmlLink
 
 	return nil
 }
 
 // prepareCalendarEvent 準備要發送給 Google Calendar API 的事件物件
 func (c *Client) prepareCalendarEvent(event *CalendarEvent) (*calendar.Event, error) {
-	// 獲取台灣時區
-	loc, err := time.LoadLocation("Asia/Taipei")
+	// 未指定時區時使用台灣時區
+	timeZone := event.TimeZone
+	if timeZone == "" {
+		timeZone = "Asia/Taipei"
+	}
+	// 格式化為不帶時區信息的時間格式
+	layout := "2006-01-02T15:04:05"
+	loc, err := time.LoadLocation(timeZone)
 	if err != nil {
-		loc = time.FixedZone("GMT+8", 8*60*60)
+		// 系統缺少時區資料時，台灣時區使用固定的 GMT+8；其他時區改送帶時差的 UTC 時間，由 Google 日曆依時區顯示
+		if timeZone == "Asia/Taipei" {
+			loc = time.FixedZone("GMT+8", 8*60*60)
+		} else {
+			loc, layout = time.UTC, time.RFC3339
+		}
 	}
 
-	// 確保時間是台灣時區的
+	// 確保時間是事件時區的
 	startTime := event.StartTime.In(loc)
 	endTime := event.EndTime.In(loc)
 
-	// 格式化為不帶時區信息的時間格式
-	startDateTime := startTime.Format("2006-01-02T15:04:05")
-	endDateTime := endTime.Format("2006-01-02T15:04:05")
+	startDateTime := startTime.Format(layout)
+	endDateTime := endTime.Format(layout)
 
 	calEvent := &calendar.Event{
 		Summary:     event.Summary,
 		Description: event.Description,
 		Location:    event.Location,
+		ColorId:     event.ColorID,
+		// 更新會取代整個事件，明確寫入狀態讓暫定事件轉正時恢復為確定與佔用時間
+		Status:       "confirmed",
+		Transparency: "opaque",
 		Start: &calendar.EventDateTime{
 			DateTime: startDateTime,
-			TimeZone: "Asia/Taipei", // 明確指定台灣時區
+			TimeZone: timeZone, // 明確指定時區
 		},
 		End: &calendar.EventDateTime{
 			DateTime: endDateTime,
-			TimeZone: "Asia/Taipei", // 明確指定台灣時區
+			TimeZone: timeZone, // 明確指定時區
 		},
 	}
 
+	if event.Tentative {
+		calEvent.Status = "tentative"
+	}
+	if event.Free {
+		calEvent.Transparency = "transparent"
+	}
+
 	// 加入參與者
 	if len(event.Attendees) > 0 {
 		attendees := make([]*calendar.EventAttendee, len(event.Attendees))
@@ -121,12 +248,42 @@ func (c *Client) prepareCalendarEvent(event *CalendarEvent) (*calendar.Event, er
 		calEvent.Attendees = attendees
 	}
 
+	// 加入附件
+	for _, attachment := range event.Attachments {
+		calEvent.Attachments = append(calEvent.Attachments, &calendar.EventAttachment{
+			Title:    attachment.Title,
+			FileUrl:  attachment.URL,
+			MimeType: attachment.MimeType,
+		})
+	}
+
+	// 更新會取代整個事件，每次寫入都須帶上擴充屬性
+	if len(event.Properties) > 0 {
+		calEvent.ExtendedProperties = &calendar.EventExtendedProperties{Private: event.Properties}
+	}
+
 	return calEvent, nil
 }
 
-// DeleteEvent 刪除 Google 日曆中的事件
-func (c *Client) DeleteEvent(eventID string) error {
-	err := c.service.Events.Delete(c.calendarID, eventID).Do()
+// DeleteEvent 刪除 Google 日曆中的事件，calendarID 為空時使用預設日曆
+func (c *Client) DeleteEvent(calendarID, eventID string) error {
+	return c.DeleteEventWithUpdates(calendarID, eventID, "")
+}
+
+// DeleteEventWithUpdates 刪除事件並依 sendUpdates（SendUpdates* 常數）通知參與者，空字串使用 Google 的預設
+func (c *Client) DeleteEventWithUpdates(calendarID, eventID, sendUpdates string) error {
+	calendarID = c.ResolveCalendar(calendarID)
+	if err := c.acquireWrite(calendarID); err != nil {
+		return fmt.Errorf("刪除事件失敗: %w", err)
+	}
+	metrics.ObserveGoogleAPICall(calendarID, "events.delete")
+	err := c.call(func(service *calendar.Service) error {
+		call := service.Events.Delete(calendarID, eventID)
+		if sendUpdates != "" {
+			call = call.SendUpdates(sendUpdates)
+		}
+		return call.Do()
+	})
 	if err != nil {
 		return fmt.Errorf("刪除事件失敗: %w", err)
 	}
@@ -134,23 +291,86 @@ func (c *Client) DeleteEvent(eventID string) error {
 	return nil
 }
 
-// GetEvent 獲取特定 Google 日曆事件
-func (c *Client) GetEvent(eventID string) (*CalendarEvent, error) {
-	calEvent, err := c.service.Events.Get(c.calendarID, eventID).Do()
+// GetEvent 獲取特定 Google 日曆事件，calendarID 為空時使用預設日曆
+func (c *Client) GetEvent(calendarID, eventID string) (*CalendarEvent, error) {
+	calendarID = c.ResolveCalendar(calendarID)
+	metrics.ObserveGoogleAPICall(calendarID, "events.get")
+	var calEvent *calendar.Event
+	err := c.call(func(service *calendar.Service) error {
+		var err error
+		calEvent, err = service.Events.Get(calendarID, eventID).Do()
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("獲取事件失敗: %w", err)
 	}
 
-	startTime, _ := time.Parse(time.RFC3339, calEvent.Start.DateTime)
-	endTime, _ := time.Parse(time.RFC3339, calEvent.End.DateTime)
+	return toCalendarEvent(calendarID, calEvent), nil
+}
+
+// EachEvent 逐頁列出時間範圍內的事件（展開重複事件），並對每個事件呼叫 fn，
+// calendarID 為空時使用預設日曆
+func (c *Client) EachEvent(calendarID string, timeMin, timeMax time.Time, fn func(*CalendarEvent) error) error {
+	calendarID = c.ResolveCalendar(calendarID)
+
+	var pageToken string
+	for {
+		metrics.ObserveGoogleAPICall(calendarID, "events.list")
+		var events *calendar.Events
+		err := c.call(func(service *calendar.Service) error {
+			var err error
+			events, err = service.Events.List(calendarID).
+				TimeMin(timeMin.Format(time.RFC3339)).
+				TimeMax(timeMax.Format(time.RFC3339)).
+				SingleEvents(true).
+				MaxResults(250).
+				PageToken(pageToken).
+				Do()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("列出事件失敗: %w", err)
+		}
+
+		for _, item := range events.Items {
+			if err := fn(toCalendarEvent(calendarID, item)); err != nil {
+				return err
+			}
+		}
+
+		if events.NextPageToken == "" {
+			return nil
+		}
+		pageToken = events.NextPageToken
+	}
+}
+
+// toCalendarEvent 將 API 事件轉換為 CalendarEvent
+func toCalendarEvent(calendarID string, calEvent *calendar.Event) *CalendarEvent {
+	// 增量列出的已刪除事件只有 ID 與狀態
+	var startTime, endTime time.Time
+	if calEvent.Start != nil {
+		startTime, _ = time.Parse(time.RFC3339, calEvent.Start.DateTime)
+	}
+	if calEvent.End != nil {
+		endTime, _ = time.Parse(time.RFC3339, calEvent.End.DateTime)
+	}
+	updated, _ := time.Parse(time.RFC3339, calEvent.Updated)
 
 	event := &CalendarEvent{
 		ID:          calEvent.Id,
+		CalendarID:  calendarID,
+		ColorID:     calEvent.ColorId,
 		Summary:     calEvent.Summary,
 		Description: calEvent.Description,
 		Location:    calEvent.Location,
 		StartTime:   startTime,
 		EndTime:     endTime,
+		Status:      calEvent.Status,
+		Updated:     updated,
+		HTMLLink:    calEvent.HtmlLink,
+		Tentative:   calEvent.Status == "tentative",
+		Free:        calEvent.Transparency == "transparent",
 	}
 
 	if calEvent.Attendees != nil {
@@ -161,21 +381,89 @@ func (c *Client) GetEvent(eventID string) (*CalendarEvent, error) {
 		event.Attendees = attendees
 	}
 
-	return event, nil
+	for _, attachment := range calEvent.Attachments {
+		event.Attachments = append(event.Attachments, Attachment{
+			Title:    attachment.Title,
+			URL:      attachment.FileUrl,
+			MimeType: attachment.MimeType,
+		})
+	}
+
+	if calEvent.ExtendedProperties != nil && len(calEvent.ExtendedProperties.Private) > 0 {
+		event.Properties = calEvent.ExtendedProperties.Private
+	}
+
+	return event
+}
+
+// MoveEvent 將事件從一個日曆移動到另一個日曆
+func (c *Client) MoveEvent(fromCalendarID, eventID, toCalendarID string) error {
+	fromCalendarID = c.ResolveCalendar(fromCalendarID)
+	if err := c.acquireWrite(fromCalendarID); err != nil {
+		return fmt.Errorf("移動事件失敗: %w", err)
+	}
+	metrics.ObserveGoogleAPICall(fromCalendarID, "events.move")
+	toCalendarID = c.ResolveCalendar(toCalendarID)
+	err := c.call(func(service *calendar.Service) error {
+		_, err := service.Events.Move(fromCalendarID, eventID, toCalendarID).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("移動事件失敗: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveCalendar 返回實際使用的日曆 ID，空字串代表預設日曆
+func (c *Client) ResolveCalendar(calendarID string) string {
+	if calendarID == "" {
+		return c.calendarID
+	}
+	return calendarID
+}
+
+// FindEventsByProperty 列出帶有指定私有擴充屬性的事件（不含已刪除的事件），calendarID 為空時使用預設日曆
+func (c *Client) FindEventsByProperty(calendarID, key, value string) ([]*CalendarEvent, error) {
+	calendarID = c.ResolveCalendar(calendarID)
+	metrics.ObserveGoogleAPICall(calendarID, "events.list")
+	var events *calendar.Events
+	err := c.call(func(service *calendar.Service) error {
+		var err error
+		events, err = service.Events.List(calendarID).PrivateExtendedProperty(key + "=" + value).Do()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("搜尋事件失敗: %w", err)
+	}
+
+	result := make([]*CalendarEvent, 0, len(events.Items))
+	for _, item := range events.Items {
+		result = append(result, toCalendarEvent(calendarID, item))
+	}
+	return result, nil
 }
 
-// FindEventByBookingCode 根據預約編號從描述中搜索事件
+// FindEventByBookingCode 根據預約編號從描述中搜索事件；全文搜尋的結果再以 DescriptionHasBookingCode
+// 確認描述中的標記或單字，避免編號出現在其他事件的文字中時誤判
 func (c *Client) FindEventByBookingCode(bookingCode string) (string, error) {
 	// 搜尋描述中包含預約 Code 的事件
 	query := bookingCode
-	events, err := c.service.Events.List(c.calendarID).Q(query).Do()
+	metrics.ObserveGoogleAPICall(c.calendarID, "events.list")
+	var events *calendar.Events
+	err := c.call(func(service *calendar.Service) error {
+		var err error
+		events, err = service.Events.List(c.calendarID).Q(query).Do()
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("搜尋事件失敗: %w", err)
 	}
 
-	if len(events.Items) == 0 {
-		return "", nil // 未找到事件
+	for _, item := range events.Items {
+		if DescriptionHasBookingCode(item.Description, bookingCode) {
+			return item.Id, nil
+		}
 	}
-
-	return events.Items[0].Id, nil
+	return "", nil // 未找到事件
 }
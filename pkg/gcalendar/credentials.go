@@ -0,0 +1,149 @@
+package gcalendar
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"google.golang.org/api/calendar/v3"
+)
+
+// 服務帳號遇到錯誤後暫停使用的時間
+const (
+	quotaCooldown      = 10 * time.Minute // 配額用盡或請求頻率過高
+	permissionCooldown = time.Hour        // 金鑰被撤銷或沒有日曆權限
+)
+
+// credential 是單一服務帳號及其健康狀態
+type credential struct {
+	email   string
+	service *calendar.Service
+
+	mu             sync.Mutex
+	unhealthyUntil time.Time
+	lastError      string
+	failures       int
+}
+
+// CredentialStatus 是服務帳號的健康狀態
+type CredentialStatus struct {
+	Email          string    `json:"email"`
+	Active         bool      `json:"active"`  // 目前優先使用
+	Healthy        bool      `json:"healthy"` // 未處於暫停使用期間
+	UnhealthyUntil time.Time `json:"unhealthy_until,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	Failures       int       `json:"failures"` // 連續失敗次數
+}
+
+// newCredential 創建服務帳號並初始化健康指標
+func newCredential(email string, service *calendar.Service) *credential {
+	metrics.GoogleCredentialHealthy.WithLabelValues(email).Set(1)
+	return &credential{email: email, service: service}
+}
+
+// healthy 判斷服務帳號是否可使用
+func (cr *credential) healthy(now time.Time) bool {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return !now.Before(cr.unhealthyUntil)
+}
+
+// markHealthy 記錄呼叫成功
+func (cr *credential) markHealthy() {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.unhealthyUntil = time.Time{}
+	cr.failures = 0
+	metrics.GoogleCredentialHealthy.WithLabelValues(cr.email).Set(1)
+}
+
+// markUnhealthy 記錄服務帳號的錯誤，並在冷卻時間內暫停使用；配額錯誤帶有 Retry-After 時以其為冷卻時間
+func (cr *credential) markUnhealthy(kind ErrorKind, err error) {
+	cooldown := quotaCooldown
+	if kind == ErrorKindPermission {
+		cooldown = permissionCooldown
+	} else if retryAfter := RetryAfter(err); retryAfter > 0 {
+		cooldown = retryAfter
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.unhealthyUntil = time.Now().Add(cooldown)
+	cr.lastError = err.Error()
+	cr.failures++
+	metrics.GoogleCredentialHealthy.WithLabelValues(cr.email).Set(0)
+}
+
+// status 返回健康狀態
+func (cr *credential) status(now time.Time) CredentialStatus {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	s := CredentialStatus{
+		Email:     cr.email,
+		Healthy:   !now.Before(cr.unhealthyUntil),
+		LastError: cr.lastError,
+		Failures:  cr.failures,
+	}
+	if !s.Healthy {
+		s.UnhealthyUntil = cr.unhealthyUntil
+	}
+	return s
+}
+
+// call 以目前的服務帳號執行 API 呼叫；遇到配額或權限錯誤時暫停該服務帳號並改用下一個可用的服務帳號。
+// 所有服務帳號都暫停使用時仍會以目前的服務帳號嘗試一次
+func (c *Client) call(fn func(service *calendar.Service) error) error {
+	n := len(c.credentials)
+	start := int(c.active.Load())
+	now := time.Now()
+
+	var lastErr error
+	tried := 0
+	for i := 0; i < n; i++ {
+		idx := (start + i) % n
+		cred := c.credentials[idx]
+		if !cred.healthy(now) && !(i == n-1 && tried == 0) {
+			continue
+		}
+		tried++
+
+		err := fn(cred.service)
+		if err == nil {
+			cred.markHealthy()
+			if idx != start {
+				c.active.Store(int64(idx))
+				log.Printf("Google 日曆改用服務帳號 %s", cred.email)
+			}
+			return nil
+		}
+
+		kind := ClassifyError(err)
+		if kind != ErrorKindQuota && kind != ErrorKindPermission {
+			return err
+		}
+		cred.markUnhealthy(kind, err)
+		if n > 1 {
+			log.Printf("服務帳號 %s 發生 %s 錯誤，暫停使用: %v", cred.email, kind, err)
+		}
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// CredentialStatuses 返回所有服務帳號的健康狀態
+func (c *Client) CredentialStatuses() []CredentialStatus {
+	now := time.Now()
+	active := int(c.active.Load())
+
+	statuses := make([]CredentialStatus, len(c.credentials))
+	for i, cred := range c.credentials {
+		statuses[i] = cred.status(now)
+		statuses[i].Active = i == active
+	}
+	return statuses
+}
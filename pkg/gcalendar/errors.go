@@ -0,0 +1,129 @@
+package gcalendar
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/httpclient"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrorKind 代表 Google 日曆 API 錯誤的分類
+type ErrorKind string
+
+const (
+	// ErrorKindNone 表示非 Google API 錯誤或未分類的錯誤
+	ErrorKindNone ErrorKind = ""
+	// ErrorKindQuota 表示配額用盡或請求頻率過高
+	ErrorKindQuota ErrorKind = "quota"
+	// ErrorKindPermission 表示服務帳號沒有日曆的存取權限
+	ErrorKindPermission ErrorKind = "permission"
+	// ErrorKindUnavailable 表示 Google 日曆暫時無法使用（伺服器錯誤或無法連線）
+	ErrorKindUnavailable ErrorKind = "unavailable"
+	// ErrorKindBudget 表示本服務設定的每日寫入額度已用完
+	ErrorKindBudget ErrorKind = "budget"
+)
+
+// Deferrable 判斷此類錯誤的同步操作是否應暫存，待日曆恢復或額度重置後補送
+func (k ErrorKind) Deferrable() bool {
+	return k == ErrorKindUnavailable || k == ErrorKindBudget
+}
+
+// quotaReasons 代表配額相關的錯誤原因
+var quotaReasons = map[string]bool{
+	"usageLimits":           true,
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+	"quotaExceeded":         true,
+	"dailyLimitExceeded":    true,
+}
+
+// ClassifyError 判斷錯誤是否為配額、權限或服務無法使用的問題
+func ClassifyError(err error) ErrorKind {
+	if errors.Is(err, ErrBudgetExhausted) {
+		return ErrorKindBudget
+	}
+
+	// 取得存取權杖失敗（例如金鑰被撤銷或停用）視為權限問題
+	var tokenErr *oauth2.RetrieveError
+	if errors.As(err, &tokenErr) && tokenErr.Response != nil && tokenErr.Response.StatusCode < http.StatusInternalServerError {
+		return ErrorKindPermission
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		// 連線到 Google API 失敗（逾時、DNS、連線被拒）視為服務無法使用
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && strings.Contains(urlErr.URL, "googleapis.com") {
+			return ErrorKindUnavailable
+		}
+		return ErrorKindNone
+	}
+
+	for _, item := range apiErr.Errors {
+		if quotaReasons[item.Reason] {
+			return ErrorKindQuota
+		}
+	}
+
+	switch apiErr.Code {
+	case http.StatusTooManyRequests:
+		return ErrorKindQuota
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return ErrorKindPermission
+	}
+	if apiErr.Code >= http.StatusInternalServerError {
+		return ErrorKindUnavailable
+	}
+
+	return ErrorKindNone
+}
+
+// RetryAfter 返回配額錯誤響應中 Google 要求的等待時間，其他錯誤或未提供時返回 0
+func RetryAfter(err error) time.Duration {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || ClassifyError(err) != ErrorKindQuota {
+		return 0
+	}
+	return httpclient.RetryAfter(apiErr.Header, time.Now())
+}
+
+// RemediationHint 返回對應錯誤分類的處理建議
+func RemediationHint(kind ErrorKind) string {
+	switch kind {
+	case ErrorKindQuota:
+		return "請至 Google Cloud Console > API 和服務 > 配額 檢查 Calendar API 用量，必要時申請提高配額或降低對帳頻率"
+	case ErrorKindPermission:
+		return "請確認服務帳號仍被共用至此日曆並具備「變更活動」權限，且服務帳號金鑰未被停用"
+	case ErrorKindBudget:
+		return "今日的寫入額度已用完，同步操作已暫存並會在額度重置（太平洋時間午夜）後自動補送；若經常用完，請調高 GOOGLE_CALENDAR_DAILY_WRITES 或申請提高 Google 配額"
+	case ErrorKindUnavailable:
+		return "Google 日曆暫時無法使用，同步操作已暫存並會在服務恢復後自動補送，可至 Google Workspace 狀態資訊主頁確認"
+	}
+	return ""
+}
+
+// CalendarID 返回客戶端使用的日曆 ID
+func (c *Client) CalendarID() string {
+	return c.calendarID
+}
+
+// Ping 讀取預設日曆的資訊，用於確認 Google 日曆 API 是否可用
+func (c *Client) Ping() error {
+	metrics.ObserveGoogleAPICall(c.calendarID, "calendars.get")
+	err := c.call(func(service *calendar.Service) error {
+		_, err := service.Calendars.Get(c.calendarID).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("讀取日曆資訊失敗: %w", err)
+	}
+	return nil
+}
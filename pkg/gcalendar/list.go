@@ -0,0 +1,97 @@
+package gcalendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ErrSyncTokenExpired 表示同步令牌已失效（HTTP 410），需捨棄令牌並重新完整列出
+var ErrSyncTokenExpired = errors.New("日曆同步令牌已失效")
+
+// ListOptions 是 ListEvents 的可選設定
+type ListOptions struct {
+	CalendarID string // 空字串表示使用預設日曆
+
+	// SyncToken 上次列出返回的 NextSyncToken，設置時只返回之後變更的事件（含已刪除事件），
+	// Google 不允許同時指定時間範圍
+	SyncToken string
+
+	UpdatedMin   time.Time // 只返回此時間之後變更的事件，零值表示不限制
+	SingleEvents bool      // 展開重複事件
+	ShowDeleted  bool      // 包含已刪除的事件（Status 為 cancelled）
+	PageSize     int64     // 每頁筆數，預設 250
+}
+
+// EventList 是 ListEvents 的結果
+type EventList struct {
+	Events []*CalendarEvent
+	// NextSyncToken 供下次增量列出使用，只在列出最後一頁時由 Google 返回
+	NextSyncToken string
+}
+
+// ListEvents 逐頁列出時間範圍內的事件並返回全部結果，timeMin 或 timeMax 為零值時不限制該端；
+// 同步令牌失效時返回包裝 ErrSyncTokenExpired 的錯誤
+func (c *Client) ListEvents(ctx context.Context, timeMin, timeMax time.Time, opts ListOptions) (*EventList, error) {
+	if opts.SyncToken != "" && (!timeMin.IsZero() || !timeMax.IsZero() || !opts.UpdatedMin.IsZero()) {
+		return nil, errors.New("同步令牌不可與時間範圍同時使用")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 250
+	}
+	calendarID := c.ResolveCalendar(opts.CalendarID)
+
+	result := &EventList{}
+	var pageToken string
+	for {
+		metrics.ObserveGoogleAPICall(calendarID, "events.list")
+		var events *calendar.Events
+		err := c.call(func(service *calendar.Service) error {
+			call := service.Events.List(calendarID).
+				SingleEvents(opts.SingleEvents).
+				ShowDeleted(opts.ShowDeleted).
+				MaxResults(opts.PageSize).
+				PageToken(pageToken).
+				Context(ctx)
+			if opts.SyncToken != "" {
+				call = call.SyncToken(opts.SyncToken)
+			}
+			if !timeMin.IsZero() {
+				call = call.TimeMin(timeMin.Format(time.RFC3339))
+			}
+			if !timeMax.IsZero() {
+				call = call.TimeMax(timeMax.Format(time.RFC3339))
+			}
+			if !opts.UpdatedMin.IsZero() {
+				call = call.UpdatedMin(opts.UpdatedMin.Format(time.RFC3339))
+			}
+
+			var err error
+			events, err = call.Do()
+			return err
+		})
+		if err != nil {
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusGone {
+				return nil, fmt.Errorf("列出日曆 %s 的事件失敗: %w", calendarID, ErrSyncTokenExpired)
+			}
+			return nil, fmt.Errorf("列出日曆 %s 的事件失敗: %w", calendarID, err)
+		}
+
+		for _, item := range events.Items {
+			result.Events = append(result.Events, toCalendarEvent(calendarID, item))
+		}
+
+		if events.NextPageToken == "" {
+			result.NextSyncToken = events.NextSyncToken
+			return result, nil
+		}
+		pageToken = events.NextPageToken
+	}
+}
@@ -0,0 +1,48 @@
+package gcalendar
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// bookingCodeMarker 匹配事件描述中的預約編號標記，例如 [SB:1ab2c3d4]
+var bookingCodeMarker = regexp.MustCompile(`\[SB:([^\]\s]+)\]`)
+
+// BookingCodeMarker 返回寫入事件描述的預約編號標記，標記可與其他文字並存而不影響解析
+func BookingCodeMarker(code string) string {
+	return "[SB:" + code + "]"
+}
+
+// ParseBookingCode 從事件描述中取出預約編號標記的編號，沒有標記時返回 false
+func ParseBookingCode(description string) (string, bool) {
+	m := bookingCodeMarker.FindStringSubmatch(description)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// DescriptionHasBookingCode 判斷事件描述是否屬於指定的預約：有標記時比對標記中的編號，
+// 沒有標記時（標記啟用前建立的事件）比對描述中的完整單字，避免編號只是其他文字的一部分時誤判
+func DescriptionHasBookingCode(description, code string) bool {
+	if code == "" {
+		return false
+	}
+	if marked, ok := ParseBookingCode(description); ok {
+		return marked == code
+	}
+	for _, word := range DescriptionWords(description) {
+		if word == code {
+			return true
+		}
+	}
+	return false
+}
+
+// DescriptionWords 將事件描述以字母與數字以外的字元切分為單字，供沒有標記的描述比對預約編號
+func DescriptionWords(description string) []string {
+	return strings.FieldsFunc(description, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
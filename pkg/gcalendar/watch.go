@@ -0,0 +1,66 @@
+package gcalendar
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"google.golang.org/api/calendar/v3"
+)
+
+// WatchChannel 代表日曆事件的推播通知頻道
+type WatchChannel struct {
+	ID         string
+	ResourceID string
+	CalendarID string
+	Expiration time.Time
+}
+
+// Watch 訂閱日曆事件的變更通知，Google 會在事件變更時 POST 到 address，
+// 並在 X-Goog-Channel-Token 標頭帶上 token；calendarID 為空時使用預設日曆
+func (c *Client) Watch(calendarID, channelID, address, token string, ttl time.Duration) (*WatchChannel, error) {
+	calendarID = c.ResolveCalendar(calendarID)
+	metrics.ObserveGoogleAPICall(calendarID, "events.watch")
+
+	request := &calendar.Channel{
+		Id:      channelID,
+		Type:    "web_hook",
+		Address: address,
+		Token:   token,
+	}
+	if ttl > 0 {
+		request.Params = map[string]string{"ttl": fmt.Sprintf("%d", int64(ttl.Seconds()))}
+	}
+
+	var channel *calendar.Channel
+	err := c.call(func(service *calendar.Service) error {
+		var err error
+		channel, err = service.Events.Watch(calendarID, request).Do()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("訂閱日曆 %s 的變更通知失敗: %w", calendarID, err)
+	}
+
+	return &WatchChannel{
+		ID:         channel.Id,
+		ResourceID: channel.ResourceId,
+		CalendarID: calendarID,
+		Expiration: time.UnixMilli(channel.Expiration),
+	}, nil
+}
+
+// StopWatch 停止推播通知頻道
+func (c *Client) StopWatch(channel *WatchChannel) error {
+	metrics.ObserveGoogleAPICall(channel.CalendarID, "channels.stop")
+	err := c.call(func(service *calendar.Service) error {
+		return service.Channels.Stop(&calendar.Channel{
+			Id:         channel.ID,
+			ResourceId: channel.ResourceID,
+		}).Do()
+	})
+	if err != nil {
+		return fmt.Errorf("停止通知頻道 %s 失敗: %w", channel.ID, err)
+	}
+	return nil
+}
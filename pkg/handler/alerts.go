@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+)
+
+// calendarAlerter 在日曆配額或權限錯誤時通知維運人員，並限制相同類型告警的頻率
+type calendarAlerter struct {
+	mu   sync.Mutex
+	last map[gcalendar.ErrorKind]time.Time
+}
+
+// newCalendarAlerter 創建新的日曆告警器
+func newCalendarAlerter() *calendarAlerter {
+	return &calendarAlerter{
+		last: make(map[gcalendar.ErrorKind]time.Time),
+	}
+}
+
+// shouldAlert 判斷是否已超過冷卻時間，可再次發送相同類型的告警
+func (a *calendarAlerter) shouldAlert(kind gcalendar.ErrorKind, cooldown time.Duration) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := a.last[kind]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+
+	a.last[kind] = now
+	return true
+}
+
+// alertOnCalendarError 在同步錯誤為日曆配額、權限或服務中斷問題時立即通知維運人員
+func (h *WebhookHandler) alertOnCalendarError(bookingID, eventLink string, syncErr error) {
+	kind := gcalendar.ClassifyError(syncErr)
+	if kind == gcalendar.ErrorKindNone || h.opts.OpsNotifier == nil {
+		return
+	}
+
+	if !h.alerter.shouldAlert(kind, h.opts.AlertCooldown) {
+		return
+	}
+
+	calendarID := h.calendarClient.CalendarID()
+	subject := fmt.Sprintf("Google 日曆%s錯誤: %s", alertLabel(kind), calendarID)
+	body := fmt.Sprintf("日曆: %s\n預約 ID: %s\n錯誤: %v\n\n處理建議: %s",
+		calendarID, bookingID, syncErr, gcalendar.RemediationHint(kind))
+	if eventLink != "" {
+		body += "\n\n日曆事件: " + eventLink
+	}
+
+	if err := h.opts.OpsNotifier.Notify(subject, body); err != nil {
+		log.Printf("發送日曆告警失敗: %v", err)
+	}
+}
+
+// alertLabel 返回錯誤分類的顯示名稱
+func alertLabel(kind gcalendar.ErrorKind) string {
+	switch kind {
+	case gcalendar.ErrorKindQuota:
+		return "配額"
+	case gcalendar.ErrorKindPermission:
+		return "權限"
+	case gcalendar.ErrorKindUnavailable:
+		return "服務中斷"
+	case gcalendar.ErrorKindBudget:
+		return "寫入額度"
+	}
+	return string(kind)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+)
+
+// ErrNotPending 表示預約不是待核准狀態（已核准、已拒絕或平台未啟用核准流程）
+var ErrNotPending = errors.New("預約不是待核准狀態")
+
+// ApprovalRequester 在待核准的預約建立日曆事件後通知服務提供者（例如附有按鈕的 Slack 訊息）
+type ApprovalRequester interface {
+	RequestApproval(bookingID, text string) error
+}
+
+// DecideBooking 在預約平台核准或拒絕待核准的預約，成功後立即更新日曆事件，
+// 不等待平台的 webhook：核准時事件轉為一般事件，拒絕時刪除事件
+func (h *WebhookHandler) DecideBooking(bookingID string, approve bool) error {
+	approver, ok := h.sourceFor(bookingID).(source.Approver)
+	if !ok {
+		return fmt.Errorf("預約平台不支援核准預約")
+	}
+
+	h.bookings.Remove(bookingID)
+	booking, err := h.fetchBooking(bookingID)
+	if err != nil {
+		return fmt.Errorf("獲取預約詳情失敗: %w", err)
+	}
+	if !booking.PendingApproval() {
+		return fmt.Errorf("%w: %s", ErrNotPending, booking.Status)
+	}
+
+	_, id := source.SplitBookingID(bookingID)
+	trigger, action, verb := "approve", "change", "核准"
+	if approve {
+		err = approver.ApproveBooking(id)
+	} else {
+		trigger, action, verb = "decline", "cancel", "拒絕"
+		err = approver.DeclineBooking(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("已%s預約 %s", verb, bookingID)
+	return h.resync(trigger, action, bookingID)
+}
+
+// requestApproval 待核准的預約建立日曆事件後通知服務提供者，失敗時僅記錄日誌
+func (h *WebhookHandler) requestApproval(s *SyncContext) {
+	if h.opts.Approvals == nil || s.Action != "create" || s.Event == nil || !s.Booking.PendingApproval() {
+		return
+	}
+
+	text := fmt.Sprintf("預約 %s 等待核准：%s／%s，%s",
+		s.Booking.Code, s.Booking.ServiceName, s.Booking.ProviderName, s.Booking.StartTime.Time.Format("2006-01-02 15:04"))
+	if err := h.opts.Approvals.RequestApproval(s.BookingID, text); err != nil {
+		log.Printf("發送預約 %s 的核准請求失敗: %v", s.BookingID, err)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// filterAttendees 移除格式錯誤或在停止邀請清單中的參與者地址，避免反覆邀請無效或曾退信、檢舉的地址；
+// 地址被移除時事件照常同步
+func (h *WebhookHandler) filterAttendees(bookingID string, event *gcalendar.CalendarEvent) error {
+	if len(event.Attendees) == 0 {
+		return nil
+	}
+
+	var kept []string
+	for _, email := range event.Attendees {
+		if !validEmail(email) {
+			log.Printf("預約 %s 的客戶地址格式錯誤，不加為事件參與者", bookingID)
+			metrics.AttendeesDropped.WithLabelValues("invalid").Inc()
+			continue
+		}
+
+		suppressed, err := h.store.GetSuppressedEmail(email)
+		if err != nil {
+			return fmt.Errorf("讀取停止邀請清單失敗: %w", err)
+		}
+		if suppressed != nil {
+			log.Printf("預約 %s 的客戶地址在停止邀請清單中（%s），不加為事件參與者", bookingID, suppressed.Reason)
+			metrics.AttendeesDropped.WithLabelValues("suppressed").Inc()
+			continue
+		}
+		kept = append(kept, store.NormalizeEmail(email))
+	}
+	event.Attendees = kept
+	return nil
+}
+
+// validEmail 判斷字串是否為單一、不含顯示名稱的電子郵件地址，且網域至少包含一個點
+func validEmail(email string) bool {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return false
+	}
+
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	return at > 0 && strings.Contains(domain, ".") &&
+		!strings.HasPrefix(domain, ".") && !strings.HasSuffix(domain, ".") && !strings.Contains(domain, "..")
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/audit"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// forwardAudit 將同步記錄轉送到稽核事件接收端；只帶出變更的欄位名稱，不轉送客戶資料
+func (h *WebhookHandler) forwardAudit(record *store.SyncRecord) {
+	if h.opts.Audit == nil {
+		return
+	}
+
+	sourceName := h.bookingSource(record.BookingID)
+	event := &audit.Event{
+		Time:      time.Now(),
+		Tenant:    h.tenantOf(sourceName),
+		Source:    sourceName,
+		BookingID: record.BookingID,
+		Action:    record.Action,
+		EventID:   record.EventID,
+		Success:   record.Success,
+		ErrorKind: record.ErrorKind,
+		Error:     record.Error,
+	}
+	for _, change := range record.Changes {
+		event.ChangedFields = append(event.ChangedFields, change.Field)
+	}
+	h.opts.Audit.Send(event)
+}
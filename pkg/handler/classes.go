@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
+)
+
+// applyClass 將團體課程的預約寫入同一時段共用的課程事件，取代個別預約的事件：
+// 加入時課程事件的人數加一，取消、改期或換服務提供者時退出原本的課程事件，最後一位參與者退出時刪除課程事件。
+// 預約的對應關係指向課程事件；課程事件不另建交通與資源占用事件
+func (h *WebhookHandler) applyClass(s *SyncContext, capacity int) error {
+	if s.Action == "cancel" {
+		if s.EventID == "" {
+			log.Printf("未找到預約 %s 的課程事件", s.BookingID)
+			return nil
+		}
+		if err := h.leaveClass(s.CalendarID, s.EventID, s.BookingID); err != nil {
+			return err
+		}
+		if err := h.store.DeleteMapping(s.BookingID); err != nil {
+			return fmt.Errorf("刪除事件對應關係失敗: %w", err)
+		}
+		return nil
+	}
+	// 事件已存在的 create 沒有重新渲染，預約已在課程中
+	if s.Event == nil {
+		return nil
+	}
+
+	event := h.renderer.ClassEvent(s.Booking, s.Event)
+	key := event.Properties[gcalendar.PropertyClass]
+	var joined *gcalendar.CalendarEvent
+	err := h.withClassLock(key, func() error {
+		var err error
+		joined, err = h.joinClass(s, event, capacity)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 原本的事件不是目前的課程事件時（改期、換服務提供者或原本是個別事件），退出原本的事件
+	if s.EventID != "" && s.EventID != joined.ID {
+		if err := h.leaveClass(s.CalendarID, s.EventID, s.BookingID); err != nil {
+			return err
+		}
+	}
+	s.EventID = joined.ID
+	_, err = h.saveMapping(s.Booking, joined.ID, joined.CalendarID, joined.HTMLLink, s.BookingID)
+	return err
+}
+
+// leaveFormerClass 在服務不再是團體課程時，將預約退出原本共用的課程事件並刪除指向課程事件的對應關係，
+// 之後預約改以個別事件同步
+func (h *WebhookHandler) leaveFormerClass(s *SyncContext) error {
+	if err := h.leaveClass(s.CalendarID, s.EventID, s.BookingID); err != nil {
+		return err
+	}
+	if err := h.store.DeleteMapping(s.BookingID); err != nil {
+		return fmt.Errorf("刪除事件對應關係失敗: %w", err)
+	}
+	log.Printf("預約 %s 的服務已不是團體課程，已退出課程事件 %s", s.BookingID, s.EventID)
+	s.EventID, s.EventLink = "", ""
+	return nil
+}
+
+// joinClass 將預約加入課程事件，時段尚無課程事件時以 event 建立
+func (h *WebhookHandler) joinClass(s *SyncContext, event *gcalendar.CalendarEvent, capacity int) (*gcalendar.CalendarEvent, error) {
+	current, err := h.findClassEvent(event.CalendarID, event.Properties[gcalendar.PropertyClass])
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if current != nil {
+		ids, _ = render.ClassParticipants(current)
+		// 沿用課程事件既有的標題（可能經過手動修改），只更新結尾的人數
+		event.Summary = current.Summary
+	}
+	// 已在課程中的預約仍重寫事件，讓客戶更名反映在參與者名單
+	joined := false
+	for _, id := range ids {
+		joined = joined || id == s.BookingID
+	}
+	if !joined {
+		ids = append(ids, s.BookingID)
+	}
+	if capacity > 0 && len(ids) > capacity {
+		log.Printf("警告: 課程 %s 的參與者 %d 人超過上限 %d", event.Summary, len(ids), capacity)
+	}
+
+	names, err := h.classNames(ids, s)
+	if err != nil {
+		return nil, err
+	}
+	h.stampEvent(s.BookingID, s.Booking, event)
+	render.SetClassParticipants(event, ids, names, capacity)
+
+	if current != nil {
+		if err := h.calendarClient.UpdateEvent(current.ID, event); err != nil {
+			return nil, fmt.Errorf("更新課程事件失敗: %w", err)
+		}
+		log.Printf("預約 %s 加入課程事件 %s（%d 人）", s.BookingID, current.ID, len(ids))
+		event.ID, event.HTMLLink = current.ID, current.HTMLLink
+		return event, nil
+	}
+
+	event.ID, err = h.calendarClient.CreateEvent(event)
+	if err != nil {
+		return nil, fmt.Errorf("創建課程事件失敗: %w", err)
+	}
+	log.Printf("為預約 %s 創建了課程事件 %s", s.BookingID, event.ID)
+	return event, nil
+}
+
+// leaveClass 將預約從課程事件移除並更新人數，最後一位參與者退出時刪除課程事件；
+// 事件不是課程事件時（例如設為團體課程前建立的個別事件）直接刪除
+func (h *WebhookHandler) leaveClass(calendarID, eventID, bookingID string) error {
+	current, err := h.calendarClient.GetEvent(calendarID, eventID)
+	if err != nil {
+		return fmt.Errorf("獲取課程事件失敗: %w", err)
+	}
+	if current.Cancelled() {
+		return nil
+	}
+	key := current.Properties[gcalendar.PropertyClass]
+	if key == "" {
+		if err := h.calendarClient.DeleteEvent(current.CalendarID, eventID); err != nil {
+			return fmt.Errorf("刪除日曆事件失敗: %w", err)
+		}
+		log.Printf("已刪除預約 %s 加入課程前的日曆事件 %s", bookingID, eventID)
+		return nil
+	}
+
+	return h.withClassLock(key, func() error {
+		// 取得鎖後重新讀取，避免覆蓋其他預約同時寫入的參與者
+		current, err := h.calendarClient.GetEvent(calendarID, eventID)
+		if err != nil {
+			return fmt.Errorf("獲取課程事件失敗: %w", err)
+		}
+		ids, capacity := render.ClassParticipants(current)
+		remaining := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if id != bookingID {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) == len(ids) {
+			return nil
+		}
+
+		if len(remaining) == 0 {
+			if err := h.calendarClient.DeleteEvent(current.CalendarID, eventID); err != nil {
+				return fmt.Errorf("刪除課程事件失敗: %w", err)
+			}
+			log.Printf("預約 %s 是課程事件 %s 的最後一位參與者，已刪除課程事件", bookingID, eventID)
+			return nil
+		}
+
+		names, err := h.classNames(remaining, nil)
+		if err != nil {
+			return err
+		}
+		render.SetClassParticipants(current, remaining, names, capacity)
+		if err := h.calendarClient.UpdateEvent(eventID, current); err != nil {
+			return fmt.Errorf("更新課程事件失敗: %w", err)
+		}
+		log.Printf("預約 %s 退出課程事件 %s（剩 %d 人）", bookingID, eventID, len(remaining))
+		return nil
+	})
+}
+
+// findClassEvent 在日曆中查找課程時段的事件，略過已刪除與其他租戶的事件
+func (h *WebhookHandler) findClassEvent(calendarID, key string) (*gcalendar.CalendarEvent, error) {
+	events, err := h.calendarClient.FindEventsByProperty(calendarID, gcalendar.PropertyClass, key)
+	if err != nil {
+		return nil, fmt.Errorf("查找課程事件失敗: %w", err)
+	}
+	for _, event := range events {
+		if !event.Cancelled() && !h.foreignEvent(event) {
+			return event, nil
+		}
+	}
+	return nil, nil
+}
+
+// classNames 返回參與者的姓名；同步中的預約使用最新的客戶姓名，其他預約取自對應關係
+func (h *WebhookHandler) classNames(ids []string, s *SyncContext) ([]string, error) {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		if s != nil && id == s.BookingID {
+			names[i] = s.Booking.Client.Name
+			continue
+		}
+		mapping, err := h.store.GetMapping(id)
+		if err != nil {
+			return nil, fmt.Errorf("讀取事件對應關係失敗: %w", err)
+		}
+		if mapping != nil {
+			names[i] = mapping.ClientName
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/fake"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+)
+
+// newClassTestHandler 創建將服務 3 設為團體課程的同步處理器
+func newClassTestHandler(t *testing.T, server *fake.Server) *WebhookHandler {
+	t.Helper()
+	r, err := render.New(render.Options{GroupClasses: map[string]int{"3": 8}})
+	if err != nil {
+		t.Fatalf("初始化渲染器失敗: %v", err)
+	}
+	return newTestHandler(t, server, Options{Renderer: r})
+}
+
+// processWithin 在限定時間內處理 webhook 事件，逾時視為死結
+func processWithin(h *WebhookHandler, action, bookingID string) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- h.processWebhookEvent(&source.Event{Source: "simplybook", Action: action, BookingID: bookingID, Time: time.Now()})
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		return fmt.Errorf("處理預約 %s 的 %s webhook 逾時，可能發生死結", bookingID, action)
+	}
+}
+
+// classParticipants 返回預約所在課程事件的參與者
+func classParticipants(t *testing.T, h *WebhookHandler, bookingID string) []string {
+	t.Helper()
+	m, err := h.store.GetMapping(bookingID)
+	if err != nil || m == nil {
+		t.Fatalf("讀取預約 %s 的對應關係失敗: %v, %v", bookingID, m, err)
+	}
+	event, err := h.calendarClient.GetEvent(testCalendarID, m.EventID)
+	if err != nil {
+		t.Fatalf("獲取課程事件失敗: %v", err)
+	}
+	ids, _ := render.ClassParticipants(event)
+	return ids
+}
+
+// 預約識別碼與課程鍵落在同一個分片時，加入與退出課程仍不可自我死結
+func TestClassLockSameShardAsBooking(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	server := fake.NewServer()
+	defer server.Close()
+	h := newClassTestHandler(t, server)
+
+	// 模擬伺服器以開始時間的時區輸出不帶時區的時間，客戶端以台灣時間解析，課程鍵才會與渲染器一致
+	start := time.Now().In(time.FixedZone("GMT+8", 8*60*60)).Add(48 * time.Hour).Truncate(time.Hour)
+	key := "class:3/1/" + start.UTC().Format("20060102T150405Z")
+	bookingID := ""
+	for i := 0; i < 10*bookingLockShards && bookingID == ""; i++ {
+		id := strconv.Itoa(server.SimplyBook.Add(fake.Booking{Start: start, ServiceID: 3, ProviderID: 1, ClientName: "同分片學員", ClientEmail: "shard@example.com"}))
+		if h.bookingLocks.get(id) == h.bookingLocks.get(key) {
+			bookingID = id
+		}
+	}
+	if bookingID == "" {
+		t.Fatalf("找不到與課程鍵 %s 落在同一分片的預約", key)
+	}
+
+	if err := processWithin(h, "create", bookingID); err != nil {
+		t.Fatalf("加入課程失敗: %v", err)
+	}
+	if ids := classParticipants(t, h, bookingID); len(ids) != 1 || ids[0] != bookingID {
+		t.Fatalf("課程參與者應為 [%s]，實際 %v", bookingID, ids)
+	}
+
+	n, _ := strconv.Atoi(bookingID)
+	server.SimplyBook.Update(n, func(b *fake.Booking) { b.Status = "canceled" })
+	if err := processWithin(h, "cancel", bookingID); err != nil {
+		t.Fatalf("退出課程失敗: %v", err)
+	}
+	if events := server.Calendar.Events(testCalendarID); len(events) != 0 {
+		t.Fatalf("最後一位參與者退出後應刪除課程事件，實際剩 %d 個事件", len(events))
+	}
+}
+
+// 多個預約同時加入與退出同一堂課時不可死結，且參與者名單不可遺失
+func TestConcurrentClassJoinLeave(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	server := fake.NewServer()
+	defer server.Close()
+	h := newClassTestHandler(t, server)
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	ids := make([]string, 6)
+	for i := range ids {
+		ids[i] = strconv.Itoa(server.SimplyBook.Add(fake.Booking{
+			Start:       start,
+			ServiceID:   3,
+			ProviderID:  1,
+			ClientName:  "學員" + strconv.Itoa(i),
+			ClientEmail: "student" + strconv.Itoa(i) + "@example.com",
+		}))
+	}
+
+	// run 同時處理多個預約的 webhook，返回第一個錯誤
+	run := func(action string, bookingIDs []string) error {
+		var wg sync.WaitGroup
+		errs := make(chan error, len(bookingIDs))
+		for _, id := range bookingIDs {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				errs <- processWithin(h, action, id)
+			}(id)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 前三位先加入，之後其餘三位加入的同時前三位退出
+	if err := run("create", ids[:3]); err != nil {
+		t.Fatalf("加入課程失敗: %v", err)
+	}
+	for _, id := range ids[:3] {
+		n, _ := strconv.Atoi(id)
+		server.SimplyBook.Update(n, func(b *fake.Booking) { b.Status = "canceled" })
+	}
+	joinErr := make(chan error, 1)
+	go func() { joinErr <- run("create", ids[3:]) }()
+	if err := run("cancel", ids[:3]); err != nil {
+		t.Fatalf("退出課程失敗: %v", err)
+	}
+	if err := <-joinErr; err != nil {
+		t.Fatalf("加入課程失敗: %v", err)
+	}
+
+	if events := server.Calendar.Events(testCalendarID); len(events) != 1 {
+		t.Fatalf("日曆應只有 1 個課程事件，實際 %d 個", len(events))
+	}
+	got := classParticipants(t, h, ids[3])
+	want := map[string]bool{ids[3]: true, ids[4]: true, ids[5]: true}
+	if len(got) != len(want) {
+		t.Fatalf("課程參與者應為 %v，實際 %v", ids[3:], got)
+	}
+	for _, id := range got {
+		if !want[id] {
+			t.Fatalf("課程參與者應為 %v，實際 %v", ids[3:], got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// debounceResult 是 debouncer 對 webhook 的處理結果
+type debounceResult int
+
+const (
+	debounceNone      debounceResult = iota // 未延後，應立即處理
+	debounceScheduled                       // 開始新的窗口，窗口結束時處理
+	debounceMerged                          // 已合併到同一預約尚未處理的事件
+)
+
+// debouncer 合併同一預約在時間窗口內的多個 webhook，窗口結束時只同步一次，
+// 避免客戶反覆修改預約時連續改寫日曆
+type debouncer struct {
+	window time.Duration
+	fire   func(payload *source.Event)
+
+	mu      sync.Mutex
+	pending map[string]*debouncedEvent
+}
+
+// debouncedEvent 是等待窗口結束的事件
+type debouncedEvent struct {
+	payload source.Event
+	timer   *time.Timer
+	merged  int // 已合併的 webhook 數量
+}
+
+// newDebouncer 創建 debouncer，窗口結束時以合併後的事件呼叫 fire
+func newDebouncer(window time.Duration, fire func(payload *source.Event)) *debouncer {
+	return &debouncer{
+		window:  window,
+		fire:    fire,
+		pending: make(map[string]*debouncedEvent),
+	}
+}
+
+// add 處理新的 webhook：同一預約已有等待中的事件時合併操作類型（取消優先，建立維持建立）；
+// 否則 change 開始新的窗口，create 與 cancel 立即處理
+func (d *debouncer) add(payload *source.Event) debounceResult {
+	action := strings.ToLower(payload.Action)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if event, ok := d.pending[payload.BookingID]; ok {
+		event.payload.Action = store.MergeAction(event.payload.Action, action)
+		// 平台可能不按順序送達，只保留較新的事件時間
+		if payload.Time.IsZero() || !payload.Time.Before(event.payload.Time) {
+			event.payload.Timestamp = payload.Timestamp
+			event.payload.Time = payload.Time
+		}
+		event.merged++
+		return debounceMerged
+	}
+
+	if action != "change" {
+		return debounceNone
+	}
+
+	event := &debouncedEvent{payload: *payload}
+	event.payload.Action = action
+	event.timer = time.AfterFunc(d.window, func() {
+		d.release(payload.BookingID, event)
+	})
+	d.pending[payload.BookingID] = event
+	return debounceScheduled
+}
+
+// release 在窗口結束時移除並處理事件
+func (d *debouncer) release(bookingID string, event *debouncedEvent) {
+	d.mu.Lock()
+	if d.pending[bookingID] != event {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, bookingID)
+	d.mu.Unlock()
+
+	if event.merged > 0 {
+		log.Printf("預約 %s 在 %s 內的 %d 個 webhook 已合併為一次 %s 操作", bookingID, d.window, event.merged+1, event.payload.Action)
+	}
+	d.fire(&event.payload)
+}
+
+// flush 立即處理所有等待中的事件，用於關閉服務前
+func (d *debouncer) flush() {
+	d.mu.Lock()
+	events := make(map[string]*debouncedEvent, len(d.pending))
+	for bookingID, event := range d.pending {
+		if event.timer.Stop() {
+			events[bookingID] = event
+		}
+	}
+	d.mu.Unlock()
+
+	for bookingID, event := range events {
+		d.release(bookingID, event)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// diffEvents 比較現有事件與新事件的欄位，返回有變更的欄位；
+// currentCalendarID 為對應關係中記錄的日曆，空字串表示預設日曆
+func diffEvents(current, next *gcalendar.CalendarEvent, currentCalendarID string) []store.FieldChange {
+	var changes []store.FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, store.FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("calendar", currentCalendarID, next.CalendarID)
+	add("summary", current.Summary, next.Summary)
+	add("start", formatTime(current.StartTime), formatTime(next.StartTime))
+	add("end", formatTime(current.EndTime), formatTime(next.EndTime))
+	add("description", current.Description, next.Description)
+	add("location", current.Location, next.Location)
+	add("color", current.ColorID, next.ColorID)
+	add("attendees", strings.Join(current.Attendees, ","), strings.Join(next.Attendees, ","))
+
+	return changes
+}
+
+// formatTime 以固定格式表示時間，零值返回空字串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"google.golang.org/api/googleapi"
+)
+
+// 同步錯誤的分類，供支援人員分流
+const (
+	ErrorKindAuth        = "auth"        // 憑證失效或沒有權限
+	ErrorKindRateLimit   = "rate_limit"  // 配額用盡或請求過於頻繁
+	ErrorKindNotFound    = "not_found"   // 預約或日曆事件不存在
+	ErrorKindValidation  = "validation"  // 預約資料不合理，已寫入死信
+	ErrorKindUnavailable = "unavailable" // 外部服務暫時無法使用
+	ErrorKindConflict    = "conflict"    // 日曆與預約平台的備註同時被修改
+	ErrorKindOther       = "other"
+)
+
+// ClassifyError 依錯誤來源判斷同步錯誤的分類，nil 返回空字串
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var validationErr *BookingValidationError
+	if errors.As(err, &validationErr) {
+		return ErrorKindValidation
+	}
+
+	var conflictErr *NotesConflictError
+	if errors.As(err, &conflictErr) {
+		return ErrorKindConflict
+	}
+
+	switch gcalendar.ClassifyError(err) {
+	case gcalendar.ErrorKindPermission:
+		return ErrorKindAuth
+	case gcalendar.ErrorKindQuota, gcalendar.ErrorKindBudget:
+		return ErrorKindRateLimit
+	case gcalendar.ErrorKindUnavailable:
+		return ErrorKindUnavailable
+	}
+
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) && (googleErr.Code == http.StatusNotFound || googleErr.Code == http.StatusGone) {
+		return ErrorKindNotFound
+	}
+
+	if apiErr, ok := simplybook.AsAPIError(err); ok {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return ErrorKindAuth
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return ErrorKindRateLimit
+		case apiErr.StatusCode == http.StatusNotFound:
+			return ErrorKindNotFound
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return ErrorKindUnavailable
+		}
+	}
+
+	return ErrorKindOther
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/errreport"
+)
+
+// reportError 將同步階段的失敗回報到錯誤追蹤服務，附上預約、租戶與階段
+func (h *WebhookHandler) reportError(stage, bookingID string, err error) {
+	if h.opts.Errors == nil {
+		return
+	}
+
+	sourceName := h.bookingSource(bookingID)
+	h.opts.Errors.Report(&errreport.Report{
+		Time:      time.Now(),
+		Err:       err,
+		BookingID: bookingID,
+		Tenant:    h.tenantOf(sourceName),
+		Source:    sourceName,
+		Stage:     stage,
+		Kind:      ClassifyError(err),
+	})
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+)
+
+// ReplayHeader 攜帶 ReplayToken 的請求略過時間戳檢查，供管理員重送擷取的 webhook
+const ReplayHeader = "X-Booking-Sync-Replay"
+
+// StaleWebhookError 表示 webhook 的時間戳超出允許範圍，可能是重放攻擊
+type StaleWebhookError struct {
+	Timestamp string
+	Age       time.Duration
+}
+
+func (e *StaleWebhookError) Error() string {
+	if e.Age == 0 {
+		return fmt.Sprintf("webhook 時間戳無效: %q", e.Timestamp)
+	}
+	return fmt.Sprintf("webhook 時間戳 %q 超出允許範圍（相差 %s）", e.Timestamp, e.Age)
+}
+
+// checkFreshness 在設置 MaxWebhookAge 時拒絕過舊或時間戳無效的 webhook，
+// 未來時間同樣以 MaxWebhookAge 為容許的時鐘誤差
+func (h *WebhookHandler) checkFreshness(r *http.Request, event *source.Event) error {
+	if h.opts.MaxWebhookAge <= 0 || h.replayAllowed(r) {
+		return nil
+	}
+
+	if event.Time.IsZero() {
+		return &StaleWebhookError{Timestamp: event.Timestamp}
+	}
+	if age := time.Since(event.Time); age > h.opts.MaxWebhookAge || age < -h.opts.MaxWebhookAge {
+		return &StaleWebhookError{Timestamp: event.Timestamp, Age: age}
+	}
+	return nil
+}
+
+// replayAllowed 檢查請求是否攜帶正確的重送令牌
+func (h *WebhookHandler) replayAllowed(r *http.Request) bool {
+	if h.opts.ReplayToken == "" {
+		return false
+	}
+	token := r.Header.Get(ReplayHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.opts.ReplayToken)) == 1
+}
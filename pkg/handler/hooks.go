@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// ErrVeto 由 Hook 返回（可包裝）時略過該次日曆操作，不視為同步失敗
+var ErrVeto = errors.New("操作已被 hook 否決")
+
+// Hook 在寫入日曆前執行自訂的業務邏輯，可修改即將寫入的事件或否決操作。
+// 返回 ErrVeto 時略過操作，返回其他錯誤時該次同步失敗並照常重試；
+// booking 僅供讀取，不應修改
+type Hook interface {
+	BeforeCreate(booking *simplybook.Booking, event *gcalendar.CalendarEvent) error
+	BeforeUpdate(booking *simplybook.Booking, eventID string, event *gcalendar.CalendarEvent) error
+	BeforeDelete(bookingID, calendarID, eventID string) error
+}
+
+// HookFuncs 以函式實作 Hook，未設置的函式視為允許操作
+type HookFuncs struct {
+	Create func(booking *simplybook.Booking, event *gcalendar.CalendarEvent) error
+	Update func(booking *simplybook.Booking, eventID string, event *gcalendar.CalendarEvent) error
+	Delete func(bookingID, calendarID, eventID string) error
+}
+
+// BeforeCreate 實作 Hook
+func (f HookFuncs) BeforeCreate(booking *simplybook.Booking, event *gcalendar.CalendarEvent) error {
+	if f.Create == nil {
+		return nil
+	}
+	return f.Create(booking, event)
+}
+
+// BeforeUpdate 實作 Hook
+func (f HookFuncs) BeforeUpdate(booking *simplybook.Booking, eventID string, event *gcalendar.CalendarEvent) error {
+	if f.Update == nil {
+		return nil
+	}
+	return f.Update(booking, eventID, event)
+}
+
+// BeforeDelete 實作 Hook
+func (f HookFuncs) BeforeDelete(bookingID, calendarID, eventID string) error {
+	if f.Delete == nil {
+		return nil
+	}
+	return f.Delete(bookingID, calendarID, eventID)
+}
+
+var (
+	registeredMu    sync.Mutex
+	registeredHooks []Hook
+)
+
+// RegisterHook 註冊全域 hook，供以 build tag 編入的檔案在 init 中呼叫；
+// 之後創建的處理器會在 Options.Hooks 之前執行已註冊的 hook
+func RegisterHook(hook Hook) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registeredHooks = append(registeredHooks, hook)
+}
+
+// globalHooks 返回已註冊的全域 hook
+func globalHooks() []Hook {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	return append([]Hook(nil), registeredHooks...)
+}
+
+// runHooks 依序執行 hook，返回是否被否決；非否決的錯誤會包裝後返回
+func (h *WebhookHandler) runHooks(op, bookingID string, call func(Hook) error) (bool, error) {
+	for _, hook := range h.opts.Hooks {
+		err := call(hook)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrVeto) {
+			log.Printf("預約 %s 的日曆%s操作被 hook 否決: %v", bookingID, op, err)
+			return true, nil
+		}
+		return false, fmt.Errorf("執行%s前的 hook 失敗: %w", op, err)
+	}
+	return false, nil
+}
+
+func (h *WebhookHandler) beforeCreate(booking *simplybook.Booking, event *gcalendar.CalendarEvent, bookingID string) (bool, error) {
+	return h.runHooks("創建", bookingID, func(hook Hook) error {
+		return hook.BeforeCreate(booking, event)
+	})
+}
+
+func (h *WebhookHandler) beforeUpdate(booking *simplybook.Booking, eventID string, event *gcalendar.CalendarEvent, bookingID string) (bool, error) {
+	return h.runHooks("更新", bookingID, func(hook Hook) error {
+		return hook.BeforeUpdate(booking, eventID, event)
+	})
+}
+
+func (h *WebhookHandler) beforeDelete(calendarID, eventID, bookingID string) (bool, error) {
+	return h.runHooks("刪除", bookingID, func(hook Hook) error {
+		return hook.BeforeDelete(bookingID, calendarID, eventID)
+	})
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+)
+
+// latencyWindow 計算 p95 時保留的最近延遲筆數
+const latencyWindow = 200
+
+// minLatencySamples 樣本少於此數量時不判斷是否超過 SLO，避免少數慢速 webhook 觸發告警
+const minLatencySamples = 20
+
+// latencyTracker 保留最近的 webhook 端到端延遲以計算 p95，並限制超過 SLO 告警的頻率
+type latencyTracker struct {
+	mu        sync.Mutex
+	samples   []time.Duration // 環狀緩衝，最多 latencyWindow 筆
+	next      int
+	lastAlert time.Time
+}
+
+// add 加入一筆延遲，返回最近延遲的 p95 與樣本數
+func (t *latencyTracker) add(d time.Duration) (time.Duration, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.samples) < latencyWindow {
+		t.samples = append(t.samples, d)
+	} else {
+		t.samples[t.next] = d
+	}
+	t.next = (t.next + 1) % latencyWindow
+
+	sorted := append([]time.Duration(nil), t.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (len(sorted)*95+99)/100 - 1
+	return sorted[rank], len(sorted)
+}
+
+// shouldAlert 判斷是否已超過冷卻時間，可再次發送 SLO 告警
+func (t *latencyTracker) shouldAlert(now time.Time, cooldown time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.lastAlert.IsZero() && now.Sub(t.lastAlert) < cooldown {
+		return false
+	}
+	t.lastAlert = now
+	return true
+}
+
+// observeWebhookLatency 記錄從平台產生 webhook 到日曆寫入完成的延遲；
+// 最近延遲的 p95 超過 LatencySLO 時記錄警告並通知維運人員
+func (h *WebhookHandler) observeWebhookLatency(s *SyncContext) {
+	if s.WebhookTime.IsZero() {
+		return
+	}
+	// 平台與本機時鐘的誤差可能使延遲為負，以 0 計
+	latency := time.Since(s.WebhookTime)
+	if latency < 0 {
+		latency = 0
+	}
+
+	sourceName := h.sourceFor(s.BookingID).Name()
+	metrics.WebhookLatency.WithLabelValues(sourceName).Observe(latency.Seconds())
+	p95, n := h.latency.add(latency)
+	metrics.WebhookLatencyP95.Set(p95.Seconds())
+
+	if h.opts.LatencySLO <= 0 || n < minLatencySamples || p95 <= h.opts.LatencySLO {
+		return
+	}
+	if !h.latency.shouldAlert(time.Now(), h.opts.AlertCooldown) {
+		return
+	}
+
+	log.Printf("警告: 最近 %d 筆 webhook 的端到端延遲 p95 為 %s，超過 SLO %s", n, p95.Round(time.Millisecond), h.opts.LatencySLO)
+	if h.opts.OpsNotifier == nil {
+		return
+	}
+	subject := fmt.Sprintf("webhook 延遲 p95 %s 超過 SLO %s", p95.Round(time.Second), h.opts.LatencySLO)
+	body := fmt.Sprintf("最近 %d 筆 webhook 從平台產生到寫入日曆的延遲 p95 為 %s，超過 SLO %s。\n最近一筆: 預約 %s，延遲 %s\n\n"+
+		"處理建議: 檢查 /metrics 中 booking_sync_pipeline_stage_duration_seconds 找出變慢的階段，"+
+		"以及 Google 日曆是否處於降級模式或寫入額度不足。",
+		n, p95.Round(time.Millisecond), h.opts.LatencySLO, s.BookingID, latency.Round(time.Millisecond))
+	if err := h.opts.OpsNotifier.Notify(subject, body); err != nil {
+		log.Printf("發送 webhook 延遲告警失敗: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+// bookingLockShards 本實例內預約互斥鎖的分片數量
+const bookingLockShards = 64
+
+// bookingLockRetryInterval 等待跨實例預約鎖的重試間隔
+const bookingLockRetryInterval = 200 * time.Millisecond
+
+// bookingLocks 以分片互斥鎖序列化本實例內同一預約的處理
+type bookingLocks struct {
+	shards [bookingLockShards]sync.Mutex
+}
+
+// newBookingLocks 創建新的預約互斥鎖
+func newBookingLocks() *bookingLocks {
+	return &bookingLocks{}
+}
+
+// get 返回預約對應的互斥鎖
+func (l *bookingLocks) get(bookingID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(bookingID))
+	return &l.shards[h.Sum32()%bookingLockShards]
+}
+
+// withBookingLock 在持有預約鎖的情況下執行 fn，
+// 先取得本實例內的互斥鎖，再視設定取得跨實例的分散式鎖
+func (h *WebhookHandler) withBookingLock(bookingID string, fn func() error) error {
+	return h.withLock(h.bookingLocks.get(bookingID), "booking:"+bookingID, "預約 "+bookingID, fn)
+}
+
+// withClassLock 在持有團體課鎖的情況下執行 fn。團體課鎖使用獨立的分片表，
+// 且只會在持有預約鎖時取得、不會反過來，因此巢狀取得時不會與預約鎖落在同一分片而自我死結
+func (h *WebhookHandler) withClassLock(classKey string, fn func() error) error {
+	return h.withLock(h.classLocks.get(classKey), "class:"+classKey, "團體課 "+classKey, fn)
+}
+
+// withLock 先取得本實例內的互斥鎖 mu，再視設定取得名為 name 的跨實例分散式鎖後執行 fn，
+// label 用於錯誤與日誌訊息
+func (h *WebhookHandler) withLock(mu *sync.Mutex, name, label string, fn func() error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if h.opts.Locker == nil {
+		return fn()
+	}
+
+	deadline := time.Now().Add(h.opts.LockTTL)
+	for {
+		acquired, err := h.opts.Locker.TryLock(name, h.opts.LockTTL)
+		if err != nil {
+			return fmt.Errorf("取得%s 的鎖失敗: %w", label, err)
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待%s 的鎖逾時", label)
+		}
+		time.Sleep(bookingLockRetryInterval)
+	}
+
+	defer func() {
+		if err := h.opts.Locker.Unlock(name); err != nil {
+			log.Printf("釋放%s 的鎖失敗: %v", label, err)
+		}
+	}()
+
+	return fn()
+}
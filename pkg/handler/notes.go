@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// notesLookback 日曆沒有同步令牌（第一次檢查或令牌失效）時回溯檢查的時間
+const notesLookback = 10 * time.Minute
+
+// NotesConflictError 表示日曆與平台兩邊的備註都在上次同步後被修改，不自動覆蓋
+type NotesConflictError struct {
+	BookingID string
+	Calendar  string
+	Source    string
+}
+
+func (e *NotesConflictError) Error() string {
+	return fmt.Sprintf("預約 %s 的備註在日曆與預約平台都已修改，請手動處理（日曆: %q，預約平台: %q）", e.BookingID, e.Calendar, e.Source)
+}
+
+// calendarWatcher 管理日曆的變更通知頻道，並避免同一日曆同時檢查
+type calendarWatcher struct {
+	mu       sync.Mutex
+	channels map[string]*gcalendar.WatchChannel // 日曆 ID → 目前的通知頻道
+	running  map[string]bool                    // 日曆 ID → 是否正在檢查
+	rerun    map[string]bool                    // 日曆 ID → 檢查期間是否又收到通知
+}
+
+// newCalendarWatcher 創建日曆變更通知管理器
+func newCalendarWatcher() *calendarWatcher {
+	return &calendarWatcher{
+		channels: make(map[string]*gcalendar.WatchChannel),
+		running:  make(map[string]bool),
+		rerun:    make(map[string]bool),
+	}
+}
+
+// RenewCalendarWatches 為預設日曆與 Options.WatchCalendars 建立新的通知頻道並停止舊的頻道，
+// 頻道會在 WatchTTL 後失效，應定期呼叫
+func (h *WebhookHandler) RenewCalendarWatches() error {
+	calendars := append([]string{h.calendarClient.CalendarID()}, h.opts.WatchCalendars...)
+
+	var errs []string
+	seen := make(map[string]bool)
+	for _, calendarID := range calendars {
+		calendarID = h.calendarClient.ResolveCalendar(calendarID)
+		if seen[calendarID] {
+			continue
+		}
+		seen[calendarID] = true
+
+		channel, err := h.calendarClient.Watch(calendarID, newChannelID(), h.opts.WatchAddress, h.opts.WatchToken, h.opts.WatchTTL)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		log.Printf("已訂閱日曆 %s 的變更通知，頻道 %s 於 %s 失效", calendarID, channel.ID, channel.Expiration.Format(time.RFC3339))
+
+		h.watcher.mu.Lock()
+		old := h.watcher.channels[calendarID]
+		h.watcher.channels[calendarID] = channel
+		h.watcher.mu.Unlock()
+
+		if old != nil {
+			if err := h.calendarClient.StopWatch(old); err != nil {
+				log.Printf("停止舊的通知頻道失敗: %v", err)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("訂閱日曆變更通知失敗: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// newChannelID 產生隨機的通知頻道 ID
+func newChannelID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "booking-sync-" + hex.EncodeToString(b)
+}
+
+// HandleCalendarNotification 接收 Google 日曆的變更通知，檢查有變更的事件的備註區塊
+func (h *WebhookHandler) HandleCalendarNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+		return
+	}
+	// 以固定時間比較令牌，避免由回應時間推測令牌內容
+	if h.opts.WatchToken != "" && !hmac.Equal([]byte(r.Header.Get("X-Goog-Channel-Token")), []byte(h.opts.WatchToken)) {
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "未授權")
+		return
+	}
+
+	// 建立頻道時 Google 會先送出 sync 通知，不代表有變更
+	if r.Header.Get("X-Goog-Resource-State") != "exists" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// 由資源 URI 取得日曆 ID，其他實例建立的頻道也能處理
+	calendarID := calendarFromResourceURI(r.Header.Get("X-Goog-Resource-URI"))
+	if calendarID == "" {
+		log.Printf("無法從通知取得日曆 ID: %s", r.Header.Get("X-Goog-Resource-URI"))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	h.inflight.Add(1)
+	go func() {
+		defer h.inflight.Done()
+		h.checkCalendarNotes(calendarID)
+	}()
+	w.WriteHeader(http.StatusOK)
+}
+
+// calendarFromResourceURI 從 https://www.googleapis.com/calendar/v3/calendars/<ID>/events?... 取出日曆 ID
+func calendarFromResourceURI(resourceURI string) string {
+	u, err := url.Parse(resourceURI)
+	if err != nil {
+		return ""
+	}
+	_, rest, ok := strings.Cut(u.EscapedPath(), "/calendars/")
+	if !ok {
+		return ""
+	}
+	escaped, _, _ := strings.Cut(rest, "/")
+	calendarID, err := url.PathUnescape(escaped)
+	if err != nil {
+		return ""
+	}
+	return calendarID
+}
+
+// checkCalendarNotes 檢查日曆上次檢查後變更的事件，將編輯過的備註寫回預約平台；
+// 檢查期間收到的通知會在本次檢查結束後再檢查一次
+func (h *WebhookHandler) checkCalendarNotes(calendarID string) {
+	h.watcher.mu.Lock()
+	if h.watcher.running[calendarID] {
+		h.watcher.rerun[calendarID] = true
+		h.watcher.mu.Unlock()
+		return
+	}
+	h.watcher.running[calendarID] = true
+	h.watcher.mu.Unlock()
+
+	for {
+		h.watcher.mu.Lock()
+		h.watcher.rerun[calendarID] = false
+		h.watcher.mu.Unlock()
+
+		if err := h.syncCalendarNotes(calendarID); err != nil {
+			log.Printf("檢查日曆 %s 的備註失敗: %v", calendarID, err)
+		}
+
+		h.watcher.mu.Lock()
+		if !h.watcher.rerun[calendarID] {
+			h.watcher.running[calendarID] = false
+			h.watcher.mu.Unlock()
+			return
+		}
+		h.watcher.mu.Unlock()
+	}
+}
+
+// listCalendarChanges 以儲存的同步令牌增量列出日曆上次檢查後變更的事件，並返回新的令牌；
+// 沒有令牌或令牌失效時改為列出 notesLookback 內變更的事件
+func (h *WebhookHandler) listCalendarChanges(calendarID string) (*gcalendar.EventList, error) {
+	token, err := h.store.GetCalendarSyncToken(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if token != "" {
+		list, err := h.calendarClient.ListEvents(ctx, time.Time{}, time.Time{}, gcalendar.ListOptions{CalendarID: calendarID, SyncToken: token})
+		if !errors.Is(err, gcalendar.ErrSyncTokenExpired) {
+			return list, err
+		}
+		log.Printf("日曆 %s 的同步令牌已失效，改為檢查最近 %s 的變更", calendarID, notesLookback)
+	}
+
+	return h.calendarClient.ListEvents(ctx, time.Time{}, time.Time{}, gcalendar.ListOptions{
+		CalendarID: calendarID,
+		UpdatedMin: time.Now().Add(-notesLookback),
+	})
+}
+
+// syncCalendarNotes 將日曆上次檢查後變更的事件中的備註寫回預約平台，成功後儲存新的同步令牌
+func (h *WebhookHandler) syncCalendarNotes(calendarID string) error {
+	list, err := h.listCalendarChanges(calendarID)
+	if err != nil {
+		return err
+	}
+
+	var bookingsByEvent, bookingsByCode map[string]string
+	for _, event := range list.Events {
+		// 已刪除與全天事件沒有備註可寫回
+		if event.Cancelled() || event.StartTime.IsZero() {
+			continue
+		}
+		// 共用日曆時不處理其他租戶建立的事件
+		if h.foreignEvent(event) {
+			continue
+		}
+		notes, ok := render.ExtractNotes(event.Description)
+		if !ok {
+			continue
+		}
+
+		// 只在有備註區塊的事件時讀取對應關係
+		if bookingsByEvent == nil {
+			mappings, err := h.store.ListMappings()
+			if err != nil {
+				return fmt.Errorf("讀取事件對應關係失敗: %w", err)
+			}
+			bookingsByEvent = make(map[string]string, len(mappings))
+			bookingsByCode = make(map[string]string, len(mappings))
+			for _, m := range mappings {
+				bookingsByEvent[m.EventID] = m.BookingID
+				if m.BookingCode != "" {
+					bookingsByCode[m.BookingCode] = m.BookingID
+				}
+			}
+		}
+
+		// 對應關係記錄的事件 ID 可能已過時（例如事件被複製後刪除原事件），改以描述中的預約編號標記比對
+		bookingID, ok := bookingsByEvent[event.ID]
+		if !ok {
+			code, marked := gcalendar.ParseBookingCode(event.Description)
+			if !marked {
+				continue
+			}
+			if bookingID, ok = bookingsByCode[code]; !ok {
+				continue
+			}
+		}
+
+		var changes []store.FieldChange
+		err := h.withBookingLock(bookingID, func() error {
+			var err error
+			changes, err = h.pushNotes(bookingID, notes)
+			return err
+		})
+		if err != nil || len(changes) > 0 {
+			h.recordSync("notes", &SyncContext{BookingID: bookingID, EventID: event.ID, CalendarID: event.CalendarID, Changes: changes}, err)
+		}
+	}
+
+	// 只返回最後一頁時才有新令牌，處理完所有變更後才儲存，失敗時下次重新處理同一批變更
+	if list.NextSyncToken == "" {
+		return nil
+	}
+	return h.store.SaveCalendarSyncToken(calendarID, list.NextSyncToken)
+}
+
+// pushNotes 比對日曆、預約平台與上次同步的備註：只有日曆被修改時寫回預約平台，
+// 兩邊都被修改時返回 NotesConflictError，不覆蓋任何一邊
+func (h *WebhookHandler) pushNotes(bookingID, calendarNotes string) ([]store.FieldChange, error) {
+	writer, ok := h.sourceFor(bookingID).(source.NotesWriter)
+	if !ok {
+		return nil, nil
+	}
+
+	// 本服務更新事件也會觸發通知，快取中的備註相同時不需查詢
+	if cached, ok := h.bookings.Get(bookingID); ok && strings.TrimSpace(cached.Notes) == calendarNotes {
+		return nil, nil
+	}
+
+	booking, err := h.fetchBooking(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("獲取預約詳情失敗: %w", err)
+	}
+	current := strings.TrimSpace(booking.Notes)
+	if calendarNotes == current {
+		return nil, nil
+	}
+
+	base, hasBase, err := h.lastSyncedNotes(bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if hasBase {
+		// 日曆未修改，預約平台的新備註會由平台的 webhook 更新到日曆
+		if calendarNotes == base {
+			return nil, nil
+		}
+		if current != base {
+			return nil, &NotesConflictError{BookingID: bookingID, Calendar: calendarNotes, Source: current}
+		}
+	}
+
+	_, id := source.SplitBookingID(bookingID)
+	if err := writer.UpdateNotes(id, calendarNotes); err != nil {
+		return nil, err
+	}
+	h.bookings.Remove(bookingID)
+
+	log.Printf("已將日曆上編輯的備註寫回預約 %s", bookingID)
+	return []store.FieldChange{{Field: "notes", Old: current, New: calendarNotes}}, nil
+}
+
+// lastSyncedNotes 返回最新快照中的備註，即上次寫入日曆的備註
+func (h *WebhookHandler) lastSyncedNotes(bookingID string) (string, bool, error) {
+	snapshots, err := h.store.ListBookingSnapshots(bookingID)
+	if err != nil {
+		return "", false, fmt.Errorf("讀取預約快照失敗: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return "", false, nil
+	}
+
+	var booking simplybook.Booking
+	if err := json.Unmarshal(snapshots[len(snapshots)-1].Payload, &booking); err != nil {
+		return "", false, fmt.Errorf("解析預約快照失敗: %w", err)
+	}
+	return strings.TrimSpace(booking.Notes), true, nil
+}
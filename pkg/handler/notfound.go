@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// notFoundCache 記錄 SimplyBook 查無資料的預約，連續達到門檻次數後在存活時間內直接返回相同錯誤，
+// 避免重送的取消 webhook 或對帳反覆查詢已刪除的預約
+type notFoundCache struct {
+	mu        sync.Mutex
+	threshold int
+	ttl       time.Duration
+	entries   map[string]*notFoundEntry
+}
+
+// notFoundEntry 是單一預約連續查無資料的記錄
+type notFoundEntry struct {
+	misses   int
+	lastMiss time.Time
+	err      error
+}
+
+// newNotFoundCache 創建新的查無資料快取
+func newNotFoundCache(threshold int, ttl time.Duration) *notFoundCache {
+	return &notFoundCache{
+		threshold: threshold,
+		ttl:       ttl,
+		entries:   make(map[string]*notFoundEntry),
+	}
+}
+
+// lookup 返回已達門檻且未過期的查無資料錯誤，否則返回 nil
+func (c *notFoundCache) lookup(bookingID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[bookingID]
+	if !ok || entry.misses < c.threshold || time.Since(entry.lastMiss) > c.ttl {
+		return nil
+	}
+	return entry.err
+}
+
+// miss 記錄一次查無資料，距上次超過存活時間時重新計數，並清除其他過期的記錄
+func (c *notFoundCache) miss(bookingID string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.Sub(entry.lastMiss) > c.ttl {
+			delete(c.entries, id)
+		}
+	}
+
+	entry, ok := c.entries[bookingID]
+	if !ok {
+		entry = &notFoundEntry{}
+		c.entries[bookingID] = entry
+	}
+	entry.misses++
+	entry.lastMiss = now
+	entry.err = err
+	if entry.misses == c.threshold {
+		log.Printf("預約 %s 已連續 %d 次查無資料，%s 內不再向 SimplyBook 查詢", bookingID, entry.misses, c.ttl)
+	}
+}
+
+// forget 移除預約的查無資料記錄
+func (c *notFoundCache) forget(bookingID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, bookingID)
+}
+
+// readBooking 從來源平台讀取預約，近期多次查無資料的預約直接返回先前的錯誤
+func (h *WebhookHandler) readBooking(bookingID string) (*simplybook.Booking, error) {
+	if err := h.notFound.lookup(bookingID); err != nil {
+		return nil, fmt.Errorf("預約近期多次查無資料，略過查詢: %w", err)
+	}
+
+	booking, err := h.getBooking(h.sourceFor(bookingID), bookingID)
+	if err != nil {
+		if simplybook.IsNotFound(err) {
+			h.notFound.miss(bookingID, err)
+		}
+		return nil, err
+	}
+
+	h.notFound.forget(bookingID)
+	return booking, nil
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// outboxBatchSize 每次讀取的暫存操作筆數
+const outboxBatchSize = 100
+
+// maxRetryAfter 採用 API 要求的等待時間上限，避免異常的標頭讓同步停擺過久
+const maxRetryAfter = time.Hour
+
+// errCalendarDegraded 表示處於降級模式，同步操作直接暫存而不呼叫 Google 日曆
+var errCalendarDegraded = errors.New("Google 日曆處於降級模式")
+
+// errThrottled 表示仍在 API 要求的等待時間內，同步操作直接暫存
+var errThrottled = errors.New("API 要求稍後重試")
+
+// syncOrDefer 同步預約；Google 日曆無法使用、寫入額度用完或 API 以 Retry-After 要求等待時改為暫存操作，
+// 待恢復後由 FlushOutbox 補送。呼叫者需持有預約鎖
+func (h *WebhookHandler) syncOrDefer(s *SyncContext) (string, []store.FieldChange, error) {
+	if h.calendarDegraded() {
+		return "", nil, h.deferSync(s.Action, s.BookingID, errCalendarDegraded)
+	}
+	if h.throttled() {
+		return "", nil, h.deferSync(s.Action, s.BookingID, errThrottled)
+	}
+
+	eventID, changes, err := h.syncBooking(s)
+	if err != nil {
+		if delay := retryAfter(err); delay > 0 {
+			h.throttle(delay)
+			return eventID, changes, h.deferSync(s.Action, s.BookingID, err)
+		}
+		if gcalendar.ClassifyError(err).Deferrable() {
+			h.enterDegraded()
+			return eventID, changes, h.deferSync(s.Action, s.BookingID, err)
+		}
+		return eventID, changes, err
+	}
+
+	// 已直接同步成功，先前暫存的操作不再需要補送，避免舊操作覆蓋較新的結果
+	if err := h.store.DeletePendingSync(s.BookingID); err != nil {
+		log.Printf("刪除預約 %s 的暫存操作失敗: %v", s.BookingID, err)
+	}
+	return eventID, changes, nil
+}
+
+// deferSync 暫存同步操作，返回說明已暫存的錯誤以寫入同步記錄
+func (h *WebhookHandler) deferSync(action, bookingID string, cause error) error {
+	defer h.refreshOutboxMetrics()
+
+	err := h.store.EnqueuePendingSync(&store.PendingSync{
+		BookingID: bookingID,
+		Action:    strings.ToLower(action),
+		LastError: cause.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("暫存同步操作失敗: %v: %w", cause, err)
+	}
+
+	log.Printf("已暫存預約 %s 的 %s 操作: %v", bookingID, action, cause)
+	return fmt.Errorf("已暫存待補送: %w", cause)
+}
+
+// CalendarDegraded 判斷目前是否處於降級模式，同步操作改為暫存
+func (h *WebhookHandler) CalendarDegraded() bool {
+	return h.calendarDegraded()
+}
+
+// CredentialStatuses 返回 Google 服務帳號的健康狀態
+func (h *WebhookHandler) CredentialStatuses() []gcalendar.CredentialStatus {
+	return h.calendarClient.CredentialStatuses()
+}
+
+// calendarDegraded 判斷目前是否處於降級模式
+func (h *WebhookHandler) calendarDegraded() bool {
+	return time.Now().UnixNano() < h.degradedUntil.Load()
+}
+
+// enterDegraded 進入降級模式，在退避時間內不再呼叫 Google 日曆
+func (h *WebhookHandler) enterDegraded() {
+	h.degradedUntil.Store(time.Now().Add(h.opts.DegradedBackoff).UnixNano())
+	metrics.CalendarDegraded.Set(1)
+}
+
+// retryAfter 返回 SimplyBook 或 Google 日曆因請求過於頻繁而要求的等待時間，未要求時返回 0
+func retryAfter(err error) time.Duration {
+	delay := simplybook.RetryAfter(err)
+	if d := gcalendar.RetryAfter(err); d > delay {
+		delay = d
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	return delay
+}
+
+// throttled 判斷目前是否仍在 API 要求的等待時間內
+func (h *WebhookHandler) throttled() bool {
+	return time.Now().UnixNano() < h.throttledUntil.Load()
+}
+
+// throttle 依 API 要求的等待時間暫停同步，已有較晚的結束時間時保留
+func (h *WebhookHandler) throttle(delay time.Duration) {
+	until := time.Now().Add(delay).UnixNano()
+	for {
+		current := h.throttledUntil.Load()
+		if current >= until {
+			return
+		}
+		if h.throttledUntil.CompareAndSwap(current, until) {
+			log.Printf("API 要求等待 %s 後重試，期間同步操作改為暫存", delay)
+			return
+		}
+	}
+}
+
+// FlushOutbox 確認 Google 日曆恢復後依暫存順序補送同步操作，返回成功補送的筆數
+func (h *WebhookHandler) FlushOutbox() (int, error) {
+	defer h.refreshOutboxMetrics()
+
+	stats, err := h.store.OutboxStats()
+	if err != nil {
+		return 0, err
+	}
+	if stats.Count == 0 {
+		return 0, nil
+	}
+	if h.throttled() {
+		log.Printf("仍在 API 要求的等待時間內，%d 筆暫存操作待補送", stats.Count)
+		return 0, nil
+	}
+
+	if err := h.calendarClient.Ping(); err != nil {
+		h.enterDegraded()
+		log.Printf("Google 日曆仍無法使用，%d 筆暫存操作待補送: %v", stats.Count, err)
+		return 0, nil
+	}
+	h.degradedUntil.Store(0)
+
+	log.Printf("Google 日曆已恢復，開始補送 %d 筆暫存操作", stats.Count)
+	flushed := 0
+	for {
+		pending, err := h.store.ListPendingSyncs(outboxBatchSize)
+		if err != nil {
+			return flushed, err
+		}
+		if len(pending) == 0 {
+			break
+		}
+
+		for _, p := range pending {
+			ok, err := h.flushOne(p)
+			if err != nil {
+				return flushed, err
+			}
+			if !ok {
+				log.Printf("補送中斷，已補送 %d 筆", flushed)
+				return flushed, nil
+			}
+			flushed++
+		}
+	}
+
+	log.Printf("暫存操作補送完成，共 %d 筆", flushed)
+	return flushed, nil
+}
+
+// flushOne 補送單一暫存操作；Google 日曆再次無法使用時重新暫存並返回 false
+func (h *WebhookHandler) flushOne(p *store.PendingSync) (bool, error) {
+	s := &SyncContext{Action: p.Action, BookingID: p.BookingID}
+	var syncErr error
+	err := h.withBookingLock(p.BookingID, func() error {
+		_, _, syncErr = h.syncBooking(s)
+		if retryAfter(syncErr) > 0 || gcalendar.ClassifyError(syncErr).Deferrable() {
+			return nil
+		}
+		// 非服務中斷的錯誤重試也無法解決，記錄失敗後移出暫存
+		return h.store.DeletePendingSync(p.BookingID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if delay := retryAfter(syncErr); delay > 0 {
+		h.throttle(delay)
+		if err := h.deferSync(p.Action, p.BookingID, syncErr); err != nil {
+			log.Printf("預約 %s 補送失敗: %v", p.BookingID, err)
+		}
+		return false, nil
+	}
+	if gcalendar.ClassifyError(syncErr).Deferrable() {
+		h.enterDegraded()
+		if err := h.deferSync(p.Action, p.BookingID, syncErr); err != nil {
+			log.Printf("預約 %s 補送失敗: %v", p.BookingID, err)
+		}
+		return false, nil
+	}
+
+	h.recordSync(p.Action, s, syncErr)
+	return true, nil
+}
+
+// refreshOutboxMetrics 更新暫存操作的指標
+func (h *WebhookHandler) refreshOutboxMetrics() {
+	stats, err := h.store.OutboxStats()
+	if err != nil {
+		log.Printf("統計暫存操作失敗: %v", err)
+		return
+	}
+
+	metrics.OutboxSize.Set(float64(stats.Count))
+	if stats.Oldest.IsZero() {
+		metrics.OutboxOldestAge.Set(0)
+	} else {
+		metrics.OutboxOldestAge.Set(time.Since(stats.Oldest).Seconds())
+	}
+
+	if h.calendarDegraded() {
+		metrics.CalendarDegraded.Set(1)
+	} else {
+		metrics.CalendarDegraded.Set(0)
+	}
+}
+
+// OutboxStats 返回暫存操作的統計
+func (h *WebhookHandler) OutboxStats() (*store.OutboxStats, error) {
+	return h.store.OutboxStats()
+}
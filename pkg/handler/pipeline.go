@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// 同步流程的階段名稱，依執行順序排列；validate 與 dedupe 在接收 webhook 時執行，
+// 其餘階段在每次同步預約時執行（包括重播、補同步與暫存操作補送）
+const (
+	StageValidate = "validate" // 解析 webhook、驗證簽章與時間戳
+	StageDedupe   = "dedupe"   // 忽略重複送達的 webhook
+	StageFetch    = "fetch"    // 讀取預約，優先使用快取
+	StageEnrich   = "enrich"   // 以外部資料補充剛讀取的預約並放入快取
+	StageRoute    = "route"    // 套用排除清單，查找對應的日曆事件，套用同步範圍、略過規則與資料驗證
+	StageRender   = "render"   // 產生日曆事件並標記來源
+	StageApply    = "apply"    // 寫入日曆並儲存對應關係，同步交通事件與資源占用事件
+	StageRecord   = "record"   // 更新提醒與報表，通知待核准的預約
+)
+
+// SyncContext 為單次預約同步在各階段之間傳遞的狀態
+type SyncContext struct {
+	Action    string // create、change 或 cancel
+	BookingID string
+
+	WebhookTime time.Time       // 平台產生 webhook 的時間，非 webhook 觸發的同步（重播、補同步、暫存補送）為零值
+	Ctx         context.Context // 呼叫端的 context，結束時不再執行後續階段；未設置時不檢查
+
+	Booking    *simplybook.Booking      // fetch 之後可用
+	EventID    string                   // route 之後可用，空字串表示尚無日曆事件
+	CalendarID string                   // 事件所在日曆，空字串表示預設日曆
+	EventLink  string                   // 對應關係中的事件網址，沒有對應關係時為空
+	Event      *gcalendar.CalendarEvent // render 之後可用，取消或事件已存在時為 nil
+	Changes    []store.FieldChange      // apply 之後可用，更新時的欄位差異
+
+	fetched bool // 本次從平台讀取，未命中快取
+	stopped bool
+}
+
+// Stop 結束本次同步，後續階段不再執行，不視為失敗
+func (s *SyncContext) Stop() {
+	s.stopped = true
+}
+
+// Stage 為同步流程中的步驟。自訂步驟透過 Options.Stages 插入於 After 指定的階段之後，
+// 每次同步都會執行（包括取消與快取命中），返回錯誤時該次同步失敗並照常重試
+type Stage struct {
+	Name  string
+	After string // 內建階段（fetch 至 record）或先前插入的自訂步驟，未知時插入於 record 之前
+	Run   func(s *SyncContext) error
+}
+
+// buildStages 依序排列內建階段並插入自訂步驟
+func (h *WebhookHandler) buildStages(custom []Stage) []Stage {
+	stages := []Stage{
+		{Name: StageFetch, Run: h.fetchStage},
+		{Name: StageEnrich, Run: h.enrichStage},
+		{Name: StageRoute, Run: h.routeStage},
+		{Name: StageRender, Run: h.renderStage},
+		{Name: StageApply, Run: h.applyStage},
+		{Name: StageRecord, Run: h.recordStage},
+	}
+
+	for _, stage := range custom {
+		at := -1
+		for i, existing := range stages {
+			if existing.Name == stage.After {
+				at = i + 1
+			}
+		}
+		if at < 0 {
+			log.Printf("同步步驟 %s 指定的階段 %q 不存在，改為插入於 record 之前", stage.Name, stage.After)
+			at = len(stages) - 1
+		}
+		stages = append(stages[:at], append([]Stage{stage}, stages[at:]...)...)
+	}
+	return stages
+}
+
+// runPipeline 依序執行同步階段，任一階段失敗或呼叫 Stop 時結束；
+// 內建階段完成後推進預約的同步狀態，狀態轉換無效時本次同步失敗
+func (h *WebhookHandler) runPipeline(s *SyncContext) error {
+	machine := h.beginSync(s)
+	for _, stage := range h.stages {
+		if s.Ctx != nil {
+			if err := s.Ctx.Err(); err != nil {
+				machine.fail(err)
+				return err
+			}
+		}
+		start := time.Now()
+		err := stage.Run(s)
+		observeStage(stage.Name, start, ClassifyError(err))
+		if err == nil {
+			if state, ok := stageStates[stage.Name]; ok {
+				err = machine.advance(state)
+			}
+			if err == nil && stage.Name == StageApply {
+				h.observeWebhookLatency(s)
+			}
+		}
+		if err != nil {
+			h.reportError(stage.Name, s.BookingID, err)
+			machine.fail(err)
+			return err
+		}
+		if s.stopped {
+			break
+		}
+	}
+	if err := machine.advance(SyncConfirmed); err != nil {
+		machine.fail(err)
+		return err
+	}
+	return nil
+}
+
+// observeStage 記錄階段耗時，kind 不為空時計入該階段的錯誤分類
+func observeStage(stage string, start time.Time, kind string) {
+	metrics.PipelineStageDuration.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+	if kind != "" {
+		metrics.PipelineStageErrors.WithLabelValues(stage, kind).Inc()
+	}
+}
+
+// fetchStage 讀取預約，快取命中時不再查詢來源平台
+func (h *WebhookHandler) fetchStage(s *SyncContext) error {
+	// 呼叫端已從平台讀取的預約（例如依預約狀態決定操作時）直接使用，視為本次讀取
+	if s.Booking != nil {
+		s.fetched = true
+		return nil
+	}
+	if booking, ok := h.bookings.Get(s.BookingID); ok {
+		s.Booking = booking
+		return nil
+	}
+
+	booking, err := h.readBooking(s.BookingID)
+	if err != nil {
+		return fmt.Errorf("獲取預約詳情失敗: %w", err)
+	}
+	s.Booking = booking
+	s.fetched = true
+	return nil
+}
+
+// enrichStage 補充剛從平台讀取的預約，轉換時區後放入快取；快取中的預約已補充過
+func (h *WebhookHandler) enrichStage(s *SyncContext) error {
+	if !s.fetched {
+		return nil
+	}
+
+	h.enrich(s.BookingID, s.Booking)
+	// 快照保留平台返回的原始時間
+	h.recordSnapshot(s.BookingID, s.Booking)
+	h.renderer.Localize(s.Booking)
+	h.bookings.Add(s.BookingID, s.Booking)
+	return nil
+}
+
+// routeStage 排除不在同步範圍內與手動略過的預約，查找對應的日曆事件及所在日曆，並決定預約是否需要寫入日曆
+func (h *WebhookHandler) routeStage(s *SyncContext) error {
+	// 不在同步範圍內的服務提供者或服務最先排除，不查詢日曆；取消時仍刪除範圍調整前建立的事件
+	if reason := h.outOfScope(s.Booking); reason != "" && s.Action != "cancel" {
+		log.Printf("預約 %s 的%s不在同步範圍內，略過", s.BookingID, scopeReasons[reason])
+		metrics.BookingsOutOfScope.WithLabelValues(reason).Inc()
+		s.Stop()
+		return nil
+	}
+
+	// 手動排除的預約不建立、更新或刪除事件
+	skipped, err := h.bookingSkipped(s.Booking.Code)
+	if err != nil {
+		return err
+	}
+	if skipped {
+		log.Printf("預約 %s（%s）已手動排除同步，略過", s.BookingID, s.Booking.Code)
+		s.Stop()
+		return nil
+	}
+
+	// 優先使用已儲存的對應關係，否則查找預設日曆中現有的日曆事件
+	mapping, err := h.store.GetMapping(s.BookingID)
+	if err != nil {
+		return fmt.Errorf("讀取事件對應關係失敗: %w", err)
+	}
+	if mapping != nil {
+		s.EventID, s.CalendarID, s.EventLink = mapping.EventID, mapping.CalendarID, mapping.EventLink
+	} else {
+		s.EventID, err = h.calendarClient.FindEventByBookingCode(s.Booking.Code)
+		if err != nil {
+			return fmt.Errorf("查找日曆事件失敗: %w", err)
+		}
+	}
+
+	// 取消時一律刪除既有事件
+	if s.Action == "cancel" {
+		return nil
+	}
+
+	// 超出同步時間範圍的預約不建立或更新事件
+	if !h.inSyncWindow(s.Booking) {
+		log.Printf("預約 %s 超出同步時間範圍，略過", s.BookingID)
+		s.Stop()
+		return nil
+	}
+
+	// 符合略過規則的預約不建立或更新事件
+	if h.renderer.Skip(s.Booking) {
+		log.Printf("預約 %s 符合略過規則，略過", s.BookingID)
+		s.Stop()
+		return nil
+	}
+
+	// 時間不合理的預約不寫入日曆，改寫入死信待人工處理
+	if err := h.validateBooking(s.Booking); err != nil {
+		log.Printf("拒絕同步預約 %s: %v", s.BookingID, err)
+		h.deadLetter(s.Action, s.Booking, err)
+		return err
+	}
+	return nil
+}
+
+// renderStage 為需要創建或更新的事件產生日曆事件
+func (h *WebhookHandler) renderStage(s *SyncContext) error {
+	switch {
+	case s.Action == "change", s.Action == "create" && s.EventID == "":
+	default:
+		return nil
+	}
+
+	calEvent, err := h.renderer.Render(s.Booking)
+	if err != nil {
+		return fmt.Errorf("產生日曆事件失敗: %w", err)
+	}
+	h.stampEvent(s.BookingID, s.Booking, calEvent)
+	if err := h.filterAttendees(s.BookingID, calEvent); err != nil {
+		return err
+	}
+	s.Event = calEvent
+	return nil
+}
+
+// applyStage 依操作類型寫入日曆，團體課程的預約改寫入時段共用的課程事件
+func (h *WebhookHandler) applyStage(s *SyncContext) error {
+	if capacity, ok := h.renderer.GroupClass(s.Booking); ok {
+		return h.applyClass(s, capacity)
+	}
+
+	// 讀取現有事件供更新時計算欄位差異，並確認預約是否仍指向服務改為個別預約前的課程事件
+	var current *gcalendar.CalendarEvent
+	if s.EventID != "" && s.Action != "create" {
+		var err error
+		if current, err = h.calendarClient.GetEvent(s.CalendarID, s.EventID); err != nil {
+			log.Printf("讀取預約 %s 的日曆事件 %s 失敗，略過差異記錄: %v", s.BookingID, s.EventID, err)
+			current = nil
+		} else if current.Properties[gcalendar.PropertyClass] != "" {
+			// 共用的課程事件不可直接更新或刪除，先退出課程，之後以個別事件同步
+			if err := h.leaveFormerClass(s); err != nil {
+				return err
+			}
+			current = nil
+			if s.Action == "cancel" {
+				return nil
+			}
+		}
+	}
+
+	var err error
+	switch s.Action {
+	case "create":
+		s.EventID, err = h.handleBookingCreated(s.Booking, s.Event, s.EventID, s.CalendarID, s.EventLink, s.BookingID)
+	case "change":
+		s.EventID, s.Changes, err = h.handleBookingUpdated(s.Booking, s.Event, current, s.EventID, s.CalendarID, s.BookingID)
+	case "cancel":
+		err = h.handleBookingDeleted(s.EventID, s.CalendarID, s.BookingID)
+	default:
+		return fmt.Errorf("不支持的操作類型: %s", s.Action)
+	}
+	if err != nil {
+		return err
+	}
+	if err := h.syncTravelEvents(s); err != nil {
+		return err
+	}
+	return h.syncResourceEvents(s)
+}
+
+// recordStage 同步成功後更新提醒與報表，失敗時僅記錄日誌
+func (h *WebhookHandler) recordStage(s *SyncContext) error {
+	h.updateReminder(s.Action, s.Booking, s.BookingID)
+	h.appendReport(s.Action, s.Booking, s.BookingID)
+	h.requestApproval(s)
+	return nil
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+)
+
+// Drift 代表預約與日曆事件之間不一致的項目
+type Drift struct {
+	BookingID string `json:"booking_id"`
+	EventID   string `json:"event_id"`
+	Reason    string `json:"reason"`
+	EventLink string `json:"event_link,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"` // 事件已從日曆刪除，對帳時重新建立
+}
+
+// reasonEventDeleted 是事件已從日曆刪除時的偏差原因
+const reasonEventDeleted = "事件已從日曆刪除"
+
+// reasonBeforeWindow 表示預約已在同步範圍的過去範圍之前結束，不列為偏差
+const reasonBeforeWindow = "預約早於同步時間範圍"
+
+// DriftReport 代表一次偏差檢查的結果
+type DriftReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Checked   int       `json:"checked"`
+	Drifts    []Drift   `json:"drifts"`
+	Expired   []string  `json:"expired,omitempty"` // 結束時間早於 SYNC_PAST_WINDOW 的預約，對帳時清除其對應關係
+}
+
+// ReconcileResult 代表一次對帳的結果
+type ReconcileResult struct {
+	Report   *DriftReport `json:"report"`
+	Repaired int          `json:"repaired"`
+	Pruned   int          `json:"pruned"` // 清除的過期對應關係
+	Failed   int          `json:"failed"`
+}
+
+// DetectDrift 逐一比對已對應的預約與日曆事件，找出不一致的項目
+func (h *WebhookHandler) DetectDrift() (*DriftReport, error) {
+	return h.DetectDriftSince(context.Background(), time.Time{})
+}
+
+// DetectDriftSince 只比對對應關係在 since 之後更新過的預約，since 為零值時比對全部；
+// ctx 結束時停止並返回已比對的結果
+func (h *WebhookHandler) DetectDriftSince(ctx context.Context, since time.Time) (*DriftReport, error) {
+	mappings, err := h.store.ListMappings()
+	if err != nil {
+		return nil, fmt.Errorf("讀取事件對應關係失敗: %w", err)
+	}
+
+	skipped, err := h.skippedCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DriftReport{CheckedAt: time.Now()}
+	for _, m := range mappings {
+		if !since.IsZero() && m.UpdatedAt.Before(since) {
+			continue
+		}
+		if skipped[m.BookingCode] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Checked++
+
+		reason, err := h.checkDrift(m.BookingID, m.EventID, m.CalendarID)
+		if err != nil {
+			reason = err.Error()
+		}
+		if reason == reasonBeforeWindow {
+			report.Expired = append(report.Expired, m.BookingID)
+			continue
+		}
+		if reason != "" {
+			report.Drifts = append(report.Drifts, Drift{
+				BookingID: m.BookingID,
+				EventID:   m.EventID,
+				EventLink: m.EventLink,
+				Reason:    reason,
+				Deleted:   reason == reasonEventDeleted,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// checkDrift 比對單一預約與日曆事件，一致時返回空字串
+func (h *WebhookHandler) checkDrift(bookingID, eventID, calendarID string) (string, error) {
+	booking, err := h.fetchBooking(bookingID)
+	if err != nil {
+		return "", fmt.Errorf("獲取預約詳情失敗: %w", err)
+	}
+	h.renderer.Localize(booking)
+
+	if h.beforeSyncWindow(booking) {
+		return reasonBeforeWindow, nil
+	}
+	// 超出同步時間範圍、不在同步範圍內或符合略過規則的預約不列入偏差
+	if !h.inSyncWindow(booking) || h.outOfScope(booking) != "" || h.renderer.Skip(booking) {
+		return "", nil
+	}
+
+	event, err := h.calendarClient.GetEvent(calendarID, eventID)
+	if err != nil {
+		return "", fmt.Errorf("獲取日曆事件失敗: %w", err)
+	}
+
+	expected, err := h.renderer.Render(booking)
+	if err != nil {
+		return "", fmt.Errorf("產生日曆事件失敗: %w", err)
+	}
+
+	// 團體課程的共用事件標題是課程名稱與人數，改為比對事件的課程時段
+	if _, ok := h.renderer.GroupClass(booking); ok && !event.Cancelled() {
+		if key := h.renderer.ClassKey(booking); event.Properties[gcalendar.PropertyClass] != key {
+			return fmt.Sprintf("課程時段不一致: %q != %q", event.Properties[gcalendar.PropertyClass], key), nil
+		}
+		expected.Summary = event.Summary
+	}
+
+	switch {
+	case event.Cancelled():
+		return reasonEventDeleted, nil
+	case calendarID != expected.CalendarID:
+		return fmt.Sprintf("日曆不一致: %q != %q", calendarID, expected.CalendarID), nil
+	case event.Summary != expected.Summary:
+		return fmt.Sprintf("標題不一致: %q != %q", event.Summary, expected.Summary), nil
+	case !h.sameTime(event.StartTime, expected.StartTime):
+		return fmt.Sprintf("開始時間不一致: %s != %s", event.StartTime, expected.StartTime), nil
+	case !h.sameTime(event.EndTime, expected.EndTime):
+		return fmt.Sprintf("結束時間不一致: %s != %s", event.EndTime, expected.EndTime), nil
+	}
+
+	return "", nil
+}
+
+// sameTime 判斷兩個時間的差異是否在容許範圍內，避免兩邊 API 截斷秒數造成重複改寫
+func (h *WebhookHandler) sameTime(a, b time.Time) bool {
+	diff := a.Sub(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff < h.opts.TimeTolerance
+}
+
+// Reconcile 檢查偏差並重新同步所有不一致的預約
+func (h *WebhookHandler) Reconcile() (*ReconcileResult, error) {
+	return h.ReconcileSince(context.Background(), time.Time{})
+}
+
+// ReconcileSince 檢查對應關係在 since 之後更新過的預約，並重新同步不一致的項目
+func (h *WebhookHandler) ReconcileSince(ctx context.Context, since time.Time) (*ReconcileResult, error) {
+	report, err := h.DetectDriftSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReconcileResult{Report: report}
+
+	// 早於同步時間範圍的預約不會再同步，清除對應關係與提醒避免儲存無限增長；日曆事件保留作為歷史紀錄
+	for _, bookingID := range report.Expired {
+		if err := h.store.DeleteMapping(bookingID); err != nil {
+			log.Printf("清除預約 %s 的過期對應關係失敗: %v", bookingID, err)
+			result.Failed++
+			continue
+		}
+		if err := h.store.DeleteReminder(bookingID); err != nil {
+			log.Printf("清除預約 %s 的提醒失敗: %v", bookingID, err)
+		}
+		result.Pruned++
+	}
+
+	for _, d := range report.Drifts {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		// 已刪除的事件無法更新，移除對應關係讓重新同步建立新事件
+		if d.Deleted {
+			if err := h.store.DeleteMapping(d.BookingID); err != nil {
+				log.Printf("對帳時移除預約 %s 的對應關係失敗: %v", d.BookingID, err)
+				result.Failed++
+				continue
+			}
+		}
+		if err := h.ReplayBooking(d.BookingID); err != nil {
+			log.Printf("對帳時重新同步預約 %s 失敗: %v", d.BookingID, err)
+			result.Failed++
+			continue
+		}
+		result.Repaired++
+	}
+
+	log.Printf("對帳完成: 檢查 %d 筆，偏差 %d 筆，修復 %d 筆，清除過期 %d 筆，失敗 %d 筆",
+		report.Checked, len(report.Drifts), result.Repaired, result.Pruned, result.Failed)
+	return result, nil
+}
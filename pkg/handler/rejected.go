@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+)
+
+// logRejected 記錄驗證失敗的 webhook 並計入指標；日誌包含原因、租戶與來源位址，不包含令牌本身
+func (h *WebhookHandler) logRejected(r *http.Request, sourceName string, err error) {
+	reason, tenant := source.RejectInvalidToken, ""
+	var rejected *source.RejectedError
+	if errors.As(err, &rejected) {
+		reason, tenant = rejected.Reason, rejected.Tenant
+	}
+
+	remote := r.RemoteAddr
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		remote = forwarded
+	}
+	if tenant != "" {
+		log.Printf("拒絕 %s webhook（%s，租戶 %s），來源 %s", sourceName, reason, tenant, remote)
+	} else {
+		log.Printf("拒絕 %s webhook（%s），來源 %s", sourceName, reason, remote)
+	}
+	metrics.WebhooksRejected.WithLabelValues(sourceName, reason).Inc()
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+)
+
+// syncResourceEvents 在預約占用的服務資源（房間、設備）日曆上建立或更新占用事件；預約取消、
+// 不再占用該資源時刪除既有的占用事件。占用事件以預約識別碼的擴充屬性在所有資源日曆中查找，不儲存對應關係
+func (h *WebhookHandler) syncResourceEvents(s *SyncContext) error {
+	calendars := h.renderer.ResourceCalendars()
+	if len(calendars) == 0 {
+		return nil
+	}
+	// 與交通事件相同，事件已存在的 create 沒有重新渲染，hook 否決建立時也沒有預約事件
+	if s.Action != "cancel" && (s.Event == nil || s.EventID == "") {
+		return nil
+	}
+
+	wanted := make(map[string]*gcalendar.CalendarEvent)
+	if s.Action != "cancel" {
+		for _, event := range h.renderer.ResourceEvents(s.Booking, s.Event) {
+			wanted[event.CalendarID+"\x00"+event.Properties[gcalendar.PropertyResource]] = event
+		}
+	}
+
+	existing := make(map[string]*gcalendar.CalendarEvent)
+	var stale []*gcalendar.CalendarEvent
+	for _, calendarID := range calendars {
+		events, err := h.calendarClient.FindEventsByProperty(calendarID, gcalendar.PropertyBookingID, s.BookingID)
+		if err != nil {
+			return fmt.Errorf("查找資源占用事件失敗: %w", err)
+		}
+		for _, event := range events {
+			resource := event.Properties[gcalendar.PropertyResource]
+			// 略過預約事件、交通事件（資源日曆與預約日曆相同時）與共用日曆中其他租戶的事件
+			if resource == "" || event.Cancelled() || h.foreignEvent(event) {
+				continue
+			}
+			key := calendarID + "\x00" + resource
+			if _, dup := existing[key]; dup || wanted[key] == nil {
+				stale = append(stale, event)
+				continue
+			}
+			existing[key] = event
+		}
+	}
+
+	for key, event := range wanted {
+		h.stampEvent(s.BookingID, s.Booking, event)
+		if current, ok := existing[key]; ok {
+			if err := h.calendarClient.UpdateEvent(current.ID, event); err != nil {
+				return fmt.Errorf("更新資源占用事件失敗: %w", err)
+			}
+			continue
+		}
+		eventID, err := h.calendarClient.CreateEvent(event)
+		if err != nil {
+			return fmt.Errorf("創建資源占用事件失敗: %w", err)
+		}
+		log.Printf("為預約 %s 在資源日曆 %s 創建了占用事件 %s", s.BookingID, event.CalendarID, eventID)
+	}
+
+	for _, event := range stale {
+		if err := h.calendarClient.DeleteEvent(event.CalendarID, event.ID); err != nil {
+			return fmt.Errorf("刪除資源占用事件失敗: %w", err)
+		}
+		log.Printf("刪除了預約 %s 在資源日曆 %s 的占用事件 %s", s.BookingID, event.CalendarID, event.ID)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// Scope 限定同步的服務提供者與服務，皆可填名稱或 ID（不分大小寫）。
+// Allow 非空時只同步清單中的項目，Deny 中的項目一律不同步
+type Scope struct {
+	AllowProviders []string
+	DenyProviders  []string
+	AllowServices  []string
+	DenyServices   []string
+}
+
+// scopeReasons 是排除原因在日誌中的說明
+var scopeReasons = map[string]string{
+	"provider": "服務提供者",
+	"service":  "服務",
+}
+
+// outOfScope 判斷預約的服務提供者與服務是否在同步範圍內，在範圍內時返回空字串，
+// 否則返回 provider 或 service 表示被排除的原因
+func (h *WebhookHandler) outOfScope(booking *simplybook.Booking) string {
+	scope := h.opts.Scope
+	if !scopeAllows(scope.AllowProviders, scope.DenyProviders, booking.ProviderName, booking.ProviderID) {
+		return "provider"
+	}
+	if !scopeAllows(scope.AllowServices, scope.DenyServices, booking.ServiceName, booking.ServiceID) {
+		return "service"
+	}
+	return ""
+}
+
+// scopeAllows 依允許與排除清單判斷名稱或 ID 是否可同步
+func scopeAllows(allow, deny []string, name string, id int) bool {
+	if scopeContains(deny, name, id) {
+		return false
+	}
+	return len(allow) == 0 || scopeContains(allow, name, id)
+}
+
+// scopeContains 不分大小寫檢查名稱或數字 ID 是否在清單中
+func scopeContains(list []string, name string, id int) bool {
+	name = strings.TrimSpace(name)
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if (name != "" && strings.EqualFold(item, name)) || (id != 0 && item == strconv.Itoa(id)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"log"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// changeWeights 是各事件欄位變更對參與者的重要程度：時間與地點影響客戶是否能準時赴約，
+// 參與者變更時需寄出邀請或取消通知；標題、描述與顏色多為內部整理，不值得另寄郵件
+var changeWeights = map[string]int{
+	"start":       5,
+	"end":         3,
+	"location":    3,
+	"attendees":   5,
+	"summary":     1,
+	"description": 1,
+	"color":       0,
+	"calendar":    0,
+}
+
+// changeSignificance 加總欄位變更的重要程度分數
+func changeSignificance(changes []store.FieldChange) int {
+	score := 0
+	for _, c := range changes {
+		score += changeWeights[c.Field]
+	}
+	return score
+}
+
+// updateSendUpdates 依欄位變更的重要程度決定更新事件時是否通知參與者；
+// 無法取得差異時（diffed 為 false）視為重要變更，以免客戶漏接改期通知
+func (h *WebhookHandler) updateSendUpdates(diffed bool, changes []store.FieldChange, event *gcalendar.CalendarEvent, bookingID string) string {
+	if !diffed {
+		return h.opts.SendUpdates
+	}
+	score := changeSignificance(changes)
+	if score >= h.opts.UpdateSignificance {
+		return h.opts.SendUpdates
+	}
+	if len(event.Attendees) > 0 {
+		log.Printf("預約 %s 的事件變更分數 %d 低於 %d，更新時不通知參與者", bookingID, score, h.opts.UpdateSignificance)
+	}
+	return gcalendar.SendUpdatesNone
+}
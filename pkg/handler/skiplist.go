@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bookingSkipped 判斷預約編號是否已手動排除同步
+func (h *WebhookHandler) bookingSkipped(code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return false, nil
+	}
+
+	skipped, err := h.store.GetSkippedBooking(code)
+	if err != nil {
+		return false, err
+	}
+	return skipped != nil, nil
+}
+
+// skippedCodes 讀取所有手動排除同步的預約編號，供逐筆檢查大量預約時使用
+func (h *WebhookHandler) skippedCodes() (map[string]bool, error) {
+	list, err := h.store.ListSkippedBookings()
+	if err != nil {
+		return nil, fmt.Errorf("讀取排除同步的預約失敗: %w", err)
+	}
+
+	codes := make(map[string]bool, len(list))
+	for _, b := range list {
+		codes[b.BookingCode] = true
+	}
+	return codes, nil
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"log"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+)
+
+// getBooking 從平台讀取預約並記錄耗時，超過門檻時以耗時分解（平台支援時）記錄變慢警告
+func (h *WebhookHandler) getBooking(src source.Source, bookingID string) (*simplybook.Booking, error) {
+	start := time.Now()
+	var (
+		booking *simplybook.Booking
+		timing  *simplybook.Timing
+		err     error
+	)
+	if timed, ok := src.(source.TimedGetter); ok {
+		booking, timing, err = timed.GetBookingTimed(bookingID)
+	} else {
+		booking, err = src.GetBooking(bookingID)
+	}
+	elapsed := time.Since(start)
+
+	metrics.BookingFetchDuration.WithLabelValues(src.Name()).Observe(elapsed.Seconds())
+	if elapsed >= h.opts.SlowFetchThreshold {
+		metrics.SlowBookingFetches.WithLabelValues(src.Name()).Inc()
+		breakdown := "平台未提供耗時分解"
+		if timing != nil {
+			breakdown = timing.String()
+		}
+		log.Printf("讀取 %s 預約 %s 耗時 %s，超過 %s，平台可能變慢（%s）",
+			src.Name(), bookingID, elapsed.Round(time.Millisecond), h.opts.SlowFetchThreshold, breakdown)
+	}
+	return booking, err
+}
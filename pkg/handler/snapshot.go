@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/logging"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// BookingVersion 是預約的一個快照版本及其相對上一版的變更
+type BookingVersion struct {
+	Version   int                 `json:"version"`
+	CreatedAt time.Time           `json:"created_at"`
+	Changes   []store.FieldChange `json:"changes,omitempty"`
+	Payload   json.RawMessage     `json:"payload,omitempty"`
+}
+
+// recordSnapshot 儲存從 SimplyBook 讀取的預約快照，內容與上一版不同時記錄變更的欄位。
+// 快照失敗不影響同步
+func (h *WebhookHandler) recordSnapshot(bookingID string, booking *simplybook.Booking) {
+	payload, err := json.Marshal(booking)
+	if err != nil {
+		log.Printf("序列化預約 %s 快照失敗: %v", bookingID, err)
+		return
+	}
+
+	previous, err := h.store.ListBookingSnapshots(bookingID)
+	if err != nil {
+		log.Printf("讀取預約 %s 快照失敗: %v", bookingID, err)
+		return
+	}
+
+	snap := &store.BookingSnapshot{BookingID: bookingID, Payload: payload}
+	saved, err := h.store.SaveBookingSnapshot(snap)
+	if err != nil {
+		log.Printf("儲存預約 %s 快照失敗: %v", bookingID, err)
+		return
+	}
+	if !saved || len(previous) == 0 {
+		return
+	}
+
+	// 欄位值可能包含客戶資料，僅在除錯時記錄
+	changes := diffSnapshots(previous[len(previous)-1].Payload, payload)
+	fields := make([]string, len(changes))
+	for i, c := range changes {
+		fields[i] = c.Field
+		logging.Debugf("預約 %s 的欄位 %s 變更: %q -> %q", bookingID, c.Field, c.Old, c.New)
+	}
+	log.Printf("預約 %s 更新至第 %d 版，變更欄位: %s", bookingID, snap.Version, strings.Join(fields, ", "))
+}
+
+// BookingHistory 返回預約的所有快照版本，以及每一版相對上一版的變更
+func (h *WebhookHandler) BookingHistory(bookingID string) ([]*BookingVersion, error) {
+	snapshots, err := h.store.ListBookingSnapshots(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]*BookingVersion, len(snapshots))
+	for i, snap := range snapshots {
+		versions[i] = &BookingVersion{
+			Version:   snap.Version,
+			CreatedAt: snap.CreatedAt,
+			Payload:   snap.Payload,
+		}
+		if i > 0 {
+			versions[i].Changes = diffSnapshots(snapshots[i-1].Payload, snap.Payload)
+		}
+	}
+	return versions, nil
+}
+
+// diffSnapshots 比較兩個預約快照，返回依欄位路徑排序的變更
+func diffSnapshots(previous, next json.RawMessage) []store.FieldChange {
+	oldFields := flattenJSON(previous)
+	newFields := flattenJSON(next)
+
+	seen := make(map[string]bool)
+	var changes []store.FieldChange
+	for _, fields := range []map[string]string{oldFields, newFields} {
+		for field := range fields {
+			if seen[field] {
+				continue
+			}
+			seen[field] = true
+			if oldFields[field] != newFields[field] {
+				changes = append(changes, store.FieldChange{Field: field, Old: oldFields[field], New: newFields[field]})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// flattenJSON 將 JSON 物件展開為「欄位路徑 → 值」，例如 client.email；無法解析時返回空結果
+func flattenJSON(data json.RawMessage) map[string]string {
+	fields := make(map[string]string)
+	var v interface{}
+	if len(data) == 0 || json.Unmarshal(data, &v) != nil {
+		return fields
+	}
+	flattenValue("", v, fields)
+	return fields
+}
+
+// flattenValue 遞迴展開 JSON 值
+func flattenValue(path string, v interface{}, fields map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenValue(childPath, child, fields)
+		}
+	case []interface{}:
+		for i, child := range value {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	case nil:
+		fields[path] = ""
+	case string:
+		fields[path] = value
+	default:
+		fields[path] = fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/version"
+)
+
+// stampEvent 在事件的私有擴充屬性標記預約平台、租戶、預約識別碼與服務版本，保留 hook 或渲染已設置的其他屬性
+func (h *WebhookHandler) stampEvent(bookingID string, booking *simplybook.Booking, event *gcalendar.CalendarEvent) {
+	sourceName := booking.Source
+	if sourceName == "" {
+		sourceName = h.primary.Name()
+	}
+
+	if event.Properties == nil {
+		event.Properties = make(map[string]string)
+	}
+	event.Properties[gcalendar.PropertySource] = sourceName
+	event.Properties[gcalendar.PropertyBookingID] = bookingID
+	event.Properties[gcalendar.PropertyVersion] = version.Version
+	if tenant := h.tenantOf(sourceName); tenant != "" {
+		event.Properties[gcalendar.PropertyTenant] = tenant
+	}
+}
+
+// bookingSource 依預約識別碼的前綴返回預約平台名稱，沒有前綴時為主要平台
+func (h *WebhookHandler) bookingSource(bookingID string) string {
+	if name, _ := source.SplitBookingID(bookingID); name != "" {
+		return name
+	}
+	return h.primary.Name()
+}
+
+// tenantOf 返回預約平台在事件標記中的租戶：主要平台為 Options.Tenant，其他平台為平台名稱
+func (h *WebhookHandler) tenantOf(sourceName string) string {
+	if sourceName == h.primary.Name() {
+		return h.opts.Tenant
+	}
+	return sourceName
+}
+
+// foreignEvent 判斷事件是否由共用日曆的其他租戶標記；沒有標記的事件（手動建立或升級前建立）不視為其他租戶
+func (h *WebhookHandler) foreignEvent(event *gcalendar.CalendarEvent) bool {
+	tenant, ok := event.Properties[gcalendar.PropertyTenant]
+	if !ok {
+		return false
+	}
+	return tenant != h.tenantOf(event.Properties[gcalendar.PropertySource])
+}
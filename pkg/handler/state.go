@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// bookingStateRecords 查詢預約狀態時返回的最近同步記錄筆數
+const bookingStateRecords = 20
+
+// BookingState 彙整單一預約的同步狀態，供支援人員排查
+type BookingState struct {
+	BookingID   string              `json:"booking_id"`
+	Snapshot    *BookingVersion     `json:"snapshot"`              // 最近一次讀取的預約快照
+	Mapping     *store.Mapping      `json:"mapping"`               // 對應的日曆事件，尚未建立時為 nil
+	Event       *EventState         `json:"event,omitempty"`       // 日曆上的事件現況
+	EventError  string              `json:"event_error,omitempty"` // 讀取日曆事件失敗的原因
+	LastSync    *store.SyncRecord   `json:"last_sync"`
+	RecentSyncs []*store.SyncRecord `json:"recent_syncs"`
+	Pending     *store.PendingSync  `json:"pending"`    // 等待 Google 日曆恢復後補送的操作
+	SyncState   *store.SyncState    `json:"sync_state"` // 最近一次同步在狀態機中的位置
+}
+
+// EventState 是日曆事件的摘要
+type EventState struct {
+	ID         string    `json:"id"`
+	CalendarID string    `json:"calendar_id"`
+	Summary    string    `json:"summary"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Status     string    `json:"status"`
+	Updated    time.Time `json:"updated"`
+	Link       string    `json:"link"`
+}
+
+// BookingState 從同步狀態儲存彙整預約的快照、對應事件、同步結果與暫存操作，
+// 並讀取日曆上的事件現況；沒有任何記錄時返回 nil
+func (h *WebhookHandler) BookingState(bookingID string) (*BookingState, error) {
+	state := &BookingState{BookingID: bookingID}
+
+	snapshots, err := h.store.ListBookingSnapshots(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("讀取預約快照失敗: %w", err)
+	}
+	if n := len(snapshots); n > 0 {
+		latest := snapshots[n-1]
+		state.Snapshot = &BookingVersion{Version: latest.Version, CreatedAt: latest.CreatedAt, Payload: latest.Payload}
+		if n > 1 {
+			state.Snapshot.Changes = diffSnapshots(snapshots[n-2].Payload, latest.Payload)
+		}
+	}
+
+	if state.Mapping, err = h.store.GetMapping(bookingID); err != nil {
+		return nil, fmt.Errorf("讀取事件對應關係失敗: %w", err)
+	}
+	if state.RecentSyncs, err = h.store.ListBookingSyncRecords(bookingID, bookingStateRecords); err != nil {
+		return nil, fmt.Errorf("讀取同步記錄失敗: %w", err)
+	}
+	if len(state.RecentSyncs) > 0 {
+		state.LastSync = state.RecentSyncs[0]
+	}
+	if state.Pending, err = h.store.GetPendingSync(bookingID); err != nil {
+		return nil, fmt.Errorf("讀取暫存操作失敗: %w", err)
+	}
+	if state.SyncState, err = h.store.GetSyncState(bookingID); err != nil {
+		return nil, fmt.Errorf("讀取同步狀態失敗: %w", err)
+	}
+
+	if state.Snapshot == nil && state.Mapping == nil && state.LastSync == nil && state.Pending == nil && state.SyncState == nil {
+		return nil, nil
+	}
+
+	// 降級模式期間不呼叫 Google 日曆
+	switch {
+	case state.Mapping == nil:
+	case h.calendarDegraded():
+		state.EventError = errCalendarDegraded.Error()
+	default:
+		event, err := h.calendarClient.GetEvent(state.Mapping.CalendarID, state.Mapping.EventID)
+		if err != nil {
+			state.EventError = err.Error()
+			break
+		}
+		state.Event = &EventState{
+			ID:         event.ID,
+			CalendarID: event.CalendarID,
+			Summary:    event.Summary,
+			Start:      event.StartTime,
+			End:        event.EndTime,
+			Status:     event.Status,
+			Updated:    event.Updated,
+			Link:       event.HTMLLink,
+		}
+	}
+
+	return state, nil
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// 預約同步的狀態，每次同步從 received 開始，依序經過內建階段，以 confirmed 或 failed 結束
+const (
+	SyncReceived  = "received"  // 開始同步
+	SyncFetched   = "fetched"   // 已讀取預約
+	SyncRendered  = "rendered"  // 已產生日曆事件
+	SyncApplied   = "applied"   // 已寫入日曆
+	SyncConfirmed = "confirmed" // 同步完成，包括依規則略過
+	SyncFailed    = "failed"    // 任一階段失敗
+)
+
+// syncStateNone 是尚無同步狀態時的起點，僅用於轉換表與指標標籤
+const syncStateNone = "none"
+
+// syncTransitions 列出每個狀態允許轉換的下一個狀態；
+// 略過或取消的預約可能不經 rendered 或 applied 直接完成
+var syncTransitions = map[string][]string{
+	syncStateNone: {SyncReceived},
+	SyncReceived:  {SyncFetched, SyncFailed},
+	SyncFetched:   {SyncRendered, SyncConfirmed, SyncFailed},
+	SyncRendered:  {SyncApplied, SyncConfirmed, SyncFailed},
+	SyncApplied:   {SyncConfirmed, SyncFailed},
+	SyncConfirmed: {SyncReceived},
+	SyncFailed:    {SyncReceived},
+}
+
+// stageStates 是內建階段成功後進入的狀態
+var stageStates = map[string]string{
+	StageFetch:  SyncFetched,
+	StageRender: SyncRendered,
+	StageApply:  SyncApplied,
+}
+
+// errInvalidSyncTransition 表示同步狀態的轉換不在 syncTransitions 中
+var errInvalidSyncTransition = errors.New("無效的同步狀態轉換")
+
+// validSyncTransition 判斷是否允許從 from 轉換為 to
+func validSyncTransition(from, to string) bool {
+	for _, next := range syncTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// syncMachine 追蹤單次同步的狀態，每次轉換都寫入同步狀態儲存
+type syncMachine struct {
+	h     *WebhookHandler
+	state *store.SyncState
+}
+
+// beginSync 將預約的同步狀態重設為 received。上次同步停在中間狀態（例如程序在寫入日曆時中斷）
+// 時仍重新開始，但記錄日誌並計入無效轉換，方便追查未完成的同步
+func (h *WebhookHandler) beginSync(s *SyncContext) *syncMachine {
+	from := syncStateNone
+	previous, err := h.store.GetSyncState(s.BookingID)
+	if err != nil {
+		log.Printf("讀取預約 %s 的同步狀態失敗: %v", s.BookingID, err)
+	} else if previous != nil {
+		from = previous.State
+	}
+	if !validSyncTransition(from, SyncReceived) {
+		log.Printf("預約 %s 上次的 %s 同步停在 %s 未完成，重新開始同步", s.BookingID, previous.Action, from)
+		metrics.InvalidSyncTransitions.WithLabelValues(from, SyncReceived).Inc()
+	}
+
+	now := time.Now()
+	m := &syncMachine{h: h, state: &store.SyncState{
+		BookingID: s.BookingID,
+		State:     SyncReceived,
+		Action:    s.Action,
+		StartedAt: now,
+		UpdatedAt: now,
+	}}
+	m.save()
+	return m
+}
+
+// advance 轉換到下一個狀態，轉換無效時返回錯誤且不改變狀態
+func (m *syncMachine) advance(to string) error {
+	return m.transition(to, "")
+}
+
+// fail 以失敗原因結束本次同步
+func (m *syncMachine) fail(cause error) {
+	if err := m.transition(SyncFailed, cause.Error()); err != nil {
+		log.Printf("預約 %s 的同步狀態: %v", m.state.BookingID, err)
+	}
+}
+
+// transition 檢查並執行狀態轉換
+func (m *syncMachine) transition(to, reason string) error {
+	from := m.state.State
+	if !validSyncTransition(from, to) {
+		metrics.InvalidSyncTransitions.WithLabelValues(from, to).Inc()
+		return fmt.Errorf("%w: %s → %s", errInvalidSyncTransition, from, to)
+	}
+
+	m.state.State = to
+	m.state.Error = reason
+	m.state.UpdatedAt = time.Now()
+	m.save()
+	return nil
+}
+
+// save 寫入同步狀態，失敗時僅記錄日誌，不影響同步本身
+func (m *syncMachine) save() {
+	if err := m.h.store.SaveSyncState(m.state); err != nil {
+		log.Printf("儲存預約 %s 的同步狀態失敗: %v", m.state.BookingID, err)
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+)
+
+// syncTravelEvents 依規則建立或更新預約前後的交通事件；預約取消、規則不再適用或預約移到其他日曆時
+// 刪除既有的交通事件。交通事件以預約識別碼的擴充屬性查找，不儲存對應關係
+func (h *WebhookHandler) syncTravelEvents(s *SyncContext) error {
+	if !h.renderer.HasTravelEvents() {
+		return nil
+	}
+	// 事件已存在的 create 沒有重新渲染，交通事件維持不變；hook 否決建立時也沒有預約事件
+	if s.Action != "cancel" && (s.Event == nil || s.EventID == "") {
+		return nil
+	}
+
+	var wanted []*gcalendar.CalendarEvent
+	targetCalendar := ""
+	if s.Action != "cancel" {
+		wanted = h.renderer.TravelEvents(s.Booking, s.Event)
+		targetCalendar = h.calendarClient.ResolveCalendar(s.Event.CalendarID)
+	}
+
+	// 預約移到其他日曆時，既有的交通事件仍在原本的日曆
+	calendars := []string{h.calendarClient.ResolveCalendar(s.CalendarID)}
+	if targetCalendar != "" && targetCalendar != calendars[0] {
+		calendars = append(calendars, targetCalendar)
+	}
+
+	existing := make(map[string]*gcalendar.CalendarEvent)
+	var stale []*gcalendar.CalendarEvent
+	for _, calendarID := range calendars {
+		events, err := h.calendarClient.FindEventsByProperty(calendarID, gcalendar.PropertyBookingID, s.BookingID)
+		if err != nil {
+			return fmt.Errorf("查找交通事件失敗: %w", err)
+		}
+		for _, event := range events {
+			position := event.Properties[gcalendar.PropertyTravel]
+			// 略過預約事件本身與共用日曆中其他租戶的事件
+			if position == "" || event.Cancelled() || h.foreignEvent(event) {
+				continue
+			}
+			if _, dup := existing[position]; dup || calendarID != targetCalendar {
+				stale = append(stale, event)
+				continue
+			}
+			existing[position] = event
+		}
+	}
+
+	for _, event := range wanted {
+		h.stampEvent(s.BookingID, s.Booking, event)
+		position := event.Properties[gcalendar.PropertyTravel]
+		if current, ok := existing[position]; ok {
+			delete(existing, position)
+			if err := h.calendarClient.UpdateEvent(current.ID, event); err != nil {
+				return fmt.Errorf("更新交通事件失敗: %w", err)
+			}
+			continue
+		}
+		eventID, err := h.calendarClient.CreateEvent(event)
+		if err != nil {
+			return fmt.Errorf("創建交通事件失敗: %w", err)
+		}
+		log.Printf("為預約 %s 創建了交通事件 %s（%s）", s.BookingID, eventID, position)
+	}
+
+	for _, event := range existing {
+		stale = append(stale, event)
+	}
+	for _, event := range stale {
+		if err := h.calendarClient.DeleteEvent(event.CalendarID, event.ID); err != nil {
+			return fmt.Errorf("刪除交通事件失敗: %w", err)
+		}
+		log.Printf("刪除了預約 %s 的交通事件 %s", s.BookingID, event.ID)
+	}
+	return nil
+}
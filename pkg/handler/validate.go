@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// BookingValidationError 表示預約時間不合理，拒絕寫入日曆
+type BookingValidationError struct {
+	BookingID string
+	Field     string
+	Reason    string
+}
+
+func (e *BookingValidationError) Error() string {
+	return fmt.Sprintf("預約 %s 的 %s 無效: %s", e.BookingID, e.Field, e.Reason)
+}
+
+// validateBooking 檢查預約時間是否合理，避免將錯誤的事件寫入日曆
+func (h *WebhookHandler) validateBooking(booking *simplybook.Booking) error {
+	bookingID := booking.Key()
+	start, end := booking.StartTime.Time, booking.EndTime.Time
+
+	switch {
+	case start.IsZero() || start.Unix() <= 0:
+		return &BookingValidationError{BookingID: bookingID, Field: "start_datetime", Reason: "開始時間為空或為 1970 年"}
+	case end.IsZero() || end.Unix() <= 0:
+		return &BookingValidationError{BookingID: bookingID, Field: "end_datetime", Reason: "結束時間為空或為 1970 年"}
+	case end.Before(start):
+		return &BookingValidationError{BookingID: bookingID, Field: "end_datetime",
+			Reason: fmt.Sprintf("結束時間 %s 早於開始時間 %s", end.Format("2006-01-02 15:04:05"), start.Format("2006-01-02 15:04:05"))}
+	case end.Sub(start) > h.opts.MaxDuration:
+		return &BookingValidationError{BookingID: bookingID, Field: "end_datetime",
+			Reason: fmt.Sprintf("時長 %s 超過上限 %s", end.Sub(start), h.opts.MaxDuration)}
+	}
+
+	return nil
+}
+
+// deadLetter 將無法同步的預約寫入死信，寫入失敗僅記錄日誌
+func (h *WebhookHandler) deadLetter(action string, booking *simplybook.Booking, reason error) {
+	payload, err := json.Marshal(booking)
+	if err != nil {
+		log.Printf("編碼預約 %s 的快照失敗: %v", booking.Key(), err)
+	}
+
+	dead := &store.DeadLetter{
+		BookingID: booking.Key(),
+		Action:    action,
+		Reason:    reason.Error(),
+		Payload:   payload,
+	}
+	if err := h.store.AddDeadLetter(dead); err != nil {
+		log.Printf("寫入預約 %s 的死信失敗: %v", booking.Key(), err)
+	}
+}
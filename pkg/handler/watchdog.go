@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// heartbeatInterval 寫入 webhook 接收時間的最短間隔，避免每個 webhook 都寫入儲存
+const heartbeatInterval = time.Minute
+
+// webhookWatchdog 記錄各平台最後收到 webhook 的時間，供多個實例共同判斷 webhook 訂閱是否失效
+type webhookWatchdog struct {
+	store   store.Store
+	started time.Time // 儲存中沒有接收記錄時，以服務啟動時間起算
+
+	mu        sync.Mutex
+	saved     map[string]time.Time // 各平台最後寫入儲存的時間
+	alertedAt time.Time            // 最後一次發送告警的時間
+}
+
+// newWebhookWatchdog 創建 webhook 監控
+func newWebhookWatchdog(syncStore store.Store) *webhookWatchdog {
+	return &webhookWatchdog{
+		store:   syncStore,
+		started: time.Now(),
+		saved:   make(map[string]time.Time),
+	}
+}
+
+// heartbeat 記錄收到已驗證的 webhook，距上次寫入超過 heartbeatInterval 時才寫入儲存
+func (w *webhookWatchdog) heartbeat(name string) {
+	now := time.Now()
+	metrics.WebhookLastReceived.WithLabelValues(name).Set(float64(now.Unix()))
+
+	w.mu.Lock()
+	if now.Sub(w.saved[name]) < heartbeatInterval {
+		w.mu.Unlock()
+		return
+	}
+	w.saved[name] = now
+	w.mu.Unlock()
+
+	if err := w.store.SaveWebhookHeartbeat(name, now); err != nil {
+		log.Printf("記錄 %s webhook 接收時間失敗: %v", name, err)
+	}
+}
+
+// shouldAlert 判斷距上次告警是否已超過 cooldown
+func (w *webhookWatchdog) shouldAlert(now time.Time, cooldown time.Duration) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if now.Sub(w.alertedAt) < cooldown {
+		return false
+	}
+	w.alertedAt = now
+	return true
+}
+
+// CatchUpResult 代表一次輪詢補同步的結果
+type CatchUpResult struct {
+	Checked   int `json:"checked"`
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Cancelled int `json:"cancelled"`
+	Failed    int `json:"failed"`
+}
+
+// CheckWebhooks 在營業時間內超過 WebhookSilence 未收到 SimplyBook webhook 時，
+// 輪詢補同步期間遺漏的預約並通知維運人員檢查 webhook 訂閱
+func (h *WebhookHandler) CheckWebhooks() error {
+	if h.opts.WebhookSilence <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if !h.opts.BusinessHours.For(h.opts.Tenant).Contains(now) {
+		return nil
+	}
+
+	name := h.primary.Name()
+	last, err := h.store.GetWebhookHeartbeat(name)
+	if err != nil {
+		return err
+	}
+	if last.IsZero() {
+		last = h.watchdog.started
+	}
+	silence := now.Sub(last)
+	if silence < h.opts.WebhookSilence {
+		return nil
+	}
+
+	log.Printf("已 %s 未收到 %s webhook，開始輪詢補同步", silence.Round(time.Minute), name)
+	result, catchUpErr := h.CatchUp(context.Background(), last)
+	h.alertWebhookSilence(name, last, result, catchUpErr)
+	return catchUpErr
+}
+
+// alertWebhookSilence 通知維運人員 webhook 可能已失效，每個靜默期間最多通知一次
+func (h *WebhookHandler) alertWebhookSilence(name string, last time.Time, result *CatchUpResult, catchUpErr error) {
+	if h.opts.OpsNotifier == nil || !h.watchdog.shouldAlert(time.Now(), h.opts.WebhookSilence) {
+		return
+	}
+
+	subject := fmt.Sprintf("已 %s 未收到 %s webhook", time.Since(last).Round(time.Minute), name)
+	body := fmt.Sprintf("最後一次收到 webhook: %s\n\nwebhook 訂閱可能已失效，請檢查 SimplyBook 後台的回呼網址設定。",
+		last.Format("2006-01-02 15:04"))
+	if catchUpErr != nil {
+		body += fmt.Sprintf("\n\n輪詢補同步失敗: %v", catchUpErr)
+	} else {
+		body += fmt.Sprintf("\n\n已輪詢補同步: 檢查 %d 筆，建立 %d 筆，更新 %d 筆，取消 %d 筆，失敗 %d 筆",
+			result.Checked, result.Created, result.Updated, result.Cancelled, result.Failed)
+	}
+
+	if err := h.opts.OpsNotifier.Notify(subject, body); err != nil {
+		log.Printf("發送 webhook 監控告警失敗: %v", err)
+	}
+}
+
+// CatchUp 輪詢 SimplyBook 中開始時間在 from 到 CatchUpWindow 之後的預約，
+// 補建遺漏的事件、刪除已取消預約的事件，並更新與預約不一致的事件
+func (h *WebhookHandler) CatchUp(ctx context.Context, from time.Time) (*CatchUpResult, error) {
+	lister, ok := h.primary.(source.Lister)
+	if !ok {
+		return nil, fmt.Errorf("%s 不支援列出預約", h.primary.Name())
+	}
+
+	bookings, err := lister.ListBookings(from, time.Now().Add(h.opts.CatchUpWindow))
+	if err != nil {
+		return nil, fmt.Errorf("列出預約失敗: %w", err)
+	}
+
+	skipped, err := h.skippedCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CatchUpResult{}
+	for bookingID, booking := range bookings {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if skipped[booking.Code] {
+			continue
+		}
+		result.Checked++
+
+		action, err := h.catchUpAction(bookingID, booking)
+		if err == nil && action != "" {
+			err = h.resync("catchup", action, bookingID)
+		}
+		if err != nil {
+			log.Printf("補同步預約 %s 失敗: %v", bookingID, err)
+			result.Failed++
+			continue
+		}
+
+		switch action {
+		case "create":
+			result.Created++
+		case "change":
+			result.Updated++
+		case "cancel":
+			result.Cancelled++
+		}
+	}
+
+	log.Printf("輪詢補同步完成: 檢查 %d 筆，建立 %d 筆，更新 %d 筆，取消 %d 筆，失敗 %d 筆",
+		result.Checked, result.Created, result.Updated, result.Cancelled, result.Failed)
+	return result, nil
+}
+
+// catchUpAction 比對預約與對應關係，返回需要補同步的操作，已一致時返回空字串
+func (h *WebhookHandler) catchUpAction(bookingID string, booking *simplybook.Booking) (string, error) {
+	mapping, err := h.store.GetMapping(bookingID)
+	if err != nil {
+		return "", fmt.Errorf("讀取事件對應關係失敗: %w", err)
+	}
+
+	cancelled := isCancelled(booking.Status)
+	switch {
+	case mapping == nil && cancelled:
+		return "", nil
+	case mapping == nil:
+		// 不會同步的預約不列入，避免每次輪詢都留下同步記錄
+		h.renderer.Localize(booking)
+		if !h.inSyncWindow(booking) || h.outOfScope(booking) != "" || h.renderer.Skip(booking) {
+			return "", nil
+		}
+		return "create", nil
+	case cancelled:
+		return "cancel", nil
+	}
+
+	reason, err := h.checkDrift(bookingID, mapping.EventID, mapping.CalendarID)
+	if err != nil {
+		return "", err
+	}
+	switch reason {
+	case "", reasonBeforeWindow:
+		return "", nil
+	case reasonEventDeleted:
+		// 與對帳一致：事件已從日曆刪除時移除對應關係並重新建立
+		if err := h.store.DeleteMapping(bookingID); err != nil {
+			return "", fmt.Errorf("移除對應關係失敗: %w", err)
+		}
+		return "create", nil
+	}
+	return "change", nil
+}
+
+// isCancelled 判斷預約狀態是否為已取消
+func isCancelled(status string) bool {
+	return strings.EqualFold(status, "canceled") || strings.EqualFold(status, "cancelled")
+}
@@ -1,74 +1,365 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
-
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/audit"
+	"github.com/booking-sync-455103/booking-sync/pkg/confirm"
+	"github.com/booking-sync-455103/booking-sync/pkg/dedup"
+	"github.com/booking-sync-455103/booking-sync/pkg/errreport"
+	"github.com/booking-sync-455103/booking-sync/pkg/export"
 	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/hours"
+	"github.com/booking-sync-455103/booking-sync/pkg/lock"
+	"github.com/booking-sync-455103/booking-sync/pkg/logging"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+	"github.com/booking-sync-455103/booking-sync/pkg/notify"
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+	"github.com/booking-sync-455103/booking-sync/pkg/reminder"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
 	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
 )
 
 // WebhookHandler 處理 SimplyBook webhook 通知
 type WebhookHandler struct {
-	simplybookClient *simplybook.Client
-	calendarClient   *gcalendar.Client
-	secretToken      string // 可選的安全令牌，用於驗證請求
+	calendarClient *gcalendar.Client
+	store          store.Store
+	primary        source.Source            // SimplyBook 轉接器，處理不帶前綴的預約識別碼
+	sources        map[string]source.Source // 依平台名稱索引的其他預約平台
+	pending        atomic.Int64             // 尚在處理中的 webhook 事件數量
+	inflight       sync.WaitGroup           // 背景處理中的 webhook 事件，關閉服務時等待其完成
+	opts           Options
+	bookingLocks   *bookingLocks
+	classLocks     *bookingLocks // 團體課事件的鎖，與預約鎖分開以免巢狀取得時落在同一分片
+	alerter        *calendarAlerter
+	latency        *latencyTracker
+	renderer       *render.Renderer
+	bookings       *simplybook.BookingCache
+	debounce       *debouncer // 未設置 Debounce 時為 nil
+	watcher        *calendarWatcher
+	degradedUntil  atomic.Int64 // 降級模式的結束時間（Unix 奈秒），期間同步操作改為暫存
+	throttledUntil atomic.Int64 // API 以 Retry-After 要求等待的結束時間（Unix 奈秒），期間同步操作改為暫存
+	watchdog       *webhookWatchdog
+	notFound       *notFoundCache
+	stages         []Stage
+}
+
+// Options 包含 webhook 處理器的可選設定
+type Options struct {
+	Deduper  dedup.Deduper // webhook 去重器，未設置時使用記憶體實作
+	DedupTTL time.Duration // 相同 webhook 視為重複的時間窗口
+	Locker   lock.Locker   // 跨實例的預約鎖，未設置時僅在本實例內互斥
+	LockTTL  time.Duration // 預約鎖的租約時間
+
+	Reminders     *reminder.Scheduler // 簡訊提醒排程器，未設置時不發送提醒
+	Confirmations *confirm.Sender     // 客戶確認郵件發送器，未設置時不寄送
+	Approvals     ApprovalRequester   // 待核准預約的核准請求（例如 Slack 按鈕），未設置時不通知
+	Reports       export.ReportSink   // 同步成功的預約報表輸出（試算表、每日 CSV 或 BigQuery），未設置時不輸出
+	Enrichers     []Enricher          // 同步前補充預約資料（例如付款狀態）
+
+	Renderer *render.Renderer // 事件渲染器，未設置時使用預設格式
+
+	Sources []source.Source // SimplyBook 以外的預約平台（例如 Calendly）
+
+	PastWindow   time.Duration // 略過結束時間早於此範圍的預約，0 表示不限制
+	FutureWindow time.Duration // 略過開始時間晚於此範圍的預約，0 表示不限制
+
+	Scope Scope // 限定同步的服務提供者與服務，未設置時全部同步
+
+	TimeTolerance time.Duration // 對帳時開始與結束時間差異在此範圍內視為一致
+
+	BookingCacheSize int           // 最近預約快取的容量
+	BookingCacheTTL  time.Duration // 快取預約的存活時間；webhook 一律捨棄快取，快取僅供補送等非 webhook 的同步沿用
+
+	NotFoundThreshold int           // 預約連續查無資料達此次數後暫停查詢
+	NotFoundTTL       time.Duration // 暫停查詢查無資料預約的時間
+
+	SlowFetchThreshold time.Duration // 讀取預約超過此時間時記錄耗時分解並計入指標
+
+	// LatencySLO 最近 webhook 從平台產生到寫入日曆的延遲 p95 超過此值時告警，0 表示不告警
+	LatencySLO time.Duration
+
+	MaxQueueDepth int           // 處理中的 webhook 超過此數量時回應 429
+	RetryAfter    time.Duration // 回應 429 時的 Retry-After
+
+	MaxWebhookAge time.Duration // 拒絕時間戳超出此範圍的 webhook 以防重放，0 表示不檢查
+	ReplayToken   string        // ReplayHeader 攜帶此令牌時略過時間戳檢查，空字串表示不允許略過
+
+	DegradedBackoff time.Duration // Google 日曆無法使用後，暫停呼叫並直接暫存同步操作的時間
+
+	MaxDuration time.Duration // 預約時長上限，超過時拒絕寫入日曆
+
+	// SendUpdates 建立、取消與重要更新時通知事件參與者的方式（gcalendar.SendUpdates* 常數），預設 all
+	SendUpdates string
+	// UpdateSignificance 更新的欄位變更分數達此值才通知參與者，低於此值（例如僅描述變更）時不寄送郵件
+	UpdateSignificance int
+
+	Debounce time.Duration // 合併同一預約在此時間內的 change webhook 為一次同步，0 表示不合併
+
+	// WatchAddress 日曆變更通知的公開網址，設置時將日曆上編輯的備註寫回預約平台（需啟用描述中的備註區塊）
+	WatchAddress   string
+	WatchToken     string        // 驗證通知來源的頻道令牌
+	WatchTTL       time.Duration // 通知頻道的有效期
+	WatchCalendars []string      // 預設日曆以外需訂閱的日曆（例如規則指定的日曆）
+
+	Hooks  []Hook  // 寫入日曆前執行的自訂邏輯，可修改事件或否決操作
+	Stages []Stage // 插入同步流程的自訂步驟（例如補充資料或通知）
+
+	// WebhookSilence 營業時間內超過此時間未收到 SimplyBook webhook 時告警並輪詢補同步，0 表示不檢查
+	WebhookSilence time.Duration
+	BusinessHours  *hours.Set    // 各租戶的營業時間，nil 表示全天
+	Tenant         string        // SimplyBook company login，用於查詢營業時間
+	CatchUpWindow  time.Duration // 輪詢補同步涵蓋的未來預約範圍
+
+	OpsNotifier   notify.Notifier // 日曆配額或權限錯誤的維運通知，未設置時僅記錄日誌
+	AlertCooldown time.Duration   // 相同類型告警的最短間隔
+
+	Audit  *audit.Forwarder    // 將同步記錄轉送到 syslog 或 fluentd，未設置時不轉送
+	Errors *errreport.Reporter // 將同步失敗回報到 Sentry 或 Google Error Reporting，未設置時不回報
+}
+
+// Enricher 在同步前以外部資料補充預約（例如 Stripe 付款狀態），
+// 每次從平台讀取預約時執行，失敗時僅記錄日誌並以原資料同步
+type Enricher interface {
+	Enrich(booking *simplybook.Booking) error
+}
+
+// NewWebhookHandler 創建以 SimplyBook 為主要預約平台的 webhook 處理器
+func NewWebhookHandler(simplybookClient *simplybook.Client, calendarClient *gcalendar.Client, syncStore store.Store, secrets map[string]source.WebhookSecret, opts Options) *WebhookHandler {
+	return New(source.NewSimplyBook(simplybookClient, secrets), calendarClient, syncStore, opts)
+}
+
+// New 創建新的 webhook 處理器，primary 處理不帶平台前綴的預約識別碼
+func New(primary source.Source, calendarClient *gcalendar.Client, syncStore store.Store, opts Options) *WebhookHandler {
+	if opts.Deduper == nil {
+		opts.Deduper = dedup.NewMemoryDeduper()
+	}
+	if opts.DedupTTL <= 0 {
+		opts.DedupTTL = 10 * time.Minute
+	}
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = 2 * time.Minute
+	}
+	if opts.TimeTolerance <= 0 {
+		opts.TimeTolerance = time.Minute
+	}
+	if opts.Renderer == nil {
+		opts.Renderer, _ = render.New(render.Options{})
+	}
+	if opts.CatchUpWindow <= 0 {
+		opts.CatchUpWindow = 30 * 24 * time.Hour
+	}
+	if opts.AlertCooldown <= 0 {
+		opts.AlertCooldown = 15 * time.Minute
+	}
+	if opts.BookingCacheSize <= 0 {
+		opts.BookingCacheSize = 500
+	}
+	if opts.BookingCacheTTL <= 0 {
+		opts.BookingCacheTTL = 10 * time.Second
+	}
+	if opts.NotFoundThreshold <= 0 {
+		opts.NotFoundThreshold = 2
+	}
+	if opts.NotFoundTTL <= 0 {
+		opts.NotFoundTTL = 5 * time.Minute
+	}
+	if opts.SlowFetchThreshold <= 0 {
+		opts.SlowFetchThreshold = 3 * time.Second
+	}
+	if opts.MaxQueueDepth <= 0 {
+		opts.MaxQueueDepth = 1000
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = 30 * time.Second
+	}
+	if opts.DegradedBackoff <= 0 {
+		opts.DegradedBackoff = 30 * time.Second
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = 12 * time.Hour
+	}
+	if opts.SendUpdates == "" {
+		opts.SendUpdates = gcalendar.SendUpdatesAll
+	}
+	if opts.UpdateSignificance <= 0 {
+		opts.UpdateSignificance = 3
+	}
+	opts.Hooks = append(globalHooks(), opts.Hooks...)
+
+	h := &WebhookHandler{
+		calendarClient: calendarClient,
+		store:          syncStore,
+		primary:        primary,
+		sources:        make(map[string]source.Source),
+		opts:           opts,
+		bookingLocks:   newBookingLocks(),
+		classLocks:     newBookingLocks(),
+		alerter:        newCalendarAlerter(),
+		latency:        &latencyTracker{},
+		renderer:       opts.Renderer,
+		bookings:       simplybook.NewBookingCache(opts.BookingCacheSize, opts.BookingCacheTTL),
+		watcher:        newCalendarWatcher(),
+		watchdog:       newWebhookWatchdog(syncStore),
+		notFound:       newNotFoundCache(opts.NotFoundThreshold, opts.NotFoundTTL),
+	}
+	for _, src := range opts.Sources {
+		h.sources[src.Name()] = src
+	}
+	h.stages = h.buildStages(opts.Stages)
+	if opts.Debounce > 0 {
+		h.debounce = newDebouncer(opts.Debounce, h.processDebounced)
+	}
+	return h
 }
 
-// NewWebhookHandler 創建新的 webhook 處理器
-func NewWebhookHandler(simplybookClient *simplybook.Client, calendarClient *gcalendar.Client, secretToken string) *WebhookHandler {
-	return &WebhookHandler{
-		simplybookClient: simplybookClient,
-		calendarClient:   calendarClient,
-		secretToken:      secretToken,
+// SweepDedup 清除過期或殘留的 webhook 去重鍵
+func (h *WebhookHandler) SweepDedup() error {
+	n, err := h.opts.Deduper.Sweep()
+	if err != nil {
+		return fmt.Errorf("清除去重鍵失敗: %w", err)
 	}
+	log.Printf("已清除 %d 個去重鍵", n)
+	return nil
+}
+
+// QueueDepth 返回尚在處理中的 webhook 事件數量
+func (h *WebhookHandler) QueueDepth() int64 {
+	return h.pending.Load()
 }
 
-// HandleWebhook 處理傳入的 webhook 請求
+// HandleWebhook 處理 SimplyBook 傳入的 webhook 請求
 func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	h.handleSourceWebhook(h.primary, w, r)
+}
+
+// HandleTenant 返回綁定租戶的 SimplyBook webhook 處理函式，以路徑而非負載中的 company 決定租戶
+func (h *WebhookHandler) HandleTenant(tenant string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.handleSourceWebhook(h.primary, w, r.WithContext(source.WithTenant(r.Context(), tenant)))
+	}
+}
+
+// HandleSource 返回處理指定預約平台 webhook 的 HTTP 處理函式，
+// 平台須已透過 Options.Sources 註冊，才能在同步時讀取預約
+func (h *WebhookHandler) HandleSource(src source.Source) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.handleSourceWebhook(src, w, r)
+	}
+}
+
+// handleSourceWebhook 以平台轉接器解析 webhook，正規化後進入同步流程
+func (h *WebhookHandler) handleSourceWebhook(src source.Source, w http.ResponseWriter, r *http.Request) {
 	// 驗證請求方法
 	if r.Method != http.MethodPost {
-		http.Error(w, "僅支持 POST 請求", http.StatusMethodNotAllowed)
+		problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
 		return
 	}
 
-	// 驗證令牌（如果已設置）
-	if h.secretToken != "" {
-		token := r.Header.Get("X-Simplybook-Token")
-		if token != h.secretToken {
-			http.Error(w, "未授權", http.StatusUnauthorized)
-			return
-		}
-	}
-
 	// 讀取並解析請求體
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "讀取請求體失敗", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidPayload, "讀取請求體失敗")
 		return
 	}
 	defer r.Body.Close()
 
-	// 記錄原始的請求數據，以便查看資料格式
-	log.Printf("收到 webhook 請求，原始數據: %s", string(body))
+	// 原始數據可能包含客戶資料，僅在除錯時記錄
+	logging.Debugf("收到 %s webhook 請求，原始數據: %s", src.Name(), string(body))
 
-	var payload simplybook.WebhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Printf("Error: %s", string(err.Error()))
-		http.Error(w, "無效的 JSON 數據", http.StatusBadRequest)
+	validateStart := time.Now()
+	event, err := src.ParseWebhook(r, body)
+	switch {
+	case errors.Is(err, source.ErrUnauthorized):
+		h.logRejected(r, src.Name(), err)
+		observeStage(StageValidate, validateStart, ErrorKindAuth)
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "未授權")
+		return
+	case errors.Is(err, source.ErrIgnored):
+		observeStage(StageValidate, validateStart, "")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("webhook 已忽略"))
+		return
+	case err != nil:
+		log.Printf("解析 %s webhook 失敗: %v，原始數據: %s", src.Name(), err, string(body))
+		observeStage(StageValidate, validateStart, ErrorKindValidation)
+		problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidPayload, "無效的 webhook 數據")
+		return
+	}
+
+	log.Printf("收到 %s webhook: Action=%s, BookingID=%s", src.Name(), event.Action, event.BookingID)
+	h.watchdog.heartbeat(src.Name())
+	logging.Debugf("解析後的資料: %+v", event)
+
+	// 拒絕過舊的 webhook，避免擷取的請求被重放
+	if err := h.checkFreshness(r, event); err != nil {
+		log.Printf("拒絕預約 %s 的 %s webhook: %v", event.BookingID, src.Name(), err)
+		metrics.WebhooksRejected.WithLabelValues(src.Name(), source.RejectStale).Inc()
+		observeStage(StageValidate, validateStart, ErrorKindAuth)
+		problem.Write(w, r, http.StatusUnauthorized, problem.CodeExpired, "webhook 已過期")
+		return
+	}
+	observeStage(StageValidate, validateStart, "")
+
+	// 處理中的事件過多時要求平台稍後重試，須在去重之前檢查，否則重試會被視為重複
+	if depth := h.pending.Add(1); depth > int64(h.opts.MaxQueueDepth) {
+		h.pending.Add(-1)
+		log.Printf("處理中的 webhook 事件過多（%d），拒絕預約 %s 的 webhook", depth-1, event.BookingID)
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.opts.RetryAfter.Seconds())))
+		problem.Write(w, r, http.StatusTooManyRequests, problem.CodeOverloaded, "處理中的事件過多，請稍後重試")
+		return
+	}
+	h.inflight.Add(1)
+
+	// 忽略重複送達的 webhook
+	dedupKey := fmt.Sprintf("%s:%s:%s", event.BookingID, event.Action, event.Timestamp)
+	dedupeStart := time.Now()
+	duplicate, err := h.opts.Deduper.Seen(dedupKey, h.opts.DedupTTL)
+	observeStage(StageDedupe, dedupeStart, ClassifyError(err))
+	if err != nil {
+		// 去重失敗時繼續處理，重複同步的代價低於遺漏
+		log.Printf("webhook 去重檢查失敗: %v", err)
+	}
+	if duplicate {
+		h.finishEvent()
+		log.Printf("忽略重複的 webhook: %s", dedupKey)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("重複的 webhook 已忽略"))
 		return
 	}
 
-	// 記錄解析後的資料結構
-	log.Printf("解析後的資料: Action=%s, BookingID=%s", payload.Action, payload.BookingID)
+	// 短時間內的連續變更合併為一次同步
+	if h.debounce != nil {
+		switch h.debounce.add(event) {
+		case debounceMerged:
+			h.finishEvent()
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("webhook 已合併"))
+			return
+		case debounceScheduled:
+			// 處理中的計數在窗口結束、處理完成後釋放
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("webhook 已接收"))
+			return
+		}
+	}
 
 	// 處理 webhook 事件（非同步處理，避免超時）
 	go func() {
-		if err := h.processWebhookEvent(&payload); err != nil {
+		defer h.finishEvent()
+		if err := h.processWebhookEvent(event); err != nil {
 			log.Printf("處理 webhook 事件失敗: %v", err)
 		}
 	}()
@@ -78,126 +369,371 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("webhook 已接收"))
 }
 
+// processDebounced 處理合併窗口結束的事件
+func (h *WebhookHandler) processDebounced(event *source.Event) {
+	defer h.finishEvent()
+	if err := h.processWebhookEvent(event); err != nil {
+		log.Printf("處理 webhook 事件失敗: %v", err)
+	}
+}
+
+// FlushDebounced 立即處理所有等待合併窗口結束的事件，應在關閉服務前呼叫
+func (h *WebhookHandler) FlushDebounced() {
+	if h.debounce != nil {
+		h.debounce.flush()
+	}
+}
+
+// finishEvent 釋放一個處理中的 webhook 事件
+func (h *WebhookHandler) finishEvent() {
+	h.pending.Add(-1)
+	h.inflight.Done()
+}
+
+// Drain 等待背景處理中的 webhook 事件完成，ctx 結束時返回錯誤；
+// 應在停止接收請求並呼叫 FlushDebounced 之後呼叫
+func (h *WebhookHandler) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("仍有 %d 個 webhook 事件未處理完成", h.pending.Load())
+	}
+}
+
 // processWebhookEvent 處理 webhook 事件並更新 Google 日曆
-func (h *WebhookHandler) processWebhookEvent(payload *simplybook.WebhookPayload) error {
-	log.Printf("處理 %s 操作，預約 ID: %s", payload.Action, payload.BookingID)
+func (h *WebhookHandler) processWebhookEvent(event *source.Event) error {
+	log.Printf("處理 %s 操作，預約 ID: %s", event.Action, event.BookingID)
+
+	s := &SyncContext{Action: event.Action, BookingID: event.BookingID, WebhookTime: event.Time}
+	err := h.withBookingLock(event.BookingID, func() error {
+		// 每個 webhook 都代表預約可能已變更，捨棄快取中的舊資料，改向平台讀取最新的預約
+		h.bookings.Remove(event.BookingID)
+		_, _, err := h.syncOrDefer(s)
+		return err
+	})
+	h.recordSync(event.Action, s, err)
+	return err
+}
+
+// syncBooking 根據操作類型同步單一預約，返回相關的日曆事件ID及更新時的欄位差異
+func (h *WebhookHandler) syncBooking(s *SyncContext) (string, []store.FieldChange, error) {
+	s.Action = strings.ToLower(s.Action)
+	err := h.runPipeline(s)
+	return s.EventID, s.Changes, err
+}
 
-	// 先獲取預約詳情和對應的日曆事件ID
-	booking, eventID, err := h.getBookingAndEvent(payload.BookingID)
+// appendReport 將同步成功的預約寫入報表，失敗時僅記錄日誌
+func (h *WebhookHandler) appendReport(action string, booking *simplybook.Booking, bookingID string) {
+	if h.opts.Reports == nil {
+		return
+	}
+
+	if err := h.opts.Reports.Append(export.NewReportRow(action, booking)); err != nil {
+		log.Printf("寫入預約 %s 的報表失敗: %v", bookingID, err)
+	}
+}
+
+// updateReminder 依操作類型排程或取消簡訊提醒，失敗時僅記錄日誌
+func (h *WebhookHandler) updateReminder(action string, booking *simplybook.Booking, bookingID string) {
+	if h.opts.Reminders == nil {
+		return
+	}
+
+	// 候補名單的預約轉正前不發送提醒
+	var err error
+	if action == "cancel" || booking.WaitingList() {
+		err = h.opts.Reminders.Cancel(bookingID)
+	} else {
+		err = h.opts.Reminders.Schedule(booking)
+	}
 	if err != nil {
-		return err
+		log.Printf("更新預約 %s 的提醒失敗: %v", bookingID, err)
+	}
+}
+
+// recordSync 將同步結果寫入儲存，寫入失敗僅記錄日誌；s 為本次同步的狀態，提供事件、欄位差異與指標標籤
+func (h *WebhookHandler) recordSync(action string, s *SyncContext, syncErr error) {
+	bookingID, eventID, changes := s.BookingID, s.EventID, s.Changes
+	record := &store.SyncRecord{
+		BookingID: bookingID,
+		Action:    strings.ToLower(action),
+		EventID:   eventID,
+		EventLink: h.eventLink(bookingID, eventID),
+		Success:   syncErr == nil,
+		Changes:   changes,
+	}
+	if syncErr != nil {
+		record.Error = syncErr.Error()
+		record.ErrorKind = ClassifyError(syncErr)
+		h.alertOnCalendarError(bookingID, record.EventLink, syncErr)
+	}
+
+	if err := h.store.AddSyncRecord(record); err != nil {
+		log.Printf("寫入同步記錄失敗: %v", err)
 	}
 
-	action := strings.ToLower(payload.Action)
+	h.observeSync(record, s)
+	h.forwardAudit(record)
+}
+
+// eventLink 從對應關係取得事件在 Google 日曆的網址，事件已不同或讀取失敗時返回空字串
+func (h *WebhookHandler) eventLink(bookingID, eventID string) string {
+	if eventID == "" {
+		return ""
+	}
+	mapping, err := h.store.GetMapping(bookingID)
+	if err != nil || mapping == nil || mapping.EventID != eventID {
+		return ""
+	}
+	return mapping.EventLink
+}
 
-	// 根據操作類型處理
-	switch action {
-	case "create":
-		return h.handleBookingCreated(booking, eventID, payload.BookingID)
-	case "change":
-		return h.handleBookingUpdated(booking, eventID, payload.BookingID)
-	case "cancel":
-		return h.handleBookingDeleted(eventID, payload.BookingID)
+// observeSync 以本次同步讀取的預約與寫入的日曆更新同步指標；同步在讀取預約前結束時（例如暫存操作），
+// 日曆取自對應關係，服務提供者無從得知
+func (h *WebhookHandler) observeSync(record *store.SyncRecord, s *SyncContext) {
+	calendarID := s.CalendarID
+	switch {
+	case s.Event != nil:
+		// 本次寫入事件的日曆
+		calendarID = s.Event.CalendarID
+	case s.EventID != "":
+		// 取消或事件已存在時沿用事件所在的日曆
+	case s.Booking != nil:
+		// 尚無事件（例如超出同步範圍而略過）時以規則決定的日曆
+		calendarID = h.renderer.CalendarID(s.Booking)
 	default:
-		return fmt.Errorf("不支持的操作類型: %s", payload.Action)
+		// 同步在讀取預約前結束（例如暫存操作）時取自對應關係
+		if mapping, err := h.store.GetMapping(record.BookingID); err == nil && mapping != nil {
+			calendarID = mapping.CalendarID
+		}
+	}
+	var provider string
+	if s.Booking != nil {
+		provider = s.Booking.ProviderName
 	}
+	if h.calendarClient != nil {
+		calendarID = h.calendarClient.ResolveCalendar(calendarID)
+	}
+
+	metrics.ObserveSync(provider, calendarID, record.Action, record.Success)
+}
+
+// ReplayBooking 重新同步指定預約，以 change 操作處理
+func (h *WebhookHandler) ReplayBooking(bookingID string) error {
+	log.Printf("重新同步預約 %s", bookingID)
+	h.notFound.forget(bookingID)
+	return h.resync("replay", "change", bookingID)
+}
+
+// SyncBooking 讀取預約的最新資料後依預約狀態同步：已取消的預約刪除日曆事件，其他預約建立或更新事件；
+// ctx 在讀取預約前與每個同步階段開始前檢查，結束時停止同步，不再寫入日曆
+func (h *WebhookHandler) SyncBooking(ctx context.Context, bookingID string) error {
+	h.notFound.forget(bookingID)
+	h.bookings.Remove(bookingID)
+
+	s := &SyncContext{Action: "change", BookingID: bookingID, Ctx: ctx}
+	err := h.withBookingLock(bookingID, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		booking, err := h.readBooking(bookingID)
+		if err != nil {
+			return fmt.Errorf("獲取預約詳情失敗: %w", err)
+		}
+		if isCancelled(booking.Status) {
+			s.Action = "cancel"
+		}
+		s.Booking = booking
+		_, _, err = h.syncOrDefer(s)
+		return err
+	})
+	h.recordSync("replay", s, err)
+	return err
+}
+
+// resync 讀取最新的預約資料後以指定操作同步，同步記錄的操作為 trigger
+func (h *WebhookHandler) resync(trigger, action, bookingID string) error {
+	h.bookings.Remove(bookingID)
+
+	s := &SyncContext{Action: action, BookingID: bookingID}
+	err := h.withBookingLock(bookingID, func() error {
+		_, _, err := h.syncOrDefer(s)
+		return err
+	})
+	h.recordSync(trigger, s, err)
+	return err
 }
 
-// getBookingAndEvent 獲取預約詳情和對應的日曆事件ID（如存在）
-func (h *WebhookHandler) getBookingAndEvent(bookingID string) (*simplybook.Booking, string, error) {
-	// 獲取預約詳情
-	booking, err := h.simplybookClient.GetBooking(bookingID)
+// fetchBooking 從來源平台讀取預約並補充外部資料，不使用快取
+func (h *WebhookHandler) fetchBooking(bookingID string) (*simplybook.Booking, error) {
+	booking, err := h.readBooking(bookingID)
 	if err != nil {
-		return nil, "", fmt.Errorf("獲取預約詳情失敗: %w", err)
+		return nil, err
 	}
 
-	// 查找現有的日曆事件
-	eventID, err := h.calendarClient.FindEventByBookingCode(booking.Code)
-	if err != nil {
-		return booking, "", fmt.Errorf("查找日曆事件失敗: %w", err)
+	h.enrich(bookingID, booking)
+	return booking, nil
+}
+
+// enrich 以 Enrichers 補充預約資料，失敗時僅記錄日誌
+func (h *WebhookHandler) enrich(bookingID string, booking *simplybook.Booking) {
+	for _, enricher := range h.opts.Enrichers {
+		if err := enricher.Enrich(booking); err != nil {
+			log.Printf("補充預約 %s 的資料失敗: %v", bookingID, err)
+		}
 	}
+}
 
-	return booking, eventID, nil
+// sourceFor 依預約識別碼的前綴找出來源平台，沒有前綴或平台未註冊時視為 SimplyBook 預約
+func (h *WebhookHandler) sourceFor(bookingID string) source.Source {
+	if name, _ := source.SplitBookingID(bookingID); name != "" {
+		if src, ok := h.sources[name]; ok {
+			return src
+		}
+	}
+	return h.primary
 }
 
-// handleBookingCreated 處理新預約創建
-func (h *WebhookHandler) handleBookingCreated(booking *simplybook.Booking, eventID, bookingID string) error {
-	// 如果已經存在事件，則不需要再創建
+// handleBookingCreated 處理新預約創建；事件已存在時 calendarID 與 link 為對應關係中事件所在的日曆與網址
+func (h *WebhookHandler) handleBookingCreated(booking *simplybook.Booking, calEvent *gcalendar.CalendarEvent, eventID, calendarID, link, bookingID string) (string, error) {
+	// 如果已經存在事件，則不需要再創建；保留事件所在的日曆，規則指定的日曆才不會被改回預設日曆
 	if eventID != "" {
 		log.Printf("預約 %s 的日曆事件已存在 %s", bookingID, eventID)
-		return nil
+		_, err := h.saveMapping(booking, eventID, calendarID, link, bookingID)
+		return eventID, err
 	}
 
 	// 創建日曆事件
-	calEvent := createCalendarEventFromBooking(booking)
+	if vetoed, err := h.beforeCreate(booking, calEvent, bookingID); vetoed || err != nil {
+		return "", err
+	}
+	calEvent.SendUpdates = h.opts.SendUpdates
 	newEventID, err := h.calendarClient.CreateEvent(calEvent)
 	if err != nil {
-		return fmt.Errorf("創建日曆事件失敗: %w", err)
+		return "", fmt.Errorf("創建日曆事件失敗: %w", err)
 	}
 
-	log.Printf("為預約 %s 創建了日曆事件 %s", bookingID, newEventID)
-	return nil
+	log.Printf("為預約 %s 創建了日曆事件 %s %s", bookingID, newEventID, calEvent.HTMLLink)
+	h.sendConfirmation(booking, bookingID)
+	_, err = h.saveMapping(booking, newEventID, calEvent.CalendarID, calEvent.HTMLLink, bookingID)
+	return newEventID, err
 }
 
-// handleBookingUpdated 處理預約更新
-func (h *WebhookHandler) handleBookingUpdated(booking *simplybook.Booking, eventID, bookingID string) error {
+// sendConfirmation 寄送確認郵件給客戶，失敗時僅記錄日誌
+func (h *WebhookHandler) sendConfirmation(booking *simplybook.Booking, bookingID string) {
+	if h.opts.Confirmations == nil || booking.WaitingList() {
+		return
+	}
+
+	if err := h.opts.Confirmations.Send(booking); err != nil {
+		log.Printf("寄送預約 %s 的確認郵件失敗: %v", bookingID, err)
+	}
+}
+
+// handleBookingUpdated 處理預約更新，返回事件ID及欄位差異；current 為日曆上的現有事件，讀取失敗時為 nil
+func (h *WebhookHandler) handleBookingUpdated(booking *simplybook.Booking, calEvent, current *gcalendar.CalendarEvent, eventID, calendarID, bookingID string) (string, []store.FieldChange, error) {
 	if eventID == "" {
 		// 事件不存在，創建新事件
-		calEvent := createCalendarEventFromBooking(booking)
+		if vetoed, err := h.beforeCreate(booking, calEvent, bookingID); vetoed || err != nil {
+			return "", nil, err
+		}
+		calEvent.SendUpdates = h.opts.SendUpdates
 		newEventID, err := h.calendarClient.CreateEvent(calEvent)
 		if err != nil {
-			return fmt.Errorf("創建日曆事件失敗: %w", err)
+			return "", nil, fmt.Errorf("創建日曆事件失敗: %w", err)
 		}
-		log.Printf("為更新的預約 %s 創建了新的日曆事件 %s", bookingID, newEventID)
-		return nil
+		log.Printf("為更新的預約 %s 創建了新的日曆事件 %s %s", bookingID, newEventID, calEvent.HTMLLink)
+		changes, err := h.saveMapping(booking, newEventID, calEvent.CalendarID, calEvent.HTMLLink, bookingID)
+		return newEventID, changes, err
 	}
 
 	// 更新日曆事件
-	calEvent := createCalendarEventFromBooking(booking)
+	if vetoed, err := h.beforeUpdate(booking, eventID, calEvent, bookingID); vetoed || err != nil {
+		return eventID, nil, err
+	}
+
+	// 以現有事件計算欄位差異，讀取失敗時不影響更新
+	var changes []store.FieldChange
+	diffed := current != nil
+	if diffed {
+		changes = diffEvents(current, calEvent, calendarID)
+		for _, c := range changes {
+			log.Printf("預約 %s 的事件欄位 %s 變更: %q -> %q", bookingID, c.Field, c.Old, c.New)
+		}
+	}
+
+	// 規則變更目標日曆時，先將事件移至新日曆
+	if calEvent.CalendarID != calendarID {
+		if err := h.calendarClient.MoveEvent(calendarID, eventID, calEvent.CalendarID); err != nil {
+			return eventID, changes, fmt.Errorf("移動日曆事件失敗: %w", err)
+		}
+		log.Printf("已將預約 %s 的日曆事件 %s 移至日曆 %q", bookingID, eventID, calEvent.CalendarID)
+	}
+
+	calEvent.SendUpdates = h.updateSendUpdates(diffed, changes, calEvent, bookingID)
 	if err := h.calendarClient.UpdateEvent(eventID, calEvent); err != nil {
-		return fmt.Errorf("更新日曆事件失敗: %w", err)
+		return eventID, changes, fmt.Errorf("更新日曆事件失敗: %w", err)
 	}
 
-	log.Printf("已更新預約 %s 的日曆事件 %s", bookingID, eventID)
-	return nil
+	log.Printf("已更新預約 %s 的日曆事件 %s（%d 個欄位變更）%s", bookingID, eventID, len(changes), calEvent.HTMLLink)
+	clientChanges, err := h.saveMapping(booking, eventID, calEvent.CalendarID, calEvent.HTMLLink, bookingID)
+	return eventID, append(changes, clientChanges...), err
 }
 
 // handleBookingDeleted 處理預約刪除
-func (h *WebhookHandler) handleBookingDeleted(eventID, bookingID string) error {
+func (h *WebhookHandler) handleBookingDeleted(eventID, calendarID, bookingID string) error {
 	if eventID == "" {
 		// 事件不存在，無需操作
 		log.Printf("未找到預約 %s 的日曆事件", bookingID)
 		return nil
 	}
 
+	// 被否決時保留事件與對應關係
+	if vetoed, err := h.beforeDelete(calendarID, eventID, bookingID); vetoed || err != nil {
+		return err
+	}
+
 	// 刪除日曆事件
-	if err := h.calendarClient.DeleteEvent(eventID); err != nil {
+	if err := h.calendarClient.DeleteEventWithUpdates(calendarID, eventID, h.opts.SendUpdates); err != nil {
 		return fmt.Errorf("刪除日曆事件失敗: %w", err)
 	}
 
+	if err := h.store.DeleteMapping(bookingID); err != nil {
+		return fmt.Errorf("刪除事件對應關係失敗: %w", err)
+	}
+
 	log.Printf("已刪除預約 %s 的日曆事件 %s", bookingID, eventID)
 	return nil
 }
 
-// createCalendarEventFromBooking 從預約信息創建日曆事件
-func createCalendarEventFromBooking(booking *simplybook.Booking) *gcalendar.CalendarEvent {
-	// 創建事件描述，包含預約詳情
-	description := booking.Code
-
-	// 創建事件標題
-	summary := booking.Client.Name
+// saveMapping 儲存預約與日曆事件的對應關係，link 為空時保留同一事件原有的網址；
+// 客戶更改姓名或電子郵件時保留舊資料供搜尋，並返回變更的客戶欄位以寫入同步記錄
+func (h *WebhookHandler) saveMapping(booking *simplybook.Booking, eventID, calendarID, link, bookingID string) ([]store.FieldChange, error) {
+	previous, err := h.store.GetMapping(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("讀取事件對應關係失敗: %w", err)
+	}
 
-	// 設置參與者（如果有電子郵件）
-	// var attendees []string
-	// if booking.ClientEmail != "" {
-	// 	attendees = append(attendees, booking.ClientEmail)
-	// }
+	mapping := &store.Mapping{
+		BookingID:   bookingID,
+		BookingCode: booking.Code,
+		EventID:     eventID,
+		CalendarID:  calendarID,
+		EventLink:   link,
+	}
+	changes := mapping.MergeClient(previous, booking.Client.Name, booking.Client.Email)
+	for _, c := range changes {
+		log.Printf("預約 %s 的客戶欄位 %s 變更", bookingID, c.Field)
+	}
 
-	return &gcalendar.CalendarEvent{
-		Summary:     summary,
-		Description: description,
-		StartTime:   booking.StartTime.Time,
-		EndTime:     booking.EndTime.Time,
-		// Attendees:   attendees,
+	if err := h.store.SaveMapping(mapping); err != nil {
+		return nil, fmt.Errorf("儲存事件對應關係失敗: %w", err)
 	}
+	return changes, nil
 }
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/fake"
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+const testCalendarID = "test@group.calendar.google.com"
+
+// newTestHandler 以模擬伺服器與記憶體儲存創建同步處理器
+func newTestHandler(t *testing.T, server *fake.Server, opts Options) *WebhookHandler {
+	t.Helper()
+	httpClient := server.Client()
+	sb, err := simplybook.NewClient("test", "test", "test", simplybook.Options{HTTPClient: httpClient})
+	if err != nil {
+		t.Fatalf("初始化模擬 SimplyBook 客戶端失敗: %v", err)
+	}
+	creds, err := server.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendar, err := gcalendar.NewClient(creds, testCalendarID, httpClient)
+	if err != nil {
+		t.Fatalf("初始化模擬日曆客戶端失敗: %v", err)
+	}
+	return NewWebhookHandler(sb, calendar, store.NewMemoryStore(), nil, opts)
+}
+
+// 連續兩個變更通知在快取存活時間內送達時，第二次修改仍須寫入日曆
+func TestBackToBackChangesReachCalendar(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	server := fake.NewServer()
+	defer server.Close()
+	h := newTestHandler(t, server, Options{BookingCacheTTL: time.Hour})
+
+	id := server.SimplyBook.Add(fake.Booking{
+		Start:       time.Now().Add(48 * time.Hour).Truncate(time.Hour),
+		ServiceID:   1,
+		ProviderID:  1,
+		ClientName:  "原始客戶",
+		ClientEmail: "client@example.com",
+	})
+	bookingID := strconv.Itoa(id)
+
+	send := func(action string) {
+		t.Helper()
+		event := &source.Event{Source: "simplybook", Action: action, BookingID: bookingID, Time: time.Now()}
+		if err := h.processWebhookEvent(event); err != nil {
+			t.Fatalf("處理 %s webhook 失敗: %v", action, err)
+		}
+	}
+
+	send("create")
+	for _, name := range []string{"第一次修改", "第二次修改"} {
+		server.SimplyBook.Update(id, func(b *fake.Booking) { b.ClientName = name })
+		send("change")
+
+		events := server.Calendar.Events(testCalendarID)
+		if len(events) != 1 {
+			t.Fatalf("日曆應有 1 個事件，實際 %d 個", len(events))
+		}
+		if !strings.Contains(events[0].Summary, name) {
+			t.Fatalf("事件標題 %q 未反映修改 %q", events[0].Summary, name)
+		}
+	}
+}
+
+// 客戶更名時，同步記錄只記下變更的客戶欄位，不可留下明文的新舊姓名
+func TestClientRenameKeepsPIIOutOfHistory(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	server := fake.NewServer()
+	defer server.Close()
+	h := newTestHandler(t, server, Options{})
+
+	id := server.SimplyBook.Add(fake.Booking{
+		Start:       time.Now().Add(48 * time.Hour).Truncate(time.Hour),
+		ServiceID:   1,
+		ProviderID:  1,
+		ClientName:  "王小明",
+		ClientEmail: "ming@example.com",
+	})
+	bookingID := strconv.Itoa(id)
+
+	if err := h.processWebhookEvent(&source.Event{Source: "simplybook", Action: "create", BookingID: bookingID, Time: time.Now()}); err != nil {
+		t.Fatalf("處理 create webhook 失敗: %v", err)
+	}
+	server.SimplyBook.Update(id, func(b *fake.Booking) { b.ClientName = "王大明" })
+	if err := h.processWebhookEvent(&source.Event{Source: "simplybook", Action: "change", BookingID: bookingID, Time: time.Now()}); err != nil {
+		t.Fatalf("處理 change webhook 失敗: %v", err)
+	}
+
+	records, err := h.store.ListBookingSyncRecords(bookingID, 0)
+	if err != nil {
+		t.Fatalf("列出同步記錄失敗: %v", err)
+	}
+	found := false
+	for _, r := range records {
+		for _, c := range r.Changes {
+			if c.Field != "client_name" {
+				continue
+			}
+			found = true
+			if c.Old != "" || c.New != "" {
+				t.Errorf("客戶欄位的變更不應記錄姓名: %q -> %q", c.Old, c.New)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("同步記錄應包含 client_name 的變更")
+	}
+
+	m, err := h.store.GetMapping(bookingID)
+	if err != nil || m == nil {
+		t.Fatalf("讀取對應關係失敗: %v, %v", m, err)
+	}
+	if m.ClientName != "王大明" || len(m.ClientAliases) != 1 || m.ClientAliases[0] != "王小明" {
+		t.Errorf("對應關係應更新姓名並保留舊姓名供搜尋: %q, %v", m.ClientName, m.ClientAliases)
+	}
+}
+
+// 關閉服務時須等待背景處理中的 webhook 完成，超過時限則返回錯誤
+func TestDrainWaitsForInflightWebhooks(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	server := fake.NewServer()
+	defer server.Close()
+	h := newTestHandler(t, server, Options{})
+
+	id := strconv.Itoa(server.SimplyBook.Add(fake.Booking{
+		Start:       time.Now().Add(48 * time.Hour).Truncate(time.Hour),
+		ServiceID:   1,
+		ProviderID:  1,
+		ClientName:  "王小明",
+		ClientEmail: "ming@example.com",
+	}))
+
+	// 持有預約鎖，讓背景處理停在取得鎖之前
+	mu := h.bookingLocks.get(id)
+	mu.Lock()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"booking_id":        id,
+		"booking_hash":      fmt.Sprintf("%x", md5.Sum([]byte("test"+id))),
+		"company":           "test",
+		"notification_type": "create",
+		"webhook_timestamp": time.Now().Unix(),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.HandleWebhook(rec, req)
+	if rec.Code != http.StatusOK {
+		mu.Unlock()
+		t.Fatalf("webhook 應回應 200，實際 %d: %s", rec.Code, rec.Body.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	err := h.Drain(ctx)
+	cancel()
+	if err == nil {
+		mu.Unlock()
+		t.Fatalf("仍有 webhook 處理中時，Drain 應在時限結束後返回錯誤")
+	}
+
+	mu.Unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.Drain(ctx); err != nil {
+		t.Fatalf("等待 webhook 處理完成失敗: %v", err)
+	}
+	if events := server.Calendar.Events(testCalendarID); len(events) != 1 {
+		t.Fatalf("Drain 返回時 webhook 應已處理完成，日曆應有 1 個事件，實際 %d 個", len(events))
+	}
+	if depth := h.QueueDepth(); depth != 0 {
+		t.Errorf("處理完成後佇列深度應為 0，實際 %d", depth)
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// inSyncWindow 判斷預約是否在允許同步的時間範圍內，範圍未設置時不限制
+func (h *WebhookHandler) inSyncWindow(booking *simplybook.Booking) bool {
+	if h.beforeSyncWindow(booking) {
+		return false
+	}
+
+	if h.opts.FutureWindow > 0 && booking.StartTime.After(time.Now().Add(h.opts.FutureWindow)) {
+		return false
+	}
+
+	return true
+}
+
+// beforeSyncWindow 判斷預約是否已在同步範圍的過去範圍之前結束，這類預約不會再同步，對帳時清除其對應關係
+func (h *WebhookHandler) beforeSyncWindow(booking *simplybook.Booking) bool {
+	return h.opts.PastWindow > 0 && booking.EndTime.Before(time.Now().Add(-h.opts.PastWindow))
+}
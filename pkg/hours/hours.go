@@ -0,0 +1,174 @@
+// Package hours 判斷時間是否在營業時間內，讓告警與輪詢只在有人值班、預期有預約進來的時段進行
+package hours
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdays 將星期的縮寫對應到 time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule 代表每週的營業時間，nil 表示全天營業
+type Schedule struct {
+	loc   *time.Location
+	days  map[time.Weekday]bool // 空時每天營業
+	start int                   // 開始時間，自午夜起的分鐘數
+	end   int                   // 結束時間，小於開始時間時表示跨越午夜
+}
+
+// New 解析營業日（mon、tue 等縮寫）、HH:MM 格式的開始與結束時間及 IANA 時區。
+// 開始與結束時間皆未設置時返回 nil，表示全天營業；時區未設置時使用 Asia/Taipei
+func New(days []string, start, end, timezone string) (*Schedule, error) {
+	if start == "" && end == "" {
+		if len(days) > 0 {
+			return nil, fmt.Errorf("設置營業日時必須設置營業時間")
+		}
+		return nil, nil
+	}
+
+	s := &Schedule{days: make(map[time.Weekday]bool)}
+	var err error
+	if s.start, err = parseClock(start); err != nil {
+		return nil, err
+	}
+	if s.end, err = parseClock(end); err != nil {
+		return nil, err
+	}
+	if s.start == s.end {
+		return nil, fmt.Errorf("營業時間的開始與結束時間不可相同: %s", start)
+	}
+
+	for _, day := range days {
+		weekday, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+		if !ok {
+			return nil, fmt.Errorf("無效的營業日: %s（應為 mon、tue、wed、thu、fri、sat 或 sun）", day)
+		}
+		s.days[weekday] = true
+	}
+
+	if timezone == "" {
+		timezone = "Asia/Taipei"
+	}
+	if s.loc, err = time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("無效的營業時區: %w", err)
+	}
+
+	return s, nil
+}
+
+// parseClock 解析 HH:MM 格式的時間為自午夜起的分鐘數
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("營業時間格式必須為 HH:MM: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains 判斷時間是否在營業時間內；跨越午夜的營業時間以開始當天的星期判斷營業日
+func (s *Schedule) Contains(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	t = t.In(s.loc)
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	if s.start < s.end {
+		return minute >= s.start && minute < s.end && s.openOn(day)
+	}
+	// 跨越午夜：午夜前屬於當天，午夜後屬於前一天的營業時間
+	if minute >= s.start {
+		return s.openOn(day)
+	}
+	if minute < s.end {
+		return s.openOn((day + 6) % 7)
+	}
+	return false
+}
+
+// Next 返回 t 之後（含 t）最早的營業時間
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s == nil {
+		return t
+	}
+	// 從前一天起檢查，涵蓋跨越午夜的營業時間
+	for offset := -1; offset <= 7; offset++ {
+		open, close, ok := s.period(t, offset)
+		if !ok || !close.After(t) {
+			continue
+		}
+		if open.After(t) {
+			return open
+		}
+		return t
+	}
+	return t
+}
+
+// Prev 返回 t 之前（含 t）最晚的營業時間，即前一段營業時間結束前一分鐘
+func (s *Schedule) Prev(t time.Time) time.Time {
+	if s == nil {
+		return t
+	}
+	for offset := 0; offset >= -8; offset-- {
+		open, close, ok := s.period(t, offset)
+		if !ok || open.After(t) {
+			continue
+		}
+		if close.After(t) {
+			return t
+		}
+		return close.Add(-time.Minute)
+	}
+	return t
+}
+
+// period 返回 t 所在日期加上 offset 天的營業時間，當天不營業時 ok 為 false
+func (s *Schedule) period(t time.Time, offset int) (open, close time.Time, ok bool) {
+	t = t.In(s.loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc).AddDate(0, 0, offset)
+	if !s.openOn(day.Weekday()) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	open = day.Add(time.Duration(s.start) * time.Minute)
+	close = day.Add(time.Duration(s.end) * time.Minute)
+	if s.end < s.start {
+		close = close.AddDate(0, 0, 1)
+	}
+	return open, close, true
+}
+
+// openOn 判斷當天是否為營業日
+func (s *Schedule) openOn(day time.Weekday) bool {
+	return len(s.days) == 0 || s.days[day]
+}
+
+// Set 是各租戶的營業時間
+type Set struct {
+	Default *Schedule            // 未列出租戶的營業時間，nil 表示全天
+	Tenants map[string]*Schedule // 以租戶名稱為鍵的營業時間
+}
+
+// For 返回租戶的營業時間，未列出時返回預設值；Set 為 nil 時返回 nil（全天）
+func (s *Set) For(tenant string) *Schedule {
+	if s == nil {
+		return nil
+	}
+	if schedule, ok := s.Tenants[tenant]; ok {
+		return schedule
+	}
+	return s.Default
+}
@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/vcr"
+)
+
+// Options 包含對外 HTTP 客戶端的設定
+type Options struct {
+	ProxyURL string        // 對外代理伺服器，未設置時使用 HTTPS_PROXY 等環境變數
+	CAFile   string        // 額外信任的 CA 憑證（PEM 格式）
+	Timeout  time.Duration // 請求逾時時間
+	Record   string        // 將去除敏感資料的請求與響應錄製到此 cassette 檔案
+	Replay   string        // 從此 cassette 檔案重播響應，不發出任何對外請求
+
+	// 連線池設定，SimplyBook 與 Google 共用；對帳與大量補同步時保留足夠的閒置連線可避免反覆建立連線
+	MaxIdleConnsPerHost int           // 每個主機保留的閒置連線數，預設 16
+	MaxConnsPerHost     int           // 每個主機的連線上限，0 表示不限制
+	IdleConnTimeout     time.Duration // 閒置連線保留的時間，預設 90s
+	DisableKeepAlives   bool          // 每個請求使用新連線
+	DisableHTTP2        bool          // 僅使用 HTTP/1.1，用於不支援 HTTP/2 的代理
+	TLSSessionCache     int           // TLS session 快取的容量，重新連線時略過完整交握，預設 64
+}
+
+// New 根據設定創建對外 HTTP 客戶端
+func New(opts Options) (*http.Client, error) {
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = 16
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = 90 * time.Second
+	}
+	if opts.TLSSessionCache <= 0 {
+		opts.TLSSessionCache = 64
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	if transport.MaxIdleConns < opts.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = opts.MaxIdleConnsPerHost
+	}
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	transport.TLSClientConfig = &tls.Config{ClientSessionCache: tls.NewLRUClientSessionCache(opts.TLSSessionCache)}
+	if opts.DisableHTTP2 {
+		// 非 nil 的空 TLSNextProto 停用 HTTP/2 協商
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("解析代理伺服器位址失敗: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CAFile != "" {
+		pool, err := loadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	var roundTripper http.RoundTripper = transport
+	switch {
+	case opts.Replay != "":
+		recorder, err := vcr.Load(opts.Replay)
+		if err != nil {
+			return nil, err
+		}
+		roundTripper = recorder
+	case opts.Record != "":
+		roundTripper = vcr.NewRecorder(opts.Record, transport)
+	}
+
+	return &http.Client{
+		Transport: roundTripper,
+		Timeout:   opts.Timeout,
+	}, nil
+}
+
+// loadCertPool 載入系統 CA 並附加自訂的 CA 憑證
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("讀取 CA 憑證失敗: %w", err)
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA 憑證文件中沒有有效的 PEM 憑證: %s", caFile)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfter 從響應標頭解析伺服器要求的等待時間，沒有相關標頭或無法解析時返回 0。
+// 依序讀取 Retry-After（秒數或 HTTP 日期）與 X-RateLimit-Reset（Unix 秒數或剩餘秒數）
+func RetryAfter(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return positive(time.Duration(seconds) * time.Second)
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return positive(at.Sub(now))
+		}
+	}
+
+	if value := strings.TrimSpace(header.Get("X-RateLimit-Reset")); value != "" {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			// 大於一年的數值視為重置時間的 Unix 秒數
+			if seconds > 365*24*60*60 {
+				return positive(time.Unix(seconds, 0).Sub(now))
+			}
+			return positive(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	return 0
+}
+
+// positive 將負值修正為 0
+func positive(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,75 @@
+package ics
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// Event 代表 iCalendar 中的單一事件
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+}
+
+// utcFormat 是 iCalendar 的 UTC 時間格式
+const utcFormat = "20060102T150405Z"
+
+// Build 產生包含單一事件的 iCalendar 文件（METHOD:PUBLISH）
+func Build(e Event) []byte {
+	var buf bytes.Buffer
+
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//booking-sync//SimplyBook Sync//ZH")
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	writeLine(&buf, "BEGIN:VEVENT")
+	writeLine(&buf, "UID:"+escape(e.UID))
+	writeLine(&buf, "DTSTAMP:"+time.Now().UTC().Format(utcFormat))
+	writeLine(&buf, "DTSTART:"+e.Start.UTC().Format(utcFormat))
+	writeLine(&buf, "DTEND:"+e.End.UTC().Format(utcFormat))
+	writeLine(&buf, "SUMMARY:"+escape(e.Summary))
+	if e.Description != "" {
+		writeLine(&buf, "DESCRIPTION:"+escape(e.Description))
+	}
+	if e.Location != "" {
+		writeLine(&buf, "LOCATION:"+escape(e.Location))
+	}
+	writeLine(&buf, "END:VEVENT")
+	writeLine(&buf, "END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+// escape 依 RFC 5545 跳脫文字中的特殊字元
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine 寫入一行內容，超過 75 位元組時依 RFC 5545 折行，且不切斷多位元組字元
+func writeLine(buf *bytes.Buffer, line string) {
+	const limit = 75
+
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			buf.WriteString("\r\n ")
+			width = 1
+		}
+		buf.WriteRune(r)
+		width += size
+	}
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+)
+
+// reliefRatio 記憶體與佇列都降到門檻的此比例以下才解除壓力，避免在門檻附近反覆切換
+const reliefRatio = 0.8
+
+// PressureMonitor 定期檢查記憶體用量與處理中的 webhook 數量，超過門檻時進入壓力狀態，
+// 讓 Runner 延後可延後的背景任務，只處理即時的 webhook
+type PressureMonitor struct {
+	maxHeap  uint64       // 堆積記憶體門檻（位元組），0 表示不檢查
+	maxQueue int64        // 處理中 webhook 數量的門檻，0 表示不檢查
+	queue    func() int64 // 返回處理中的 webhook 數量
+
+	mu       sync.Mutex
+	active   bool
+	onRelief []func()
+}
+
+// NewPressureMonitor 創建壓力監控，maxHeap 以位元組表示，門檻為 0 時不檢查該項
+func NewPressureMonitor(maxHeap uint64, maxQueue int64, queue func() int64) *PressureMonitor {
+	return &PressureMonitor{
+		maxHeap:  maxHeap,
+		maxQueue: maxQueue,
+		queue:    queue,
+	}
+}
+
+// Active 返回目前是否處於壓力狀態
+func (m *PressureMonitor) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+// OnRelief 註冊壓力解除時執行的函式，例如補執行延後的任務
+func (m *PressureMonitor) OnRelief(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRelief = append(m.onRelief, fn)
+}
+
+// Run 每隔 interval 檢查一次，直到 ctx 結束
+func (m *PressureMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			m.check(stats.HeapAlloc, m.queue())
+		}
+	}
+}
+
+// check 依目前的記憶體與佇列大小切換壓力狀態
+func (m *PressureMonitor) check(heap uint64, queue int64) {
+	m.mu.Lock()
+	wasActive := m.active
+	if wasActive {
+		m.active = m.over(heap, queue, reliefRatio)
+	} else {
+		m.active = m.over(heap, queue, 1)
+	}
+	active := m.active
+	relief := append([]func(){}, m.onRelief...)
+	m.mu.Unlock()
+
+	switch {
+	case active && !wasActive:
+		metrics.LoadShedding.Set(1)
+		log.Printf("警告: 資源壓力過高（堆積記憶體 %d MB，處理中的 webhook %d 筆），延後對帳、清除與摘要等背景任務", heap>>20, queue)
+	case !active && wasActive:
+		metrics.LoadShedding.Set(0)
+		log.Printf("資源壓力已解除（堆積記憶體 %d MB，處理中的 webhook %d 筆），恢復背景任務", heap>>20, queue)
+		for _, fn := range relief {
+			go fn()
+		}
+	}
+}
+
+// over 判斷記憶體或佇列是否達到門檻的 ratio 倍
+func (m *PressureMonitor) over(heap uint64, queue int64, ratio float64) bool {
+	if m.maxHeap > 0 && float64(heap) >= float64(m.maxHeap)*ratio {
+		return true
+	}
+	return m.maxQueue > 0 && float64(queue) >= float64(m.maxQueue)*ratio
+}
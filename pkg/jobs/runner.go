@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/hours"
+	"github.com/booking-sync-455103/booking-sync/pkg/lock"
+	"github.com/booking-sync-455103/booking-sync/pkg/metrics"
+)
+
+// Runner 執行背景任務，並透過分散式鎖確保多個實例中只有一個執行
+type Runner struct {
+	locker lock.Locker
+
+	pressure   *PressureMonitor
+	deferrable map[string]bool // 資源壓力過高時延後的任務名稱
+
+	mu       sync.Mutex
+	deferred map[string]deferredJob // 已延後、等待壓力解除後補執行的任務
+}
+
+// deferredJob 是因資源壓力延後的任務
+type deferredJob struct {
+	ttl time.Duration
+	fn  func() error
+}
+
+// NewRunner 創建新的背景任務執行器
+func NewRunner(locker lock.Locker) *Runner {
+	return &Runner{
+		locker:     locker,
+		deferrable: make(map[string]bool),
+		deferred:   make(map[string]deferredJob),
+	}
+}
+
+// DeferUnderPressure 在 monitor 處於壓力狀態時延後指定名稱的任務，壓力解除後每個延後的任務補執行一次；
+// 應在開始執行任務前呼叫
+func (r *Runner) DeferUnderPressure(monitor *PressureMonitor, names ...string) {
+	r.pressure = monitor
+	for _, name := range names {
+		r.deferrable[name] = true
+	}
+	monitor.OnRelief(r.resumeDeferred)
+}
+
+// deferJob 在資源壓力過高時記錄可延後的任務並返回 true，同名任務只保留最後一次
+func (r *Runner) deferJob(name string, ttl time.Duration, fn func() error) bool {
+	if r.pressure == nil || !r.deferrable[name] || !r.pressure.Active() {
+		return false
+	}
+
+	r.mu.Lock()
+	r.deferred[name] = deferredJob{ttl: ttl, fn: fn}
+	r.mu.Unlock()
+
+	log.Printf("資源壓力過高，延後任務 %s", name)
+	metrics.JobsDeferred.WithLabelValues(name).Inc()
+	return true
+}
+
+// resumeDeferred 依序補執行延後的任務；補執行期間壓力再次升高時任務會再被延後
+func (r *Runner) resumeDeferred() {
+	r.mu.Lock()
+	deferred := r.deferred
+	r.deferred = make(map[string]deferredJob)
+	r.mu.Unlock()
+
+	for name, job := range deferred {
+		log.Printf("補執行延後的任務 %s", name)
+		r.runOnce(name, job.ttl, job.fn)
+	}
+}
+
+// RunPeriodic 每隔 interval 嘗試執行任務，直到 ctx 結束。
+// 鎖的租約與間隔相同且執行後不釋放，使同一間隔內其他實例不會重複執行
+func (r *Runner) RunPeriodic(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.runOnce(name, interval, fn)
+		}
+	}
+}
+
+// RunOnce 取得鎖後立即執行一次任務，鎖在 ttl 內避免其他實例重複執行
+func (r *Runner) RunOnce(name string, ttl time.Duration, fn func() error) {
+	r.runOnce(name, ttl, fn)
+}
+
+// runOnce 取得鎖後執行一次任務
+func (r *Runner) runOnce(name string, ttl time.Duration, fn func() error) {
+	if r.deferJob(name, ttl, fn) {
+		return
+	}
+
+	acquired, err := r.locker.TryLock(name, ttl)
+	if err != nil {
+		log.Printf("取得任務 %s 的鎖失敗: %v", name, err)
+		return
+	}
+	if !acquired {
+		log.Printf("任務 %s 由其他實例執行中，略過", name)
+		return
+	}
+
+	start := time.Now()
+	if err := fn(); err != nil {
+		log.Printf("任務 %s 執行失敗: %v", name, err)
+		return
+	}
+	log.Printf("任務 %s 執行完成，耗時 %s", name, time.Since(start))
+}
+
+// dailyLockTTL 每日任務的鎖租約，涵蓋各實例排程時間的誤差
+const dailyLockTTL = time.Hour
+
+// RunDaily 每天在 loc 時區的 hour:minute 執行任務，直到 ctx 結束。
+// within 不為 nil 時，排定時間不在營業時間內則延後到下一段營業時間，非營業日的任務併入下一個營業日
+func (r *Runner) RunDaily(ctx context.Context, name string, hour, minute int, loc *time.Location, within *hours.Schedule, fn func() error) {
+	for {
+		now := time.Now().In(loc)
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		next = within.Next(next)
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			r.runOnce(name, dailyLockTTL, fn)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Scheduler 依 cron 表達式執行背景任務。
+// 同一實例內任務尚未結束時會略過下一次觸發，跨實例則透過 Runner 的分散式鎖避免重複執行
+type Scheduler struct {
+	runner *Runner
+	cron   *cron.Cron
+	parser cron.Parser
+}
+
+// NewScheduler 創建新的 cron 排程器，cron 表達式以 loc 時區解讀
+func NewScheduler(runner *Runner, loc *time.Location) *Scheduler {
+	logger := cron.PrintfLogger(log.Default())
+	return &Scheduler{
+		runner: runner,
+		cron: cron.New(
+			cron.WithLocation(loc),
+			cron.WithChain(cron.Recover(logger), cron.SkipIfStillRunning(logger)),
+		),
+		parser: cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
+	}
+}
+
+// Add 註冊一個依 spec（五欄位 cron 表達式或 @daily 等描述）執行的任務
+func (s *Scheduler) Add(name, spec string, fn func() error) error {
+	schedule, err := s.parser.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("解析任務 %s 的 cron 表達式失敗: %w", name, err)
+	}
+
+	// 鎖的租約取兩次觸發間隔的一半，足以涵蓋各實例的時鐘誤差，又不會擋住下一次觸發
+	next := schedule.Next(time.Now())
+	ttl := schedule.Next(next).Sub(next) / 2
+
+	s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.runner.runOnce(name, ttl, fn)
+	}))
+	return nil
+}
+
+// Run 啟動排程器，直到 ctx 結束後等待執行中的任務完成
+func (s *Scheduler) Run(ctx context.Context) {
+	s.cron.Start()
+	<-ctx.Done()
+	<-s.cron.Stop().Done()
+}
@@ -0,0 +1,130 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// GCSLocker 使用 Google Cloud Storage 物件實作分散式鎖，
+// 依靠 generation / metageneration 前置條件保證只有一個實例能取得鎖
+type GCSLocker struct {
+	service *storage.Service
+	bucket  string
+	prefix  string
+	owner   string
+}
+
+// NewGCSLocker 創建新的 GCS 分散式鎖
+func NewGCSLocker(credentialsJSON []byte, bucket, prefix, owner string) (*GCSLocker, error) {
+	ctx := context.Background()
+
+	config, err := google.JWTConfigFromJSON(credentialsJSON, storage.DevstorageReadWriteScope)
+	if err != nil {
+		return nil, fmt.Errorf("無法解析服務帳號金鑰: %w", err)
+	}
+
+	service, err := storage.NewService(ctx, option.WithHTTPClient(config.Client(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("無法創建 Cloud Storage 服務: %w", err)
+	}
+
+	return &GCSLocker{
+		service: service,
+		bucket:  bucket,
+		prefix:  prefix,
+		owner:   owner,
+	}, nil
+}
+
+// objectName 返回鎖對應的物件名稱
+func (l *GCSLocker) objectName(name string) string {
+	return path.Join(l.prefix, name+".lock")
+}
+
+// TryLock 嘗試取得指定名稱的鎖
+func (l *GCSLocker) TryLock(name string, ttl time.Duration) (bool, error) {
+	objectName := l.objectName(name)
+	metadata := map[string]string{
+		"owner":      l.owner,
+		"expires_at": time.Now().Add(ttl).UTC().Format(time.RFC3339),
+	}
+
+	// 物件不存在時建立（ifGenerationMatch=0）
+	_, err := l.service.Objects.Insert(l.bucket, &storage.Object{
+		Name:     objectName,
+		Metadata: metadata,
+	}).IfGenerationMatch(0).Media(strings.NewReader(l.owner)).Do()
+	if err == nil {
+		return true, nil
+	}
+	if !isStatus(err, http.StatusPreconditionFailed) {
+		return false, fmt.Errorf("建立鎖物件失敗: %w", err)
+	}
+
+	// 鎖已存在，檢查持有者與過期時間
+	obj, err := l.service.Objects.Get(l.bucket, objectName).Do()
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("讀取鎖物件失敗: %w", err)
+	}
+
+	expiresAt, _ := time.Parse(time.RFC3339, obj.Metadata["expires_at"])
+	if obj.Metadata["owner"] != l.owner && time.Now().Before(expiresAt) {
+		return false, nil
+	}
+
+	// 續約或接管過期的鎖，metageneration 前置條件避免與其他實例競爭
+	_, err = l.service.Objects.Patch(l.bucket, objectName, &storage.Object{
+		Metadata: metadata,
+	}).IfMetagenerationMatch(obj.Metageneration).Do()
+	if err != nil {
+		if isStatus(err, http.StatusPreconditionFailed) {
+			return false, nil
+		}
+		return false, fmt.Errorf("更新鎖物件失敗: %w", err)
+	}
+
+	return true, nil
+}
+
+// Unlock 釋放本實例持有的鎖
+func (l *GCSLocker) Unlock(name string) error {
+	objectName := l.objectName(name)
+
+	obj, err := l.service.Objects.Get(l.bucket, objectName).Do()
+	if err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return nil
+		}
+		return fmt.Errorf("讀取鎖物件失敗: %w", err)
+	}
+
+	if obj.Metadata["owner"] != l.owner {
+		return nil
+	}
+
+	err = l.service.Objects.Delete(l.bucket, objectName).IfGenerationMatch(obj.Generation).Do()
+	if err != nil && !isStatus(err, http.StatusNotFound) && !isStatus(err, http.StatusPreconditionFailed) {
+		return fmt.Errorf("刪除鎖物件失敗: %w", err)
+	}
+
+	return nil
+}
+
+// isStatus 判斷錯誤是否為指定 HTTP 狀態碼的 Google API 錯誤
+func isStatus(err error, code int) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
@@ -0,0 +1,28 @@
+package lock
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// Locker 定義跨實例的分散式鎖介面
+type Locker interface {
+	// TryLock 嘗試取得指定名稱的鎖，鎖在 ttl 後自動過期；
+	// 已由本實例持有時會延長租約並返回 true
+	TryLock(name string, ttl time.Duration) (bool, error)
+	// Unlock 釋放本實例持有的鎖
+	Unlock(name string) error
+}
+
+// InstanceID 產生用於識別鎖持有者的實例 ID
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return fmt.Sprintf("%s-%d-%06d", host, os.Getpid(), r.Intn(1000000))
+}
@@ -0,0 +1,52 @@
+package lock
+
+import (
+	"sync"
+	"time"
+)
+
+// lease 代表一個鎖的租約
+type lease struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// MemoryLocker 是單一實例使用的記憶體鎖
+type MemoryLocker struct {
+	owner  string
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+// NewMemoryLocker 創建新的記憶體鎖
+func NewMemoryLocker(owner string) *MemoryLocker {
+	return &MemoryLocker{
+		owner:  owner,
+		leases: make(map[string]lease),
+	}
+}
+
+// TryLock 嘗試取得指定名稱的鎖
+func (l *MemoryLocker) TryLock(name string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if current, ok := l.leases[name]; ok && current.owner != l.owner && now.Before(current.expiresAt) {
+		return false, nil
+	}
+
+	l.leases[name] = lease{owner: l.owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// Unlock 釋放本實例持有的鎖
+func (l *MemoryLocker) Unlock(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if current, ok := l.leases[name]; ok && current.owner == l.owner {
+		delete(l.leases, name)
+	}
+	return nil
+}
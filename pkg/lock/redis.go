@@ -0,0 +1,69 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// renewScript 僅在持有者相同時延長租約
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// unlockScript 僅在持有者相同時刪除鎖
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisLocker 使用 Redis 實作低延遲的分散式鎖
+type RedisLocker struct {
+	client *redis.Client
+	prefix string
+	owner  string
+}
+
+// NewRedisLocker 創建新的 Redis 分散式鎖
+func NewRedisLocker(client *redis.Client, prefix, owner string) *RedisLocker {
+	return &RedisLocker{
+		client: client,
+		prefix: prefix,
+		owner:  owner,
+	}
+}
+
+// TryLock 嘗試取得指定名稱的鎖
+func (l *RedisLocker) TryLock(name string, ttl time.Duration) (bool, error) {
+	ctx := context.Background()
+	key := l.prefix + name
+
+	acquired, err := l.client.SetNX(ctx, key, l.owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("寫入 Redis 鎖失敗: %w", err)
+	}
+	if acquired {
+		return true, nil
+	}
+
+	// 已由本實例持有時延長租約
+	renewed, err := renewScript.Run(ctx, l.client, []string{key}, l.owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("延長 Redis 鎖失敗: %w", err)
+	}
+
+	return renewed == 1, nil
+}
+
+// Unlock 釋放本實例持有的鎖
+func (l *RedisLocker) Unlock(name string) error {
+	if err := unlockScript.Run(context.Background(), l.client, []string{l.prefix + name}, l.owner).Err(); err != nil {
+		return fmt.Errorf("釋放 Redis 鎖失敗: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// 支持的日誌等級
+const (
+	LevelInfo  = "info"
+	LevelDebug = "debug"
+)
+
+// debug 是否輸出除錯日誌，可在執行期間切換
+var debug atomic.Bool
+
+// SetLevel 設置日誌等級
+func SetLevel(level string) error {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case LevelInfo, "":
+		debug.Store(false)
+	case LevelDebug:
+		debug.Store(true)
+	default:
+		return fmt.Errorf("不支持的日誌等級: %s", level)
+	}
+	return nil
+}
+
+// Level 返回目前的日誌等級
+func Level() string {
+	if debug.Load() {
+		return LevelDebug
+	}
+	return LevelInfo
+}
+
+// ToggleDebug 切換除錯日誌並返回切換後的等級
+func ToggleDebug() string {
+	for {
+		old := debug.Load()
+		if debug.CompareAndSwap(old, !old) {
+			return Level()
+		}
+	}
+}
+
+// Debugf 在啟用除錯日誌時輸出日誌
+func Debugf(format string, args ...interface{}) {
+	if debug.Load() {
+		log.Printf("[DEBUG] "+format, args...)
+	}
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 所有指標的前綴
+const namespace = "booking_sync"
+
+var (
+	// OutboxSize 暫存中、等待 Google 日曆恢復後補送的同步操作數量
+	OutboxSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "calendar_outbox_size",
+		Help:      "Number of calendar operations queued while Google Calendar is unavailable.",
+	})
+
+	// OutboxOldestAge 最早暫存的同步操作已等待的秒數
+	OutboxOldestAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "calendar_outbox_oldest_age_seconds",
+		Help:      "Age in seconds of the oldest queued calendar operation.",
+	})
+
+	// CalendarDegraded Google 日曆是否處於降級模式（1 表示同步操作改為暫存）
+	CalendarDegraded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "calendar_degraded",
+		Help:      "Whether calendar operations are currently being queued instead of sent (1) or not (0).",
+	})
+
+	// SyncOperations 依租戶、服務提供者與日曆統計的同步操作次數
+	SyncOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_operations_total",
+		Help:      "Number of booking sync operations by tenant, provider, calendar, action and result.",
+	}, []string{"tenant", "provider", "calendar", "action", "result"})
+
+	// GoogleAPIRequests 依租戶、日曆與 API 方法統計的 Google 日曆 API 請求次數
+	GoogleAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "google_api_requests_total",
+		Help:      "Number of Google Calendar API requests sent by tenant, calendar and method, counted before each call regardless of outcome.",
+	}, []string{"tenant", "calendar", "method"})
+
+	// GoogleAPIRequestsToday 當日（太平洋時間，與 Google 配額重置時間一致）的 Google 日曆 API 請求次數，
+	// 用於估算每日配額用量
+	GoogleAPIRequestsToday = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "google_api_requests_today",
+		Help:      "Number of Google Calendar API requests since the daily quota reset (midnight Pacific Time) by tenant and calendar.",
+	}, []string{"tenant", "calendar"})
+
+	// GoogleCredentialHealthy Google 服務帳號是否可使用（0 表示因配額或金鑰失效暫停使用）
+	GoogleCredentialHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "google_credential_healthy",
+		Help:      "Whether a Google service account is currently usable (1) or cooling down after quota or key errors (0).",
+	}, []string{"credential"})
+
+	// WebhookLastReceived 各平台最後一次收到已驗證 webhook 的時間（Unix 秒數），僅反映本實例
+	WebhookLastReceived = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_last_received_timestamp_seconds",
+		Help:      "Unix time of the last authenticated webhook received by this instance, per source.",
+	}, []string{"source"})
+
+	// PipelineStageDuration 同步流程各階段的耗時
+	PipelineStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pipeline_stage_duration_seconds",
+		Help:      "Duration of each booking sync pipeline stage.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"stage"})
+
+	// PipelineStageErrors 依階段與錯誤分類統計的同步流程失敗次數
+	PipelineStageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipeline_stage_errors_total",
+		Help:      "Number of booking sync pipeline stage failures by stage and error kind.",
+	}, []string{"stage", "kind"})
+
+	// AuditEventsDropped 因超過速率、佇列已滿或傳送失敗而未轉送的稽核事件數
+	AuditEventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_events_dropped_total",
+		Help:      "Number of audit events not forwarded because of the rate limit, a full queue or a delivery failure.",
+	})
+
+	// BookingFetchDuration 從各預約平台讀取預約的耗時
+	BookingFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "booking_fetch_duration_seconds",
+		Help:      "Duration of reading a booking from its source platform.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"source"})
+
+	// SlowBookingFetches 耗時超過門檻的預約讀取次數，可在同步開始失敗前察覺平台變慢
+	SlowBookingFetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "slow_booking_fetches_total",
+		Help:      "Number of booking reads that exceeded the slow fetch threshold, per source.",
+	}, []string{"source"})
+
+	// AttendeesDropped 未加為事件參與者的客戶地址數量，reason 為 invalid（格式錯誤）或 suppressed（在停止邀請清單中）
+	AttendeesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "attendees_dropped_total",
+		Help:      "Number of client addresses not invited to events, by reason.",
+	}, []string{"reason"})
+
+	// WebhooksRejected 驗證失敗或過期而被拒絕的 webhook 數量，持續增加可能表示令牌設定錯誤或有人嘗試偽造請求
+	WebhooksRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_rejected_total",
+		Help:      "Number of webhooks rejected by authentication or freshness checks, per source and reason.",
+	}, []string{"source", "reason"})
+
+	// InvalidSyncTransitions 不在預約同步狀態機允許範圍內的轉換次數，from 為 received 等中間狀態時通常表示上次同步被中斷
+	InvalidSyncTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_invalid_transitions_total",
+		Help:      "Number of booking sync state transitions rejected by the state machine, per from and to state.",
+	}, []string{"from", "to"})
+
+	// WebhookLatency webhook 從平台產生（webhook_timestamp）到日曆寫入完成的端到端延遲，包括平台送達、排隊與同步的時間
+	WebhookLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_end_to_end_latency_seconds",
+		Help:      "Latency from the platform's webhook timestamp to completion of the calendar write, per source.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+	}, []string{"source"})
+
+	// WebhookLatencyP95 最近 webhook 端到端延遲的 p95，與 LatencySLO 比較以發出告警
+	WebhookLatencyP95 = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_end_to_end_latency_p95_seconds",
+		Help:      "p95 of the most recent webhook end-to-end latencies, as compared against the latency SLO.",
+	})
+
+	// LoadShedding 是否因記憶體或佇列壓力而延後背景任務（1 或 0）
+	LoadShedding = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "load_shedding",
+		Help:      "Whether background jobs are being deferred due to memory or queue pressure (1 or 0).",
+	})
+
+	// JobsDeferred 因資源壓力而延後的背景任務次數
+	JobsDeferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_deferred_total",
+		Help:      "Number of background job runs deferred due to memory or queue pressure, per job.",
+	}, []string{"job"})
+
+	// BookingsOutOfScope 服務提供者或服務不在同步範圍內而略過的預約數
+	BookingsOutOfScope = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_out_of_scope_total",
+		Help:      "Number of bookings not synced because their provider or service is outside the sync scope, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	prometheus.MustRegister(OutboxSize, OutboxOldestAge, CalendarDegraded,
+		SyncOperations, GoogleAPIRequests, GoogleAPIRequestsToday, GoogleCredentialHealthy,
+		WebhookLastReceived, PipelineStageDuration, PipelineStageErrors,
+		AuditEventsDropped, BookingFetchDuration, SlowBookingFetches,
+		AttendeesDropped, WebhooksRejected, InvalidSyncTransitions,
+		WebhookLatency, WebhookLatencyP95, LoadShedding, JobsDeferred, BookingsOutOfScope)
+}
+
+// Handler 返回輸出 Prometheus 指標的 HTTP 處理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
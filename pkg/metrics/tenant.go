@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// quotaLocation Google API 每日配額的重置時區
+var quotaLocation = loadQuotaLocation()
+
+var (
+	mu        sync.Mutex
+	tenant    = "default"
+	quotaDate string // 目前統計中的配額日期（YYYY-MM-DD）
+)
+
+// loadQuotaLocation 載入太平洋時區，系統缺少時區資料時使用固定時差
+func loadQuotaLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}
+
+// SetTenant 設置指標的租戶標籤（SimplyBook 公司登入名），需在開始處理請求前呼叫
+func SetTenant(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if name != "" {
+		tenant = name
+	}
+}
+
+// ObserveSync 記錄一次同步操作，calendar 為空時表示預設日曆
+func ObserveSync(provider, calendar, action string, success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+
+	mu.Lock()
+	t := tenant
+	mu.Unlock()
+
+	SyncOperations.WithLabelValues(t, labelOrUnknown(provider), labelOrUnknown(calendar), action, result).Inc()
+}
+
+// ObserveGoogleAPICall 記錄一次 Google 日曆 API 請求，跨過太平洋時間午夜時重置當日計數。
+// 於每次發出請求前呼叫，失敗與重試的請求同樣計入且不區分結果，與 Google 計算配額的方式一致
+func ObserveGoogleAPICall(calendar, method string) {
+	today := time.Now().In(quotaLocation).Format("2006-01-02")
+
+	mu.Lock()
+	if today != quotaDate {
+		quotaDate = today
+		GoogleAPIRequestsToday.Reset()
+	}
+	calendar = labelOrUnknown(calendar)
+	// 與重置在同一把鎖內遞增，避免前一日的請求計入新的一天
+	GoogleAPIRequestsToday.WithLabelValues(tenant, calendar).Inc()
+	GoogleAPIRequests.WithLabelValues(tenant, calendar, method).Inc()
+	mu.Unlock()
+}
+
+// labelOrUnknown 將空值轉為 unknown，避免標籤為空字串
+func labelOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Notifier 定義發送通知的介面
+type Notifier interface {
+	Notify(subject, body string) error
+}
+
+// Multi 將通知發送到多個通知管道
+type Multi []Notifier
+
+// Notify 依序發送到所有通知管道，任一管道失敗時返回彙總的錯誤
+func (m Multi) Notify(subject, body string) error {
+	var errs []string
+	for _, n := range m {
+		if err := n.Notify(subject, body); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("發送通知失敗: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// SlackNotifier 透過 Slack Incoming Webhook 發送通知
+type SlackNotifier struct {
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+// NewSlackNotifier 創建新的 Slack 通知器
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify 將標題與內容發送到 Slack 頻道
+func (n *SlackNotifier) Notify(subject, body string) error {
+	payload, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", subject, body),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化 Slack 訊息失敗: %w", err)
+	}
+
+	resp, err := n.HTTPClient.Post(n.WebhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("發送 Slack 訊息失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("發送 Slack 訊息失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/problem"
+)
+
+// Slack 按鈕的 action_id
+const (
+	slackApproveAction = "approve_booking"
+	slackDeclineAction = "decline_booking"
+)
+
+// SlackApprovals 透過附有核准與拒絕按鈕的 Slack 訊息處理待核准的預約；
+// Incoming Webhook 須屬於啟用 Interactivity 的 Slack App，Request URL 指向 Handler 的路徑
+type SlackApprovals struct {
+	WebhookURL    string
+	SigningSecret string // 驗證按鈕回呼的 Slack App Signing Secret
+	HTTPClient    *http.Client
+}
+
+// NewSlackApprovals 創建 Slack 核准請求
+func NewSlackApprovals(webhookURL, signingSecret string) *SlackApprovals {
+	return &SlackApprovals{
+		WebhookURL:    webhookURL,
+		SigningSecret: signingSecret,
+		HTTPClient:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// RequestApproval 發送附有核准與拒絕按鈕的訊息，按鈕值為預約識別碼
+func (s *SlackApprovals) RequestApproval(bookingID, text string) error {
+	button := func(label, style, actionID string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":      "button",
+			"text":      map[string]string{"type": "plain_text", "text": label},
+			"style":     style,
+			"action_id": actionID,
+			"value":     bookingID,
+		}
+	}
+	message := map[string]interface{}{
+		"text": text,
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": text},
+			},
+			map[string]interface{}{
+				"type": "actions",
+				"elements": []interface{}{
+					button("核准", "primary", slackApproveAction),
+					button("拒絕", "danger", slackDeclineAction),
+				},
+			},
+		},
+	}
+	return s.post(s.WebhookURL, message)
+}
+
+// slackActionPayload 是 Slack 按鈕回呼中與核准相關的欄位
+type slackActionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// Handler 處理 Slack 按鈕回呼：驗證簽章後立即回應（Slack 要求 3 秒內回應），
+// 於背景呼叫 decide 並以 response_url 將原訊息更新為處理結果
+func (s *SlackApprovals) Handler(decide func(bookingID string, approve bool) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			problem.MethodNotAllowed(w, r, "POST", "僅支持 POST 請求")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidPayload, "讀取請求體失敗")
+			return
+		}
+		if !s.verify(r.Header, body, time.Now()) {
+			problem.Write(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "未授權")
+			return
+		}
+
+		// 驗證簽章需要原始請求體，之後才解析表單
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var payload slackActionPayload
+		if err := json.Unmarshal([]byte(r.PostFormValue("payload")), &payload); err != nil || payload.Type != "block_actions" || len(payload.Actions) == 0 {
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidPayload, "無效的 Slack 回呼")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		action := payload.Actions[0]
+		approve := action.ActionID == slackApproveAction
+		// 其他按鈕不需處理
+		if !approve && action.ActionID != slackDeclineAction {
+			return
+		}
+
+		go func() {
+			verb := "核准"
+			if !approve {
+				verb = "拒絕"
+			}
+			text := fmt.Sprintf("%s 已%s預約 %s", payload.User.Username, verb, action.Value)
+			if err := decide(action.Value, approve); err != nil {
+				log.Printf("以 Slack %s預約 %s 失敗: %v", verb, action.Value, err)
+				text = fmt.Sprintf("%s預約 %s 失敗: %v", verb, action.Value, err)
+			}
+			if err := s.post(payload.ResponseURL, map[string]interface{}{"replace_original": true, "text": text}); err != nil {
+				log.Printf("更新 Slack 核准訊息失敗: %v", err)
+			}
+		}()
+	})
+}
+
+// verify 以 Signing Secret 驗證 Slack 的請求簽章，拒絕超過 5 分鐘的請求以防重放
+func (s *SlackApprovals) verify(header http.Header, body []byte, now time.Time) bool {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > 5*time.Minute || age < -5*time.Minute {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.SigningSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+// post 以 JSON 發送訊息到 Slack 的 webhook 或 response_url
+func (s *SlackApprovals) post(url string, message interface{}) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("序列化 Slack 訊息失敗: %w", err)
+	}
+
+	resp, err := s.HTTPClient.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("發送 Slack 訊息失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("發送 Slack 訊息失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
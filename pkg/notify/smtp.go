@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier 透過 SMTP 發送電子郵件通知
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify 發送純文字電子郵件
+func (n *SMTPNotifier) Notify(subject, body string) error {
+	return n.Send(n.To, subject, body)
+}
+
+// Send 發送純文字電子郵件給指定的收件者
+func (n *SMTPNotifier) Send(to []string, subject, body string) error {
+	if len(to) == 0 {
+		return fmt.Errorf("缺少郵件收件者")
+	}
+
+	var msg bytes.Buffer
+	n.writeHeaders(&msg, to, subject)
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return n.sendRaw(to, msg.Bytes())
+}
+
+// SendWithAttachment 發送附帶單一附件的電子郵件
+func (n *SMTPNotifier) SendWithAttachment(to []string, subject, body, filename, contentType string, data []byte) error {
+	if len(to) == 0 {
+		return fmt.Errorf("缺少郵件收件者")
+	}
+
+	var msg bytes.Buffer
+	writer := multipart.NewWriter(&msg)
+
+	n.writeHeaders(&msg, to, subject)
+	msg.WriteString("Content-Type: multipart/mixed; boundary=" + writer.Boundary() + "\r\n")
+	msg.WriteString("\r\n")
+
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return fmt.Errorf("建立郵件內容失敗: %w", err)
+	}
+	textPart.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+
+	attachmentPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, filename)},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", filename)},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return fmt.Errorf("建立郵件附件失敗: %w", err)
+	}
+
+	// base64 內容每 76 個字元換行
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		attachmentPart.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	attachmentPart.Write([]byte(encoded + "\r\n"))
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("組裝郵件失敗: %w", err)
+	}
+
+	return n.sendRaw(to, msg.Bytes())
+}
+
+// writeHeaders 寫入共用的郵件標頭
+func (n *SMTPNotifier) writeHeaders(msg *bytes.Buffer, to []string, subject string) {
+	msg.WriteString("From: " + n.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+}
+
+// sendRaw 透過 SMTP 伺服器發送已組裝好的郵件
+func (n *SMTPNotifier) sendRaw(to []string, msg []byte) error {
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, n.From, to, msg); err != nil {
+		return fmt.Errorf("發送郵件失敗: %w", err)
+	}
+	return nil
+}
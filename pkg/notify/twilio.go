@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioSMS 透過 Twilio REST API 發送簡訊
+type TwilioSMS struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewTwilioSMS 創建新的 Twilio 簡訊發送器
+func NewTwilioSMS(accountSID, authToken, from string) *TwilioSMS {
+	return &TwilioSMS{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		BaseURL:    "https://api.twilio.com",
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendSMS 發送簡訊到指定號碼（E.164 格式）
+func (t *TwilioSMS) SendSMS(to, body string) error {
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", t.BaseURL, t.AccountSID)
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.From)
+	form.Set("Body", body)
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("創建簡訊請求失敗: %w", err)
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("發送簡訊失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("發送簡訊失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// 付款狀態
+const (
+	StatusPaid     = "paid"
+	StatusUnpaid   = "unpaid"
+	StatusRefunded = "refunded"
+)
+
+// DefaultMetadataKey 是 PaymentIntent metadata 中存放預約代碼的預設鍵
+const DefaultMetadataKey = "booking_code"
+
+// Stripe 依預約代碼查詢 Stripe PaymentIntent 的付款狀態
+type Stripe struct {
+	SecretKey   string
+	MetadataKey string // PaymentIntent metadata 中存放預約代碼的鍵
+	BaseURL     string
+	HTTPClient  *http.Client
+}
+
+// NewStripe 創建 Stripe 付款狀態查詢器
+func NewStripe(secretKey, metadataKey string, httpClient *http.Client) *Stripe {
+	if metadataKey == "" {
+		metadataKey = DefaultMetadataKey
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Stripe{
+		SecretKey:   secretKey,
+		MetadataKey: metadataKey,
+		BaseURL:     "https://api.stripe.com",
+		HTTPClient:  httpClient,
+	}
+}
+
+// paymentIntent 是查詢結果中用到的 PaymentIntent 欄位
+type paymentIntent struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	LatestCharge *struct {
+		Refunded bool `json:"refunded"`
+	} `json:"latest_charge"`
+}
+
+// Status 返回預約代碼對應的付款狀態：任一筆已付款且未全額退款時為 paid，
+// 僅有已退款的付款時為 refunded，其餘（包含查無付款）為 unpaid
+func (s *Stripe) Status(bookingCode string) (string, error) {
+	intents, err := s.search(bookingCode)
+	if err != nil {
+		return "", err
+	}
+
+	status := StatusUnpaid
+	for _, pi := range intents {
+		if pi.Status != "succeeded" {
+			continue
+		}
+		if pi.LatestCharge != nil && pi.LatestCharge.Refunded {
+			status = StatusRefunded
+			continue
+		}
+		return StatusPaid, nil
+	}
+	return status, nil
+}
+
+// search 以 metadata 搜尋預約代碼對應的 PaymentIntent
+func (s *Stripe) search(bookingCode string) ([]paymentIntent, error) {
+	query := url.Values{}
+	query.Set("query", fmt.Sprintf("metadata['%s']:'%s'", s.MetadataKey, strings.ReplaceAll(bookingCode, "'", `\'`)))
+	query.Add("expand[]", "data.latest_charge")
+	query.Set("limit", "20")
+
+	req, err := http.NewRequest(http.MethodGet, s.BaseURL+"/v1/payment_intents/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("創建 Stripe 請求失敗: %w", err)
+	}
+	req.SetBasicAuth(s.SecretKey, "")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("查詢 Stripe 付款失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("查詢 Stripe 付款失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Data []paymentIntent `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析 Stripe 響應失敗: %w", err)
+	}
+	return result.Data, nil
+}
+
+// Enrich 將付款狀態寫入預約，沒有預約代碼時略過
+func (s *Stripe) Enrich(booking *simplybook.Booking) error {
+	if booking.Code == "" {
+		return nil
+	}
+
+	status, err := s.Status(booking.Code)
+	if err != nil {
+		return err
+	}
+	booking.PaymentStatus = status
+	return nil
+}
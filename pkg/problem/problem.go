@@ -0,0 +1,70 @@
+// Package problem 以 RFC 7807 problem+json 格式輸出 HTTP 錯誤回應，
+// 讓 SimplyBook 的重試機制與維運工具能以錯誤代碼區分請求錯誤與暫時性失敗
+package problem
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// ContentType 是 problem+json 回應的媒體類型
+const ContentType = "application/problem+json"
+
+// 錯誤代碼，同時作為 type 欄位 urn:booking-sync:problem:<code> 的結尾
+const (
+	CodeMethodNotAllowed = "method_not_allowed" // 不支援的請求方法
+	CodeInvalidRequest   = "invalid_request"    // 缺少或無效的參數
+	CodeInvalidPayload   = "invalid_payload"    // 無法解析的請求體或 webhook 數據
+	CodeUnauthorized     = "unauthorized"       // 認證或簽章驗證失敗
+	CodeExpired          = "expired"            // 請求已過期，可能為重放
+	CodeNotFound         = "not_found"          // 找不到資源
+	CodeConflict         = "conflict"           // 資源狀態不允許此操作
+	CodeOverloaded       = "overloaded"         // 處理中的事件過多，稍後重試
+	CodeStorage          = "storage_error"      // 讀寫同步狀態儲存失敗
+	CodeUpstream         = "upstream_error"     // 預約平台或 Google 日曆呼叫失敗
+)
+
+// Problem 是 RFC 7807 的錯誤描述，附加 code 與 retryable 擴充欄位
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	Retryable bool   `json:"retryable"` // 429 與 5xx 為暫時性失敗，可稍後重試
+}
+
+// New 創建錯誤描述，title 使用狀態碼的標準說明
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:      "urn:booking-sync:problem:" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Code:      code,
+		Retryable: status == http.StatusTooManyRequests || status >= 500,
+	}
+}
+
+// Write 輸出 problem+json 錯誤回應，instance 為請求路徑
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	p := New(status, code, detail)
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("輸出錯誤回應失敗: %v", err)
+	}
+}
+
+// MethodNotAllowed 輸出不支援請求方法的錯誤，並以 Allow 標頭列出支援的方法
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request, allow, detail string) {
+	w.Header().Set("Allow", allow)
+	Write(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, detail)
+}
@@ -0,0 +1,179 @@
+package reminder
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/hours"
+	"github.com/booking-sync-455103/booking-sync/pkg/render"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// DefaultTemplate 是預設的提醒簡訊模板
+const DefaultTemplate = `{{.ClientName}} 您好，提醒您 {{.StartTime.Format "01/02 15:04"}} 有 {{.ServiceName}} 預約（編號 {{.Code}}），如需更改請提前聯繫我們。`
+
+// SMSSender 定義發送簡訊的介面
+type SMSSender interface {
+	SendSMS(to, body string) error
+}
+
+// TemplateData 是提醒簡訊模板可使用的資料
+type TemplateData struct {
+	Code         string
+	ClientName   string
+	ServiceName  string
+	ProviderName string
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// Scheduler 負責排程與發送預約前的簡訊提醒
+type Scheduler struct {
+	store    store.Store
+	sender   SMSSender
+	before   time.Duration
+	template *template.Template
+	hours    *hours.Set // 各租戶的營業時間，nil 表示全天發送
+	tenant   string     // SimplyBook 預約所屬的租戶
+}
+
+// NewScheduler 創建新的提醒排程器，在預約開始前 before 發送提醒；locale 為模板中 localTime 等函式的語系
+func NewScheduler(syncStore store.Store, sender SMSSender, before time.Duration, tmpl, locale string) (*Scheduler, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+
+	parsed, err := template.New("reminder").Funcs(render.FuncMap(locale)).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("解析提醒模板失敗: %w", err)
+	}
+
+	return &Scheduler{
+		store:    syncStore,
+		sender:   sender,
+		before:   before,
+		template: parsed,
+	}, nil
+}
+
+// SetBusinessHours 設置各租戶的營業時間，提醒只在營業時間內發送；tenant 為 SimplyBook 預約所屬的租戶，
+// 其他平台的預約以平台名稱查詢
+func (s *Scheduler) SetBusinessHours(set *hours.Set, tenant string) {
+	s.hours = set
+	s.tenant = tenant
+}
+
+// Schedule 為預約排程（或重新排程）提醒
+func (s *Scheduler) Schedule(booking *simplybook.Booking) error {
+	bookingID := booking.Key()
+
+	if booking.Client.Phone == "" {
+		return s.store.DeleteReminder(bookingID)
+	}
+
+	now := time.Now()
+	if !booking.StartTime.After(now) {
+		return nil
+	}
+
+	// 預約建立時已在提醒時間之後，則盡快發送
+	sendAt := booking.StartTime.Add(-s.before)
+	if sendAt.Before(now) {
+		sendAt = now
+	}
+	sendAt = s.withinHours(booking, sendAt, now)
+
+	body, err := s.render(booking)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.SaveReminder(&store.Reminder{
+		BookingID: bookingID,
+		Phone:     booking.Client.Phone,
+		Body:      body,
+		SendAt:    sendAt,
+	}); err != nil {
+		return fmt.Errorf("儲存提醒失敗: %w", err)
+	}
+
+	return nil
+}
+
+// withinHours 將不在營業時間內的發送時間提前到前一段營業時間；已來不及時延後到下一段營業時間，
+// 但不晚於預約開始，兩者皆不可行時維持原時間
+func (s *Scheduler) withinHours(booking *simplybook.Booking, sendAt, now time.Time) time.Time {
+	tenant := booking.Source
+	if tenant == "" {
+		tenant = s.tenant
+	}
+
+	schedule := s.hours.For(tenant)
+	if schedule.Contains(sendAt) {
+		return sendAt
+	}
+	if prev := schedule.Prev(sendAt); prev.After(now) {
+		return prev
+	}
+	if next := schedule.Next(sendAt); next.Before(booking.StartTime.Time) {
+		return next
+	}
+	return sendAt
+}
+
+// Cancel 取消預約的提醒
+func (s *Scheduler) Cancel(bookingID string) error {
+	if err := s.store.DeleteReminder(bookingID); err != nil {
+		return fmt.Errorf("取消提醒失敗: %w", err)
+	}
+	return nil
+}
+
+// render 使用模板產生簡訊內容
+func (s *Scheduler) render(booking *simplybook.Booking) (string, error) {
+	data := TemplateData{
+		Code:         booking.Code,
+		ClientName:   booking.Client.Name,
+		ServiceName:  booking.ServiceName,
+		ProviderName: booking.ProviderName,
+		StartTime:    booking.StartTime.Time,
+		EndTime:      booking.EndTime.Time,
+	}
+
+	var buf bytes.Buffer
+	if err := s.template.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("產生提醒內容失敗: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// SendDue 發送所有已到期的提醒
+func (s *Scheduler) SendDue() error {
+	reminders, err := s.store.ListDueReminders(time.Now())
+	if err != nil {
+		return fmt.Errorf("讀取到期提醒失敗: %w", err)
+	}
+
+	var failed int
+	for _, r := range reminders {
+		if err := s.sender.SendSMS(r.Phone, r.Body); err != nil {
+			log.Printf("發送預約 %s 的提醒失敗: %v", r.BookingID, err)
+			failed++
+			continue
+		}
+
+		if err := s.store.MarkReminderSent(r.BookingID, time.Now()); err != nil {
+			log.Printf("標記預約 %s 的提醒已發送失敗: %v", r.BookingID, err)
+		}
+		log.Printf("已發送預約 %s 的簡訊提醒", r.BookingID)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d 筆提醒發送失敗", failed)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// classCount 是課程事件標題結尾的人數，例如「（3/8）」或未知上限時的「（3 人）」
+var classCount = regexp.MustCompile(`（\d+(/\d+| 人)）$`)
+
+// GroupClass 判斷預約是否屬於團體課程，依服務 ID 或名稱（不分大小寫）查找，ID 優先；
+// 返回的人數上限在設定為 0 時取自服務設定，兩者皆無時為 0
+func (r *Renderer) GroupClass(booking *simplybook.Booking) (int, bool) {
+	capacity, ok := r.groupClasses[strconv.Itoa(booking.ServiceID)]
+	if !ok {
+		capacity, ok = r.groupClasses[strings.ToLower(strings.TrimSpace(booking.ServiceName))]
+	}
+	if !ok {
+		return 0, false
+	}
+	if capacity == 0 {
+		capacity = booking.ServiceCapacity
+	}
+	return capacity, true
+}
+
+// ClassKey 返回預約所屬課程時段的識別，同一服務、服務提供者與開始時間的預約共用一個課程事件
+func (r *Renderer) ClassKey(booking *simplybook.Booking) string {
+	return fmt.Sprintf("%d/%d/%s", booking.ServiceID, booking.ProviderID, booking.StartTime.UTC().Format("20060102T150405Z"))
+}
+
+// ClassEvent 以預約渲染後的事件為範本產生課程事件，沿用時間、地點、顏色與日曆，
+// 標題為服務與服務提供者名稱。課程事件不邀請參與者，避免客戶看到彼此的電子郵件；
+// 描述不包含預約編號，避免被 FindEventByBookingCode 當作單一預約的事件
+func (r *Renderer) ClassEvent(booking *simplybook.Booking, event *gcalendar.CalendarEvent) *gcalendar.CalendarEvent {
+	summary := booking.ServiceName
+	if booking.ProviderName != "" {
+		summary += " · " + booking.ProviderName
+	}
+	return &gcalendar.CalendarEvent{
+		CalendarID: event.CalendarID,
+		ColorID:    event.ColorID,
+		Summary:    summary,
+		Location:   event.Location,
+		StartTime:  event.StartTime,
+		EndTime:    event.EndTime,
+		TimeZone:   event.TimeZone,
+		Properties: map[string]string{gcalendar.PropertyClass: r.ClassKey(booking)},
+	}
+}
+
+// SetClassParticipants 依參與者更新課程事件：標題結尾改為目前人數與上限，描述列出參與者姓名，
+// 並記錄參與的預約識別碼。ids 與 names 一一對應，capacity 為 0 表示上限未知
+func SetClassParticipants(event *gcalendar.CalendarEvent, ids, names []string, capacity int) {
+	count := fmt.Sprintf("（%d 人）", len(ids))
+	if capacity > 0 {
+		count = fmt.Sprintf("（%d/%d）", len(ids), capacity)
+	}
+	event.Summary = strings.TrimSpace(classCount.ReplaceAllString(event.Summary, "")) + count
+
+	var description strings.Builder
+	description.WriteString("參與者:")
+	for i, name := range names {
+		if name == "" {
+			name = "預約 " + ids[i]
+		}
+		fmt.Fprintf(&description, "\n%d. %s", i+1, name)
+	}
+	event.Description = description.String()
+
+	if event.Properties == nil {
+		event.Properties = make(map[string]string)
+	}
+	event.Properties[gcalendar.PropertyParticipants] = strings.Join(ids, ",")
+	if capacity > 0 {
+		event.Properties[gcalendar.PropertyCapacity] = strconv.Itoa(capacity)
+	} else {
+		delete(event.Properties, gcalendar.PropertyCapacity)
+	}
+}
+
+// ClassParticipants 返回課程事件記錄的參與預約識別碼與人數上限
+func ClassParticipants(event *gcalendar.CalendarEvent) ([]string, int) {
+	var ids []string
+	for _, id := range strings.Split(event.Properties[gcalendar.PropertyParticipants], ",") {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	capacity, _ := strconv.Atoi(event.Properties[gcalendar.PropertyCapacity])
+	return ids, capacity
+}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// FuncMap 返回事件連結、確認郵件與提醒簡訊模板共用的函式：
+//
+//	truncate N s          截斷為最多 N 個字元（以 rune 計算，不拆開表情符號），超過時以「…」結尾
+//	upper s               轉為大寫
+//	phoneFormat s         將台灣手機號碼（09xx 或 +8869xx）格式化為 0912-345-678，其他號碼僅移除分隔符號
+//	tzFormat ZONE LAYOUT t 以指定時區（IANA 名稱）與 Go 時間格式顯示時間
+//	duration START END    以「1 小時 30 分鐘」的格式顯示時長
+//	localTime t           依語系顯示時間，例如「下午 2:30」或「2:30 PM」
+//	localDate t           依語系顯示日期，例如「1月2日（週二）」或「Tue, Jan 2」
+//	localDateTime t       依語系顯示日期與時間
+//
+// 例如 {{.ClientName | truncate 10}}、{{.StartTime | tzFormat "Asia/Tokyo" "15:04"}}、{{duration .StartTime .EndTime}}。
+// locale 為 LocaleZhTW 或 LocaleEn，空字串使用 LocaleZhTW
+func FuncMap(locale string) template.FuncMap {
+	funcs := template.FuncMap{
+		"truncate":    truncate,
+		"upper":       strings.ToUpper,
+		"phoneFormat": phoneFormat,
+		"tzFormat":    tzFormat,
+		"duration":    formatDuration,
+	}
+	for name, fn := range localeFuncs(locale) {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// truncate 將字串截斷為最多 n 個字元，超過時以「…」結尾（計入 n）
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 0 || len(runes) <= n {
+		return s
+	}
+	if n == 0 {
+		return ""
+	}
+
+	end := n - 1
+	for end > 0 && !boundary(runes, end) {
+		end--
+	}
+	return string(runes[:end]) + ellipsis
+}
+
+// phoneFormat 將台灣手機號碼格式化為 0912-345-678，其他號碼僅移除空白、連字號、括號與點
+func phoneFormat(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || strings.ContainsRune("-().", r) {
+			return -1
+		}
+		return r
+	}, phone)
+
+	local := digits
+	if strings.HasPrefix(local, "+886") {
+		local = "0" + strings.TrimPrefix(local, "+886")
+	}
+	if len(local) == 10 && strings.HasPrefix(local, "09") && strings.Trim(local, "0123456789") == "" {
+		return local[:4] + "-" + local[4:7] + "-" + local[7:]
+	}
+	return digits
+}
+
+// tzFormat 以 IANA 時區名稱（例如 Asia/Tokyo）與 Go 時間格式顯示時間，時區無效時模板執行失敗
+func tzFormat(zone, layout string, t time.Time) (string, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return "", fmt.Errorf("無效的時區: %s", zone)
+	}
+	return t.In(loc).Format(layout), nil
+}
+
+// formatDuration 以「1 小時 30 分鐘」的格式顯示 start 到 end 的時長，不足一分鐘的部分捨去
+func formatDuration(start, end time.Time) string {
+	minutes := int(end.Sub(start) / time.Minute)
+	if minutes < 0 {
+		minutes = 0
+	}
+
+	hours, minutes := minutes/60, minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d 分鐘", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%d 小時", hours)
+	default:
+		return fmt.Sprintf("%d 小時 %d 分鐘", hours, minutes)
+	}
+}
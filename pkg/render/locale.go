@@ -0,0 +1,49 @@
+package render
+
+import (
+	"fmt"
+	"time"
+)
+
+// 模板中時間的顯示語系
+const (
+	LocaleZhTW = "zh-TW" // 上午/下午 2:30、1月2日（週二）
+	LocaleEn   = "en"    // 2:30 PM、Tue, Jan 2
+)
+
+// zhWeekdays 是中文的星期名稱
+var zhWeekdays = [...]string{"週日", "週一", "週二", "週三", "週四", "週五", "週六"}
+
+// localeFuncs 返回依語系格式化時間的模板函式，時間以其本身的時區顯示
+func localeFuncs(locale string) map[string]interface{} {
+	timeOf, dateOf := zhTime, zhDate
+	dateTime := func(t time.Time) string { return zhDate(t) + " " + zhTime(t) }
+	if locale == LocaleEn {
+		timeOf = func(t time.Time) string { return t.Format("3:04 PM") }
+		dateOf = func(t time.Time) string { return t.Format("Mon, Jan 2") }
+		dateTime = func(t time.Time) string { return t.Format("Mon, Jan 2, 3:04 PM") }
+	}
+	return map[string]interface{}{
+		"localTime":     timeOf,
+		"localDate":     dateOf,
+		"localDateTime": dateTime,
+	}
+}
+
+// zhTime 以 12 小時制顯示時間，例如「下午 2:30」
+func zhTime(t time.Time) string {
+	period := "上午"
+	if t.Hour() >= 12 {
+		period = "下午"
+	}
+	hour := t.Hour() % 12
+	if hour == 0 {
+		hour = 12
+	}
+	return fmt.Sprintf("%s %d:%02d", period, hour, t.Minute())
+}
+
+// zhDate 顯示月、日與星期，例如「1月2日（週二）」
+func zhDate(t time.Time) string {
+	return fmt.Sprintf("%d月%d日（%s）", t.Month(), t.Day(), zhWeekdays[t.Weekday()])
+}
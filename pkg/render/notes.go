@@ -0,0 +1,42 @@
+package render
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// 事件描述中備註區塊的開始與結束標記，工作人員可編輯兩者之間的文字
+const (
+	NotesStart = "--- 備註 ---"
+	NotesEnd   = "--- 備註結束 ---"
+)
+
+var (
+	// htmlBreak 是 Google 日曆網頁版編輯描述後產生的換行標籤
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	// htmlTag 是其餘的 HTML 標籤
+	htmlTag = regexp.MustCompile(`<[^>]+>`)
+)
+
+// ExtractNotes 從事件描述取出備註區塊的內容，找不到完整的區塊時返回 false。
+// 在 Google 日曆網頁版編輯過的描述會變成 HTML，取出前先轉回純文字
+func ExtractNotes(description string) (string, bool) {
+	if strings.Contains(description, "<") {
+		description = htmlBreak.ReplaceAllString(description, "\n")
+		description = htmlTag.ReplaceAllString(description, "")
+		description = html.UnescapeString(description)
+	}
+
+	start := strings.Index(description, NotesStart)
+	if start < 0 {
+		return "", false
+	}
+	rest := description[start+len(NotesStart):]
+
+	end := strings.Index(rest, NotesEnd)
+	if end < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(rest[:end]), true
+}
@@ -0,0 +1,374 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/rules"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// DefaultAdminURL 是 SimplyBook 後台預約頁面的預設網址模板
+const DefaultAdminURL = "https://{{.Company}}.secure.simplybook.me/v2/index/index/#/bookings/edit/{{.ID}}"
+
+// Data 是事件模板可使用的預約資料
+type Data struct {
+	ID           string
+	Code         string
+	Company      string
+	AdminURL     string // SimplyBook 後台的預約頁面
+	ClientName   string
+	ClientEmail  string
+	ClientPhone  string
+	ServiceName  string
+	Categories   []string // 服務所屬的分類名稱，僅規則依分類比對時查詢
+	ProviderName string
+	Status       string
+	Payment      string // 付款狀態（paid、unpaid 或 refunded），未查詢時為空
+	StartTime    time.Time
+	EndTime      time.Time
+	Fields       map[string]string // 自訂欄位，以 field_<ID> 及配置的變數名稱為鍵
+}
+
+// LinkOption 定義附加於事件的連結，URL 為 Go text/template
+type LinkOption struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Mode  string `json:"mode"` // description（預設）或 attachment
+}
+
+// Options 包含事件渲染的設定
+type Options struct {
+	Company  string        // SimplyBook 公司登錄名
+	AdminURL string        // SimplyBook 後台預約頁面的網址模板，未設置時使用 DefaultAdminURL
+	Links    []LinkOption  // 附加於事件的連結
+	Rules    *rules.Engine // 事件規則，可為 nil
+	// FieldMap 將自訂欄位 ID（12345 或 field_12345）或欄位名稱對應到模板變數名稱
+	FieldMap map[string]string
+	// Notes 在描述中加入可編輯的備註區塊，內容為預約的備註
+	Notes bool
+	// WaitingList 候補名單預約的處理方式：WaitingListTentative（預設）或 WaitingListSkip
+	WaitingList string
+	// WaitingListColor 候補名單暫定事件的顏色 ID，預設 8（灰色）
+	WaitingListColor string
+	// InviteClients 將客戶的電子郵件地址加為事件參與者
+	InviteClients bool
+	// CodeMarker 以預約編號標記（例如 [SB:1ab2c3d4]）取代描述開頭的純編號，描述加入其他文字後仍可解析
+	CodeMarker bool
+	// TitleMaxLength 事件標題的字元數上限，超過時以 TruncateTitle 從中段刪減，0 表示不限制
+	TitleMaxLength int
+	// Locale 模板中 localTime 等函式的顯示語系，LocaleZhTW（預設）或 LocaleEn
+	Locale string
+	// ResourceCalendars 將服務資源（房間、設備）的名稱或 ID 對應到資源日曆，預約在該日曆另建占用事件
+	ResourceCalendars map[string]string
+	// GroupClasses 將團體課程服務的名稱或 ID 對應到每時段人數上限，同一時段的預約共用一個課程事件；
+	// 上限為 0 時使用 SimplyBook 服務設定的人數上限
+	GroupClasses map[string]int
+}
+
+// 候補名單預約的處理方式
+const (
+	WaitingListTentative = "tentative" // 建立不佔用時間的暫定事件，轉正後更新為一般事件
+	WaitingListSkip      = "skip"      // 不同步，轉正後才建立事件
+)
+
+// link 是已解析模板的連結
+type link struct {
+	title      string
+	url        *template.Template
+	attachment bool
+}
+
+// Renderer 負責將預約轉換為日曆事件
+type Renderer struct {
+	company  string
+	adminURL *template.Template
+	links    []link
+	rules    *rules.Engine
+	fieldMap map[string]string
+	notes    bool
+	invite   bool
+	// codeMarker 以標記格式寫入預約編號
+	codeMarker bool
+	titleMax   int
+	locale     string
+
+	resourceCalendars map[string]string // 資源名稱（小寫）或 ID → 日曆
+	groupClasses      map[string]int    // 服務名稱（小寫）或 ID → 人數上限
+
+	waitingList      string
+	waitingListColor string
+}
+
+// New 創建新的事件渲染器
+func New(opts Options) (*Renderer, error) {
+	if opts.AdminURL == "" {
+		opts.AdminURL = DefaultAdminURL
+	}
+
+	switch opts.Locale {
+	case "":
+		opts.Locale = LocaleZhTW
+	case LocaleZhTW, LocaleEn:
+	default:
+		return nil, fmt.Errorf("不支援的語系: %s", opts.Locale)
+	}
+
+	adminURL, err := template.New("admin-url").Option("missingkey=zero").Funcs(FuncMap(opts.Locale)).Parse(opts.AdminURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析後台網址模板失敗: %w", err)
+	}
+
+	switch opts.WaitingList {
+	case "":
+		opts.WaitingList = WaitingListTentative
+	case WaitingListTentative, WaitingListSkip:
+	default:
+		return nil, fmt.Errorf("候補名單處理方式無效: %s", opts.WaitingList)
+	}
+	if opts.WaitingListColor == "" {
+		opts.WaitingListColor = "8"
+	}
+
+	r := &Renderer{company: opts.Company, adminURL: adminURL, rules: opts.Rules, fieldMap: make(map[string]string), notes: opts.Notes,
+		invite: opts.InviteClients, codeMarker: opts.CodeMarker, titleMax: opts.TitleMaxLength, locale: opts.Locale, waitingList: opts.WaitingList, waitingListColor: opts.WaitingListColor}
+
+	r.resourceCalendars = make(map[string]string, len(opts.ResourceCalendars))
+	for key, calendarID := range opts.ResourceCalendars {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || calendarID == "" {
+			return nil, fmt.Errorf("資源日曆的資源或日曆為空: %q → %q", key, calendarID)
+		}
+		r.resourceCalendars[key] = calendarID
+	}
+
+	r.groupClasses = make(map[string]int, len(opts.GroupClasses))
+	for key, capacity := range opts.GroupClasses {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" || capacity < 0 {
+			return nil, fmt.Errorf("團體課程的服務為空或人數上限為負數: %q → %d", key, capacity)
+		}
+		r.groupClasses[key] = capacity
+	}
+
+	for key, name := range opts.FieldMap {
+		if name == "" {
+			return nil, fmt.Errorf("自訂欄位 %q 的變數名稱為空", key)
+		}
+		if _, err := strconv.Atoi(key); err == nil {
+			key = "field_" + key
+		}
+		r.fieldMap[key] = name
+	}
+
+	for i, opt := range opts.Links {
+		tmpl, err := template.New(fmt.Sprintf("link-%d", i)).Option("missingkey=zero").Funcs(FuncMap(opts.Locale)).Parse(opt.URL)
+		if err != nil {
+			return nil, fmt.Errorf("解析連結 %q 的模板失敗: %w", opt.Title, err)
+		}
+
+		switch opt.Mode {
+		case "", "description", "attachment":
+		default:
+			return nil, fmt.Errorf("連結 %q 的模式無效: %s", opt.Title, opt.Mode)
+		}
+
+		r.links = append(r.links, link{
+			title:      opt.Title,
+			url:        tmpl,
+			attachment: opt.Mode == "attachment",
+		})
+	}
+
+	return r, nil
+}
+
+// Data 從預約建立模板資料
+func (r *Renderer) Data(booking *simplybook.Booking) Data {
+	data := Data{
+		ID:           booking.Key(),
+		Code:         booking.Code,
+		Company:      r.company,
+		ClientName:   booking.Client.Name,
+		ClientEmail:  booking.Client.Email,
+		ClientPhone:  booking.Client.Phone,
+		ServiceName:  booking.ServiceName,
+		ProviderName: booking.ProviderName,
+		Status:       booking.Status,
+		Payment:      booking.PaymentStatus,
+		StartTime:    booking.StartTime.Time,
+		EndTime:      booking.EndTime.Time,
+		Fields:       make(map[string]string, len(booking.AdditionalFields)),
+	}
+	for _, category := range booking.ServiceCategories {
+		data.Categories = append(data.Categories, category.Name)
+	}
+
+	for _, f := range booking.AdditionalFields {
+		key := "field_" + strconv.Itoa(f.ID)
+		value := f.String()
+		data.Fields[key] = value
+
+		// 依 ID 或欄位名稱對應到可讀的變數名稱，讓不同表單的模板保持一致
+		if name, ok := r.fieldMap[key]; ok {
+			data.Fields[name] = value
+		} else if name, ok := r.fieldMap[f.Name]; ok {
+			data.Fields[name] = value
+		}
+	}
+
+	// 後台網址只依賴公司與預約 ID，模板錯誤在 New 時已檢查
+	data.AdminURL, _ = execute(r.adminURL, data)
+	return data
+}
+
+// Funcs 返回依渲染器語系設定的模板函式，供確認郵件等使用相同預約資料的模板
+func (r *Renderer) Funcs() template.FuncMap {
+	return FuncMap(r.locale)
+}
+
+// paymentLabels 是事件描述中顯示的付款狀態
+var paymentLabels = map[string]string{
+	"paid":     "已付款",
+	"unpaid":   "未付款",
+	"refunded": "已退款",
+}
+
+// Render 從預約信息創建日曆事件
+func (r *Renderer) Render(booking *simplybook.Booking) (*gcalendar.CalendarEvent, error) {
+	data := r.Data(booking)
+
+	// 事件描述以預約編號開頭，供 FindEventByBookingCode 搜尋
+	var description strings.Builder
+	if r.codeMarker {
+		description.WriteString(gcalendar.BookingCodeMarker(booking.Code))
+	} else {
+		description.WriteString(booking.Code)
+	}
+	if label, ok := paymentLabels[booking.PaymentStatus]; ok {
+		description.WriteString("\n付款狀態: " + label)
+	}
+
+	result := r.rules.Evaluate(booking)
+	event := &gcalendar.CalendarEvent{
+		CalendarID: result.CalendarID,
+		ColorID:    result.ColorID,
+		TimeZone:   result.Timezone,
+		Summary:    result.TitlePrefix + booking.Client.Name + result.TitleSuffix,
+		StartTime:  booking.StartTime.Time,
+		EndTime:    booking.EndTime.Time,
+	}
+
+	// 候補名單的預約以暫定事件標示，不佔用工作人員的時間
+	if booking.WaitingList() {
+		event.Summary = "[候補] " + event.Summary
+		event.ColorID = r.waitingListColor
+		event.Tentative = true
+		event.Free = true
+	}
+
+	// 待核准的預約以暫定事件標示，核准後更新為一般事件
+	if booking.PendingApproval() {
+		event.Summary = "[待核准] " + event.Summary
+		event.Tentative = true
+	}
+
+	// 客戶姓名與規則前後綴過長時，手機上的標題難以閱讀
+	event.Summary = TruncateTitle(event.Summary, r.titleMax, booking.Code)
+
+	// 延長事件涵蓋前後的交通時間，描述中保留實際的預約時間
+	if result.TravelMode == rules.TravelExtend {
+		event.StartTime = event.StartTime.Add(-result.TravelBefore)
+		event.EndTime = event.EndTime.Add(result.TravelAfter)
+		fmt.Fprintf(&description, "\n預約時間: %s–%s（%s）",
+			booking.StartTime.Time.Format("15:04"), booking.EndTime.Time.Format("15:04"), travelNote(result))
+	}
+
+	// 準備事項以清單列於描述中，事件隨預約取消刪除時一併清除
+	if len(result.Checklist) > 0 {
+		due := booking.StartTime.Time.Add(-result.ChecklistBy)
+		fmt.Fprintf(&description, "\n\n準備事項（請於 %s 前完成）:", due.Format("01/02 15:04"))
+		for _, item := range result.Checklist {
+			description.WriteString("\n☐ " + item)
+		}
+	}
+
+	if r.notes {
+		description.WriteString("\n\n" + NotesStart + "\n")
+		if notes := strings.TrimSpace(booking.Notes); notes != "" {
+			description.WriteString(notes + "\n")
+		}
+		description.WriteString(NotesEnd)
+	}
+
+	// 後台連結讓工作人員可從日曆一鍵開啟預約
+	var descriptionLinks []string
+	if data.AdminURL != "" {
+		descriptionLinks = append(descriptionLinks, "SimplyBook 後台: "+data.AdminURL)
+	}
+
+	for _, l := range r.links {
+		url, err := execute(l.url, data)
+		if err != nil {
+			return nil, fmt.Errorf("產生連結 %q 失敗: %w", l.title, err)
+		}
+		// 預約缺少對應資料時略過該連結
+		if url == "" {
+			continue
+		}
+
+		if l.attachment {
+			event.Attachments = append(event.Attachments, gcalendar.Attachment{Title: l.title, URL: url})
+			continue
+		}
+		descriptionLinks = append(descriptionLinks, fmt.Sprintf("%s: %s", l.title, url))
+	}
+
+	if len(descriptionLinks) > 0 {
+		description.WriteString("\n\n")
+		description.WriteString(strings.Join(descriptionLinks, "\n"))
+	}
+
+	// 參與者地址的格式與停止邀請清單由同步處理器檢查
+	if r.invite && strings.TrimSpace(booking.Client.Email) != "" {
+		event.Attendees = []string{strings.TrimSpace(booking.Client.Email)}
+	}
+
+	event.Description = description.String()
+	return event, nil
+}
+
+// Localize 依規則設定的服務提供者時區重新解讀預約時間，未設定時區時不變更；
+// 重複呼叫不影響結果
+func (r *Renderer) Localize(booking *simplybook.Booking) {
+	if loc := r.rules.Evaluate(booking).Location; loc != nil {
+		booking.SetLocation(loc)
+	}
+}
+
+// CalendarID 返回規則為預約指定的日曆，空字串表示預設日曆
+func (r *Renderer) CalendarID(booking *simplybook.Booking) string {
+	return r.rules.Evaluate(booking).CalendarID
+}
+
+// Skip 判斷預約是否符合略過規則，或為設定不同步的候補名單預約
+func (r *Renderer) Skip(booking *simplybook.Booking) bool {
+	if booking.WaitingList() && r.waitingList == WaitingListSkip {
+		return true
+	}
+	return r.rules.Evaluate(booking).Skip
+}
+
+// execute 執行模板並去除前後空白
+func execute(tmpl *template.Template, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,75 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// ResourceEvents 為預約占用、且設有資源日曆的服務資源產生占用事件；event 為同一預約渲染後的事件，
+// 占用事件沿用其標題、時間、顏色與暫定狀態，但不邀請參與者。
+// 占用事件的描述不包含預約編號，避免被 FindEventByBookingCode 當作預約事件
+func (r *Renderer) ResourceEvents(booking *simplybook.Booking, event *gcalendar.CalendarEvent) []*gcalendar.CalendarEvent {
+	var events []*gcalendar.CalendarEvent
+	seen := make(map[string]bool)
+	for _, resource := range booking.Resources {
+		id := resource.ID.String()
+		calendarID := r.resourceCalendar(resource)
+		if calendarID == "" || id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		name := resource.Name
+		if name == "" {
+			name = id
+		}
+		description := fmt.Sprintf("資源: %s\n服務: %s", name, booking.ServiceName)
+		if booking.ProviderName != "" {
+			description += "\n服務提供者: " + booking.ProviderName
+		}
+		events = append(events, &gcalendar.CalendarEvent{
+			CalendarID:  calendarID,
+			ColorID:     event.ColorID,
+			Summary:     event.Summary,
+			Description: description,
+			Location:    event.Location,
+			StartTime:   event.StartTime,
+			EndTime:     event.EndTime,
+			TimeZone:    event.TimeZone,
+			Tentative:   event.Tentative,
+			Free:        event.Free,
+			Properties:  map[string]string{gcalendar.PropertyResource: id},
+		})
+	}
+	return events
+}
+
+// ResourceCalendars 依字母順序返回所有資源日曆，未設置資源日曆時返回 nil
+func (r *Renderer) ResourceCalendars() []string {
+	if len(r.resourceCalendars) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var calendars []string
+	for _, calendarID := range r.resourceCalendars {
+		if !seen[calendarID] {
+			seen[calendarID] = true
+			calendars = append(calendars, calendarID)
+		}
+	}
+	sort.Strings(calendars)
+	return calendars
+}
+
+// resourceCalendar 以資源 ID 或名稱（不分大小寫）查找資源日曆，ID 優先
+func (r *Renderer) resourceCalendar(resource simplybook.Resource) string {
+	if calendarID, ok := r.resourceCalendars[strings.ToLower(resource.ID.String())]; ok {
+		return calendarID
+	}
+	return r.resourceCalendars[strings.ToLower(strings.TrimSpace(resource.Name))]
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ellipsis 取代標題被刪減的部分
+const ellipsis = "…"
+
+// TruncateTitle 將標題縮短至 budget 個字元（rune）以內：從中段刪減並以「…」取代，開頭的狀態標記與結尾的規則後綴通常得以保留；
+// 標題包含 keep（預約編號）時編號不會被刪減，只刪減編號前後的文字。budget 為 0 或負數時不限制
+func TruncateTitle(title string, budget int, keep string) string {
+	if budget <= 0 || utf8.RuneCountInString(title) <= budget {
+		return title
+	}
+
+	i := -1
+	if keep != "" {
+		i = strings.Index(title, keep)
+	}
+	keepLen := utf8.RuneCountInString(keep)
+	if i < 0 || keepLen+1 > budget {
+		return trimMiddle(title, budget)
+	}
+
+	// 編號以外的預算依前後文字的長度比例分配
+	before, after := title[:i], title[i+len(keep):]
+	beforeLen, afterLen := utf8.RuneCountInString(before), utf8.RuneCountInString(after)
+	rest := budget - keepLen
+	beforeBudget := rest * beforeLen / (beforeLen + afterLen)
+	afterBudget := rest - beforeBudget
+	return trimMiddle(before, beforeBudget) + keep + trimMiddle(after, afterBudget)
+}
+
+// trimMiddle 刪減字串中段使其不超過 budget 個字元，不會拆開以組合字元、變體選擇符或零寬連接符組成的表情符號
+func trimMiddle(s string, budget int) string {
+	runes := []rune(s)
+	if len(runes) <= budget {
+		return s
+	}
+	if budget <= 0 {
+		return ""
+	}
+
+	// 保留 budget-1 個字元，其餘一個字元為「…」，開頭保留的字元較多
+	kept := budget - 1
+	head := (kept + 1) / 2
+	start := len(runes) - (kept - head)
+	for head > 0 && !boundary(runes, head) {
+		head--
+	}
+	for start < len(runes) && !boundary(runes, start) {
+		start++
+	}
+	return string(runes[:head]) + ellipsis + string(runes[start:])
+}
+
+// zeroWidthJoiner 將前後的表情符號組合為一個字形，例如家庭或職業表情符號
+const zeroWidthJoiner = '\u200d'
+
+// boundary 判斷在第 i 個字元之前切開是否會拆開同一個字形：
+// 組合字元、變體選擇符、零寬連接符與膚色修飾符依附於前一個字元，零寬連接符之後的字元依附於連接符
+func boundary(runes []rune, i int) bool {
+	r := runes[i]
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Variation_Selector, r) ||
+		r == zeroWidthJoiner || (r >= 0x1f3fb && r <= 0x1f3ff) {
+		return false
+	}
+	return i == 0 || runes[i-1] != zeroWidthJoiner
+}
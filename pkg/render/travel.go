@@ -0,0 +1,70 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/rules"
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// 交通事件的 gcalendar.PropertyTravel 值
+const (
+	TravelBefore = "before"
+	TravelAfter  = "after"
+)
+
+// TravelEvents 為規則設定另建交通事件的預約產生預約前後的交通事件，其他預約返回 nil；
+// event 為同一預約渲染後的事件，交通事件沿用其日曆、顏色與時區。
+// 交通事件的描述不包含預約編號，避免被 FindEventByBookingCode 當作預約事件
+func (r *Renderer) TravelEvents(booking *simplybook.Booking, event *gcalendar.CalendarEvent) []*gcalendar.CalendarEvent {
+	result := r.rules.Evaluate(booking)
+	if result.TravelMode != rules.TravelEvents {
+		return nil
+	}
+
+	travel := func(position string, start, end time.Time, description string) *gcalendar.CalendarEvent {
+		return &gcalendar.CalendarEvent{
+			CalendarID:  event.CalendarID,
+			ColorID:     event.ColorID,
+			TimeZone:    event.TimeZone,
+			Summary:     TruncateTitle("交通: "+booking.Client.Name, r.titleMax, ""),
+			Description: description,
+			StartTime:   start,
+			EndTime:     end,
+			Properties:  map[string]string{gcalendar.PropertyTravel: position},
+		}
+	}
+
+	var events []*gcalendar.CalendarEvent
+	if d := result.TravelBefore; d > 0 {
+		start := booking.StartTime.Time
+		events = append(events, travel(TravelBefore, start.Add(-d), start,
+			fmt.Sprintf("前往%s（%s 開始）", booking.ServiceName, start.Format("15:04"))))
+	}
+	if d := result.TravelAfter; d > 0 {
+		end := booking.EndTime.Time
+		events = append(events, travel(TravelAfter, end, end.Add(d),
+			fmt.Sprintf("%s結束後返回（%s 結束）", booking.ServiceName, end.Format("15:04"))))
+	}
+	return events
+}
+
+// HasTravelEvents 判斷是否有規則另建交通事件
+func (r *Renderer) HasTravelEvents() bool {
+	return r.rules.HasTravelEvents()
+}
+
+// travelNote 描述事件延長的交通時間，例如「含前 30 分鐘、後 15 分鐘交通時間」
+func travelNote(result rules.Result) string {
+	var parts []string
+	if result.TravelBefore > 0 {
+		parts = append(parts, fmt.Sprintf("前 %d 分鐘", int(result.TravelBefore.Minutes())))
+	}
+	if result.TravelAfter > 0 {
+		parts = append(parts, fmt.Sprintf("後 %d 分鐘", int(result.TravelAfter.Minutes())))
+	}
+	return "含" + strings.Join(parts, "、") + "交通時間"
+}
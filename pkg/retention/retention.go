@@ -0,0 +1,137 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// Policy 定義各類資料的保留期限，0 表示永久保留
+type Policy struct {
+	SyncRecords time.Duration // 同步記錄（稽核日誌）
+	DeadLetters time.Duration // 死信
+	Payloads    time.Duration // 預約快照：清除死信中的快照（保留死信本身）並刪除預約歷史快照
+}
+
+// Enabled 判斷是否設置了任何保留期限
+func (p Policy) Enabled() bool {
+	return p.SyncRecords > 0 || p.DeadLetters > 0 || p.Payloads > 0
+}
+
+// Purger 依保留政策清除過期資料
+type Purger struct {
+	store  store.Store
+	policy Policy
+}
+
+// NewPurger 創建新的資料清除器
+func NewPurger(syncStore store.Store, policy Policy) *Purger {
+	return &Purger{store: syncStore, policy: policy}
+}
+
+// Run 清除超過保留期限的資料
+func (p *Purger) Run() error {
+	now := time.Now()
+
+	if p.policy.SyncRecords > 0 {
+		n, err := p.store.PurgeSyncRecords(now.Add(-p.policy.SyncRecords))
+		if err != nil {
+			return fmt.Errorf("清除同步記錄失敗: %w", err)
+		}
+		log.Printf("已清除 %d 筆超過 %s 的同步記錄", n, p.policy.SyncRecords)
+	}
+
+	if p.policy.DeadLetters > 0 {
+		n, err := p.store.PurgeDeadLetters(now.Add(-p.policy.DeadLetters))
+		if err != nil {
+			return fmt.Errorf("清除死信失敗: %w", err)
+		}
+		log.Printf("已清除 %d 筆超過 %s 的死信", n, p.policy.DeadLetters)
+	}
+
+	if p.policy.Payloads > 0 {
+		n, err := p.store.ClearDeadLetterPayloads(now.Add(-p.policy.Payloads))
+		if err != nil {
+			return fmt.Errorf("清除預約快照失敗: %w", err)
+		}
+		log.Printf("已清除 %d 筆超過 %s 的死信預約快照", n, p.policy.Payloads)
+
+		n, err = p.store.PurgeBookingSnapshots(now.Add(-p.policy.Payloads))
+		if err != nil {
+			return fmt.Errorf("清除預約歷史快照失敗: %w", err)
+		}
+		log.Printf("已清除 %d 筆超過 %s 的預約歷史快照", n, p.policy.Payloads)
+	}
+
+	return nil
+}
+
+// FindClientBookings 找出客戶電子郵件對應的所有預約 ID，來源包括 SimplyBook 的預約列表、
+// 對應關係中的客戶電子郵件（含更名前的舊地址）與死信中保存的預約快照
+func FindClientBookings(syncStore store.Store, sb *simplybook.Client, email string) ([]string, error) {
+	email = strings.TrimSpace(email)
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if sb != nil {
+		bookings, err := sb.ListBookings(simplybook.BookingFilter{Search: email})
+		if err != nil {
+			return nil, err
+		}
+		// 搜尋結果可能包含名稱或電話部分符合的預約，需再比對電子郵件
+		for _, b := range bookings {
+			if strings.EqualFold(b.Client.Email, email) {
+				add(strconv.Itoa(b.ID))
+			}
+		}
+	}
+
+	// SimplyBook 已刪除或客戶已改用其他地址的預約只留在對應關係中
+	mappings, err := syncStore.ListMappings()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		if strings.EqualFold(strings.TrimSpace(m.ClientEmail), email) {
+			add(m.BookingID)
+			continue
+		}
+		for _, alias := range m.ClientAliases {
+			if strings.EqualFold(strings.TrimSpace(alias), email) {
+				add(m.BookingID)
+				break
+			}
+		}
+	}
+
+	dead, err := syncStore.ListDeadLetters(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dead {
+		if len(d.Payload) == 0 {
+			continue
+		}
+		var b simplybook.Booking
+		if err := json.Unmarshal(d.Payload, &b); err != nil {
+			continue
+		}
+		if strings.EqualFold(b.Client.Email, email) {
+			add(d.BookingID)
+		}
+	}
+
+	return ids, nil
+}
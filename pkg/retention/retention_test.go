@@ -0,0 +1,182 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// testStores 返回各儲存驅動的建立函式；PostgreSQL 只在設定 TEST_DATABASE_URL 時測試
+func testStores(t *testing.T) map[string]func(t *testing.T) store.Store {
+	return map[string]func(t *testing.T) store.Store{
+		"memory": func(t *testing.T) store.Store {
+			return store.NewMemoryStore()
+		},
+		"bolt": func(t *testing.T) store.Store {
+			s, err := store.NewBoltStore(filepath.Join(t.TempDir(), "sync.db"))
+			if err != nil {
+				t.Fatalf("開啟 bolt 儲存失敗: %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+		"postgres": func(t *testing.T) store.Store {
+			dsn := os.Getenv("TEST_DATABASE_URL")
+			if dsn == "" {
+				t.Skip("未設定 TEST_DATABASE_URL")
+			}
+			s, err := store.NewPostgresStore(dsn)
+			if err != nil {
+				t.Fatalf("連線 PostgreSQL 失敗: %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	}
+}
+
+// TestPurgeClientParity 確認各驅動都能以對應關係中的電子郵件找到客戶的預約，並清除預約的所有資料
+func TestPurgeClientParity(t *testing.T) {
+	for name, open := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			// 以時間區隔預約 ID，避免共用的 PostgreSQL 資料庫殘留先前測試的資料
+			bookingID := "retention-" + time.Now().Format("150405.000000")
+			other := bookingID + "-other"
+			now := time.Now()
+
+			mappings := []*store.Mapping{
+				{BookingID: bookingID, EventID: "evt-1", ClientName: "王小明", ClientEmail: "Ming@Example.com", CreatedAt: now, UpdatedAt: now},
+				{BookingID: other, EventID: "evt-2", ClientName: "陳大文", ClientEmail: "new@example.com", ClientAliases: []string{"ming@example.com"}, CreatedAt: now, UpdatedAt: now},
+			}
+			for _, m := range mappings {
+				if err := s.SaveMapping(m); err != nil {
+					t.Fatalf("儲存對應關係失敗: %v", err)
+				}
+			}
+			if err := s.AddSyncRecord(&store.SyncRecord{BookingID: bookingID, Action: "create", Success: true, CreatedAt: now}); err != nil {
+				t.Fatalf("新增同步記錄失敗: %v", err)
+			}
+			if err := s.SaveReminder(&store.Reminder{BookingID: bookingID, Phone: "0912345678", Body: "提醒", SendAt: now.Add(-time.Minute)}); err != nil {
+				t.Fatalf("儲存提醒失敗: %v", err)
+			}
+			if err := s.AddDeadLetter(&store.DeadLetter{BookingID: bookingID, Action: "change", Reason: "測試", CreatedAt: now}); err != nil {
+				t.Fatalf("新增死信失敗: %v", err)
+			}
+			if _, err := s.SaveBookingSnapshot(&store.BookingSnapshot{BookingID: bookingID, Payload: []byte(`{"id":1}`), CreatedAt: now}); err != nil {
+				t.Fatalf("儲存預約快照失敗: %v", err)
+			}
+			if err := s.SaveSyncState(&store.SyncState{BookingID: bookingID, State: "confirmed", Action: "create", StartedAt: now, UpdatedAt: now}); err != nil {
+				t.Fatalf("儲存同步狀態失敗: %v", err)
+			}
+
+			ids, err := FindClientBookings(s, nil, "ming@example.com")
+			if err != nil {
+				t.Fatalf("查找客戶預約失敗: %v", err)
+			}
+			found := make(map[string]bool)
+			for _, id := range ids {
+				found[id] = true
+			}
+			if !found[bookingID] || !found[other] {
+				t.Fatalf("應以對應關係的電子郵件與舊地址找到 %s 與 %s，實際為 %v", bookingID, other, ids)
+			}
+
+			for _, id := range []string{bookingID, other} {
+				if err := s.PurgeBooking(id); err != nil {
+					t.Fatalf("清除預約 %s 失敗: %v", id, err)
+				}
+			}
+
+			if m, err := s.GetMapping(bookingID); err != nil || m != nil {
+				t.Errorf("對應關係應已清除: %v, %v", m, err)
+			}
+			if records, err := s.ListBookingSyncRecords(bookingID, 0); err != nil || len(records) != 0 {
+				t.Errorf("同步記錄應已清除: %d 筆, %v", len(records), err)
+			}
+			reminders, err := s.ListDueReminders(now)
+			if err != nil {
+				t.Fatalf("列出提醒失敗: %v", err)
+			}
+			for _, r := range reminders {
+				if r.BookingID == bookingID {
+					t.Errorf("提醒應已清除")
+				}
+			}
+			dead, err := s.ListDeadLetters(0)
+			if err != nil {
+				t.Fatalf("列出死信失敗: %v", err)
+			}
+			for _, d := range dead {
+				if d.BookingID == bookingID {
+					t.Errorf("死信應已清除")
+				}
+			}
+			if snaps, err := s.ListBookingSnapshots(bookingID); err != nil || len(snaps) != 0 {
+				t.Errorf("預約快照應已清除: %d 筆, %v", len(snaps), err)
+			}
+			if st, err := s.GetSyncState(bookingID); err != nil || st != nil {
+				t.Errorf("同步狀態應已清除: %v, %v", st, err)
+			}
+			if ids, err := FindClientBookings(s, nil, "ming@example.com"); err != nil || len(ids) != 0 {
+				t.Errorf("清除後不應再找到客戶的預約: %v, %v", ids, err)
+			}
+		})
+	}
+}
+
+// TestPurgeBookingSnapshotsParity 確認各驅動都會實際刪除過期的預約快照，包括快照全部過期的預約
+func TestPurgeBookingSnapshotsParity(t *testing.T) {
+	for name, open := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			expired := "snapshots-" + time.Now().Format("150405.000000")
+			partial := expired + "-partial"
+			now := time.Now()
+			old := now.Add(-48 * time.Hour)
+
+			snapshots := []*store.BookingSnapshot{
+				{BookingID: expired, Payload: []byte(`{"version":1}`), CreatedAt: old},
+				{BookingID: expired, Payload: []byte(`{"version":2}`), CreatedAt: old.Add(time.Minute)},
+				{BookingID: partial, Payload: []byte(`{"version":1}`), CreatedAt: old},
+				{BookingID: partial, Payload: []byte(`{"version":2}`), CreatedAt: now},
+			}
+			for _, snap := range snapshots {
+				if _, err := s.SaveBookingSnapshot(snap); err != nil {
+					t.Fatalf("儲存預約快照失敗: %v", err)
+				}
+			}
+
+			n, err := s.PurgeBookingSnapshots(now.Add(-24 * time.Hour))
+			if err != nil {
+				t.Fatalf("清除預約快照失敗: %v", err)
+			}
+			if n < 3 {
+				t.Errorf("應至少清除 3 筆快照，實際 %d 筆", n)
+			}
+
+			if snaps, err := s.ListBookingSnapshots(expired); err != nil || len(snaps) != 0 {
+				t.Errorf("全部過期的預約快照應已刪除: %d 筆, %v", len(snaps), err)
+			}
+			snaps, err := s.ListBookingSnapshots(partial)
+			if err != nil {
+				t.Fatalf("列出預約快照失敗: %v", err)
+			}
+			if len(snaps) != 1 || string(snaps[0].Payload) != `{"version":2}` {
+				t.Errorf("應只保留未過期的快照，實際 %d 筆", len(snaps))
+			}
+
+			// 已清除的快照不應影響之後的儲存，相同內容須能重新建立快照
+			added, err := s.SaveBookingSnapshot(&store.BookingSnapshot{BookingID: expired, Payload: []byte(`{"version":2}`), CreatedAt: now})
+			if err != nil {
+				t.Fatalf("儲存預約快照失敗: %v", err)
+			}
+			if !added {
+				t.Errorf("快照清除後，相同內容應重新建立快照")
+			}
+		})
+	}
+}
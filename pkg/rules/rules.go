@@ -0,0 +1,275 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// Match 定義規則的比對條件，空條件視為符合；同一條件內的多個值只需符合其一
+type Match struct {
+	Services   []string          // 服務名稱或 ID
+	Categories []string          // 服務分類名稱或 ID，預約的服務屬於其中一個分類即符合
+	Providers  []string          // 服務提供者名稱或 ID
+	Statuses   []string          // 預約狀態
+	Payments   []string          // 付款狀態（paid、unpaid 或 refunded）
+	Fields     map[string]string // 自訂欄位名稱或標題 → 欄位值，須全部符合
+}
+
+// Rule 定義一條事件規則
+type Rule struct {
+	Name        string
+	Match       Match
+	TitlePrefix string        // 附加於事件標題之前
+	TitleSuffix string        // 附加於事件標題之後
+	ColorID     string        // Google 日曆事件顏色 ID
+	CalendarID  string        // 事件寫入的日曆
+	Timezone    string        // 服務提供者所在的 IANA 時區，預約時間以此時區解讀
+	Checklist   []string      // 附加於事件描述的準備事項
+	ChecklistBy time.Duration // 準備事項須於預約開始前多久完成，0 表示使用預設的 1 小時
+	Skip        bool          // 不同步符合條件的預約
+	Stop        bool          // 符合時停止評估後續規則
+
+	TravelBefore time.Duration // 預約開始前的交通時間（例如到府服務）
+	TravelAfter  time.Duration // 預約結束後的交通時間
+	TravelMode   string        // TravelExtend（預設）或 TravelEvents
+
+	location *time.Location
+}
+
+// DefaultChecklistBy 是準備事項預設須於預約開始前完成的時間
+const DefaultChecklistBy = time.Hour
+
+// 交通時間的呈現方式
+const (
+	TravelExtend = "extend" // 延長預約事件，涵蓋前後的交通時間
+	TravelEvents = "events" // 在預約事件前後另建交通事件
+)
+
+// Result 是對單一預約評估所有規則的結果
+type Result struct {
+	TitlePrefix string
+	TitleSuffix string
+	ColorID     string
+	CalendarID  string
+	Timezone    string
+	Location    *time.Location // Timezone 對應的時區，未設置時為 nil
+	Checklist   []string       // 所有符合規則的準備事項，依規則順序合併並去除重複
+	ChecklistBy time.Duration  // 準備事項須於預約開始前多久完成
+	Skip        bool
+	Matched     []string // 符合的規則名稱
+
+	TravelBefore time.Duration
+	TravelAfter  time.Duration
+	TravelMode   string // 有交通時間時為 TravelExtend 或 TravelEvents，否則為空
+}
+
+// Engine 依序評估事件規則
+type Engine struct {
+	rules []Rule
+}
+
+// New 創建新的規則引擎
+func New(rules []Rule) (*Engine, error) {
+	parsed := make([]Rule, len(rules))
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("第 %d 條規則缺少名稱", i+1)
+		}
+		if r.Timezone != "" {
+			loc, err := time.LoadLocation(r.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("規則 %q 的時區無效: %w", r.Name, err)
+			}
+			r.location = loc
+		}
+		if r.TravelBefore < 0 || r.TravelAfter < 0 {
+			return nil, fmt.Errorf("規則 %q 的交通時間不可為負數", r.Name)
+		}
+		switch r.TravelMode {
+		case "", TravelExtend, TravelEvents:
+		default:
+			return nil, fmt.Errorf("規則 %q 的交通時間模式無效: %s", r.Name, r.TravelMode)
+		}
+		parsed[i] = r
+	}
+	return &Engine{rules: parsed}, nil
+}
+
+// Evaluate 依序套用所有符合的規則：標題前後綴會累加，顏色、日曆與時區以後面的規則為準，
+// 遇到 Skip 或 Stop 的規則即停止評估
+func (e *Engine) Evaluate(booking *simplybook.Booking) Result {
+	var result Result
+	if e == nil {
+		return result
+	}
+
+	for _, r := range e.rules {
+		if !r.Match.matches(booking) {
+			continue
+		}
+
+		result.Matched = append(result.Matched, r.Name)
+		result.TitlePrefix += r.TitlePrefix
+		result.TitleSuffix = r.TitleSuffix + result.TitleSuffix
+		if r.ColorID != "" {
+			result.ColorID = r.ColorID
+		}
+		if r.CalendarID != "" {
+			result.CalendarID = r.CalendarID
+		}
+		if r.location != nil {
+			result.Timezone = r.Timezone
+			result.Location = r.location
+		}
+		for _, item := range r.Checklist {
+			if !containsString(result.Checklist, item) {
+				result.Checklist = append(result.Checklist, item)
+			}
+		}
+		if r.ChecklistBy > 0 {
+			result.ChecklistBy = r.ChecklistBy
+		}
+		if r.TravelBefore > 0 {
+			result.TravelBefore = r.TravelBefore
+		}
+		if r.TravelAfter > 0 {
+			result.TravelAfter = r.TravelAfter
+		}
+		if r.TravelMode != "" {
+			result.TravelMode = r.TravelMode
+		}
+
+		if r.Skip {
+			result.Skip = true
+			break
+		}
+		if r.Stop {
+			break
+		}
+	}
+
+	if len(result.Checklist) > 0 && result.ChecklistBy <= 0 {
+		result.ChecklistBy = DefaultChecklistBy
+	}
+	switch {
+	case result.TravelBefore <= 0 && result.TravelAfter <= 0:
+		result.TravelMode = ""
+	case result.TravelMode == "":
+		result.TravelMode = TravelExtend
+	}
+	return result
+}
+
+// HasTravelEvents 判斷是否有規則另建交通事件，沒有時同步不需查找既有的交通事件
+func (e *Engine) HasTravelEvents() bool {
+	if e == nil {
+		return false
+	}
+	for _, r := range e.rules {
+		if r.TravelMode == TravelEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// IconRules 將預約狀態與付款狀態的圖示轉換為附加標題前綴的規則，
+// 應放在其他規則之前，讓圖示顯示於標題最前面；狀態圖示排在付款圖示之前
+func IconRules(statusIcons, paymentIcons map[string]string) []Rule {
+	var result []Rule
+	for _, status := range sortedKeys(statusIcons) {
+		result = append(result, Rule{
+			Name:        "狀態圖示 " + status,
+			Match:       Match{Statuses: []string{status}},
+			TitlePrefix: statusIcons[status] + " ",
+		})
+	}
+	for _, payment := range sortedKeys(paymentIcons) {
+		result = append(result, Rule{
+			Name:        "付款圖示 " + payment,
+			Match:       Match{Payments: []string{payment}},
+			TitlePrefix: paymentIcons[payment] + " ",
+		})
+	}
+	return result
+}
+
+// sortedKeys 返回排序後的鍵，讓規則順序固定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString 判斷字串是否已在清單中
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matches 檢查預約是否符合所有條件
+func (m Match) matches(booking *simplybook.Booking) bool {
+	if len(m.Services) > 0 && !matchNameOrID(m.Services, booking.ServiceName, booking.ServiceID) {
+		return false
+	}
+	if len(m.Categories) > 0 && !matchCategory(m.Categories, booking.ServiceCategories) {
+		return false
+	}
+	if len(m.Providers) > 0 && !matchNameOrID(m.Providers, booking.ProviderName, booking.ProviderID) {
+		return false
+	}
+	if len(m.Statuses) > 0 && !matchAny(m.Statuses, booking.Status) {
+		return false
+	}
+	if len(m.Payments) > 0 && !matchAny(m.Payments, booking.PaymentStatus) {
+		return false
+	}
+
+	for name, want := range m.Fields {
+		got, ok := booking.Field(name)
+		if !ok || !strings.EqualFold(strings.TrimSpace(got), strings.TrimSpace(want)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchNameOrID 檢查名稱或數字 ID 是否在候選值中
+func matchNameOrID(candidates []string, name string, id int) bool {
+	return matchAny(candidates, name) || (id != 0 && matchAny(candidates, strconv.Itoa(id)))
+}
+
+// matchCategory 檢查服務所屬的任一分類名稱或 ID 是否在候選值中
+func matchCategory(candidates []string, categories []simplybook.Category) bool {
+	for _, category := range categories {
+		if matchAny(candidates, category.Name) || (category.ID != "" && matchAny(candidates, category.ID.String())) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny 不分大小寫比對候選值
+func matchAny(candidates []string, value string) bool {
+	for _, c := range candidates {
+		if strings.EqualFold(strings.TrimSpace(c), value) {
+			return true
+		}
+	}
+	return false
+}
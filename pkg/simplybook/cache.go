@@ -0,0 +1,90 @@
+package simplybook
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// BookingCache 是以預約 ID 為鍵的 LRU 快取，條目在 TTL 後過期，可安全地並行使用
+type BookingCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // 最近使用的條目在前
+	entries map[string]*list.Element
+}
+
+// cacheEntry 是快取中的單一預約
+type cacheEntry struct {
+	id        string
+	booking   Booking
+	expiresAt time.Time
+}
+
+// NewBookingCache 創建新的預約快取
+func NewBookingCache(size int, ttl time.Duration) *BookingCache {
+	return &BookingCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get 取得未過期的預約副本，未命中時返回 false
+func (c *BookingCache) Get(id string) (*Booking, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	booking := entry.booking
+	return &booking, true
+}
+
+// Add 加入或更新預約，超過容量時淘汰最久未使用的條目
+func (c *BookingCache) Add(id string, booking *Booking) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.booking = *booking
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[id] = c.order.PushFront(&cacheEntry{id: id, booking: *booking, expiresAt: expiresAt})
+	for c.size > 0 && c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Remove 移除預約
+func (c *BookingCache) Remove(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// removeElement 移除條目，呼叫者需持有鎖
+func (c *BookingCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).id)
+}
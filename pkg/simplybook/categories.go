@@ -0,0 +1,111 @@
+package simplybook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Category 表示服務分類，需在 SimplyBook 啟用 Service categories 外掛
+type Category struct {
+	ID       ID     `json:"id"`
+	Name     string `json:"name"`
+	Services []ID   `json:"services,omitempty"` // 分類中的服務 ID，預約的分類不設置
+}
+
+// categoryCache 快取各服務所屬的分類
+type categoryCache struct {
+	mu        sync.Mutex
+	byService map[int][]Category
+	fetchedAt time.Time
+}
+
+// GetCategoryList 獲取服務分類列表，SimplyBook 以陣列或以 ID 為鍵的物件返回
+func (c *Client) GetCategoryList() ([]Category, error) {
+	respBody, err := c.doRequest("GET", "/admin/categories", nil)
+	if err != nil {
+		return nil, fmt.Errorf("獲取服務分類列表失敗: %w", err)
+	}
+
+	var categories []Category
+	if err := json.Unmarshal(respBody, &categories); err == nil {
+		return categories, nil
+	}
+	var byID map[string]Category
+	if err := json.Unmarshal(respBody, &byID); err != nil {
+		return nil, fmt.Errorf("解析服務分類列表失敗: %w", err)
+	}
+	for key, category := range byID {
+		if category.ID == "" {
+			category.ID = ID(key)
+		}
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+// ServiceCategories 返回服務所屬的分類，分類列表會快取一小時；服務不屬於任何分類時返回 nil
+func (c *Client) ServiceCategories(serviceID int) ([]Category, error) {
+	c.categories.mu.Lock()
+	defer c.categories.mu.Unlock()
+
+	categories, ok := c.categories.byService[serviceID]
+	if shouldReload(c.categories.byService != nil, ok, c.categories.fetchedAt) {
+		// 重新讀取失敗時沿用快取中的分類
+		if err := c.loadCategories(); err != nil && c.categories.byService == nil {
+			return nil, err
+		}
+		categories = c.categories.byService[serviceID]
+	}
+	return categories, nil
+}
+
+// loadCategories 重新讀取分類列表，呼叫前須持有 c.categories.mu
+func (c *Client) loadCategories() error {
+	categories, err := c.GetCategoryList()
+	if err != nil {
+		return err
+	}
+
+	byService := make(map[int][]Category)
+	for _, category := range categories {
+		for _, service := range category.Services {
+			if n, err := strconv.Atoi(service.String()); err == nil {
+				byService[n] = append(byService[n], Category{ID: category.ID, Name: category.Name})
+			}
+		}
+	}
+	c.categories.byService = byService
+	c.categories.fetchedAt = time.Now()
+	return nil
+}
+
+// CategoryResolver 以快取的分類列表補上預約服務所屬的分類，讓事件規則可依分類比對；
+// 可作為 handler.Enricher 使用
+type CategoryResolver struct {
+	client *Client
+}
+
+// NewCategoryResolver 創建以 client 的分類列表補充分類的 CategoryResolver
+func NewCategoryResolver(client *Client) *CategoryResolver {
+	return &CategoryResolver{client: client}
+}
+
+// Enrich 補上預約服務所屬的分類，已有分類或沒有服務 ID 時不查詢
+func (r *CategoryResolver) Enrich(b *Booking) error {
+	// 其他平台的預約沒有 SimplyBook 的服務 ID
+	if b.Source != "" || b.ServiceID == 0 || len(b.ServiceCategories) > 0 {
+		return nil
+	}
+
+	categories, err := r.client.ServiceCategories(b.ServiceID)
+	if err != nil {
+		return fmt.Errorf("補充服務分類失敗: %w", err)
+	}
+	b.ServiceCategories = categories
+	return nil
+}
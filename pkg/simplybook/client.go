@@ -7,7 +7,11 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/version"
 )
 
 // Client 代表 SimplyBook API 客戶端
@@ -18,6 +22,23 @@ type Client struct {
 	Token        string
 	BaseURL      string
 	HTTPClient   *http.Client
+	UserAgent    string            // 識別整合流量的 User-Agent
+	Headers      map[string]string // 附加於每個請求的自訂標頭
+	// BasicBookings 讀取預約時不要求展開服務、服務提供者與帳單，改由服務與服務提供者列表補充
+	BasicBookings bool
+
+	services   serviceCache
+	providers  providerCache
+	categories categoryCache
+}
+
+// Options 包含 SimplyBook 客戶端的可選設定
+type Options struct {
+	HTTPClient *http.Client      // 未設置時使用預設客戶端
+	UserAgent  string            // 未設置時使用 booking-sync/<版本>
+	Headers    map[string]string // 附加於每個請求的自訂標頭
+	// BasicBookings 讀取預約時不使用詳細端點，用於不支援展開參數的帳號
+	BasicBookings bool
 }
 
 // TokenResponse 認證響應
@@ -26,13 +47,24 @@ type TokenResponse struct {
 }
 
 // NewClient 創建新的 SimplyBook API 客戶端
-func NewClient(companyLogin, username, password string) (*Client, error) {
+func NewClient(companyLogin, username, password string, opts Options) (*Client, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = version.UserAgent()
+	}
+
 	client := &Client{
 		CompanyLogin: companyLogin,
 		Username:     username,
 		Password:     password,
 		BaseURL:      "https://user-api-v2.simplybook.me",
-		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+		HTTPClient:   opts.HTTPClient,
+		UserAgent:    opts.UserAgent,
+		Headers:      opts.Headers,
+
+		BasicBookings: opts.BasicBookings,
 	}
 
 	// 獲取認證令牌
@@ -45,7 +77,7 @@ func NewClient(companyLogin, username, password string) (*Client, error) {
 
 // 進行 API 認證並獲取令牌
 func (c *Client) authenticate() error {
-	url := fmt.Sprintf("%s/admin/auth", c.BaseURL)
+	authURL := fmt.Sprintf("%s/admin/auth", c.BaseURL)
 
 	// 根據 CURL 範例準備認證請求
 	authRequest := map[string]string{
@@ -59,12 +91,12 @@ func (c *Client) authenticate() error {
 		return fmt.Errorf("序列化認證請求失敗: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestData))
+	req, err := http.NewRequest("POST", authURL, bytes.NewBuffer(requestData))
 	if err != nil {
 		return fmt.Errorf("創建認證請求失敗: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
@@ -79,7 +111,7 @@ func (c *Client) authenticate() error {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("認證失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("認證失敗: %w", parseAPIError(resp.StatusCode, resp.Header, body))
 	}
 
 	var response TokenResponse
@@ -95,9 +127,23 @@ func (c *Client) authenticate() error {
 	return nil
 }
 
+// setHeaders 設置所有請求共用的標頭
+func (c *Client) setHeaders(req *http.Request) {
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.UserAgent)
+}
+
 // doRequest 執行 REST API 請求
 func (c *Client) doRequest(method, endpoint string, requestBody interface{}) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
+	return c.doTimedRequest(method, endpoint, requestBody, nil)
+}
+
+// doTimedRequest 執行請求，timing 不為 nil 時記錄各環節耗時
+func (c *Client) doTimedRequest(method, endpoint string, requestBody interface{}, timing *Timing) ([]byte, error) {
+	requestURL := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
 
 	var body io.Reader
 	if requestBody != nil {
@@ -108,29 +154,44 @@ func (c *Client) doRequest(method, endpoint string, requestBody interface{}) ([]
 		body = bytes.NewBuffer(bodyBytes)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequest(method, requestURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("創建請求失敗: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
 	// 根據 CURL 範例設置請求頭
 	req.Header.Set("X-Token", c.Token)
 	req.Header.Set("X-Company-Login", c.CompanyLogin)
 
+	done := func() {}
+	if timing != nil {
+		req, done = timing.trace(req)
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("執行請求失敗: %w", err)
 	}
 	defer resp.Body.Close()
 
+	readStart := time.Now()
 	respBody, err := io.ReadAll(resp.Body)
+	if timing != nil {
+		timing.Transfer = time.Since(readStart)
+		done()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("讀取響應失敗: %w", err)
 	}
 
 	// 檢查是否是未授權錯誤（令牌可能過期）
 	if resp.StatusCode == http.StatusUnauthorized {
+		reauthStart := time.Now()
+		if timing != nil {
+			defer func() { timing.Reauth = time.Since(reauthStart) }()
+		}
+
 		// 嘗試重新認證
 		if err := c.authenticate(); err != nil {
 			return nil, fmt.Errorf("令牌過期，重新認證失敗: %w", err)
@@ -141,7 +202,7 @@ func (c *Client) doRequest(method, endpoint string, requestBody interface{}) ([]
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API請求失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+		return nil, parseAPIError(resp.StatusCode, resp.Header, respBody)
 	}
 
 	return respBody, nil
@@ -149,7 +210,7 @@ func (c *Client) doRequest(method, endpoint string, requestBody interface{}) ([]
 
 // retryRequest 使用新令牌重試請求
 func (c *Client) retryRequest(method, endpoint string, requestBody interface{}) ([]byte, error) {
-	url := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
+	requestURL := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
 
 	var body io.Reader
 	if requestBody != nil {
@@ -160,12 +221,12 @@ func (c *Client) retryRequest(method, endpoint string, requestBody interface{})
 		body = bytes.NewBuffer(bodyBytes)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequest(method, requestURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("重試時創建請求失敗: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	c.setHeaders(req)
 	// 根據 CURL 範例設置請求頭
 	req.Header.Set("X-Token", c.Token)
 	req.Header.Set("X-Company-Login", c.CompanyLogin)
@@ -182,7 +243,7 @@ func (c *Client) retryRequest(method, endpoint string, requestBody interface{})
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("重試API請求失敗，狀態碼: %d, 響應: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("重試API請求失敗: %w", parseAPIError(resp.StatusCode, resp.Header, respBody))
 	}
 
 	return respBody, nil
@@ -190,21 +251,117 @@ func (c *Client) retryRequest(method, endpoint string, requestBody interface{})
 
 // GetBooking 獲取預約詳情
 func (c *Client) GetBooking(bookingID string) (*Booking, error) {
+	booking, _, err := c.GetBookingTimed(bookingID)
+	return booking, err
+}
+
+// GetBookingTimed 獲取預約詳情，並返回連線、伺服器處理與傳輸等環節的耗時。
+// 預設以詳細端點一次取得服務、服務提供者與付款狀態，不需再查詢服務與服務提供者列表
+func (c *Client) GetBookingTimed(bookingID string) (*Booking, *Timing, error) {
 	endpoint := fmt.Sprintf("/admin/bookings/%s", bookingID)
+	if !c.BasicBookings {
+		endpoint += detailedBookingQuery
+	}
 
-	respBody, err := c.doRequest("GET", endpoint, nil)
+	timing := &Timing{}
+	respBody, err := c.doTimedRequest("GET", endpoint, nil, timing)
 	if err != nil {
-		return nil, fmt.Errorf("獲取預約失敗: %w", err)
+		return nil, timing, fmt.Errorf("獲取預約失敗: %w", err)
 	}
 
 	log.Printf("respBody: %s", string(respBody))
 
 	var booking Booking
 	if err := json.Unmarshal(respBody, &booking); err != nil {
-		return nil, fmt.Errorf("解析預約數據失敗: %w", err)
+		return nil, timing, fmt.Errorf("解析預約數據失敗: %w", err)
+	}
+	applyDetails(respBody, &booking)
+
+	serviceStart := time.Now()
+	c.fillEndTime(&booking)
+	timing.Service = time.Since(serviceStart)
+	return &booking, timing, nil
+}
+
+// UpdateBookingNotes 更新預約的備註
+func (c *Client) UpdateBookingNotes(bookingID, notes string) error {
+	endpoint := fmt.Sprintf("/admin/bookings/%s", bookingID)
+
+	if _, err := c.doRequest("PUT", endpoint, map[string]string{"notes": notes}); err != nil {
+		return fmt.Errorf("更新預約備註失敗: %w", err)
+	}
+	return nil
+}
+
+// ApproveBooking 核准待核准的預約（需啟用 SimplyBook 的 Approve booking 外掛）
+func (c *Client) ApproveBooking(bookingID string) error {
+	endpoint := fmt.Sprintf("/admin/bookings/%s/approve", bookingID)
+
+	if _, err := c.doRequest("PUT", endpoint, nil); err != nil {
+		return fmt.Errorf("核准預約失敗: %w", err)
+	}
+	return nil
+}
+
+// CancelBooking 取消預約，用於拒絕待核准的預約
+func (c *Client) CancelBooking(bookingID string) error {
+	endpoint := fmt.Sprintf("/admin/bookings/%s", bookingID)
+
+	if _, err := c.doRequest("DELETE", endpoint, nil); err != nil {
+		return fmt.Errorf("取消預約失敗: %w", err)
+	}
+	return nil
+}
+
+// bookingsPageSize 查詢預約列表時每頁的筆數
+const bookingsPageSize = 100
+
+// ListBookings 依篩選條件獲取所有分頁的預約列表
+func (c *Client) ListBookings(filter BookingFilter) ([]Booking, error) {
+	query := url.Values{}
+	if !filter.DateFrom.IsZero() {
+		query.Set("filter[date_from]", filter.DateFrom.Format("2006-01-02"))
+	}
+	if !filter.DateTo.IsZero() {
+		query.Set("filter[date_to]", filter.DateTo.Format("2006-01-02"))
+	}
+	if filter.Status != "" {
+		query.Set("filter[status]", filter.Status)
+	}
+	if filter.Search != "" {
+		query.Set("filter[search]", filter.Search)
+	}
+	pageSize := bookingsPageSize
+	if filter.Limit > 0 && filter.Limit < pageSize {
+		pageSize = filter.Limit
+	}
+	query.Set("on_page", strconv.Itoa(pageSize))
+
+	var bookings []Booking
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		respBody, err := c.doRequest("GET", "/admin/bookings?"+query.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("獲取預約列表失敗: %w", err)
+		}
+
+		var list BookingList
+		if err := json.Unmarshal(respBody, &list); err != nil {
+			return nil, fmt.Errorf("解析預約列表失敗: %w", err)
+		}
+
+		bookings = append(bookings, list.Data...)
+		if filter.Limit > 0 && len(bookings) >= filter.Limit {
+			bookings = bookings[:filter.Limit]
+			break
+		}
+		if len(list.Data) == 0 || page >= list.Metadata.PagesCount {
+			break
+		}
 	}
 
-	return &booking, nil
+	return bookings, nil
 }
 
 // GetServiceList 獲取服務列表
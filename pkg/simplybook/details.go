@@ -0,0 +1,106 @@
+package simplybook
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// detailedBookingQuery 要求預約詳情一併展開客戶、服務、服務提供者與帳單，
+// 一次請求即可取得同步所需的資料，不需再查詢服務與服務提供者列表
+const detailedBookingQuery = "?expand=client,service,provider,invoice&additional_fields=true"
+
+// bookingDetails 是詳細預約中以物件展開的欄位，未展開時這些欄位為識別碼或不存在
+type bookingDetails struct {
+	Service       json.RawMessage `json:"service"`
+	Provider      json.RawMessage `json:"provider"`
+	Invoice       json.RawMessage `json:"invoice"`
+	InvoiceStatus string          `json:"invoice_status"`
+}
+
+// detailsService 是展開的服務
+type detailsService struct {
+	ID       ID     `json:"id"`
+	Name     string `json:"name"`
+	Duration int    `json:"duration"` // 分鐘
+	Capacity int    `json:"capacity"` // 團體服務每時段的人數上限，非團體服務為 0
+}
+
+// detailsProvider 是展開的服務提供者
+type detailsProvider struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+}
+
+// detailsInvoice 是展開的帳單
+type detailsInvoice struct {
+	Status string `json:"status"`
+}
+
+// applyDetails 以詳細預約展開的物件補上服務、服務提供者、結束時間與付款狀態，
+// 已有值的欄位不覆蓋；未展開或無法解析的欄位略過，由原本的補充方式處理
+func applyDetails(body []byte, b *Booking) {
+	var details bookingDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return
+	}
+
+	var service detailsService
+	if decodeObject(details.Service, &service) {
+		if id, err := strconv.Atoi(service.ID.String()); err == nil && b.ServiceID == 0 {
+			b.ServiceID = id
+		}
+		if b.ServiceName == "" {
+			b.ServiceName = service.Name
+		}
+		if b.ServiceCapacity == 0 {
+			b.ServiceCapacity = service.Capacity
+		}
+		if b.EndTime.IsZero() && !b.StartTime.IsZero() && service.Duration > 0 {
+			b.EndTime.Time = b.StartTime.Add(time.Duration(service.Duration) * time.Minute)
+		}
+	}
+
+	var provider detailsProvider
+	if decodeObject(details.Provider, &provider) {
+		if id, err := strconv.Atoi(provider.ID.String()); err == nil && b.ProviderID == 0 {
+			b.ProviderID = id
+		}
+		if b.ProviderName == "" {
+			b.ProviderName = provider.Name
+		}
+	}
+
+	status := details.InvoiceStatus
+	var invoice detailsInvoice
+	if decodeObject(details.Invoice, &invoice) && invoice.Status != "" {
+		status = invoice.Status
+	}
+	if b.PaymentStatus == "" {
+		b.PaymentStatus = invoicePaymentStatus(status)
+	}
+}
+
+// decodeObject 僅在值為 JSON 物件時解析，未展開的欄位（識別碼或 null）返回 false
+func decodeObject(raw json.RawMessage, v interface{}) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+// invoicePaymentStatus 將 SimplyBook 帳單狀態對應為付款狀態（paid、unpaid 或 refunded），沒有帳單時為空
+func invoicePaymentStatus(status string) string {
+	switch status = strings.ToLower(strings.TrimSpace(status)); {
+	case status == "":
+		return ""
+	case status == "paid":
+		return "paid"
+	case strings.HasPrefix(status, "refund"):
+		return "refunded"
+	default:
+		return "unpaid"
+	}
+}
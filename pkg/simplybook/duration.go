@@ -0,0 +1,79 @@
+package simplybook
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// serviceCacheTTL 服務列表快取的存活時間
+const serviceCacheTTL = time.Hour
+
+// serviceCache 快取服務列表中的預設時長與名稱，避免每次推算結束時間或補充名稱都查詢服務列表
+type serviceCache struct {
+	mu        sync.Mutex
+	durations map[int]time.Duration
+	names     map[int]string
+	fetchedAt time.Time
+}
+
+// ServiceDuration 返回服務的預設時長，服務列表會快取一小時
+func (c *Client) ServiceDuration(serviceID int) (time.Duration, error) {
+	c.services.mu.Lock()
+	defer c.services.mu.Unlock()
+
+	if c.services.durations == nil || time.Since(c.services.fetchedAt) > serviceCacheTTL {
+		if err := c.loadServices(); err != nil {
+			return 0, err
+		}
+	}
+
+	duration, ok := c.services.durations[serviceID]
+	if !ok || duration <= 0 {
+		return 0, fmt.Errorf("服務 %d 沒有設定時長", serviceID)
+	}
+	return duration, nil
+}
+
+// loadServices 重新讀取服務列表，呼叫前須持有 c.services.mu
+func (c *Client) loadServices() error {
+	services, err := c.GetServiceList()
+	if err != nil {
+		return err
+	}
+
+	durations := make(map[int]time.Duration, len(services))
+	names := make(map[int]string, len(services))
+	for key, s := range services {
+		id := s.ID
+		if id == "" {
+			id = key
+		}
+		if n, err := strconv.Atoi(id); err == nil {
+			durations[n] = time.Duration(s.Duration) * time.Minute
+			names[n] = s.Name
+		}
+	}
+	c.services.durations = durations
+	c.services.names = names
+	c.services.fetchedAt = time.Now()
+	return nil
+}
+
+// fillEndTime 在 webhook 早於結束時間寫入時，以服務時長推算結束時間，失敗時僅記錄日誌
+func (c *Client) fillEndTime(b *Booking) {
+	if !b.EndTime.IsZero() || b.StartTime.IsZero() || b.ServiceID == 0 {
+		return
+	}
+
+	duration, err := c.ServiceDuration(b.ServiceID)
+	if err != nil {
+		log.Printf("預約 %d 缺少結束時間，且無法取得服務時長: %v", b.ID, err)
+		return
+	}
+
+	b.EndTime.Time = b.StartTime.Add(duration)
+	log.Printf("預約 %d 缺少結束時間，依服務時長 %s 推算為 %s", b.ID, duration, b.EndTime.Format("2006-01-02 15:04:05"))
+}
@@ -0,0 +1,87 @@
+package simplybook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/httpclient"
+)
+
+// APIError 代表 SimplyBook API 返回的錯誤
+type APIError struct {
+	StatusCode int             `json:"-"`       // HTTP 狀態碼
+	Code       int             `json:"code"`    // SimplyBook 錯誤碼
+	Message    string          `json:"message"` // 錯誤訊息
+	Data       json.RawMessage `json:"data"`    // 附加的錯誤資料（例如欄位驗證錯誤）
+	Body       string          `json:"-"`       // 無法解析時保留的原始響應
+	RetryAfter time.Duration   `json:"-"`       // 響應標頭要求的等待時間，未提供時為 0
+}
+
+// Error 實作 error 介面
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API請求失敗，狀態碼: %d, 響應: %s", e.StatusCode, e.Body)
+	}
+	if len(e.Data) > 0 && string(e.Data) != "null" {
+		return fmt.Sprintf("API請求失敗，狀態碼: %d, 錯誤碼: %d, 訊息: %s, 資料: %s", e.StatusCode, e.Code, e.Message, string(e.Data))
+	}
+	return fmt.Sprintf("API請求失敗，狀態碼: %d, 錯誤碼: %d, 訊息: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// parseAPIError 將錯誤響應解析為 APIError，無法解析的響應體會保留在 Body 中
+func parseAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		RetryAfter: httpclient.RetryAfter(header, time.Now()),
+	}
+	if err := json.Unmarshal(body, apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = ""
+		apiErr.Body = string(body)
+	}
+	if apiErr.Code == 0 {
+		apiErr.Code = statusCode
+	}
+	return apiErr
+}
+
+// AsAPIError 從錯誤鏈中取出 APIError
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsNotFound 判斷錯誤是否代表資源不存在
+func IsNotFound(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsRateLimited 判斷錯誤是否代表請求過於頻繁
+func IsRateLimited(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// RetryAfter 返回請求過於頻繁時 SimplyBook 要求的等待時間，其他錯誤或未提供時返回 0
+func RetryAfter(err error) time.Duration {
+	apiErr, ok := AsAPIError(err)
+	if !ok || apiErr.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+	return apiErr.RetryAfter
+}
+
+// IsRetryable 判斷錯誤是否為可重試的暫時性錯誤
+func IsRetryable(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
@@ -1,6 +1,9 @@
 package simplybook
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,7 +23,8 @@ type customTime struct {
 // UnmarshalJSON 自定義時間解析方法
 func (ct *customTime) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), "\"")
-	if s == "null" {
+	// 結束時間尚未寫入時 SimplyBook 可能返回空值或全零日期
+	if s == "null" || s == "" || strings.HasPrefix(s, "0000-00-00") {
 		ct.Time = time.Time{}
 		return nil
 	}
@@ -61,6 +65,115 @@ type Booking struct {
 	Confirmed    bool          `json:"confirmed,omitempty"`
 	Notes        string        `json:"notes,omitempty"`
 	Status       string        `json:"status,omitempty"`
+
+	AdditionalFields []AdditionalField `json:"additional_fields,omitempty"`
+
+	// Resources 預約占用的服務資源（房間、設備），僅在 SimplyBook 啟用 Service resources 外掛時存在
+	Resources []Resource `json:"resources,omitempty"`
+
+	// ServiceCapacity 服務設定的每時段人數上限，由展開的服務補充，非團體服務為 0
+	ServiceCapacity int `json:"service_capacity,omitempty"`
+
+	// ServiceCategories 服務所屬的分類，由 CategoryResolver 補充，未查詢時為空
+	ServiceCategories []Category `json:"service_categories,omitempty"`
+
+	// PaymentStatus 由付款資料補充的狀態（paid、unpaid 或 refunded），未查詢時為空
+	PaymentStatus string `json:"payment_status,omitempty"`
+
+	// Source 與 ExternalID 標示非 SimplyBook 的預約（例如 Calendly），SimplyBook 預約兩者皆為空
+	Source     string `json:"source,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+}
+
+// Key 返回預約在同步狀態中的識別碼
+func (b *Booking) Key() string {
+	if b.ExternalID != "" {
+		return b.ExternalID
+	}
+	return strconv.Itoa(b.ID)
+}
+
+// SetLocation 保留預約開始與結束的當地時間，改以指定時區解讀，
+// 用於服務提供者所在時區與公司預設時區不同的情況
+func (b *Booking) SetLocation(loc *time.Location) {
+	b.StartTime.Time = inLocation(b.StartTime.Time, loc)
+	b.EndTime.Time = inLocation(b.EndTime.Time, loc)
+}
+
+// inLocation 以相同的年月日時分秒在指定時區建立時間
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// waitingListStatuses 是候補名單預約的狀態，候補轉正後狀態改變並送出 change 通知
+var waitingListStatuses = []string{"waiting_list", "wait_list", "waitlist"}
+
+// WaitingList 判斷預約是否仍在候補名單
+func (b *Booking) WaitingList() bool {
+	for _, status := range waitingListStatuses {
+		if strings.EqualFold(b.Status, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingApproval 判斷預約是否等待服務提供者核准（SimplyBook 的 Approve booking 外掛）
+func (b *Booking) PendingApproval() bool {
+	return strings.EqualFold(b.Status, "pending")
+}
+
+// AdditionalField 表示預約的自訂欄位
+type AdditionalField struct {
+	ID    int             `json:"id"`
+	Name  string          `json:"field_name"`
+	Title string          `json:"field_title"`
+	Value json.RawMessage `json:"value"`
+}
+
+// String 以字串形式返回欄位值，數字等非字串值保留原始 JSON 表示
+func (f AdditionalField) String() string {
+	var s string
+	if err := json.Unmarshal(f.Value, &s); err == nil {
+		return s
+	}
+	if string(f.Value) == "null" {
+		return ""
+	}
+	return string(f.Value)
+}
+
+// Field 依欄位名稱或標題取得自訂欄位值，未找到時返回 false
+func (b *Booking) Field(name string) (string, bool) {
+	for _, f := range b.AdditionalFields {
+		if f.Name == name || f.Title == name {
+			return f.String(), true
+		}
+	}
+	return "", false
+}
+
+// BookingList 表示預約列表的分頁響應
+type BookingList struct {
+	Data     []Booking `json:"data"`
+	Metadata struct {
+		ItemsCount int `json:"items_count"`
+		PagesCount int `json:"pages_count"`
+		Page       int `json:"page"`
+		OnPage     int `json:"on_page"`
+	} `json:"metadata"`
+}
+
+// BookingFilter 表示查詢預約列表的篩選條件
+type BookingFilter struct {
+	DateFrom time.Time // 開始日期（含）
+	DateTo   time.Time // 結束日期（含）
+	Status   string    // 預約狀態，空字串表示不篩選
+	Search   string    // 依客戶名稱、電子郵件或電話搜尋，空字串表示不篩選
+	Limit    int       // 最多返回的筆數，0 表示不限制
 }
 
 // Service 表示服務信息
@@ -79,11 +192,118 @@ type Provider struct {
 
 // WebhookPayload 表示 SimplyBook 的 webhook 負載
 type WebhookPayload struct {
-	Action      string `json:"notification_type"` // 'create', 'change', 'cancel', 'notify'
-	BookingID   string `json:"booking_id"`
-	Company     string `json:"company"`
-	BookingHash string `json:"booking_hash"`
-	Timestamp   string `json:"webhook_timestamp"`
+	Action      string    `json:"notification_type"` // 'create', 'change', 'cancel', 'notify'
+	BookingID   ID        `json:"booking_id"`
+	Company     string    `json:"company"`
+	BookingHash string    `json:"booking_hash"`
+	Timestamp   Timestamp `json:"webhook_timestamp"`
+}
+
+// ID 是 webhook 中的識別碼，部分 SimplyBook 設定以 JSON 數字送出，
+// 一律正規化為十進位字串，讓數字與字串形式對應到同一筆同步狀態
+type ID string
+
+// UnmarshalJSON 接受整數或字串形式的識別碼
+func (id *ID) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		*id = ""
+		return nil
+	}
+	if strings.HasPrefix(s, "\"") {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		*id = ID(strings.TrimSpace(str))
+		return nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("無效的識別碼 %s: 必須為整數或字串", s)
+	}
+	*id = ID(strconv.FormatInt(n, 10))
+	return nil
+}
+
+// String 返回識別碼字串
+func (id ID) String() string {
+	return string(id)
+}
+
+// Resource 是預約占用的服務資源
+type Resource struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// UnmarshalJSON 接受資源物件或僅有識別碼的整數、字串
+func (r *Resource) UnmarshalJSON(b []byte) error {
+	if !strings.HasPrefix(strings.TrimSpace(string(b)), "{") {
+		r.Name = ""
+		return r.ID.UnmarshalJSON(b)
+	}
+
+	var object struct {
+		ID   ID     `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(b, &object); err != nil {
+		return err
+	}
+	*r = Resource(object)
+	return nil
+}
+
+// Timestamp 是 webhook 的時間戳，SimplyBook 送出 JSON 數字形式的 Unix 秒，
+// 同時接受字串形式的 Unix 秒、毫秒或 RFC 3339；無法解析時保留原始值且 Time 為零值
+type Timestamp struct {
+	Raw  string    // 原始值，用於去重
+	Time time.Time // 解析後的時間
+}
+
+// UnmarshalJSON 接受數字或字串形式的時間戳
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "null" {
+		s = ""
+	}
+	*t = ParseTimestamp(s)
+	return nil
+}
+
+// MarshalJSON 以原始形式輸出時間戳
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if _, err := strconv.ParseInt(t.Raw, 10, 64); err == nil {
+		return []byte(t.Raw), nil
+	}
+	return json.Marshal(t.Raw)
+}
+
+// String 返回原始值
+func (t Timestamp) String() string {
+	return t.Raw
+}
+
+// ParseTimestamp 解析 Unix 秒、Unix 毫秒或 RFC 3339 格式的時間戳
+func ParseTimestamp(s string) Timestamp {
+	ts := Timestamp{Raw: s}
+	if s == "" {
+		return ts
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e12 {
+			ts.Time = time.UnixMilli(n)
+		} else {
+			ts.Time = time.Unix(n, 0)
+		}
+		return ts
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		ts.Time = parsed
+	}
+	return ts
 }
 
 /** webhook example
@@ -0,0 +1,132 @@
+package simplybook
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nameRefreshInterval 查無名稱時重新讀取列表的最短間隔，讓新增的服務或服務提供者不必等到快取過期
+const nameRefreshInterval = time.Minute
+
+// providerCache 快取服務提供者列表中的名稱
+type providerCache struct {
+	mu        sync.Mutex
+	names     map[int]string
+	fetchedAt time.Time
+}
+
+// ServiceName 依 ID 返回服務名稱，服務列表與 ServiceDuration 共用快取
+func (c *Client) ServiceName(serviceID int) (string, error) {
+	c.services.mu.Lock()
+	defer c.services.mu.Unlock()
+
+	name, ok := c.services.names[serviceID]
+	if shouldReload(c.services.names != nil, ok, c.services.fetchedAt) {
+		// 重新讀取失敗時沿用快取中的名稱
+		if err := c.loadServices(); err != nil && !ok {
+			return "", err
+		}
+		if n, found := c.services.names[serviceID]; found {
+			name, ok = n, found
+		}
+	}
+	if !ok || name == "" {
+		return "", fmt.Errorf("服務 %d 不存在或沒有名稱", serviceID)
+	}
+	return name, nil
+}
+
+// ProviderName 依 ID 返回服務提供者名稱，服務提供者列表會快取一小時
+func (c *Client) ProviderName(providerID int) (string, error) {
+	c.providers.mu.Lock()
+	defer c.providers.mu.Unlock()
+
+	name, ok := c.providers.names[providerID]
+	if shouldReload(c.providers.names != nil, ok, c.providers.fetchedAt) {
+		// 重新讀取失敗時沿用快取中的名稱
+		if err := c.loadProviders(); err != nil && !ok {
+			return "", err
+		}
+		if n, found := c.providers.names[providerID]; found {
+			name, ok = n, found
+		}
+	}
+	if !ok || name == "" {
+		return "", fmt.Errorf("服務提供者 %d 不存在或沒有名稱", providerID)
+	}
+	return name, nil
+}
+
+// shouldReload 判斷是否重新讀取列表：尚未讀取或快取已過期，或查無名稱且距上次讀取已超過 nameRefreshInterval
+func shouldReload(loaded, found bool, fetchedAt time.Time) bool {
+	age := time.Since(fetchedAt)
+	return !loaded || age > serviceCacheTTL || (!found && age > nameRefreshInterval)
+}
+
+// loadProviders 重新讀取服務提供者列表，呼叫前須持有 c.providers.mu
+func (c *Client) loadProviders() error {
+	providers, err := c.GetProviderList()
+	if err != nil {
+		return err
+	}
+
+	names := make(map[int]string, len(providers))
+	for key, p := range providers {
+		id := p.ID
+		if id == "" {
+			id = key
+		}
+		if n, err := strconv.Atoi(id); err == nil {
+			names[n] = p.Name
+		}
+	}
+	c.providers.names = names
+	c.providers.fetchedAt = time.Now()
+	return nil
+}
+
+// NameResolver 在預約缺少服務或服務提供者名稱時，以快取的列表依 ID 補上，
+// 避免事件標題與範本出現空白欄位；可作為 handler.Enricher 使用
+type NameResolver struct {
+	client *Client
+}
+
+// NewNameResolver 創建以 client 的服務與服務提供者列表補充名稱的 NameResolver
+func NewNameResolver(client *Client) *NameResolver {
+	return &NameResolver{client: client}
+}
+
+// Enrich 補上預約缺少的服務與服務提供者名稱，查無名稱時保留空白並返回錯誤
+func (r *NameResolver) Enrich(b *Booking) error {
+	// 其他平台的預約沒有 SimplyBook 的服務 ID
+	if b.Source != "" {
+		return nil
+	}
+
+	var errs []string
+	if b.ServiceName == "" && b.ServiceID != 0 {
+		if name, err := r.client.ServiceName(b.ServiceID); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			b.ServiceName = name
+			log.Printf("預約 %d 缺少服務名稱，依服務 %d 補上 %q", b.ID, b.ServiceID, name)
+		}
+	}
+	if b.ProviderName == "" && b.ProviderID != 0 {
+		if name, err := r.client.ProviderName(b.ProviderID); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			b.ProviderName = name
+			log.Printf("預約 %d 缺少服務提供者名稱，依服務提供者 %d 補上 %q", b.ID, b.ProviderID, name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("補充名稱失敗: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package simplybook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// utf8BOM 是部分用戶端在內容開頭附加的位元組順序記號
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// ParseWebhookPayload 依 Content-Type 解析 JSON 或 application/x-www-form-urlencoded 格式的 webhook 負載。
+// Content-Type 缺少或不明確時依內容判斷：以 { 開頭視為 JSON，否則視為表單
+func ParseWebhookPayload(contentType string, body []byte) (*WebhookPayload, error) {
+	body = bytes.TrimSpace(bytes.TrimPrefix(body, utf8BOM))
+	if len(body) == 0 {
+		return nil, fmt.Errorf("webhook 內容為空")
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return parseFormPayload(body)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || body[0] == '{':
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("無效的 JSON 數據: %w", err)
+		}
+		return &payload, nil
+	}
+	return parseFormPayload(body)
+}
+
+// parseFormPayload 解析表單格式的 webhook 負載，欄位名稱與 JSON 相同
+func parseFormPayload(body []byte) (*WebhookPayload, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("無效的表單數據: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("表單數據中沒有任何欄位")
+	}
+
+	get := func(key string) string {
+		return strings.TrimSpace(values.Get(key))
+	}
+	return &WebhookPayload{
+		Action:      get("notification_type"),
+		BookingID:   ID(get("booking_id")),
+		Company:     get("company"),
+		BookingHash: get("booking_hash"),
+		Timestamp:   ParseTimestamp(get("webhook_timestamp")),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package simplybook
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing 是一次讀取預約的耗時分解，用於找出 SimplyBook 變慢的環節
+type Timing struct {
+	Connect  time.Duration // 取得連線，包含 DNS、TCP 與 TLS；重用連線時接近 0
+	Reused   bool          // 是否重用既有連線
+	Server   time.Duration // 送出請求到收到第一個位元組，即 SimplyBook 的處理時間
+	Transfer time.Duration // 讀取響應體
+	Reauth   time.Duration // 令牌過期時重新認證並重試請求
+	Service  time.Duration // 預約缺少結束時間時查詢服務時長
+}
+
+// String 以 connect=…、server=… 的格式列出各環節耗時
+func (t *Timing) String() string {
+	connect := fmt.Sprintf("connect=%s", t.Connect.Round(time.Millisecond))
+	if t.Reused {
+		connect += "（重用連線）"
+	}
+	parts := []string{connect,
+		fmt.Sprintf("server=%s", t.Server.Round(time.Millisecond)),
+		fmt.Sprintf("transfer=%s", t.Transfer.Round(time.Millisecond)),
+	}
+	if t.Reauth > 0 {
+		parts = append(parts, fmt.Sprintf("reauth=%s", t.Reauth.Round(time.Millisecond)))
+	}
+	if t.Service > 0 {
+		parts = append(parts, fmt.Sprintf("service=%s", t.Service.Round(time.Millisecond)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// trace 為請求加上 httptrace 以記錄連線與伺服器處理時間，返回的 done 須在請求完成後呼叫以寫入 Timing。
+// 寫出請求的回呼可能在響應返回後才執行，以鎖保護並於 done 時複製結果
+func (t *Timing) trace(req *http.Request) (traced *http.Request, done func()) {
+	var (
+		mu                    sync.Mutex
+		start                 = time.Now()
+		gotConn, wrote, first time.Time
+		reused                bool
+	)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			mu.Lock()
+			gotConn, reused = time.Now(), info.Reused
+			mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wrote = time.Now()
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			first = time.Now()
+			mu.Unlock()
+		},
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !gotConn.IsZero() {
+			t.Connect = gotConn.Sub(start)
+			t.Reused = reused
+		}
+		if !wrote.IsZero() && first.After(wrote) {
+			t.Server = first.Sub(wrote)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package simplybook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// callbackSettingsEndpoint 是 API 自訂功能的設定端點，包含 webhook 回呼網址；
+// 公司需先在 SimplyBook 後台啟用 API 自訂功能
+const callbackSettingsEndpoint = "/admin/plugins/api/settings"
+
+// CallbackSettings 表示 SimplyBook 發送 webhook 的設定
+type CallbackSettings struct {
+	URL     string `json:"callback_url"`
+	Enabled bool   `json:"send_callback"`
+}
+
+// GetCallbackSettings 獲取公司目前的 webhook 回呼設定
+func (c *Client) GetCallbackSettings() (*CallbackSettings, error) {
+	respBody, err := c.doRequest("GET", callbackSettingsEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("獲取 webhook 設定失敗: %w", err)
+	}
+
+	var settings CallbackSettings
+	if err := json.Unmarshal(respBody, &settings); err != nil {
+		return nil, fmt.Errorf("解析 webhook 設定失敗: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SetCallbackURL 設置並啟用 webhook 回呼網址
+func (c *Client) SetCallbackURL(callbackURL string) error {
+	_, err := c.doRequest("PUT", callbackSettingsEndpoint, &CallbackSettings{URL: callbackURL, Enabled: true})
+	if err != nil {
+		return fmt.Errorf("設置 webhook 回呼網址失敗: %w", err)
+	}
+	return nil
+}
+
+// EnsureCallbackURL 確認 SimplyBook 已啟用指向 callbackURL 的 webhook；
+// 設定不符且 register 為 true 時更新設定，返回是否已更新
+func (c *Client) EnsureCallbackURL(callbackURL string, register bool) (bool, error) {
+	settings, err := c.GetCallbackSettings()
+	if err != nil {
+		return false, err
+	}
+
+	if settings.Enabled && sameCallbackURL(settings.URL, callbackURL) {
+		return false, nil
+	}
+
+	if !register {
+		if !settings.Enabled {
+			return false, fmt.Errorf("SimplyBook 未啟用 webhook 通知")
+		}
+		return false, fmt.Errorf("SimplyBook 的 webhook 回呼網址為 %q，與預期的 %q 不符", settings.URL, callbackURL)
+	}
+
+	if err := c.SetCallbackURL(callbackURL); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CheckCallbackReachable 確認回呼網址可從外部連線且由本服務處理：
+// webhook 端點對 GET 請求回應 405
+func CheckCallbackReachable(httpClient *http.Client, callbackURL string) error {
+	if _, err := url.ParseRequestURI(callbackURL); err != nil {
+		return fmt.Errorf("無效的回呼網址 %q: %w", callbackURL, err)
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Get(callbackURL)
+	if err != nil {
+		return fmt.Errorf("無法連線到回呼網址: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("回呼網址返回非預期的狀態碼 %d，可能未指向本服務的 webhook 路徑", resp.StatusCode)
+	}
+	return nil
+}
+
+// sameCallbackURL 比對兩個回呼網址，忽略前後空白與結尾斜線
+func sameCallbackURL(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.TrimRight(strings.TrimSpace(s), "/")
+	}
+	return normalize(a) == normalize(b)
+}
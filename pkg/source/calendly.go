@@ -0,0 +1,236 @@
+package source
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// calendlyAPI 是 Calendly API v2 的位址
+	calendlyAPI = "https://api.calendly.com"
+
+	// calendlySignatureTolerance 簽章時間與現在的最大差距，避免重送舊的請求
+	calendlySignatureTolerance = 3 * time.Minute
+)
+
+// Calendly 是 Calendly webhook 的轉接器，預約識別碼為 calendly:<事件 UUID>/<受邀者 UUID>。
+// Calendly 改期時會取消原受邀者並建立新的受邀者，因此只會產生 create 與 cancel 事件
+type Calendly struct {
+	token      string // 個人存取令牌，用於讀取預約詳情
+	signingKey string // webhook 簽章金鑰，未設置時不驗證簽章
+	httpClient *http.Client
+	location   *time.Location
+}
+
+// NewCalendly 創建 Calendly 轉接器，預約時間轉換到 location 時區
+func NewCalendly(token, signingKey string, httpClient *http.Client, location *time.Location) *Calendly {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return &Calendly{
+		token:      token,
+		signingKey: signingKey,
+		httpClient: httpClient,
+		location:   location,
+	}
+}
+
+// Name 返回平台名稱
+func (c *Calendly) Name() string {
+	return "calendly"
+}
+
+// calendlyWebhook 是 Calendly webhook 的負載
+type calendlyWebhook struct {
+	Event     string `json:"event"` // invitee.created 或 invitee.canceled
+	CreatedAt string `json:"created_at"`
+	Payload   struct {
+		URI string `json:"uri"` // https://api.calendly.com/scheduled_events/<事件>/invitees/<受邀者>
+	} `json:"payload"`
+}
+
+// calendlyActions 將 Calendly 事件類型對應到同步操作
+var calendlyActions = map[string]string{
+	"invitee.created":  "create",
+	"invitee.canceled": "cancel",
+}
+
+// ParseWebhook 驗證簽章並解析 Calendly 的 webhook 負載
+func (c *Calendly) ParseWebhook(r *http.Request, body []byte) (*Event, error) {
+	if c.signingKey != "" {
+		if err := c.verifySignature(r.Header.Get("Calendly-Webhook-Signature"), body); err != nil {
+			return nil, err
+		}
+	}
+
+	var webhook calendlyWebhook
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return nil, fmt.Errorf("無效的 JSON 數據: %w", err)
+	}
+
+	action, ok := calendlyActions[webhook.Event]
+	if !ok {
+		return nil, ErrIgnored
+	}
+
+	id, err := calendlyInviteeID(webhook.Payload.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	// created_at 為 RFC 3339，無法解析時保留零值
+	createdAt, _ := time.Parse(time.RFC3339Nano, webhook.CreatedAt)
+
+	return &Event{
+		Source:    c.Name(),
+		Action:    action,
+		BookingID: BookingID(c.Name(), id),
+		Timestamp: webhook.CreatedAt,
+		Time:      createdAt,
+	}, nil
+}
+
+// verifySignature 驗證 Calendly-Webhook-Signature 標頭（格式為 t=<時間>,v1=<簽章>）
+func (c *Calendly) verifySignature(header string, body []byte) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == "" || signature == "" {
+		return rejected(RejectMissingToken, "")
+	}
+
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return rejected(RejectInvalidToken, "")
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > calendlySignatureTolerance || age < -calendlySignatureTolerance {
+		return rejected(RejectStale, "")
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.signingKey))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return rejected(RejectInvalidToken, "")
+	}
+	return nil
+}
+
+// calendlyInviteeID 由受邀者 URI 取出 <事件 UUID>/<受邀者 UUID>
+func calendlyInviteeID(uri string) (string, error) {
+	_, path, ok := strings.Cut(uri, "/scheduled_events/")
+	if !ok {
+		return "", fmt.Errorf("無效的 Calendly 受邀者 URI: %s", uri)
+	}
+	eventUUID, inviteeUUID, ok := strings.Cut(path, "/invitees/")
+	if !ok || eventUUID == "" || inviteeUUID == "" {
+		return "", fmt.Errorf("無效的 Calendly 受邀者 URI: %s", uri)
+	}
+	return eventUUID + "/" + inviteeUUID, nil
+}
+
+// calendlyEvent 是 Calendly 的預約事件
+type calendlyEvent struct {
+	Name             string    `json:"name"`
+	Status           string    `json:"status"` // active 或 canceled
+	StartTime        time.Time `json:"start_time"`
+	EndTime          time.Time `json:"end_time"`
+	EventMemberships []struct {
+		UserName string `json:"user_name"`
+	} `json:"event_memberships"`
+}
+
+// calendlyInvitee 是 Calendly 的受邀者（即預約的客戶）
+type calendlyInvitee struct {
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	Status             string `json:"status"` // active 或 canceled
+	TextReminderNumber string `json:"text_reminder_number"`
+}
+
+// GetBooking 讀取 Calendly 的事件與受邀者，轉換為共用的預約模型
+func (c *Calendly) GetBooking(bookingID string) (*Booking, error) {
+	_, id := SplitBookingID(bookingID)
+	eventUUID, inviteeUUID, ok := strings.Cut(id, "/")
+	if !ok {
+		return nil, fmt.Errorf("無效的 Calendly 預約識別碼: %s", bookingID)
+	}
+
+	var event calendlyEvent
+	if err := c.get("/scheduled_events/"+eventUUID, &event); err != nil {
+		return nil, fmt.Errorf("讀取 Calendly 事件失敗: %w", err)
+	}
+
+	var invitee calendlyInvitee
+	if err := c.get("/scheduled_events/"+eventUUID+"/invitees/"+inviteeUUID, &invitee); err != nil {
+		return nil, fmt.Errorf("讀取 Calendly 受邀者失敗: %w", err)
+	}
+
+	booking := &Booking{
+		Code:        inviteeUUID,
+		ServiceName: event.Name,
+		Confirmed:   true,
+		Status:      "confirmed",
+		Source:      c.Name(),
+		ExternalID:  bookingID,
+	}
+	booking.StartTime.Time = event.StartTime.In(c.location)
+	booking.EndTime.Time = event.EndTime.In(c.location)
+	booking.Client.Name = invitee.Name
+	booking.Client.Email = invitee.Email
+	booking.Client.Phone = invitee.TextReminderNumber
+	if len(event.EventMemberships) > 0 {
+		booking.ProviderName = event.EventMemberships[0].UserName
+	}
+	if event.Status == "canceled" || invitee.Status == "canceled" {
+		booking.Status = "canceled"
+	}
+	return booking, nil
+}
+
+// get 以 GET 讀取 Calendly API 資源，解析回應中的 resource 欄位
+func (c *Calendly) get(path string, resource interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, calendlyAPI+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Calendly API 返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	envelope := struct {
+		Resource interface{} `json:"resource"`
+	}{Resource: resource}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("解析 Calendly 回應失敗: %w", err)
+	}
+	return nil
+}
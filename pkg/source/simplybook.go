@@ -0,0 +1,136 @@
+package source
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// DefaultTenant 是未列出的租戶使用的 webhook 令牌鍵
+const DefaultTenant = "*"
+
+// WebhookSecret 是租戶的 webhook 令牌，輪替期間 PreviousUntil 之前仍接受舊令牌
+type WebhookSecret struct {
+	Token         string
+	PreviousToken string
+	PreviousUntil time.Time
+}
+
+// accepts 判斷令牌是否為目前或寬限期內的舊令牌
+func (s WebhookSecret) accepts(token string, now time.Time) bool {
+	if token == "" {
+		return false
+	}
+	if hmac.Equal([]byte(token), []byte(s.Token)) {
+		return true
+	}
+	return s.PreviousToken != "" && now.Before(s.PreviousUntil) &&
+		hmac.Equal([]byte(token), []byte(s.PreviousToken))
+}
+
+// SimplyBook 是 SimplyBook webhook 的轉接器，預約識別碼不帶前綴以相容既有的對應關係
+type SimplyBook struct {
+	client  *simplybook.Client
+	secrets map[string]WebhookSecret // company login → 令牌，為空時不驗證
+}
+
+// NewSimplyBook 創建 SimplyBook 轉接器，secrets 以 company login 為鍵，
+// DefaultTenant 為未列出租戶的令牌；secrets 為空時不驗證 webhook
+func NewSimplyBook(client *simplybook.Client, secrets map[string]WebhookSecret) *SimplyBook {
+	return &SimplyBook{client: client, secrets: secrets}
+}
+
+// Name 返回平台名稱
+func (s *SimplyBook) Name() string {
+	return "simplybook"
+}
+
+// ParseWebhook 解析 SimplyBook 的 JSON 或表單格式 webhook 負載，並以租戶的令牌驗證請求；
+// 請求的 context 綁定租戶（WithTenant）時以綁定的租戶選擇令牌，負載中的租戶不同時拒絕
+func (s *SimplyBook) ParseWebhook(r *http.Request, body []byte) (*Event, error) {
+	bound := TenantFrom(r.Context())
+
+	payload, err := simplybook.ParseWebhookPayload(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		if len(s.secrets) > 0 {
+			// 未通過驗證前不透露負載格式的錯誤
+			return nil, rejected(RejectInvalidPayload, "")
+		}
+		return nil, err
+	}
+
+	tenant := payload.Company
+	if bound != "" {
+		if payload.Company != "" && payload.Company != bound {
+			return nil, rejected(RejectTenantMismatch, bound)
+		}
+		tenant = bound
+	}
+
+	if len(s.secrets) > 0 {
+		secret, ok := s.secrets[tenant]
+		if !ok {
+			secret, ok = s.secrets[DefaultTenant]
+		}
+		token := r.Header.Get("X-Simplybook-Token")
+		switch {
+		case !ok:
+			return nil, rejected(RejectUnknownTenant, tenant)
+		case token == "":
+			return nil, rejected(RejectMissingToken, tenant)
+		case !secret.accepts(token, time.Now()):
+			return nil, rejected(RejectInvalidToken, tenant)
+		}
+	}
+
+	return &Event{
+		Source:    s.Name(),
+		Action:    strings.ToLower(payload.Action),
+		BookingID: payload.BookingID.String(),
+		Timestamp: payload.Timestamp.Raw,
+		Time:      payload.Timestamp.Time,
+	}, nil
+}
+
+// UpdateNotes 更新 SimplyBook 預約的備註
+func (s *SimplyBook) UpdateNotes(bookingID, notes string) error {
+	return s.client.UpdateBookingNotes(bookingID, notes)
+}
+
+// ApproveBooking 核准待核准的 SimplyBook 預約
+func (s *SimplyBook) ApproveBooking(bookingID string) error {
+	return s.client.ApproveBooking(bookingID)
+}
+
+// DeclineBooking 拒絕待核准的 SimplyBook 預約，SimplyBook 以取消預約表示拒絕
+func (s *SimplyBook) DeclineBooking(bookingID string) error {
+	return s.client.CancelBooking(bookingID)
+}
+
+// GetBooking 從 SimplyBook 讀取預約
+func (s *SimplyBook) GetBooking(bookingID string) (*Booking, error) {
+	return s.client.GetBooking(bookingID)
+}
+
+// GetBookingTimed 從 SimplyBook 讀取預約，並返回各環節的耗時
+func (s *SimplyBook) GetBookingTimed(bookingID string) (*Booking, *simplybook.Timing, error) {
+	return s.client.GetBookingTimed(bookingID)
+}
+
+// ListBookings 列出開始日期在 from 與 to 之間的 SimplyBook 預約
+func (s *SimplyBook) ListBookings(from, to time.Time) (map[string]*Booking, error) {
+	bookings, err := s.client.ListBookings(simplybook.BookingFilter{DateFrom: from, DateTo: to})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Booking, len(bookings))
+	for i := range bookings {
+		result[strconv.Itoa(bookings[i].ID)] = &bookings[i]
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+// Package source 將各預約平台的 webhook 正規化為共同的預約事件，
+// 讓日曆同步流程不需區分預約來自哪個平台
+package source
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/simplybook"
+)
+
+// Booking 是各平台共用的預約模型，沿用 SimplyBook 的欄位，
+// 非 SimplyBook 的預約以 Source 與 ExternalID 標示來源
+type Booking = simplybook.Booking
+
+// Event 是正規化後的 webhook 事件
+type Event struct {
+	Source    string    // 來源平台名稱
+	Action    string    // create、change 或 cancel
+	BookingID string    // 同步狀態中的預約識別碼，非 SimplyBook 預約帶有平台前綴
+	Timestamp string    // 平台提供的事件時間原始值，與操作類型一起用於去重
+	Time      time.Time // 解析後的事件時間，平台未提供或無法解析時為零值
+}
+
+// ErrUnauthorized 表示 webhook 的令牌或簽章驗證失敗
+var ErrUnauthorized = errors.New("webhook 驗證失敗")
+
+// webhook 驗證失敗的原因，用於日誌與指標的標籤
+const (
+	RejectMissingToken   = "missing_token"   // 請求未攜帶令牌或簽章
+	RejectInvalidToken   = "invalid_token"   // 令牌或簽章不符
+	RejectUnknownTenant  = "unknown_tenant"  // 負載中的租戶沒有設置令牌，且沒有預設令牌
+	RejectInvalidPayload = "invalid_payload" // 啟用驗證時無法解析負載
+	RejectStale          = "stale"           // 簽章或 webhook 時間戳超出容許範圍
+	RejectTenantMismatch = "tenant_mismatch" // 負載中的租戶與 webhook 路徑綁定的租戶不同
+)
+
+// RejectedError 是帶有原因的 webhook 驗證失敗，errors.Is(err, ErrUnauthorized) 成立
+type RejectedError struct {
+	Reason string // Reject* 常數
+	Tenant string // 負載中的租戶，無法得知時為空
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrUnauthorized, e.Reason)
+}
+
+// Is 讓 errors.Is 將 RejectedError 視為 ErrUnauthorized
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// rejected 返回指定原因的驗證失敗
+func rejected(reason, tenant string) error {
+	return &RejectedError{Reason: reason, Tenant: tenant}
+}
+
+// ErrIgnored 表示 webhook 不需處理（例如平台的測試通知或不相關的事件類型）
+var ErrIgnored = errors.New("不需處理的 webhook")
+
+// Source 定義預約平台的轉接器
+type Source interface {
+	// Name 返回平台名稱，同時作為預約識別碼的前綴
+	Name() string
+	// ParseWebhook 驗證並解析 webhook 請求，驗證失敗時返回 ErrUnauthorized
+	ParseWebhook(r *http.Request, body []byte) (*Event, error)
+	// GetBooking 從平台讀取最新的預約資料
+	GetBooking(bookingID string) (*Booking, error)
+}
+
+// NotesWriter 由支援寫回預約備註的平台實作
+type NotesWriter interface {
+	UpdateNotes(bookingID, notes string) error
+}
+
+// Approver 由支援核准預約的平台實作
+type Approver interface {
+	ApproveBooking(bookingID string) error
+	DeclineBooking(bookingID string) error
+}
+
+// TimedGetter 由可提供讀取耗時分解的平台實作，用於找出平台變慢的環節
+type TimedGetter interface {
+	GetBookingTimed(bookingID string) (*Booking, *simplybook.Timing, error)
+}
+
+// Lister 由支援列出預約的平台實作，供 webhook 中斷時輪詢補同步
+type Lister interface {
+	// ListBookings 列出開始時間在 from 與 to 之間的預約，以預約識別碼為鍵
+	ListBookings(from, to time.Time) (map[string]*Booking, error)
+}
+
+// BookingID 以平台名稱為前綴組成預約識別碼
+func BookingID(source, id string) string {
+	return source + ":" + id
+}
+
+// SplitBookingID 拆出預約識別碼的平台前綴，沒有前綴時 source 為空（SimplyBook 預約）
+func SplitBookingID(bookingID string) (source, id string) {
+	if name, rest, ok := strings.Cut(bookingID, ":"); ok {
+		return name, rest
+	}
+	return "", bookingID
+}
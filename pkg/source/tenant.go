@@ -0,0 +1,18 @@
+package source
+
+import "context"
+
+// tenantKey 是請求 context 中租戶的鍵
+type tenantKey struct{}
+
+// WithTenant 將 webhook 路徑綁定的租戶（SimplyBook company login）放入 context，
+// 轉接器以此租戶選擇令牌，並拒絕負載中租戶不同的請求
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom 返回 context 中綁定的租戶，未綁定時為空字串
+func TenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,920 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltDB 的 bucket 名稱
+var (
+	boltMappings     = []byte("mappings")
+	boltSyncRecords  = []byte("sync_records")
+	boltReminders    = []byte("reminders")
+	boltPendingSyncs = []byte("pending_syncs")
+	boltDeadLetters  = []byte("dead_letters")
+	boltSnapshots    = []byte("booking_snapshots")
+	boltSyncTokens   = []byte("calendar_sync_tokens")
+	boltHeartbeats   = []byte("webhook_heartbeats")
+	boltSkipped      = []byte("skipped_bookings")
+	boltSuppressed   = []byte("suppressed_emails")
+	boltSyncStates   = []byte("sync_states")
+)
+
+// BoltStore 是基於 BoltDB（bbolt）單一檔案的 Store 實作，不需外部資料庫，
+// 適合單一實例的自行架設環境
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore 開啟或建立 BoltDB 檔案
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("開啟 BoltDB 檔案失敗: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltMappings, boltSyncRecords, boltReminders, boltPendingSyncs, boltDeadLetters, boltSnapshots, boltSyncTokens, boltHeartbeats, boltSkipped, boltSuppressed, boltSyncStates} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化 BoltDB 失敗: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close 關閉資料庫檔案
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveMapping 新增或更新預約與事件的對應關係
+func (s *BoltStore) SaveMapping(m *Mapping) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltMappings)
+
+		now := time.Now()
+		copied := *m
+		var existing Mapping
+		if found, err := getJSON(b, []byte(m.BookingID), &existing); err != nil {
+			return err
+		} else if found {
+			copied.CreatedAt = existing.CreatedAt
+			if copied.EventLink == "" && copied.EventID == existing.EventID {
+				copied.EventLink = existing.EventLink
+			}
+		} else if copied.CreatedAt.IsZero() {
+			copied.CreatedAt = now
+		}
+		copied.UpdatedAt = now
+
+		return putJSON(b, []byte(m.BookingID), &copied)
+	})
+}
+
+// GetMapping 取得預約的對應關係，未找到時返回 nil
+func (s *BoltStore) GetMapping(bookingID string) (*Mapping, error) {
+	var m Mapping
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket(boltMappings), []byte(bookingID), &m)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取對應關係失敗: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &m, nil
+}
+
+// DeleteMapping 刪除預約的對應關係
+func (s *BoltStore) DeleteMapping(bookingID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMappings).Delete([]byte(bookingID))
+	})
+}
+
+// ListMappings 列出所有對應關係
+func (s *BoltStore) ListMappings() ([]*Mapping, error) {
+	var mappings []*Mapping
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMappings).ForEach(func(_, v []byte) error {
+			var m Mapping
+			if err := json.Unmarshal(v, &m); err != nil {
+				return err
+			}
+			mappings = append(mappings, &m)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取對應關係失敗: %w", err)
+	}
+
+	// BoltDB 依鍵排序，與其他實作一致
+	return mappings, nil
+}
+
+// AddSyncRecord 新增一筆同步記錄
+func (s *BoltStore) AddSyncRecord(r *SyncRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltSyncRecords)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		copied := *r
+		copied.ID = int64(id)
+		if copied.CreatedAt.IsZero() {
+			copied.CreatedAt = time.Now()
+		}
+		return putJSON(b, sequenceKey(id), &copied)
+	})
+}
+
+// ListSyncRecords 依時間倒序列出最近的同步記錄
+func (s *BoltStore) ListSyncRecords(limit int, failedOnly bool) ([]*SyncRecord, error) {
+	var records []*SyncRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSyncRecords).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if limit > 0 && len(records) >= limit {
+				break
+			}
+
+			var r SyncRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if failedOnly && r.Success {
+				continue
+			}
+			records = append(records, &r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取同步記錄失敗: %w", err)
+	}
+
+	return records, nil
+}
+
+// ListBookingSyncRecords 依時間倒序列出單一預約的同步記錄，limit <= 0 表示不限制
+func (s *BoltStore) ListBookingSyncRecords(bookingID string, limit int) ([]*SyncRecord, error) {
+	var records []*SyncRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSyncRecords).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if limit > 0 && len(records) >= limit {
+				break
+			}
+
+			var r SyncRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.BookingID != bookingID {
+				continue
+			}
+			records = append(records, &r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取同步記錄失敗: %w", err)
+	}
+
+	return records, nil
+}
+
+// SyncStats 統計指定時間之後的同步結果
+func (s *BoltStore) SyncStats(since time.Time) (*SyncStats, error) {
+	stats := &SyncStats{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSyncRecords).Cursor()
+		// 記錄依寫入順序排列，由新到舊掃描直到早於 since
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var r SyncRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.CreatedAt.Before(since) {
+				break
+			}
+			if r.Success {
+				stats.Succeeded++
+			} else {
+				stats.Failed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("統計同步記錄失敗: %w", err)
+	}
+
+	return stats, nil
+}
+
+// SaveReminder 新增或更新預約的提醒；發送時間未變時保留已發送狀態
+func (s *BoltStore) SaveReminder(r *Reminder) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltReminders)
+
+		copied := *r
+		copied.SentAt = nil
+		var existing Reminder
+		if found, err := getJSON(b, []byte(r.BookingID), &existing); err != nil {
+			return err
+		} else if found && existing.SendAt.Equal(r.SendAt) {
+			copied.SentAt = existing.SentAt
+		}
+
+		return putJSON(b, []byte(r.BookingID), &copied)
+	})
+}
+
+// DeleteReminder 刪除預約的提醒
+func (s *BoltStore) DeleteReminder(bookingID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltReminders).Delete([]byte(bookingID))
+	})
+}
+
+// ListDueReminders 列出發送時間已到且尚未發送的提醒
+func (s *BoltStore) ListDueReminders(now time.Time) ([]*Reminder, error) {
+	var reminders []*Reminder
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltReminders).ForEach(func(_, v []byte) error {
+			var r Reminder
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if r.SentAt == nil && !r.SendAt.After(now) {
+				reminders = append(reminders, &r)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查詢提醒失敗: %w", err)
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].SendAt.Before(reminders[j].SendAt)
+	})
+
+	return reminders, nil
+}
+
+// MarkReminderSent 標記提醒已發送
+func (s *BoltStore) MarkReminderSent(bookingID string, sentAt time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltReminders)
+
+		var r Reminder
+		found, err := getJSON(b, []byte(bookingID), &r)
+		if err != nil || !found {
+			return err
+		}
+		r.SentAt = &sentAt
+		return putJSON(b, []byte(bookingID), &r)
+	})
+}
+
+// AddDeadLetter 新增一筆死信
+func (s *BoltStore) AddDeadLetter(d *DeadLetter) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDeadLetters)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		copied := *d
+		copied.ID = int64(id)
+		if copied.CreatedAt.IsZero() {
+			copied.CreatedAt = time.Now()
+		}
+		return putJSON(b, sequenceKey(id), &copied)
+	})
+}
+
+// ListDeadLetters 依時間倒序列出最近的死信
+func (s *BoltStore) ListDeadLetters(limit int) ([]*DeadLetter, error) {
+	var dead []*DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltDeadLetters).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if limit > 0 && len(dead) >= limit {
+				break
+			}
+
+			var d DeadLetter
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			dead = append(dead, &d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取死信失敗: %w", err)
+	}
+
+	return dead, nil
+}
+
+// EnqueuePendingSync 暫存同步操作；預約已有暫存時以 MergeAction 合併並累加嘗試次數
+func (s *BoltStore) EnqueuePendingSync(p *PendingSync) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltPendingSyncs)
+
+		copied := *p
+		if copied.EnqueuedAt.IsZero() {
+			copied.EnqueuedAt = time.Now()
+		}
+		copied.Attempts = 1
+
+		var existing PendingSync
+		if found, err := getJSON(b, []byte(p.BookingID), &existing); err != nil {
+			return err
+		} else if found {
+			copied.Action = MergeAction(existing.Action, p.Action)
+			copied.EnqueuedAt = existing.EnqueuedAt
+			copied.Attempts = existing.Attempts + 1
+		}
+
+		return putJSON(b, []byte(p.BookingID), &copied)
+	})
+}
+
+// ListPendingSyncs 依暫存時間列出最早的同步操作
+func (s *BoltStore) ListPendingSyncs(limit int) ([]*PendingSync, error) {
+	pending, err := s.allPendingSyncs()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].EnqueuedAt.Before(pending[j].EnqueuedAt)
+	})
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+// GetPendingSync 返回預約的暫存操作，不存在時返回 nil
+func (s *BoltStore) GetPendingSync(bookingID string) (*PendingSync, error) {
+	var p PendingSync
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket(boltPendingSyncs), []byte(bookingID), &p)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取暫存同步操作失敗: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// DeletePendingSync 刪除預約的暫存操作
+func (s *BoltStore) DeletePendingSync(bookingID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingSyncs).Delete([]byte(bookingID))
+	})
+}
+
+// OutboxStats 統計暫存的同步操作
+func (s *BoltStore) OutboxStats() (*OutboxStats, error) {
+	pending, err := s.allPendingSyncs()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &OutboxStats{Count: len(pending)}
+	for _, p := range pending {
+		if stats.Oldest.IsZero() || p.EnqueuedAt.Before(stats.Oldest) {
+			stats.Oldest = p.EnqueuedAt
+		}
+	}
+
+	return stats, nil
+}
+
+// allPendingSyncs 讀取所有暫存的同步操作
+func (s *BoltStore) allPendingSyncs() ([]*PendingSync, error) {
+	var pending []*PendingSync
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingSyncs).ForEach(func(_, v []byte) error {
+			var p PendingSync
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			pending = append(pending, &p)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取暫存同步操作失敗: %w", err)
+	}
+
+	return pending, nil
+}
+
+// PurgeSyncRecords 刪除指定時間之前的同步記錄，返回刪除筆數
+func (s *BoltStore) PurgeSyncRecords(before time.Time) (int, error) {
+	var purged int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		purged, err = deleteWhere(tx.Bucket(boltSyncRecords), func(v []byte) (bool, error) {
+			var r SyncRecord
+			err := json.Unmarshal(v, &r)
+			return err == nil && r.CreatedAt.Before(before), err
+		})
+		return err
+	})
+	return purged, err
+}
+
+// PurgeDeadLetters 刪除指定時間之前的死信，返回刪除筆數
+func (s *BoltStore) PurgeDeadLetters(before time.Time) (int, error) {
+	var purged int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		purged, err = deleteWhere(tx.Bucket(boltDeadLetters), func(v []byte) (bool, error) {
+			var d DeadLetter
+			err := json.Unmarshal(v, &d)
+			return err == nil && d.CreatedAt.Before(before), err
+		})
+		return err
+	})
+	return purged, err
+}
+
+// ClearDeadLetterPayloads 清除指定時間之前死信的預約快照並保留死信本身，返回清除筆數
+func (s *BoltStore) ClearDeadLetterPayloads(before time.Time) (int, error) {
+	var cleared int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltDeadLetters)
+
+		var updates []*DeadLetter
+		err := b.ForEach(func(_, v []byte) error {
+			var d DeadLetter
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			if d.CreatedAt.Before(before) && d.Payload != nil {
+				d.Payload = nil
+				updates = append(updates, &d)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// ForEach 期間不可修改 bucket，結束後再寫回
+		for _, d := range updates {
+			if err := putJSON(b, sequenceKey(uint64(d.ID)), d); err != nil {
+				return err
+			}
+		}
+		cleared = len(updates)
+		return nil
+	})
+	return cleared, err
+}
+
+// PurgeBooking 刪除預約的所有資料：對應關係、同步記錄、提醒、暫存操作、死信與預約快照
+func (s *BoltStore) PurgeBooking(bookingID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltMappings, boltReminders, boltPendingSyncs, boltSyncStates} {
+			if err := tx.Bucket(name).Delete([]byte(bookingID)); err != nil {
+				return err
+			}
+		}
+
+		matchBooking := func(v []byte) (bool, error) {
+			var item struct {
+				BookingID string `json:"booking_id"`
+			}
+			err := json.Unmarshal(v, &item)
+			return err == nil && item.BookingID == bookingID, err
+		}
+		if _, err := deleteWhere(tx.Bucket(boltSyncRecords), matchBooking); err != nil {
+			return err
+		}
+		if _, err := deleteWhere(tx.Bucket(boltSnapshots), matchBooking); err != nil {
+			return err
+		}
+		_, err := deleteWhere(tx.Bucket(boltDeadLetters), matchBooking)
+		return err
+	})
+}
+
+// PurgeBookingSnapshots 刪除指定時間之前的預約快照，返回刪除筆數
+func (s *BoltStore) PurgeBookingSnapshots(before time.Time) (int, error) {
+	var purged int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		purged, err = deleteWhere(tx.Bucket(boltSnapshots), func(v []byte) (bool, error) {
+			var snap BookingSnapshot
+			err := json.Unmarshal(v, &snap)
+			return err == nil && snap.CreatedAt.Before(before), err
+		})
+		return err
+	})
+	return purged, err
+}
+
+// SaveBookingSnapshot 在內容與最新版本不同時新增快照並設置版本號，返回是否新增
+func (s *BoltStore) SaveBookingSnapshot(snap *BookingSnapshot) (bool, error) {
+	if snap.Hash == "" {
+		snap.Hash = SnapshotHash(snap.Payload)
+	}
+
+	var saved bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltSnapshots)
+
+		prefix := snapshotPrefix(snap.BookingID)
+		var latest *BookingSnapshot
+		c := b.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var existing BookingSnapshot
+			if err := json.Unmarshal(v, &existing); err != nil {
+				return err
+			}
+			latest = &existing
+		}
+
+		snap.Version = 1
+		if latest != nil {
+			if latest.Hash == snap.Hash {
+				return nil
+			}
+			snap.Version = latest.Version + 1
+		}
+		if snap.CreatedAt.IsZero() {
+			snap.CreatedAt = time.Now()
+		}
+
+		saved = true
+		return putJSON(b, append(prefix, sequenceKey(uint64(snap.Version))...), snap)
+	})
+	if err != nil {
+		return false, fmt.Errorf("寫入預約快照失敗: %w", err)
+	}
+	return saved, nil
+}
+
+// ListBookingSnapshots 依版本順序列出預約的所有快照
+func (s *BoltStore) ListBookingSnapshots(bookingID string) ([]*BookingSnapshot, error) {
+	var snapshots []*BookingSnapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := snapshotPrefix(bookingID)
+		c := tx.Bucket(boltSnapshots).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var snap BookingSnapshot
+			if err := json.Unmarshal(v, &snap); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, &snap)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取預約快照失敗: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// snapshotPrefix 返回預約快照鍵的前綴，鍵為「預約 ID + 0x00 + 大端序版本號」，依版本排序
+func snapshotPrefix(bookingID string) []byte {
+	return append([]byte(bookingID), 0)
+}
+
+// deleteWhere 刪除 bucket 中符合條件的項目，返回刪除筆數
+func deleteWhere(b *bolt.Bucket, match func(v []byte) (bool, error)) (int, error) {
+	var keys [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		ok, err := match(v)
+		if err != nil {
+			return err
+		}
+		if ok {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// sequenceKey 將序號編碼為大端序鍵，確保 BoltDB 依寫入順序排列
+func sequenceKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// GetCalendarSyncToken 返回日曆上次增量列出的同步令牌，沒有時返回空字串
+func (s *BoltStore) GetCalendarSyncToken(calendarID string) (string, error) {
+	var token string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		token = string(tx.Bucket(boltSyncTokens).Get([]byte(calendarID)))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("讀取日曆同步令牌失敗: %w", err)
+	}
+	return token, nil
+}
+
+// SaveCalendarSyncToken 儲存日曆的同步令牌，令牌為空時刪除
+func (s *BoltStore) SaveCalendarSyncToken(calendarID, token string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltSyncTokens)
+		if token == "" {
+			return b.Delete([]byte(calendarID))
+		}
+		return b.Put([]byte(calendarID), []byte(token))
+	})
+	if err != nil {
+		return fmt.Errorf("儲存日曆同步令牌失敗: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookHeartbeat 返回最後一次收到平台 webhook 的時間，沒有記錄時返回零值
+func (s *BoltStore) GetWebhookHeartbeat(source string) (time.Time, error) {
+	var at time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltHeartbeats).Get([]byte(source))
+		if data == nil {
+			return nil
+		}
+		return at.UnmarshalText(data)
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("讀取 webhook 接收時間失敗: %w", err)
+	}
+	return at, nil
+}
+
+// SaveWebhookHeartbeat 記錄收到平台 webhook 的時間
+func (s *BoltStore) SaveWebhookHeartbeat(source string, at time.Time) error {
+	data, err := at.MarshalText()
+	if err != nil {
+		return err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltHeartbeats).Put([]byte(source), data)
+	})
+	if err != nil {
+		return fmt.Errorf("儲存 webhook 接收時間失敗: %w", err)
+	}
+	return nil
+}
+
+// AddSkippedBooking 新增或更新排除同步的預約編號
+func (s *BoltStore) AddSkippedBooking(b *SkippedBooking) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSkipped)
+		copied := *b
+		var existing SkippedBooking
+		found, err := getJSON(bucket, []byte(b.BookingCode), &existing)
+		if err != nil {
+			return err
+		}
+		if found {
+			copied.CreatedAt = existing.CreatedAt
+		} else if copied.CreatedAt.IsZero() {
+			copied.CreatedAt = time.Now()
+		}
+
+		data, err := json.Marshal(&copied)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(b.BookingCode), data)
+	})
+	if err != nil {
+		return fmt.Errorf("儲存排除同步的預約失敗: %w", err)
+	}
+	return nil
+}
+
+// GetSkippedBooking 返回排除同步的預約編號，未排除時返回 nil
+func (s *BoltStore) GetSkippedBooking(code string) (*SkippedBooking, error) {
+	var b SkippedBooking
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket(boltSkipped), []byte(code), &b)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取排除同步的預約失敗: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &b, nil
+}
+
+// RemoveSkippedBooking 取消排除預約編號，返回是否曾被排除
+func (s *BoltStore) RemoveSkippedBooking(code string) (bool, error) {
+	var removed bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSkipped)
+		removed = bucket.Get([]byte(code)) != nil
+		return bucket.Delete([]byte(code))
+	})
+	if err != nil {
+		return false, fmt.Errorf("刪除排除同步的預約失敗: %w", err)
+	}
+	return removed, nil
+}
+
+// ListSkippedBookings 依預約編號列出所有排除同步的預約
+func (s *BoltStore) ListSkippedBookings() ([]*SkippedBooking, error) {
+	var skipped []*SkippedBooking
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSkipped).ForEach(func(_, v []byte) error {
+			var b SkippedBooking
+			if err := json.Unmarshal(v, &b); err != nil {
+				return err
+			}
+			skipped = append(skipped, &b)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取排除同步的預約失敗: %w", err)
+	}
+
+	// BoltDB 依鍵排序，與其他實作一致
+	return skipped, nil
+}
+
+// AddSuppressedEmail 新增或更新停止邀請的電子郵件地址
+func (s *BoltStore) AddSuppressedEmail(e *SuppressedEmail) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSuppressed)
+		copied := *e
+		copied.Email = NormalizeEmail(e.Email)
+		var existing SuppressedEmail
+		found, err := getJSON(bucket, []byte(copied.Email), &existing)
+		if err != nil {
+			return err
+		}
+		if found {
+			copied.CreatedAt = existing.CreatedAt
+		} else if copied.CreatedAt.IsZero() {
+			copied.CreatedAt = time.Now()
+		}
+
+		data, err := json.Marshal(&copied)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(copied.Email), data)
+	})
+	if err != nil {
+		return fmt.Errorf("儲存停止邀請的地址失敗: %w", err)
+	}
+	return nil
+}
+
+// GetSuppressedEmail 返回停止邀請的電子郵件地址，不在清單中時返回 nil
+func (s *BoltStore) GetSuppressedEmail(email string) (*SuppressedEmail, error) {
+	var e SuppressedEmail
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket(boltSuppressed), []byte(NormalizeEmail(email)), &e)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取停止邀請的地址失敗: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// RemoveSuppressedEmail 將電子郵件地址移出停止邀請清單，返回是否曾在清單中
+func (s *BoltStore) RemoveSuppressedEmail(email string) (bool, error) {
+	key := []byte(NormalizeEmail(email))
+	var removed bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSuppressed)
+		removed = bucket.Get(key) != nil
+		return bucket.Delete(key)
+	})
+	if err != nil {
+		return false, fmt.Errorf("刪除停止邀請的地址失敗: %w", err)
+	}
+	return removed, nil
+}
+
+// ListSuppressedEmails 依地址列出停止邀請清單
+func (s *BoltStore) ListSuppressedEmails() ([]*SuppressedEmail, error) {
+	var suppressed []*SuppressedEmail
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSuppressed).ForEach(func(_, v []byte) error {
+			var e SuppressedEmail
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			suppressed = append(suppressed, &e)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取停止邀請的地址失敗: %w", err)
+	}
+	return suppressed, nil
+}
+
+// SaveSyncState 新增或更新預約的同步狀態
+func (s *BoltStore) SaveSyncState(st *SyncState) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(boltSyncStates), []byte(st.BookingID), st)
+	})
+	if err != nil {
+		return fmt.Errorf("儲存同步狀態失敗: %w", err)
+	}
+	return nil
+}
+
+// GetSyncState 返回預約的同步狀態，不存在時返回 nil
+func (s *BoltStore) GetSyncState(bookingID string) (*SyncState, error) {
+	var st SyncState
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket(boltSyncStates), []byte(bookingID), &st)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("讀取同步狀態失敗: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+// getJSON 讀取並解碼 JSON 值，鍵不存在時返回 false
+func getJSON(b *bolt.Bucket, key []byte, v interface{}) (bool, error) {
+	data := b.Get(key)
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// putJSON 編碼並寫入 JSON 值
+func putJSON(b *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/encrypt"
+)
+
+// EncryptedStore 包裝其他 Store，寫入前加密含個人資料的預約快照與對應關係中的客戶資料，讀取時解密
+type EncryptedStore struct {
+	Store
+	keys *encrypt.Keyring
+}
+
+// NewEncryptedStore 創建加密儲存
+func NewEncryptedStore(inner Store, keys *encrypt.Keyring) *EncryptedStore {
+	return &EncryptedStore{Store: inner, keys: keys}
+}
+
+// AddDeadLetter 加密預約快照後新增死信
+func (s *EncryptedStore) AddDeadLetter(d *DeadLetter) error {
+	if len(d.Payload) == 0 {
+		return s.Store.AddDeadLetter(d)
+	}
+
+	sealed, err := s.keys.Seal(d.Payload)
+	if err != nil {
+		return fmt.Errorf("加密預約快照失敗: %w", err)
+	}
+
+	copied := *d
+	copied.Payload = sealed
+	return s.Store.AddDeadLetter(&copied)
+}
+
+// SaveBookingSnapshot 以明文計算雜湊後加密預約快照再寫入
+func (s *EncryptedStore) SaveBookingSnapshot(snap *BookingSnapshot) (bool, error) {
+	sealed, err := s.keys.Seal(snap.Payload)
+	if err != nil {
+		return false, fmt.Errorf("加密預約快照失敗: %w", err)
+	}
+
+	copied := *snap
+	if copied.Hash == "" {
+		copied.Hash = SnapshotHash(snap.Payload)
+	}
+	copied.Payload = sealed
+
+	saved, err := s.Store.SaveBookingSnapshot(&copied)
+	snap.Hash = copied.Hash
+	snap.Version = copied.Version
+	snap.CreatedAt = copied.CreatedAt
+	return saved, err
+}
+
+// ListBookingSnapshots 列出預約快照並解密，無法解密的快照內容會被清空
+func (s *EncryptedStore) ListBookingSnapshots(bookingID string) ([]*BookingSnapshot, error) {
+	snapshots, err := s.Store.ListBookingSnapshots(bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snap := range snapshots {
+		plaintext, err := s.keys.Open(snap.Payload)
+		if err != nil {
+			snap.Payload = nil
+			continue
+		}
+		snap.Payload = plaintext
+	}
+
+	return snapshots, nil
+}
+
+// ListDeadLetters 列出死信並解密預約快照，無法解密的快照會被清空並標示於原因中
+func (s *EncryptedStore) ListDeadLetters(limit int) ([]*DeadLetter, error) {
+	dead, err := s.Store.ListDeadLetters(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range dead {
+		if len(d.Payload) == 0 {
+			continue
+		}
+		plaintext, err := s.keys.Open(d.Payload)
+		if err != nil {
+			d.Payload = nil
+			d.Reason = fmt.Sprintf("%s（預約快照無法解密: %v）", d.Reason, err)
+			continue
+		}
+		d.Payload = plaintext
+	}
+
+	return dead, nil
+}
+
+// SaveMapping 加密客戶姓名、電子郵件與舊資料後寫入對應關係
+func (s *EncryptedStore) SaveMapping(m *Mapping) error {
+	copied := *m
+	copied.ClientAliases = nil
+	var err error
+	if copied.ClientName, err = s.sealString(m.ClientName); err != nil {
+		return err
+	}
+	if copied.ClientEmail, err = s.sealString(m.ClientEmail); err != nil {
+		return err
+	}
+	for _, alias := range m.ClientAliases {
+		sealed, err := s.sealString(alias)
+		if err != nil {
+			return err
+		}
+		copied.ClientAliases = append(copied.ClientAliases, sealed)
+	}
+	return s.Store.SaveMapping(&copied)
+}
+
+// GetMapping 讀取對應關係並解密客戶資料
+func (s *EncryptedStore) GetMapping(bookingID string) (*Mapping, error) {
+	m, err := s.Store.GetMapping(bookingID)
+	if err != nil || m == nil {
+		return m, err
+	}
+	s.openClient(m)
+	return m, nil
+}
+
+// ListMappings 列出對應關係並解密客戶資料
+func (s *EncryptedStore) ListMappings() ([]*Mapping, error) {
+	mappings, err := s.Store.ListMappings()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		s.openClient(m)
+	}
+	return mappings, nil
+}
+
+// openClient 解密對應關係中的客戶資料，無法解密的欄位會被清空
+func (s *EncryptedStore) openClient(m *Mapping) {
+	m.ClientName = s.openString(m.ClientName)
+	m.ClientEmail = s.openString(m.ClientEmail)
+	aliases := m.ClientAliases[:0]
+	for _, alias := range m.ClientAliases {
+		if opened := s.openString(alias); opened != "" {
+			aliases = append(aliases, opened)
+		}
+	}
+	m.ClientAliases = aliases
+}
+
+// sealString 加密字串欄位，空字串不加密
+func (s *EncryptedStore) sealString(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	sealed, err := s.keys.Seal([]byte(value))
+	if err != nil {
+		return "", fmt.Errorf("加密客戶資料失敗: %w", err)
+	}
+	return string(sealed), nil
+}
+
+// openString 解密字串欄位，啟用加密前寫入的明文原樣返回，無法解密時返回空字串
+func (s *EncryptedStore) openString(value string) string {
+	if value == "" {
+		return ""
+	}
+	plaintext, err := s.keys.Open([]byte(value))
+	if err != nil {
+		return ""
+	}
+	return string(plaintext)
+}
@@ -0,0 +1,663 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxMemoryRecords 記憶體中保留的同步記錄上限
+const maxMemoryRecords = 1000
+
+// MemoryStore 是基於記憶體的 Store 實作，重啟後資料會遺失
+type MemoryStore struct {
+	mu         sync.RWMutex
+	mappings   map[string]*Mapping
+	records    []*SyncRecord
+	nextID     int64
+	reminders  map[string]*Reminder
+	outbox     map[string]*PendingSync
+	dead       []*DeadLetter
+	snapshots  map[string][]*BookingSnapshot
+	tokens     map[string]string
+	heartbeats map[string]time.Time
+	skipped    map[string]*SkippedBooking
+	suppressed map[string]*SuppressedEmail
+	states     map[string]*SyncState
+}
+
+// NewMemoryStore 創建新的記憶體儲存
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mappings:   make(map[string]*Mapping),
+		reminders:  make(map[string]*Reminder),
+		outbox:     make(map[string]*PendingSync),
+		snapshots:  make(map[string][]*BookingSnapshot),
+		tokens:     make(map[string]string),
+		heartbeats: make(map[string]time.Time),
+		skipped:    make(map[string]*SkippedBooking),
+		suppressed: make(map[string]*SuppressedEmail),
+		states:     make(map[string]*SyncState),
+	}
+}
+
+// SaveMapping 新增或更新預約與事件的對應關係
+func (s *MemoryStore) SaveMapping(m *Mapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	copied := *m
+	if existing, ok := s.mappings[m.BookingID]; ok {
+		copied.CreatedAt = existing.CreatedAt
+		if copied.EventLink == "" && copied.EventID == existing.EventID {
+			copied.EventLink = existing.EventLink
+		}
+	} else if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = now
+	}
+	copied.UpdatedAt = now
+
+	s.mappings[m.BookingID] = &copied
+	return nil
+}
+
+// GetMapping 取得預約的對應關係，未找到時返回 nil
+func (s *MemoryStore) GetMapping(bookingID string) (*Mapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, ok := s.mappings[bookingID]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *m
+	return &copied, nil
+}
+
+// DeleteMapping 刪除預約的對應關係
+func (s *MemoryStore) DeleteMapping(bookingID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.mappings, bookingID)
+	return nil
+}
+
+// ListMappings 列出所有對應關係
+func (s *MemoryStore) ListMappings() ([]*Mapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mappings := make([]*Mapping, 0, len(s.mappings))
+	for _, m := range s.mappings {
+		copied := *m
+		mappings = append(mappings, &copied)
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].BookingID < mappings[j].BookingID
+	})
+
+	return mappings, nil
+}
+
+// AddSyncRecord 新增一筆同步記錄
+func (s *MemoryStore) AddSyncRecord(r *SyncRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	copied := *r
+	copied.ID = s.nextID
+	if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = time.Now()
+	}
+
+	s.records = append(s.records, &copied)
+	if len(s.records) > maxMemoryRecords {
+		s.records = s.records[len(s.records)-maxMemoryRecords:]
+	}
+
+	return nil
+}
+
+// ListSyncRecords 依時間倒序列出最近的同步記錄
+func (s *MemoryStore) ListSyncRecords(limit int, failedOnly bool) ([]*SyncRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []*SyncRecord
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+		if failedOnly && s.records[i].Success {
+			continue
+		}
+		copied := *s.records[i]
+		records = append(records, &copied)
+	}
+
+	return records, nil
+}
+
+// ListBookingSyncRecords 依時間倒序列出單一預約的同步記錄，limit <= 0 表示不限制
+func (s *MemoryStore) ListBookingSyncRecords(bookingID string, limit int) ([]*SyncRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []*SyncRecord
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+		if s.records[i].BookingID != bookingID {
+			continue
+		}
+		copied := *s.records[i]
+		records = append(records, &copied)
+	}
+
+	return records, nil
+}
+
+// SyncStats 統計指定時間之後的同步結果
+func (s *MemoryStore) SyncStats(since time.Time) (*SyncStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &SyncStats{}
+	for _, r := range s.records {
+		if r.CreatedAt.Before(since) {
+			continue
+		}
+		if r.Success {
+			stats.Succeeded++
+		} else {
+			stats.Failed++
+		}
+	}
+
+	return stats, nil
+}
+
+// SaveReminder 新增或更新預約的提醒；發送時間未變時保留已發送狀態
+func (s *MemoryStore) SaveReminder(r *Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *r
+	copied.SentAt = nil
+	if existing, ok := s.reminders[r.BookingID]; ok && existing.SendAt.Equal(r.SendAt) {
+		copied.SentAt = existing.SentAt
+	}
+
+	s.reminders[r.BookingID] = &copied
+	return nil
+}
+
+// DeleteReminder 刪除預約的提醒
+func (s *MemoryStore) DeleteReminder(bookingID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reminders, bookingID)
+	return nil
+}
+
+// ListDueReminders 列出發送時間已到且尚未發送的提醒
+func (s *MemoryStore) ListDueReminders(now time.Time) ([]*Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var reminders []*Reminder
+	for _, r := range s.reminders {
+		if r.SentAt == nil && !r.SendAt.After(now) {
+			copied := *r
+			reminders = append(reminders, &copied)
+		}
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].SendAt.Before(reminders[j].SendAt)
+	})
+
+	return reminders, nil
+}
+
+// MarkReminderSent 標記提醒已發送
+func (s *MemoryStore) MarkReminderSent(bookingID string, sentAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.reminders[bookingID]; ok {
+		r.SentAt = &sentAt
+	}
+	return nil
+}
+
+// EnqueuePendingSync 暫存同步操作；預約已有暫存時以 MergeAction 合併並累加嘗試次數
+func (s *MemoryStore) EnqueuePendingSync(p *PendingSync) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *p
+	if copied.EnqueuedAt.IsZero() {
+		copied.EnqueuedAt = time.Now()
+	}
+	copied.Attempts = 1
+	if existing, ok := s.outbox[p.BookingID]; ok {
+		copied.Action = MergeAction(existing.Action, p.Action)
+		copied.EnqueuedAt = existing.EnqueuedAt
+		copied.Attempts = existing.Attempts + 1
+	}
+
+	s.outbox[p.BookingID] = &copied
+	return nil
+}
+
+// ListPendingSyncs 依暫存時間列出最早的同步操作
+func (s *MemoryStore) ListPendingSyncs(limit int) ([]*PendingSync, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pending := make([]*PendingSync, 0, len(s.outbox))
+	for _, p := range s.outbox {
+		copied := *p
+		pending = append(pending, &copied)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].EnqueuedAt.Before(pending[j].EnqueuedAt)
+	})
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+// GetPendingSync 返回預約的暫存操作，不存在時返回 nil
+func (s *MemoryStore) GetPendingSync(bookingID string) (*PendingSync, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.outbox[bookingID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *p
+	return &copied, nil
+}
+
+// DeletePendingSync 刪除預約的暫存操作
+func (s *MemoryStore) DeletePendingSync(bookingID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.outbox, bookingID)
+	return nil
+}
+
+// OutboxStats 統計暫存的同步操作
+func (s *MemoryStore) OutboxStats() (*OutboxStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &OutboxStats{Count: len(s.outbox)}
+	for _, p := range s.outbox {
+		if stats.Oldest.IsZero() || p.EnqueuedAt.Before(stats.Oldest) {
+			stats.Oldest = p.EnqueuedAt
+		}
+	}
+
+	return stats, nil
+}
+
+// AddDeadLetter 新增一筆死信
+func (s *MemoryStore) AddDeadLetter(d *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	copied := *d
+	copied.ID = s.nextID
+	if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = time.Now()
+	}
+
+	s.dead = append(s.dead, &copied)
+	if len(s.dead) > maxMemoryRecords {
+		s.dead = s.dead[len(s.dead)-maxMemoryRecords:]
+	}
+
+	return nil
+}
+
+// ListDeadLetters 依時間倒序列出最近的死信
+func (s *MemoryStore) ListDeadLetters(limit int) ([]*DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var dead []*DeadLetter
+	for i := len(s.dead) - 1; i >= 0; i-- {
+		if limit > 0 && len(dead) >= limit {
+			break
+		}
+		copied := *s.dead[i]
+		dead = append(dead, &copied)
+	}
+
+	return dead, nil
+}
+
+// PurgeSyncRecords 刪除指定時間之前的同步記錄，返回刪除筆數
+func (s *MemoryStore) PurgeSyncRecords(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.records[:0]
+	for _, r := range s.records {
+		if !r.CreatedAt.Before(before) {
+			kept = append(kept, r)
+		}
+	}
+	purged := len(s.records) - len(kept)
+	s.records = kept
+
+	return purged, nil
+}
+
+// PurgeDeadLetters 刪除指定時間之前的死信，返回刪除筆數
+func (s *MemoryStore) PurgeDeadLetters(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.dead[:0]
+	for _, d := range s.dead {
+		if !d.CreatedAt.Before(before) {
+			kept = append(kept, d)
+		}
+	}
+	purged := len(s.dead) - len(kept)
+	s.dead = kept
+
+	return purged, nil
+}
+
+// ClearDeadLetterPayloads 清除指定時間之前死信的預約快照並保留死信本身，返回清除筆數
+func (s *MemoryStore) ClearDeadLetterPayloads(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cleared := 0
+	for _, d := range s.dead {
+		if d.CreatedAt.Before(before) && d.Payload != nil {
+			d.Payload = nil
+			cleared++
+		}
+	}
+
+	return cleared, nil
+}
+
+// PurgeBooking 刪除預約的所有資料：對應關係、同步記錄、提醒、暫存操作、死信與預約快照
+func (s *MemoryStore) PurgeBooking(bookingID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.mappings, bookingID)
+	delete(s.reminders, bookingID)
+	delete(s.outbox, bookingID)
+	delete(s.states, bookingID)
+	delete(s.snapshots, bookingID)
+
+	records := s.records[:0]
+	for _, r := range s.records {
+		if r.BookingID != bookingID {
+			records = append(records, r)
+		}
+	}
+	s.records = records
+
+	dead := s.dead[:0]
+	for _, d := range s.dead {
+		if d.BookingID != bookingID {
+			dead = append(dead, d)
+		}
+	}
+	s.dead = dead
+
+	return nil
+}
+
+// PurgeBookingSnapshots 刪除指定時間之前的預約快照，返回刪除筆數
+func (s *MemoryStore) PurgeBookingSnapshots(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for bookingID, snapshots := range s.snapshots {
+		kept := snapshots[:0]
+		for _, snap := range snapshots {
+			if snap.CreatedAt.Before(before) {
+				purged++
+				continue
+			}
+			kept = append(kept, snap)
+		}
+		if len(kept) == 0 {
+			delete(s.snapshots, bookingID)
+		} else {
+			s.snapshots[bookingID] = kept
+		}
+	}
+
+	return purged, nil
+}
+
+// SaveBookingSnapshot 在內容與最新版本不同時新增快照並設置版本號，返回是否新增
+func (s *MemoryStore) SaveBookingSnapshot(snap *BookingSnapshot) (bool, error) {
+	if snap.Hash == "" {
+		snap.Hash = SnapshotHash(snap.Payload)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := s.snapshots[snap.BookingID]
+	snap.Version = 1
+	if n := len(snapshots); n > 0 {
+		latest := snapshots[n-1]
+		if latest.Hash == snap.Hash {
+			return false, nil
+		}
+		snap.Version = latest.Version + 1
+	}
+	if snap.CreatedAt.IsZero() {
+		snap.CreatedAt = time.Now()
+	}
+
+	copied := *snap
+	s.snapshots[snap.BookingID] = append(snapshots, &copied)
+	return true, nil
+}
+
+// ListBookingSnapshots 依版本順序列出預約的所有快照
+func (s *MemoryStore) ListBookingSnapshots(bookingID string) ([]*BookingSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshots := make([]*BookingSnapshot, len(s.snapshots[bookingID]))
+	for i, snap := range s.snapshots[bookingID] {
+		copied := *snap
+		snapshots[i] = &copied
+	}
+	return snapshots, nil
+}
+
+// GetCalendarSyncToken 返回日曆上次增量列出的同步令牌，沒有時返回空字串
+func (s *MemoryStore) GetCalendarSyncToken(calendarID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tokens[calendarID], nil
+}
+
+// SaveCalendarSyncToken 儲存日曆的同步令牌，令牌為空時刪除
+func (s *MemoryStore) SaveCalendarSyncToken(calendarID, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == "" {
+		delete(s.tokens, calendarID)
+		return nil
+	}
+	s.tokens[calendarID] = token
+	return nil
+}
+
+// GetWebhookHeartbeat 返回最後一次收到平台 webhook 的時間，沒有記錄時返回零值
+func (s *MemoryStore) GetWebhookHeartbeat(source string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.heartbeats[source], nil
+}
+
+// SaveWebhookHeartbeat 記錄收到平台 webhook 的時間
+func (s *MemoryStore) SaveWebhookHeartbeat(source string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.heartbeats[source] = at
+	return nil
+}
+
+// AddSkippedBooking 新增或更新排除同步的預約編號
+func (s *MemoryStore) AddSkippedBooking(b *SkippedBooking) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *b
+	if existing, ok := s.skipped[b.BookingCode]; ok {
+		copied.CreatedAt = existing.CreatedAt
+	} else if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = time.Now()
+	}
+	s.skipped[b.BookingCode] = &copied
+	return nil
+}
+
+// GetSkippedBooking 返回排除同步的預約編號，未排除時返回 nil
+func (s *MemoryStore) GetSkippedBooking(code string) (*SkippedBooking, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.skipped[code]
+	if !ok {
+		return nil, nil
+	}
+	copied := *b
+	return &copied, nil
+}
+
+// RemoveSkippedBooking 取消排除預約編號，返回是否曾被排除
+func (s *MemoryStore) RemoveSkippedBooking(code string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.skipped[code]
+	delete(s.skipped, code)
+	return ok, nil
+}
+
+// ListSkippedBookings 依預約編號列出所有排除同步的預約
+func (s *MemoryStore) ListSkippedBookings() ([]*SkippedBooking, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	skipped := make([]*SkippedBooking, 0, len(s.skipped))
+	for _, b := range s.skipped {
+		copied := *b
+		skipped = append(skipped, &copied)
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].BookingCode < skipped[j].BookingCode })
+	return skipped, nil
+}
+
+// AddSuppressedEmail 新增或更新停止邀請的電子郵件地址
+func (s *MemoryStore) AddSuppressedEmail(e *SuppressedEmail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *e
+	copied.Email = NormalizeEmail(e.Email)
+	if existing, ok := s.suppressed[copied.Email]; ok {
+		copied.CreatedAt = existing.CreatedAt
+	} else if copied.CreatedAt.IsZero() {
+		copied.CreatedAt = time.Now()
+	}
+	s.suppressed[copied.Email] = &copied
+	return nil
+}
+
+// GetSuppressedEmail 返回停止邀請的電子郵件地址，不在清單中時返回 nil
+func (s *MemoryStore) GetSuppressedEmail(email string) (*SuppressedEmail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.suppressed[NormalizeEmail(email)]
+	if !ok {
+		return nil, nil
+	}
+	copied := *e
+	return &copied, nil
+}
+
+// RemoveSuppressedEmail 將電子郵件地址移出停止邀請清單，返回是否曾在清單中
+func (s *MemoryStore) RemoveSuppressedEmail(email string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email = NormalizeEmail(email)
+	_, ok := s.suppressed[email]
+	delete(s.suppressed, email)
+	return ok, nil
+}
+
+// ListSuppressedEmails 依地址列出停止邀請清單
+func (s *MemoryStore) ListSuppressedEmails() ([]*SuppressedEmail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	suppressed := make([]*SuppressedEmail, 0, len(s.suppressed))
+	for _, e := range s.suppressed {
+		copied := *e
+		suppressed = append(suppressed, &copied)
+	}
+	sort.Slice(suppressed, func(i, j int) bool { return suppressed[i].Email < suppressed[j].Email })
+	return suppressed, nil
+}
+
+// SaveSyncState 新增或更新預約的同步狀態
+func (s *MemoryStore) SaveSyncState(st *SyncState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *st
+	s.states[st.BookingID] = &copied
+	return nil
+}
+
+// GetSyncState 返回預約的同步狀態，不存在時返回 nil
+func (s *MemoryStore) GetSyncState(bookingID string) (*SyncState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.states[bookingID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *st
+	return &copied, nil
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS booking_mappings (
+    booking_id   TEXT PRIMARY KEY,
+    booking_code TEXT NOT NULL DEFAULT '',
+    event_id     TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS sync_records (
+    id         BIGSERIAL PRIMARY KEY,
+    booking_id TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    event_id   TEXT NOT NULL DEFAULT '',
+    success    BOOLEAN NOT NULL,
+    error      TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sync_records_created_at_idx ON sync_records (created_at DESC);
+CREATE INDEX IF NOT EXISTS sync_records_booking_id_idx ON sync_records (booking_id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS reminders (
+    booking_id TEXT PRIMARY KEY,
+    phone      TEXT NOT NULL,
+    body       TEXT NOT NULL,
+    send_at    TIMESTAMPTZ NOT NULL,
+    sent_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS reminders_due_idx ON reminders (send_at) WHERE sent_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE booking_mappings DROP COLUMN IF EXISTS calendar_id;
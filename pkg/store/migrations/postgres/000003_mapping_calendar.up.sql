@@ -0,0 +1 @@
+ALTER TABLE booking_mappings ADD COLUMN IF NOT EXISTS calendar_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS pending_syncs (
+    booking_id  TEXT PRIMARY KEY,
+    action      TEXT NOT NULL,
+    enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts    INTEGER NOT NULL DEFAULT 1,
+    last_error  TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS pending_syncs_enqueued_at_idx ON pending_syncs (enqueued_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id         BIGSERIAL PRIMARY KEY,
+    booking_id TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    reason     TEXT NOT NULL,
+    payload    BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS dead_letters_created_at_idx ON dead_letters (created_at DESC);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS booking_snapshots (
+    booking_id TEXT NOT NULL,
+    version    INTEGER NOT NULL,
+    hash       TEXT NOT NULL,
+    payload    BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (booking_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS booking_snapshots_created_at_idx ON booking_snapshots (created_at);
@@ -0,0 +1 @@
+ALTER TABLE sync_records DROP COLUMN IF EXISTS error_kind;
@@ -0,0 +1 @@
+ALTER TABLE sync_records ADD COLUMN IF NOT EXISTS error_kind TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS calendar_sync_tokens (
+    calendar_id TEXT PRIMARY KEY,
+    sync_token  TEXT NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE sync_records DROP COLUMN IF EXISTS event_link;
+ALTER TABLE booking_mappings DROP COLUMN IF EXISTS event_link;
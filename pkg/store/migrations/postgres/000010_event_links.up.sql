@@ -0,0 +1,2 @@
+ALTER TABLE booking_mappings ADD COLUMN IF NOT EXISTS event_link TEXT NOT NULL DEFAULT '';
+ALTER TABLE sync_records ADD COLUMN IF NOT EXISTS event_link TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS webhook_heartbeats (
+    source      TEXT PRIMARY KEY,
+    received_at TIMESTAMPTZ NOT NULL
+);
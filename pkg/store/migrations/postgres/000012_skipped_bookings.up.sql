@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS skipped_bookings (
+    booking_code TEXT PRIMARY KEY,
+    reason       TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
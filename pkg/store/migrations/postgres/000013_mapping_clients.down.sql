@@ -0,0 +1,3 @@
+ALTER TABLE booking_mappings DROP COLUMN IF EXISTS client_aliases;
+ALTER TABLE booking_mappings DROP COLUMN IF EXISTS client_email;
+ALTER TABLE booking_mappings DROP COLUMN IF EXISTS client_name;
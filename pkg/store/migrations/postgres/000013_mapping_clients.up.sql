@@ -0,0 +1,3 @@
+ALTER TABLE booking_mappings ADD COLUMN IF NOT EXISTS client_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE booking_mappings ADD COLUMN IF NOT EXISTS client_email TEXT NOT NULL DEFAULT '';
+ALTER TABLE booking_mappings ADD COLUMN IF NOT EXISTS client_aliases JSONB;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS suppressed_emails (
+    email      TEXT PRIMARY KEY,
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS sync_states (
+    booking_id TEXT PRIMARY KEY,
+    state      TEXT NOT NULL,
+    action     TEXT NOT NULL DEFAULT '',
+    error      TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
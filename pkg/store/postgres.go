@@ -0,0 +1,802 @@
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepostgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq" // 註冊 postgres 驅動
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// PostgresStore 是基於 PostgreSQL 的 Store 實作，供多個實例共享狀態
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore 連接 PostgreSQL 並執行資料庫遷移
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("開啟資料庫連線失敗: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("連接資料庫失敗: %w", err)
+	}
+
+	if err := migratePostgres(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// migratePostgres 執行內嵌的資料庫遷移
+func migratePostgres(db *sql.DB) error {
+	source, err := iofs.New(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return fmt.Errorf("載入資料庫遷移失敗: %w", err)
+	}
+
+	driver, err := migratepostgres.WithInstance(db, &migratepostgres.Config{})
+	if err != nil {
+		return fmt.Errorf("初始化遷移驅動失敗: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("初始化資料庫遷移失敗: %w", err)
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("執行資料庫遷移失敗: %w", err)
+	}
+
+	return nil
+}
+
+// Close 關閉資料庫連線
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// SaveMapping 新增或更新預約與事件的對應關係，事件未變且未提供網址時保留原網址
+func (s *PostgresStore) SaveMapping(m *Mapping) error {
+	var aliases []byte
+	if len(m.ClientAliases) > 0 {
+		var err error
+		if aliases, err = json.Marshal(m.ClientAliases); err != nil {
+			return fmt.Errorf("編碼客戶舊資料失敗: %w", err)
+		}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO booking_mappings (booking_id, booking_code, event_id, calendar_id, event_link, client_name, client_email, client_aliases)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (booking_id) DO UPDATE
+		SET booking_code = EXCLUDED.booking_code,
+		    event_id = EXCLUDED.event_id,
+		    calendar_id = EXCLUDED.calendar_id,
+		    event_link = CASE
+		        WHEN EXCLUDED.event_link = '' AND booking_mappings.event_id = EXCLUDED.event_id THEN booking_mappings.event_link
+		        ELSE EXCLUDED.event_link
+		    END,
+		    client_name = EXCLUDED.client_name,
+		    client_email = EXCLUDED.client_email,
+		    client_aliases = EXCLUDED.client_aliases,
+		    updated_at = now()`,
+		m.BookingID, m.BookingCode, m.EventID, m.CalendarID, m.EventLink, m.ClientName, m.ClientEmail, aliases)
+	if err != nil {
+		return fmt.Errorf("寫入對應關係失敗: %w", err)
+	}
+	return nil
+}
+
+// GetMapping 取得預約的對應關係，未找到時返回 nil
+func (s *PostgresStore) GetMapping(bookingID string) (*Mapping, error) {
+	var m Mapping
+	var aliases []byte
+	err := s.db.QueryRow(`
+		SELECT booking_id, booking_code, event_id, calendar_id, event_link, client_name, client_email, client_aliases, created_at, updated_at
+		FROM booking_mappings WHERE booking_id = $1`, bookingID,
+	).Scan(&m.BookingID, &m.BookingCode, &m.EventID, &m.CalendarID, &m.EventLink, &m.ClientName, &m.ClientEmail, &aliases, &m.CreatedAt, &m.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("讀取對應關係失敗: %w", err)
+	}
+	if len(aliases) > 0 {
+		if err := json.Unmarshal(aliases, &m.ClientAliases); err != nil {
+			return nil, fmt.Errorf("解析客戶舊資料失敗: %w", err)
+		}
+	}
+	return &m, nil
+}
+
+// DeleteMapping 刪除預約的對應關係
+func (s *PostgresStore) DeleteMapping(bookingID string) error {
+	if _, err := s.db.Exec(`DELETE FROM booking_mappings WHERE booking_id = $1`, bookingID); err != nil {
+		return fmt.Errorf("刪除對應關係失敗: %w", err)
+	}
+	return nil
+}
+
+// ListMappings 列出所有對應關係
+func (s *PostgresStore) ListMappings() ([]*Mapping, error) {
+	rows, err := s.db.Query(`
+		SELECT booking_id, booking_code, event_id, calendar_id, event_link, client_name, client_email, client_aliases, created_at, updated_at
+		FROM booking_mappings ORDER BY booking_id`)
+	if err != nil {
+		return nil, fmt.Errorf("查詢對應關係失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []*Mapping
+	for rows.Next() {
+		var m Mapping
+		var aliases []byte
+		if err := rows.Scan(&m.BookingID, &m.BookingCode, &m.EventID, &m.CalendarID, &m.EventLink, &m.ClientName, &m.ClientEmail, &aliases, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("讀取對應關係失敗: %w", err)
+		}
+		if len(aliases) > 0 {
+			if err := json.Unmarshal(aliases, &m.ClientAliases); err != nil {
+				return nil, fmt.Errorf("解析客戶舊資料失敗: %w", err)
+			}
+		}
+		mappings = append(mappings, &m)
+	}
+
+	return mappings, rows.Err()
+}
+
+// AddSyncRecord 新增一筆同步記錄
+func (s *PostgresStore) AddSyncRecord(r *SyncRecord) error {
+	var changes []byte
+	if len(r.Changes) > 0 {
+		var err error
+		if changes, err = json.Marshal(r.Changes); err != nil {
+			return fmt.Errorf("編碼欄位差異失敗: %w", err)
+		}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO sync_records (booking_id, action, event_id, event_link, success, error, error_kind, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		r.BookingID, r.Action, r.EventID, r.EventLink, r.Success, r.Error, r.ErrorKind, changes)
+	if err != nil {
+		return fmt.Errorf("寫入同步記錄失敗: %w", err)
+	}
+	return nil
+}
+
+// ListSyncRecords 依時間倒序列出最近的同步記錄
+func (s *PostgresStore) ListSyncRecords(limit int, failedOnly bool) ([]*SyncRecord, error) {
+	query := `SELECT id, booking_id, action, event_id, event_link, success, error, error_kind, changes, created_at FROM sync_records`
+	if failedOnly {
+		query += ` WHERE NOT success`
+	}
+	query += ` ORDER BY id DESC`
+
+	var args []interface{}
+	if limit > 0 {
+		query += ` LIMIT $1`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查詢同步記錄失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*SyncRecord
+	for rows.Next() {
+		var r SyncRecord
+		var changes []byte
+		if err := rows.Scan(&r.ID, &r.BookingID, &r.Action, &r.EventID, &r.EventLink, &r.Success, &r.Error, &r.ErrorKind, &changes, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("讀取同步記錄失敗: %w", err)
+		}
+		if changes != nil {
+			if err := json.Unmarshal(changes, &r.Changes); err != nil {
+				return nil, fmt.Errorf("解析欄位差異失敗: %w", err)
+			}
+		}
+		records = append(records, &r)
+	}
+
+	return records, rows.Err()
+}
+
+// ListBookingSyncRecords 依時間倒序列出單一預約的同步記錄，limit <= 0 表示不限制
+func (s *PostgresStore) ListBookingSyncRecords(bookingID string, limit int) ([]*SyncRecord, error) {
+	query := `SELECT id, booking_id, action, event_id, event_link, success, error, error_kind, changes, created_at
+		FROM sync_records WHERE booking_id = $1 ORDER BY id DESC`
+	args := []interface{}{bookingID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查詢同步記錄失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*SyncRecord
+	for rows.Next() {
+		var r SyncRecord
+		var changes []byte
+		if err := rows.Scan(&r.ID, &r.BookingID, &r.Action, &r.EventID, &r.EventLink, &r.Success, &r.Error, &r.ErrorKind, &changes, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("讀取同步記錄失敗: %w", err)
+		}
+		if changes != nil {
+			if err := json.Unmarshal(changes, &r.Changes); err != nil {
+				return nil, fmt.Errorf("解析欄位差異失敗: %w", err)
+			}
+		}
+		records = append(records, &r)
+	}
+
+	return records, rows.Err()
+}
+
+// SyncStats 統計指定時間之後的同步結果
+func (s *PostgresStore) SyncStats(since time.Time) (*SyncStats, error) {
+	stats := &SyncStats{}
+	err := s.db.QueryRow(`
+		SELECT count(*) FILTER (WHERE success), count(*) FILTER (WHERE NOT success)
+		FROM sync_records WHERE created_at >= $1`, since,
+	).Scan(&stats.Succeeded, &stats.Failed)
+	if err != nil {
+		return nil, fmt.Errorf("統計同步記錄失敗: %w", err)
+	}
+	return stats, nil
+}
+
+// SaveReminder 新增或更新預約的提醒；發送時間未變時保留已發送狀態
+func (s *PostgresStore) SaveReminder(r *Reminder) error {
+	_, err := s.db.Exec(`
+		INSERT INTO reminders (booking_id, phone, body, send_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (booking_id) DO UPDATE
+		SET phone = EXCLUDED.phone,
+		    body = EXCLUDED.body,
+		    sent_at = CASE WHEN reminders.send_at = EXCLUDED.send_at THEN reminders.sent_at END,
+		    send_at = EXCLUDED.send_at`,
+		r.BookingID, r.Phone, r.Body, r.SendAt)
+	if err != nil {
+		return fmt.Errorf("寫入提醒失敗: %w", err)
+	}
+	return nil
+}
+
+// DeleteReminder 刪除預約的提醒
+func (s *PostgresStore) DeleteReminder(bookingID string) error {
+	if _, err := s.db.Exec(`DELETE FROM reminders WHERE booking_id = $1`, bookingID); err != nil {
+		return fmt.Errorf("刪除提醒失敗: %w", err)
+	}
+	return nil
+}
+
+// ListDueReminders 列出發送時間已到且尚未發送的提醒
+func (s *PostgresStore) ListDueReminders(now time.Time) ([]*Reminder, error) {
+	rows, err := s.db.Query(`
+		SELECT booking_id, phone, body, send_at
+		FROM reminders WHERE sent_at IS NULL AND send_at <= $1
+		ORDER BY send_at`, now)
+	if err != nil {
+		return nil, fmt.Errorf("查詢提醒失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []*Reminder
+	for rows.Next() {
+		var r Reminder
+		if err := rows.Scan(&r.BookingID, &r.Phone, &r.Body, &r.SendAt); err != nil {
+			return nil, fmt.Errorf("讀取提醒失敗: %w", err)
+		}
+		reminders = append(reminders, &r)
+	}
+
+	return reminders, rows.Err()
+}
+
+// MarkReminderSent 標記提醒已發送
+func (s *PostgresStore) MarkReminderSent(bookingID string, sentAt time.Time) error {
+	if _, err := s.db.Exec(`UPDATE reminders SET sent_at = $2 WHERE booking_id = $1`, bookingID, sentAt); err != nil {
+		return fmt.Errorf("更新提醒狀態失敗: %w", err)
+	}
+	return nil
+}
+
+// EnqueuePendingSync 暫存同步操作；預約已有暫存時以 MergeAction 合併並累加嘗試次數
+func (s *PostgresStore) EnqueuePendingSync(p *PendingSync) error {
+	enqueuedAt := p.EnqueuedAt
+	if enqueuedAt.IsZero() {
+		enqueuedAt = time.Now()
+	}
+
+	// 合併規則與 MergeAction 相同
+	_, err := s.db.Exec(`
+		INSERT INTO pending_syncs (booking_id, action, enqueued_at, last_error)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (booking_id) DO UPDATE
+		SET action = CASE
+		        WHEN EXCLUDED.action <> 'cancel' AND pending_syncs.action = 'create' THEN pending_syncs.action
+		        ELSE EXCLUDED.action
+		    END,
+		    attempts = pending_syncs.attempts + 1,
+		    last_error = EXCLUDED.last_error`,
+		p.BookingID, p.Action, enqueuedAt, p.LastError)
+	if err != nil {
+		return fmt.Errorf("暫存同步操作失敗: %w", err)
+	}
+	return nil
+}
+
+// ListPendingSyncs 依暫存時間列出最早的同步操作
+func (s *PostgresStore) ListPendingSyncs(limit int) ([]*PendingSync, error) {
+	query := `SELECT booking_id, action, enqueued_at, attempts, last_error FROM pending_syncs ORDER BY enqueued_at`
+
+	var args []interface{}
+	if limit > 0 {
+		query += ` LIMIT $1`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查詢暫存同步操作失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*PendingSync
+	for rows.Next() {
+		var p PendingSync
+		if err := rows.Scan(&p.BookingID, &p.Action, &p.EnqueuedAt, &p.Attempts, &p.LastError); err != nil {
+			return nil, fmt.Errorf("讀取暫存同步操作失敗: %w", err)
+		}
+		pending = append(pending, &p)
+	}
+
+	return pending, rows.Err()
+}
+
+// GetPendingSync 返回預約的暫存操作，不存在時返回 nil
+func (s *PostgresStore) GetPendingSync(bookingID string) (*PendingSync, error) {
+	var p PendingSync
+	err := s.db.QueryRow(`
+		SELECT booking_id, action, enqueued_at, attempts, last_error
+		FROM pending_syncs WHERE booking_id = $1`, bookingID,
+	).Scan(&p.BookingID, &p.Action, &p.EnqueuedAt, &p.Attempts, &p.LastError)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("讀取暫存同步操作失敗: %w", err)
+	}
+	return &p, nil
+}
+
+// DeletePendingSync 刪除預約的暫存操作
+func (s *PostgresStore) DeletePendingSync(bookingID string) error {
+	if _, err := s.db.Exec(`DELETE FROM pending_syncs WHERE booking_id = $1`, bookingID); err != nil {
+		return fmt.Errorf("刪除暫存同步操作失敗: %w", err)
+	}
+	return nil
+}
+
+// OutboxStats 統計暫存的同步操作
+func (s *PostgresStore) OutboxStats() (*OutboxStats, error) {
+	var stats OutboxStats
+	var oldest sql.NullTime
+	err := s.db.QueryRow(`SELECT count(*), min(enqueued_at) FROM pending_syncs`).Scan(&stats.Count, &oldest)
+	if err != nil {
+		return nil, fmt.Errorf("統計暫存同步操作失敗: %w", err)
+	}
+	if oldest.Valid {
+		stats.Oldest = oldest.Time
+	}
+	return &stats, nil
+}
+
+// AddDeadLetter 新增一筆死信
+func (s *PostgresStore) AddDeadLetter(d *DeadLetter) error {
+	_, err := s.db.Exec(`
+		INSERT INTO dead_letters (booking_id, action, reason, payload)
+		VALUES ($1, $2, $3, $4)`,
+		d.BookingID, d.Action, d.Reason, []byte(d.Payload))
+	if err != nil {
+		return fmt.Errorf("寫入死信失敗: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters 依時間倒序列出最近的死信
+func (s *PostgresStore) ListDeadLetters(limit int) ([]*DeadLetter, error) {
+	query := `SELECT id, booking_id, action, reason, payload, created_at FROM dead_letters ORDER BY id DESC`
+
+	var args []interface{}
+	if limit > 0 {
+		query += ` LIMIT $1`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查詢死信失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var dead []*DeadLetter
+	for rows.Next() {
+		var d DeadLetter
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.BookingID, &d.Action, &d.Reason, &payload, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("讀取死信失敗: %w", err)
+		}
+		d.Payload = payload
+		dead = append(dead, &d)
+	}
+
+	return dead, rows.Err()
+}
+
+// PurgeSyncRecords 刪除指定時間之前的同步記錄，返回刪除筆數
+func (s *PostgresStore) PurgeSyncRecords(before time.Time) (int, error) {
+	return s.execCount(`DELETE FROM sync_records WHERE created_at < $1`, before)
+}
+
+// PurgeDeadLetters 刪除指定時間之前的死信，返回刪除筆數
+func (s *PostgresStore) PurgeDeadLetters(before time.Time) (int, error) {
+	return s.execCount(`DELETE FROM dead_letters WHERE created_at < $1`, before)
+}
+
+// ClearDeadLetterPayloads 清除指定時間之前死信的預約快照並保留死信本身，返回清除筆數
+func (s *PostgresStore) ClearDeadLetterPayloads(before time.Time) (int, error) {
+	return s.execCount(`UPDATE dead_letters SET payload = NULL WHERE created_at < $1 AND payload IS NOT NULL`, before)
+}
+
+// PurgeBooking 刪除預約的所有資料：對應關係、同步記錄、提醒、暫存操作、死信與預約快照
+func (s *PostgresStore) PurgeBooking(bookingID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("開始交易失敗: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"booking_mappings", "sync_records", "reminders", "pending_syncs", "dead_letters", "booking_snapshots", "sync_states"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE booking_id = $1`, bookingID); err != nil {
+			return fmt.Errorf("刪除 %s 中的預約資料失敗: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交交易失敗: %w", err)
+	}
+	return nil
+}
+
+// PurgeBookingSnapshots 刪除指定時間之前的預約快照，返回刪除筆數
+func (s *PostgresStore) PurgeBookingSnapshots(before time.Time) (int, error) {
+	return s.execCount(`DELETE FROM booking_snapshots WHERE created_at < $1`, before)
+}
+
+// SaveBookingSnapshot 在內容與最新版本不同時新增快照並設置版本號，返回是否新增
+func (s *PostgresStore) SaveBookingSnapshot(snap *BookingSnapshot) (bool, error) {
+	if snap.Hash == "" {
+		snap.Hash = SnapshotHash(snap.Payload)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("開始交易失敗: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 鎖定同一預約的快照寫入，避免並行寫入取得相同版本號
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, "booking_snapshots:"+snap.BookingID); err != nil {
+		return false, fmt.Errorf("鎖定預約快照失敗: %w", err)
+	}
+
+	var latestVersion int
+	var latestHash string
+	err = tx.QueryRow(`
+		SELECT version, hash FROM booking_snapshots
+		WHERE booking_id = $1 ORDER BY version DESC LIMIT 1`, snap.BookingID).Scan(&latestVersion, &latestHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("查詢最新預約快照失敗: %w", err)
+	}
+	if err == nil && latestHash == snap.Hash {
+		return false, nil
+	}
+
+	snap.Version = latestVersion + 1
+	err = tx.QueryRow(`
+		INSERT INTO booking_snapshots (booking_id, version, hash, payload)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`,
+		snap.BookingID, snap.Version, snap.Hash, []byte(snap.Payload)).Scan(&snap.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("寫入預約快照失敗: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交交易失敗: %w", err)
+	}
+	return true, nil
+}
+
+// ListBookingSnapshots 依版本順序列出預約的所有快照
+func (s *PostgresStore) ListBookingSnapshots(bookingID string) ([]*BookingSnapshot, error) {
+	rows, err := s.db.Query(`
+		SELECT booking_id, version, hash, payload, created_at FROM booking_snapshots
+		WHERE booking_id = $1 ORDER BY version`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("查詢預約快照失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []*BookingSnapshot
+	for rows.Next() {
+		var snap BookingSnapshot
+		var payload []byte
+		if err := rows.Scan(&snap.BookingID, &snap.Version, &snap.Hash, &payload, &snap.CreatedAt); err != nil {
+			return nil, fmt.Errorf("讀取預約快照失敗: %w", err)
+		}
+		snap.Payload = payload
+		snapshots = append(snapshots, &snap)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// execCount 執行語句並返回影響的筆數
+func (s *PostgresStore) execCount(query string, args ...interface{}) (int, error) {
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("清除資料失敗: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("讀取影響筆數失敗: %w", err)
+	}
+	return int(n), nil
+}
+
+// GetCalendarSyncToken 返回日曆上次增量列出的同步令牌，沒有時返回空字串
+func (s *PostgresStore) GetCalendarSyncToken(calendarID string) (string, error) {
+	var token string
+	err := s.db.QueryRow(`SELECT sync_token FROM calendar_sync_tokens WHERE calendar_id = $1`, calendarID).Scan(&token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("讀取日曆同步令牌失敗: %w", err)
+	}
+	return token, nil
+}
+
+// SaveCalendarSyncToken 儲存日曆的同步令牌，令牌為空時刪除
+func (s *PostgresStore) SaveCalendarSyncToken(calendarID, token string) error {
+	var err error
+	if token == "" {
+		_, err = s.db.Exec(`DELETE FROM calendar_sync_tokens WHERE calendar_id = $1`, calendarID)
+	} else {
+		_, err = s.db.Exec(`
+			INSERT INTO calendar_sync_tokens (calendar_id, sync_token)
+			VALUES ($1, $2)
+			ON CONFLICT (calendar_id) DO UPDATE
+			SET sync_token = EXCLUDED.sync_token, updated_at = now()`, calendarID, token)
+	}
+	if err != nil {
+		return fmt.Errorf("儲存日曆同步令牌失敗: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookHeartbeat 返回最後一次收到平台 webhook 的時間，沒有記錄時返回零值
+func (s *PostgresStore) GetWebhookHeartbeat(source string) (time.Time, error) {
+	var at time.Time
+	err := s.db.QueryRow(`SELECT received_at FROM webhook_heartbeats WHERE source = $1`, source).Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("讀取 webhook 接收時間失敗: %w", err)
+	}
+	return at, nil
+}
+
+// SaveWebhookHeartbeat 記錄收到平台 webhook 的時間
+func (s *PostgresStore) SaveWebhookHeartbeat(source string, at time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_heartbeats (source, received_at)
+		VALUES ($1, $2)
+		ON CONFLICT (source) DO UPDATE
+		SET received_at = GREATEST(webhook_heartbeats.received_at, EXCLUDED.received_at)`, source, at)
+	if err != nil {
+		return fmt.Errorf("儲存 webhook 接收時間失敗: %w", err)
+	}
+	return nil
+}
+
+// AddSkippedBooking 新增或更新排除同步的預約編號
+func (s *PostgresStore) AddSkippedBooking(b *SkippedBooking) error {
+	createdAt := b.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO skipped_bookings (booking_code, reason, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (booking_code) DO UPDATE
+		SET reason = EXCLUDED.reason`, b.BookingCode, b.Reason, createdAt)
+	if err != nil {
+		return fmt.Errorf("儲存排除同步的預約失敗: %w", err)
+	}
+	return nil
+}
+
+// GetSkippedBooking 返回排除同步的預約編號，未排除時返回 nil
+func (s *PostgresStore) GetSkippedBooking(code string) (*SkippedBooking, error) {
+	var b SkippedBooking
+	err := s.db.QueryRow(`
+		SELECT booking_code, reason, created_at
+		FROM skipped_bookings WHERE booking_code = $1`, code,
+	).Scan(&b.BookingCode, &b.Reason, &b.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("讀取排除同步的預約失敗: %w", err)
+	}
+	return &b, nil
+}
+
+// RemoveSkippedBooking 取消排除預約編號，返回是否曾被排除
+func (s *PostgresStore) RemoveSkippedBooking(code string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM skipped_bookings WHERE booking_code = $1`, code)
+	if err != nil {
+		return false, fmt.Errorf("刪除排除同步的預約失敗: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("刪除排除同步的預約失敗: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListSkippedBookings 依預約編號列出所有排除同步的預約
+func (s *PostgresStore) ListSkippedBookings() ([]*SkippedBooking, error) {
+	rows, err := s.db.Query(`
+		SELECT booking_code, reason, created_at
+		FROM skipped_bookings ORDER BY booking_code`)
+	if err != nil {
+		return nil, fmt.Errorf("查詢排除同步的預約失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var skipped []*SkippedBooking
+	for rows.Next() {
+		var b SkippedBooking
+		if err := rows.Scan(&b.BookingCode, &b.Reason, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("讀取排除同步的預約失敗: %w", err)
+		}
+		skipped = append(skipped, &b)
+	}
+
+	return skipped, rows.Err()
+}
+
+// AddSuppressedEmail 新增或更新停止邀請的電子郵件地址
+func (s *PostgresStore) AddSuppressedEmail(e *SuppressedEmail) error {
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO suppressed_emails (email, reason, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email) DO UPDATE
+		SET reason = EXCLUDED.reason`, NormalizeEmail(e.Email), e.Reason, createdAt)
+	if err != nil {
+		return fmt.Errorf("儲存停止邀請的地址失敗: %w", err)
+	}
+	return nil
+}
+
+// GetSuppressedEmail 返回停止邀請的電子郵件地址，不在清單中時返回 nil
+func (s *PostgresStore) GetSuppressedEmail(email string) (*SuppressedEmail, error) {
+	var e SuppressedEmail
+	err := s.db.QueryRow(`
+		SELECT email, reason, created_at
+		FROM suppressed_emails WHERE email = $1`, NormalizeEmail(email),
+	).Scan(&e.Email, &e.Reason, &e.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("讀取停止邀請的地址失敗: %w", err)
+	}
+	return &e, nil
+}
+
+// RemoveSuppressedEmail 將電子郵件地址移出停止邀請清單，返回是否曾在清單中
+func (s *PostgresStore) RemoveSuppressedEmail(email string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM suppressed_emails WHERE email = $1`, NormalizeEmail(email))
+	if err != nil {
+		return false, fmt.Errorf("刪除停止邀請的地址失敗: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("刪除停止邀請的地址失敗: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListSuppressedEmails 依地址列出停止邀請清單
+func (s *PostgresStore) ListSuppressedEmails() ([]*SuppressedEmail, error) {
+	rows, err := s.db.Query(`
+		SELECT email, reason, created_at
+		FROM suppressed_emails ORDER BY email`)
+	if err != nil {
+		return nil, fmt.Errorf("查詢停止邀請的地址失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var suppressed []*SuppressedEmail
+	for rows.Next() {
+		var e SuppressedEmail
+		if err := rows.Scan(&e.Email, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("讀取停止邀請的地址失敗: %w", err)
+		}
+		suppressed = append(suppressed, &e)
+	}
+
+	return suppressed, rows.Err()
+}
+
+// SaveSyncState 新增或更新預約的同步狀態
+func (s *PostgresStore) SaveSyncState(st *SyncState) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sync_states (booking_id, state, action, error, started_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (booking_id) DO UPDATE
+		SET state = EXCLUDED.state, action = EXCLUDED.action, error = EXCLUDED.error,
+		    started_at = EXCLUDED.started_at, updated_at = EXCLUDED.updated_at`,
+		st.BookingID, st.State, st.Action, st.Error, st.StartedAt, st.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("儲存同步狀態失敗: %w", err)
+	}
+	return nil
+}
+
+// GetSyncState 返回預約的同步狀態，不存在時返回 nil
+func (s *PostgresStore) GetSyncState(bookingID string) (*SyncState, error) {
+	var st SyncState
+	err := s.db.QueryRow(`
+		SELECT booking_id, state, action, error, started_at, updated_at
+		FROM sync_states WHERE booking_id = $1`, bookingID,
+	).Scan(&st.BookingID, &st.State, &st.Action, &st.Error, &st.StartedAt, &st.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("讀取同步狀態失敗: %w", err)
+	}
+	return &st, nil
+}
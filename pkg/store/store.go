@@ -0,0 +1,291 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Mapping 代表 SimplyBook 預約與 Google 日曆事件的對應關係
+type Mapping struct {
+	BookingID   string `json:"booking_id"`
+	BookingCode string `json:"booking_code"`
+	EventID     string `json:"event_id"`
+	CalendarID  string `json:"calendar_id,omitempty"` // 空字串表示預設日曆
+	EventLink   string `json:"event_link,omitempty"`  // 事件在 Google 日曆的網址
+	ClientName  string `json:"client_name,omitempty"`
+	ClientEmail string `json:"client_email,omitempty"`
+	// ClientAliases 客戶先前使用的姓名與電子郵件，客戶更名後仍可以舊資料搜尋
+	ClientAliases []string  `json:"client_aliases,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// MergeClient 以預約的最新客戶資料更新對應關係，變更前的姓名與電子郵件保留於 ClientAliases，
+// 返回變更的客戶欄位；name 與 email 為空時沿用既有資料。
+// 返回的變更只有欄位名稱，不含舊值與新值，避免客戶個資以明文寫入同步記錄與稽核轉送
+func (m *Mapping) MergeClient(previous *Mapping, name, email string) []FieldChange {
+	if previous == nil {
+		m.ClientName, m.ClientEmail = name, email
+		return nil
+	}
+
+	m.ClientName, m.ClientEmail = previous.ClientName, previous.ClientEmail
+	m.ClientAliases = append([]string(nil), previous.ClientAliases...)
+
+	var changes []FieldChange
+	for _, f := range []struct {
+		field string
+		value *string
+		next  string
+	}{
+		{"client_name", &m.ClientName, name},
+		{"client_email", &m.ClientEmail, email},
+	} {
+		if f.next == "" || f.next == *f.value {
+			continue
+		}
+		if *f.value != "" {
+			changes = append(changes, FieldChange{Field: f.field})
+			m.addAlias(*f.value)
+		}
+		*f.value = f.next
+	}
+
+	// 客戶改回先前的資料時，不再列為舊資料
+	aliases := m.ClientAliases[:0]
+	for _, alias := range m.ClientAliases {
+		if alias != m.ClientName && alias != m.ClientEmail {
+			aliases = append(aliases, alias)
+		}
+	}
+	m.ClientAliases = aliases
+	if len(m.ClientAliases) == 0 {
+		m.ClientAliases = nil
+	}
+	return changes
+}
+
+// addAlias 加入客戶先前使用的資料，已存在時略過
+func (m *Mapping) addAlias(alias string) {
+	for _, existing := range m.ClientAliases {
+		if existing == alias {
+			return
+		}
+	}
+	m.ClientAliases = append(m.ClientAliases, alias)
+}
+
+// SyncRecord 代表一次同步操作的結果記錄
+type SyncRecord struct {
+	ID        int64         `json:"id"`
+	BookingID string        `json:"booking_id"`
+	Action    string        `json:"action"`
+	EventID   string        `json:"event_id,omitempty"`
+	EventLink string        `json:"event_link,omitempty"` // 事件在 Google 日曆的網址
+	Success   bool          `json:"success"`
+	Error     string        `json:"error,omitempty"`
+	ErrorKind string        `json:"error_kind,omitempty"` // 錯誤分類（auth、rate_limit、not_found、validation、unavailable、conflict 或 other）
+	Changes   []FieldChange `json:"changes,omitempty"`    // 更新事件時的欄位差異
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// FieldChange 代表事件單一欄位的變更
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Reminder 代表排程中的簡訊提醒，每個預約最多一筆
+type Reminder struct {
+	BookingID string     `json:"booking_id"`
+	Phone     string     `json:"phone"`
+	Body      string     `json:"body"`
+	SendAt    time.Time  `json:"send_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+// DeadLetter 代表無法同步、需人工處理的預約，Payload 為當時的預約快照（JSON）
+type DeadLetter struct {
+	ID        int64           `json:"id"`
+	BookingID string          `json:"booking_id"`
+	Action    string          `json:"action"`
+	Reason    string          `json:"reason"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// BookingSnapshot 代表從 SimplyBook 讀取的某個版本的預約資料（JSON），
+// 與最新版本內容相同的快照不會重複儲存
+type BookingSnapshot struct {
+	BookingID string          `json:"booking_id"`
+	Version   int             `json:"version"` // 每個預約從 1 開始遞增
+	Hash      string          `json:"hash"`    // 快照內容的 SHA-256，加密儲存時仍可比對內容是否相同
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// SnapshotHash 計算預約快照內容的雜湊值
+func SnapshotHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// PendingSync 代表 Google 日曆無法使用時暫存的同步操作，每個預約最多一筆
+type PendingSync struct {
+	BookingID  string    `json:"booking_id"`
+	Action     string    `json:"action"`
+	EnqueuedAt time.Time `json:"enqueued_at"` // 首次暫存的時間
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// OutboxStats 代表暫存同步操作的統計
+type OutboxStats struct {
+	Count  int       `json:"count"`
+	Oldest time.Time `json:"oldest"` // 最早暫存的時間，沒有暫存操作時為零值
+}
+
+// MergeAction 合併同一預約先後暫存的操作：取消優先，
+// 尚未建立事件的 create 不因後續的 change 而失去確認郵件等建立流程
+func MergeAction(previous, next string) string {
+	if next != "cancel" && previous == "create" {
+		return previous
+	}
+	return next
+}
+
+// SkippedBooking 代表手動排除、不同步到日曆的預約（例如內部測試預約），以預約編號識別
+type SkippedBooking struct {
+	BookingCode string    `json:"booking_code"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SuppressedEmail 代表不再邀請為事件參與者的電子郵件地址（例如退信或被檢舉為垃圾郵件），
+// 地址以 NormalizeEmail 正規化後儲存
+type SuppressedEmail struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SyncState 代表預約最近一次同步在狀態機中的位置：
+// received → fetched → rendered → applied → confirmed，任一步驟失敗時為 failed
+type SyncState struct {
+	BookingID string    `json:"booking_id"`
+	State     string    `json:"state"`
+	Action    string    `json:"action"`
+	Error     string    `json:"error,omitempty"` // 狀態為 failed 時的失敗原因
+	StartedAt time.Time `json:"started_at"`      // 本次同步進入 received 的時間
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NormalizeEmail 去除電子郵件地址前後空白並轉為小寫，作為停止邀請清單的鍵
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SyncStats 代表一段時間內的同步統計
+type SyncStats struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// Store 定義同步狀態的儲存介面
+type Store interface {
+	// SaveMapping 新增或更新預約與事件的對應關係
+	SaveMapping(m *Mapping) error
+	// GetMapping 取得預約的對應關係，未找到時返回 nil
+	GetMapping(bookingID string) (*Mapping, error)
+	// DeleteMapping 刪除預約的對應關係
+	DeleteMapping(bookingID string) error
+	// ListMappings 列出所有對應關係
+	ListMappings() ([]*Mapping, error)
+
+	// AddSyncRecord 新增一筆同步記錄
+	AddSyncRecord(r *SyncRecord) error
+	// ListSyncRecords 依時間倒序列出最近的同步記錄
+	ListSyncRecords(limit int, failedOnly bool) ([]*SyncRecord, error)
+	// ListBookingSyncRecords 依時間倒序列出單一預約的同步記錄，limit <= 0 表示不限制
+	ListBookingSyncRecords(bookingID string, limit int) ([]*SyncRecord, error)
+	// SyncStats 統計指定時間之後的同步結果
+	SyncStats(since time.Time) (*SyncStats, error)
+
+	// SaveReminder 新增或更新預約的提醒；發送時間未變時保留已發送狀態
+	SaveReminder(r *Reminder) error
+	// DeleteReminder 刪除預約的提醒
+	DeleteReminder(bookingID string) error
+	// ListDueReminders 列出發送時間已到且尚未發送的提醒
+	ListDueReminders(now time.Time) ([]*Reminder, error)
+	// MarkReminderSent 標記提醒已發送
+	MarkReminderSent(bookingID string, sentAt time.Time) error
+
+	// AddDeadLetter 新增一筆死信
+	AddDeadLetter(d *DeadLetter) error
+	// ListDeadLetters 依時間倒序列出最近的死信
+	ListDeadLetters(limit int) ([]*DeadLetter, error)
+
+	// EnqueuePendingSync 暫存同步操作；預約已有暫存時以 MergeAction 合併並累加嘗試次數
+	EnqueuePendingSync(p *PendingSync) error
+	// ListPendingSyncs 依暫存時間列出最早的同步操作
+	ListPendingSyncs(limit int) ([]*PendingSync, error)
+	// GetPendingSync 返回預約的暫存操作，不存在時返回 nil
+	GetPendingSync(bookingID string) (*PendingSync, error)
+	// DeletePendingSync 刪除預約的暫存操作
+	DeletePendingSync(bookingID string) error
+	// OutboxStats 統計暫存的同步操作
+	OutboxStats() (*OutboxStats, error)
+
+	// PurgeSyncRecords 刪除指定時間之前的同步記錄，返回刪除筆數
+	PurgeSyncRecords(before time.Time) (int, error)
+	// PurgeDeadLetters 刪除指定時間之前的死信，返回刪除筆數
+	PurgeDeadLetters(before time.Time) (int, error)
+	// ClearDeadLetterPayloads 清除指定時間之前死信的預約快照並保留死信本身，返回清除筆數
+	ClearDeadLetterPayloads(before time.Time) (int, error)
+	// PurgeBookingSnapshots 刪除指定時間之前的預約快照，返回刪除筆數
+	PurgeBookingSnapshots(before time.Time) (int, error)
+	// PurgeBooking 刪除預約的所有資料：對應關係、同步記錄、提醒、暫存操作、死信與預約快照
+	PurgeBooking(bookingID string) error
+
+	// SaveBookingSnapshot 在內容與最新版本不同時新增快照並設置版本號（Hash 為空時自動計算），返回是否新增
+	SaveBookingSnapshot(s *BookingSnapshot) (bool, error)
+	// ListBookingSnapshots 依版本順序列出預約的所有快照
+	ListBookingSnapshots(bookingID string) ([]*BookingSnapshot, error)
+
+	// GetCalendarSyncToken 返回日曆上次增量列出的同步令牌，沒有時返回空字串
+	GetCalendarSyncToken(calendarID string) (string, error)
+	// SaveCalendarSyncToken 儲存日曆的同步令牌，令牌為空時刪除
+	SaveCalendarSyncToken(calendarID, token string) error
+
+	// GetWebhookHeartbeat 返回最後一次收到平台 webhook 的時間，沒有記錄時返回零值
+	GetWebhookHeartbeat(source string) (time.Time, error)
+	// SaveWebhookHeartbeat 記錄收到平台 webhook 的時間
+	SaveWebhookHeartbeat(source string, at time.Time) error
+
+	// AddSkippedBooking 新增或更新排除同步的預約編號
+	AddSkippedBooking(b *SkippedBooking) error
+	// GetSkippedBooking 返回排除同步的預約編號，未排除時返回 nil
+	GetSkippedBooking(code string) (*SkippedBooking, error)
+	// RemoveSkippedBooking 取消排除預約編號，返回是否曾被排除
+	RemoveSkippedBooking(code string) (bool, error)
+	// ListSkippedBookings 依預約編號列出所有排除同步的預約
+	ListSkippedBookings() ([]*SkippedBooking, error)
+
+	// AddSuppressedEmail 新增或更新停止邀請的電子郵件地址
+	AddSuppressedEmail(e *SuppressedEmail) error
+	// GetSuppressedEmail 返回停止邀請的電子郵件地址，不在清單中時返回 nil
+	GetSuppressedEmail(email string) (*SuppressedEmail, error)
+	// RemoveSuppressedEmail 將電子郵件地址移出停止邀請清單，返回是否曾在清單中
+	RemoveSuppressedEmail(email string) (bool, error)
+	// ListSuppressedEmails 依地址列出停止邀請清單
+	ListSuppressedEmails() ([]*SuppressedEmail, error)
+
+	// SaveSyncState 新增或更新預約的同步狀態
+	SaveSyncState(st *SyncState) error
+	// GetSyncState 返回預約的同步狀態，不存在時返回 nil
+	GetSyncState(bookingID string) (*SyncState, error)
+}
@@ -0,0 +1,76 @@
+// Package sync 提供預約同步的函式庫 API，讓其他 Go 程式不需啟動 HTTP 伺服器即可嵌入同步邏輯：
+//
+//	syncer, err := sync.New(source.NewSimplyBook(sbClient, nil), calendarClient, store.NewMemoryStore(), sync.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	err = syncer.SyncBooking(ctx, "12345")
+//	result, err := syncer.Reconcile(ctx, 24*time.Hour)
+package sync
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/booking-sync-455103/booking-sync/pkg/gcalendar"
+	"github.com/booking-sync-455103/booking-sync/pkg/handler"
+	"github.com/booking-sync-455103/booking-sync/pkg/source"
+	"github.com/booking-sync-455103/booking-sync/pkg/store"
+)
+
+// Options 是同步的可選設定，與 webhook 處理器的設定相同
+type Options = handler.Options
+
+// ReconcileResult 是一次對帳的結果
+type ReconcileResult = handler.ReconcileResult
+
+// Hook 在寫入日曆前修改事件或否決操作
+type Hook = handler.Hook
+
+// HookFuncs 以函式實作 Hook
+type HookFuncs = handler.HookFuncs
+
+// ErrVeto 由 Hook 返回時略過該次日曆操作
+var ErrVeto = handler.ErrVeto
+
+// Syncer 將預約平台的預約同步到 Google 日曆
+type Syncer struct {
+	handler *handler.WebhookHandler
+}
+
+// New 創建新的同步器，src 為主要的預約平台（例如 source.NewSimplyBook），
+// 其他平台可透過 Options.Sources 加入
+func New(src source.Source, calendarClient *gcalendar.Client, syncStore store.Store, opts Options) (*Syncer, error) {
+	switch {
+	case src == nil:
+		return nil, errors.New("未提供預約平台")
+	case calendarClient == nil:
+		return nil, errors.New("未提供 Google 日曆客戶端")
+	case syncStore == nil:
+		return nil, errors.New("未提供同步狀態儲存")
+	}
+
+	return &Syncer{handler: handler.New(src, calendarClient, syncStore, opts)}, nil
+}
+
+// SyncBooking 讀取預約的最新資料並依預約狀態同步：已取消的預約刪除對應的日曆事件，
+// 其他預約建立或更新事件；ctx 結束時停止同步，不再寫入日曆
+func (s *Syncer) SyncBooking(ctx context.Context, bookingID string) error {
+	return s.handler.SyncBooking(ctx, bookingID)
+}
+
+// Reconcile 比對對應關係在 window 內更新過的預約與日曆事件，並重新同步不一致的項目，
+// window 為 0 時比對所有預約
+func (s *Syncer) Reconcile(ctx context.Context, window time.Duration) (*ReconcileResult, error) {
+	var since time.Time
+	if window > 0 {
+		since = time.Now().Add(-window)
+	}
+	return s.handler.ReconcileSince(ctx, since)
+}
+
+// Handler 返回底層的 webhook 處理器，需要接收 webhook 時可掛載到自己的 HTTP 伺服器
+func (s *Syncer) Handler() *handler.WebhookHandler {
+	return s.handler
+}
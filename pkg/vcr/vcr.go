@@ -0,0 +1,344 @@
+// Package vcr 錄製與重播對外 HTTP 請求，將去除敏感資料的請求與響應寫入 JSON 檔案（cassette），
+// 讓 SimplyBook 與 Google 日曆客戶端可以在不連線的情況下重播真實流量進行回歸測試
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode 代表錄製器的模式
+type Mode int
+
+const (
+	// ModeRecord 轉送請求並錄製去除敏感資料的請求與響應
+	ModeRecord Mode = iota
+	// ModeReplay 從 cassette 返回已錄製的響應，不發出任何請求
+	ModeReplay
+)
+
+// Redacted 取代敏感資料的文字
+const Redacted = "REDACTED"
+
+// ErrNoInteraction 表示重播時 cassette 中沒有符合的請求
+var ErrNoInteraction = errors.New("cassette 中沒有符合的請求")
+
+// sensitiveHeaders 錄製時遮蔽的請求與響應標頭
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Token",
+	"X-Simplybook-Token",
+	"X-Goog-Api-Key",
+}
+
+// sensitiveFields 錄製時遮蔽的 JSON 欄位與表單參數（不分大小寫），涵蓋憑證與客戶個人資料
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"refresh_token": true,
+	"access_token":  true,
+	"id_token":      true,
+	"assertion":     true,
+	"client_secret": true,
+	"private_key":   true,
+	"email":         true,
+	"phone":         true,
+	"client_email":  true,
+	"client_phone":  true,
+}
+
+// Cassette 是錄製的請求與響應，依發生順序排列
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 是一次請求與其響應
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+
+	used bool
+}
+
+// Request 是錄製的請求
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response 是錄製的響應
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Recorder 是錄製或重播 HTTP 請求的 http.RoundTripper
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// NewRecorder 創建錄製器，每次請求完成後將 cassette 寫入 path；transport 為 nil 時使用 http.DefaultTransport
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{mode: ModeRecord, path: path, transport: transport, cassette: &Cassette{}}
+}
+
+// Load 讀取 cassette 並創建重播器
+func Load(path string) (*Recorder, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取 cassette 失敗: %w", err)
+	}
+
+	cassette := &Cassette{}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("解析 cassette 失敗: %w", err)
+	}
+	return &Recorder{mode: ModeReplay, path: path, cassette: cassette}, nil
+}
+
+// Client 返回使用此錄製器的 HTTP 客戶端
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Unused 返回重播時尚未被請求的錄製數量，可用於確認流程發出了所有預期的請求
+func (r *Recorder) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, interaction := range r.cassette.Interactions {
+		if !interaction.used {
+			n++
+		}
+	}
+	return n
+}
+
+// RoundTrip 實作 http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method:  req.Method,
+		URL:     sanitizeURL(req.URL),
+		Headers: sanitizeHeaders(req.Header),
+		Body:    sanitizeBody(req.Header.Get("Content-Type"), body),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+// record 轉送請求並錄製響應
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("讀取響應失敗: %w", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: sanitizeHeaders(resp.Header),
+			Body:    sanitizeBody(resp.Header.Get("Content-Type"), data),
+		},
+	})
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay 依序找出方法與路徑相同且尚未使用的錄製，優先選擇查詢參數也相同的錄製
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var match *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.used || interaction.Request.Method != recorded.Method ||
+			!samePath(interaction.Request.URL, recorded.URL) {
+			continue
+		}
+		if interaction.Request.URL == recorded.URL {
+			match = interaction
+			break
+		}
+		if match == nil {
+			match = interaction
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+	match.used = true
+
+	header := match.Response.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Response.Status, http.StatusText(match.Response.Status)),
+		StatusCode:    match.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(match.Response.Body)),
+		ContentLength: int64(len(match.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// save 將 cassette 寫入檔案，呼叫者需持有鎖
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 cassette 失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("建立 cassette 目錄失敗: %w", err)
+	}
+	if err := ioutil.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("寫入 cassette 失敗: %w", err)
+	}
+	return nil
+}
+
+// readBody 讀取請求體並重設，讓轉送的請求仍可讀取
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("讀取請求體失敗: %w", err)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// samePath 判斷兩個網址除查詢參數外是否相同
+func samePath(a, b string) bool {
+	a, _, _ = strings.Cut(a, "?")
+	b, _, _ = strings.Cut(b, "?")
+	return a == b
+}
+
+// sanitizeURL 遮蔽查詢參數中的敏感資料
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	if clean.RawQuery != "" {
+		clean.RawQuery = sanitizeValues(clean.Query()).Encode()
+	}
+	return clean.String()
+}
+
+// sanitizeHeaders 遮蔽敏感標頭
+func sanitizeHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	clean := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if clean.Get(name) != "" {
+			clean.Set(name, Redacted)
+		}
+	}
+	return clean
+}
+
+// sanitizeBody 遮蔽 JSON 或表單內容中的敏感欄位，其他格式原樣保留
+func sanitizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return sanitizeValues(values).Encode()
+		}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	data, err := json.Marshal(sanitizeJSON(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+// sanitizeValues 遮蔽表單或查詢參數中的敏感欄位
+func sanitizeValues(values url.Values) url.Values {
+	for key := range values {
+		if sensitiveFields[strings.ToLower(key)] {
+			values.Set(key, Redacted)
+		}
+	}
+	return values
+}
+
+// sanitizeJSON 遞迴遮蔽 JSON 中的敏感欄位
+func sanitizeJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if sensitiveFields[strings.ToLower(key)] {
+				if _, isString := field.(string); isString {
+					value[key] = Redacted
+					continue
+				}
+			}
+			value[key] = sanitizeJSON(field)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = sanitizeJSON(item)
+		}
+	}
+	return v
+}
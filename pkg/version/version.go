@@ -0,0 +1,9 @@
+package version
+
+// Version 是服務版本，建置時透過 -ldflags "-X .../pkg/version.Version=x.y.z" 注入
+var Version = "dev"
+
+// UserAgent 返回對外請求使用的預設 User-Agent
+func UserAgent() string {
+	return "booking-sync/" + Version
+}